@@ -9,13 +9,14 @@ import (
 var blockCmd = &cobra.Command{
 	Use:   "block",
 	Short: "Leader block execution commands",
-	Long:  "The `block` command group has subcommands for managing leader block execution, including status and aborting.",
+	Long:  "The `block` command group has subcommands for managing leader block execution, including status and aborting, and for tracing transaction execution.",
 }
 
 func NewBlockExecCmd() *cobra.Command {
 	blockCmd.AddCommand(
 		statusCmd(),
 		abortCmd(),
+		traceCmd(),
 	)
 
 	rpc.BindRPCFlags(blockCmd)
@@ -0,0 +1,69 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kwilteam/kwil-db/app/rpc"
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+	types "github.com/kwilteam/kwil-db/core/types/admin"
+	"github.com/spf13/cobra"
+)
+
+func traceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trace <tx_hash>",
+		Short: "Trace the execution of a committed transaction.",
+		Long: `Trace the execution of a committed action or raw statement transaction, listing the statements executed,
+the variables assigned, the generated SQL and the rows it affected, notices, and nested action calls.
+
+The node does not keep historical state, so only a transaction in the latest block can be traced. It is executed
+again against the state after that block, and the result may differ from its execution in the block, e.g. if it
+depends on changes the block made. The changes are rolled back.`,
+		Example: "kwild block trace 2b9f...e1c4",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			txHash, err := ktypes.NewHashFromString(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, fmt.Errorf("invalid transaction hash: %w", err))
+			}
+
+			clt, err := rpc.AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			trace, err := clt.TraceTx(ctx, txHash)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, &respTrace{trace})
+		},
+	}
+
+	return cmd
+}
+
+type respTrace struct {
+	*types.TxTrace
+}
+
+func (r *respTrace) MarshalJSON() ([]byte, error) {
+	return json.MarshalIndent(r.TxTrace, "", "  ")
+}
+
+func (r *respTrace) MarshalText() ([]byte, error) {
+	traces, err := json.MarshalIndent(r.Traces, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if !r.StateDiffers {
+		return traces, nil
+	}
+	note := fmt.Sprintf("Note: executed against the state after block %d, not the state before it.\n", r.Height)
+	return append([]byte(note), traces...), nil
+}
@@ -23,6 +23,7 @@ func KeyCmd() *cobra.Command {
 	keyCmd.AddCommand(
 		GenCmd(),
 		InfoCmd(),
+		EncryptCmd(),
 	)
 	display.BindOutputFormatFlag(keyCmd)
 	return keyCmd
@@ -0,0 +1,77 @@
+package key
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+)
+
+var (
+	encryptLong = `Encrypt an existing private key file with a passphrase.
+
+The key file is replaced with an encrypted key file containing the same key.
+The passphrase is read from the file given with --passphrase-file, the file
+named by the ` + PassphraseFileEnv + ` environment variable, the
+` + PassphraseEnv + ` environment variable, or prompted for, in that order.
+The same sources are used to decrypt the key when the node starts.`
+
+	encryptExample = `# Encrypt the node key in the default root directory
+kwild key encrypt --key-file ~/.kwild/nodekey.json`
+)
+
+func EncryptCmd() *cobra.Command {
+	var keyFile, passphraseFile string
+
+	cmd := &cobra.Command{
+		Use:     "encrypt",
+		Short:   "Encrypt a private key file with a passphrase.",
+		Long:    encryptLong,
+		Example: encryptExample,
+		Args:    cobra.NoArgs,
+		// Override the root command's PersistentPreRunE, so that we don't
+		// try to read the config from a ~/.kwild directory
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyBts, err := os.ReadFile(keyFile)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			if IsEncryptedKey(keyBts) {
+				return display.PrintErr(cmd, fmt.Errorf("key file %s is already encrypted", keyFile))
+			}
+
+			var nk NodeKeyFile
+			if err := nk.UnmarshalJSON(keyBts); err != nil {
+				return display.PrintErr(cmd, fmt.Errorf("invalid key file: %w", err))
+			}
+
+			passphrase, err := ReadPassphrase(passphraseFile, true)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			// Write to a temporary file first so that the key is not lost if
+			// writing fails.
+			tmpFile := filepath.Join(filepath.Dir(keyFile), "."+filepath.Base(keyFile)+".tmp")
+			if err := SaveEncryptedNodeKey(tmpFile, nk.Key, passphrase); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			if err := os.Rename(tmpFile, keyFile); err != nil {
+				os.Remove(tmpFile)
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespString("Private key in "+keyFile+" encrypted"))
+		},
+	}
+
+	cmd.Flags().StringVarP(&keyFile, "key-file", "o", "", "file containing the private key to encrypt")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "file containing the passphrase")
+	cmd.MarkFlagRequired("key-file")
+
+	return cmd
+}
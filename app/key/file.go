@@ -58,12 +58,21 @@ func (nk *NodeKeyFile) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// LoadNodeKey reads a private key from a file. If the key is encrypted, the
+// passphrase is obtained with ReadPassphrase.
 func LoadNodeKey(path string) (crypto.PrivateKey, error) {
 	var nk NodeKeyFile
 	keyFile, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	if IsEncryptedKey(keyFile) {
+		passphrase, err := ReadPassphrase("", false)
+		if err != nil {
+			return nil, err
+		}
+		return DecryptKey(keyFile, passphrase)
+	}
 	if err := json.Unmarshal(keyFile, &nk); err != nil {
 		return nil, err
 	}
@@ -77,3 +86,13 @@ func SaveNodeKey(path string, pk crypto.PrivateKey) error {
 	}
 	return os.WriteFile(path, keyFile, 0600)
 }
+
+// SaveEncryptedNodeKey writes a private key to a file, encrypted with the
+// passphrase.
+func SaveEncryptedNodeKey(path string, pk crypto.PrivateKey, passphrase string) error {
+	keyFile, err := EncryptKey(pk, passphrase)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, keyFile, 0600)
+}
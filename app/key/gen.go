@@ -2,6 +2,7 @@ package key
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -14,6 +15,9 @@ var (
 	genExample = `# Generate a new key and save it to ./priv_key
 kwild key gen --key-file ./priv_key
 
+# Generate a new key and save it encrypted with a passphrase
+kwild key gen --key-file ./priv_key --encrypt
+
 # Generate a raw private key
 kwild key gen --raw`
 )
@@ -21,6 +25,8 @@ kwild key gen --raw`
 func GenCmd() *cobra.Command {
 	var raw bool // if true, output hex private key only
 	var out string
+	var encrypt bool
+	var passphraseFile string
 
 	cmd := &cobra.Command{
 		Use:     "gen [<keytype>]",
@@ -47,13 +53,24 @@ func GenCmd() *cobra.Command {
 			}
 
 			if out == "" {
+				if encrypt {
+					return display.PrintErr(cmd, errors.New("--encrypt requires --key-file"))
+				}
 				if raw {
 					return display.PrintCmd(cmd, display.RespString(hex.EncodeToString(privKey.Bytes())))
 				}
 				return display.PrintCmd(cmd, privKeyInfo(privKey))
 			}
 
-			if err := SaveNodeKey(out, privKey); err != nil {
+			if encrypt {
+				passphrase, err := ReadPassphrase(passphraseFile, true)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				if err := SaveEncryptedNodeKey(out, privKey, passphrase); err != nil {
+					return display.PrintErr(cmd, err)
+				}
+			} else if err := SaveNodeKey(out, privKey); err != nil {
 				return display.PrintErr(cmd, err)
 			}
 
@@ -63,6 +80,8 @@ func GenCmd() *cobra.Command {
 
 	cmd.Flags().BoolVarP(&raw, "raw", "R", false, "just print the private key hex without other encodings, public key, or node ID")
 	cmd.Flags().StringVarP(&out, "key-file", "o", "", "file to which the new private key is written (stdout by default)")
+	cmd.Flags().BoolVar(&encrypt, "encrypt", false, "encrypt the key file with a passphrase")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "file containing the passphrase used with --encrypt (otherwise "+PassphraseFileEnv+", "+PassphraseEnv+", or a prompt)")
 
 	return cmd
 }
@@ -0,0 +1,177 @@
+package key
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+)
+
+const (
+	keystoreVersion = 1
+	keystoreKDF     = "scrypt"
+	keystoreCipher  = "aes-256-gcm"
+)
+
+// The scrypt parameters used to encrypt new keys. These are the "standard"
+// parameters of the Ethereum keystore format.
+var (
+	scryptN = 1 << 18
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrWrongPassphrase is returned when an encrypted key cannot be decrypted
+// with the provided passphrase.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted key file")
+
+// EncryptedKeyFile is a private key encrypted with a key derived from a
+// passphrase with scrypt, using AES-256-GCM. The key type is authenticated as
+// additional data.
+type EncryptedKeyFile struct {
+	Type    string         `json:"type"`
+	Crypto  keystoreCrypto `json:"crypto"`
+	Version int            `json:"version"`
+}
+
+type keystoreCrypto struct {
+	Cipher     string       `json:"cipher"`
+	Ciphertext string       `json:"ciphertext"`
+	Nonce      string       `json:"nonce"`
+	KDF        string       `json:"kdf"`
+	KDFParams  scryptParams `json:"kdfparams"`
+}
+
+type scryptParams struct {
+	N      int    `json:"n"`
+	R      int    `json:"r"`
+	P      int    `json:"p"`
+	KeyLen int    `json:"dklen"`
+	Salt   string `json:"salt"`
+}
+
+// IsEncryptedKey reports whether the contents of a key file are an encrypted
+// key, as opposed to the plain hex format of NodeKeyFile.
+func IsEncryptedKey(data []byte) bool {
+	var aux struct {
+		Crypto json.RawMessage `json:"crypto"`
+	}
+	return json.Unmarshal(data, &aux) == nil && len(aux.Crypto) > 0
+}
+
+// EncryptKey encrypts a private key with a passphrase, returning the JSON
+// encoding of an EncryptedKeyFile.
+func EncryptKey(pk crypto.PrivateKey, passphrase string) ([]byte, error) {
+	if pk == nil {
+		return nil, errors.New("key is nil")
+	}
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params := scryptParams{N: scryptN, R: scryptR, P: scryptP, KeyLen: 32, Salt: hex.EncodeToString(salt)}
+
+	aead, err := params.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	keyType := pk.Type().String()
+	ciphertext := aead.Seal(nil, nonce, pk.Bytes(), []byte(keyType))
+
+	return json.MarshalIndent(&EncryptedKeyFile{
+		Type: keyType,
+		Crypto: keystoreCrypto{
+			Cipher:     keystoreCipher,
+			Ciphertext: hex.EncodeToString(ciphertext),
+			Nonce:      hex.EncodeToString(nonce),
+			KDF:        keystoreKDF,
+			KDFParams:  params,
+		},
+		Version: keystoreVersion,
+	}, "", "  ")
+}
+
+// DecryptKey decrypts the JSON encoding of an EncryptedKeyFile. It returns
+// ErrWrongPassphrase if authentication fails.
+func DecryptKey(data []byte, passphrase string) (crypto.PrivateKey, error) {
+	var ek EncryptedKeyFile
+	if err := json.Unmarshal(data, &ek); err != nil {
+		return nil, err
+	}
+
+	if ek.Version != keystoreVersion {
+		return nil, fmt.Errorf("unsupported key file version %d", ek.Version)
+	}
+	if ek.Crypto.KDF != keystoreKDF {
+		return nil, fmt.Errorf("unsupported key derivation function %q", ek.Crypto.KDF)
+	}
+	if ek.Crypto.Cipher != keystoreCipher {
+		return nil, fmt.Errorf("unsupported cipher %q", ek.Crypto.Cipher)
+	}
+
+	keyType, err := crypto.ParseKeyType(ek.Type)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(ek.Crypto.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	ciphertext, err := hex.DecodeString(ek.Crypto.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+
+	aead, err := ek.Crypto.KDFParams.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce length %d", len(nonce))
+	}
+
+	keyBytes, err := aead.Open(nil, nonce, ciphertext, []byte(ek.Type))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	return crypto.UnmarshalPrivateKey(keyBytes, keyType)
+}
+
+// aead derives the encryption key from the passphrase.
+func (p *scryptParams) aead(passphrase string) (cipher.AEAD, error) {
+	if p.KeyLen != 32 {
+		return nil, fmt.Errorf("unsupported derived key length %d", p.KeyLen)
+	}
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+
+	derived, err := scrypt.Key([]byte(passphrase), salt, p.N, p.R, p.P, p.KeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,68 @@
+package key
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+)
+
+func init() {
+	scryptN = 1 << 10 // fast tests
+}
+
+func TestEncryptDecryptKey(t *testing.T) {
+	edKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	secKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+
+	for _, pk := range []crypto.PrivateKey{edKey, secKey} {
+		t.Run(pk.Type().String(), func(t *testing.T) {
+			data, err := EncryptKey(pk, "correct horse")
+			require.NoError(t, err)
+			require.True(t, IsEncryptedKey(data))
+			require.NotContains(t, string(data), hex.EncodeToString(pk.Bytes()))
+
+			got, err := DecryptKey(data, "correct horse")
+			require.NoError(t, err)
+			require.True(t, pk.Equals(got))
+
+			_, err = DecryptKey(data, "wrong horse")
+			require.ErrorIs(t, err, ErrWrongPassphrase)
+		})
+	}
+
+	_, err = EncryptKey(secKey, "")
+	require.Error(t, err)
+
+	plain, err := NodeKeyFile{Key: secKey}.MarshalJSON()
+	require.NoError(t, err)
+	require.False(t, IsEncryptedKey(plain))
+}
+
+func TestLoadEncryptedNodeKey(t *testing.T) {
+	pk, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "nodekey.json")
+	require.NoError(t, SaveEncryptedNodeKey(keyFile, pk, "secret"))
+
+	passFile := filepath.Join(dir, "passphrase")
+	require.NoError(t, os.WriteFile(passFile, []byte("secret\n"), 0600))
+	t.Setenv(PassphraseFileEnv, passFile)
+
+	got, err := LoadNodeKey(keyFile)
+	require.NoError(t, err)
+	require.True(t, pk.Equals(got))
+
+	t.Setenv(PassphraseFileEnv, "")
+	t.Setenv(PassphraseEnv, "wrong")
+	_, err = LoadNodeKey(keyFile)
+	require.ErrorIs(t, err, ErrWrongPassphrase)
+}
@@ -0,0 +1,86 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	// PassphraseEnv is the environment variable that may contain the
+	// passphrase of an encrypted key file.
+	PassphraseEnv = "KWIL_KEY_PASSPHRASE"
+	// PassphraseFileEnv is the environment variable that may contain the path
+	// of a file containing the passphrase of an encrypted key file.
+	PassphraseFileEnv = "KWIL_KEY_PASSPHRASE_FILE"
+)
+
+// ErrNoPassphrase is returned when a passphrase is required, but none was
+// provided and there is no terminal to prompt for one.
+var ErrNoPassphrase = errors.New("passphrase required: set " + PassphraseEnv + " or " +
+	PassphraseFileEnv + ", or run interactively")
+
+// ReadPassphrase gets the passphrase of an encrypted key. In order of
+// preference, it is read from passphraseFile, the file named by
+// PassphraseFileEnv, the PassphraseEnv environment variable, or prompted for
+// if stdin is a terminal. If confirm is true, a prompted passphrase must be
+// entered twice, as when encrypting a new key. A trailing newline is trimmed
+// from a passphrase file.
+func ReadPassphrase(passphraseFile string, confirm bool) (string, error) {
+	if passphraseFile == "" {
+		passphraseFile = os.Getenv(PassphraseFileEnv)
+	}
+	if passphraseFile != "" {
+		bts, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		pass := strings.TrimRight(string(bts), "\r\n")
+		if pass == "" {
+			return "", fmt.Errorf("passphrase file %s is empty", passphraseFile)
+		}
+		return pass, nil
+	}
+
+	if pass, ok := os.LookupEnv(PassphraseEnv); ok && pass != "" {
+		return pass, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNoPassphrase
+	}
+
+	pass, err := promptPassphrase(fd, "Key passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", errors.New("empty passphrase")
+	}
+	if confirm {
+		again, err := promptPassphrase(fd, "Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != pass {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return pass, nil
+}
+
+// promptPassphrase reads a line from the terminal without echo. The prompt is
+// written to stderr so that it does not mix with command output.
+func promptPassphrase(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	bts, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(bts), nil
+}
@@ -28,6 +28,11 @@ import (
 	"github.com/kwilteam/kwil-db/node/engine/interpreter"
 	_ "github.com/kwilteam/kwil-db/node/exts/erc20-bridge/erc20"
 	"github.com/kwilteam/kwil-db/node/exts/erc20-bridge/signersvc"
+	_ "github.com/kwilteam/kwil-db/node/exts/evm-events"
+	_ "github.com/kwilteam/kwil-db/node/exts/http-oracle"
+	_ "github.com/kwilteam/kwil-db/node/exts/schedule"
+	_ "github.com/kwilteam/kwil-db/node/exts/sequence"
+	_ "github.com/kwilteam/kwil-db/node/exts/wasm"
 	"github.com/kwilteam/kwil-db/node/listeners"
 	"github.com/kwilteam/kwil-db/node/mempool"
 	"github.com/kwilteam/kwil-db/node/meta"
@@ -37,6 +42,7 @@ import (
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/adminsvc"
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/chainsvc"
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/funcsvc"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/ratelimit"
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/usersvc"
 	"github.com/kwilteam/kwil-db/node/snapshotter"
 	"github.com/kwilteam/kwil-db/node/store"
@@ -108,19 +114,47 @@ func buildServer(ctx context.Context, d *coreDependencies) *server {
 
 	// RPC Services
 	rpcSvcLogger := d.logger.New("USER")
-	jsonRPCTxSvc := usersvc.NewService(db, e, node, bp, vs, migrator, rpcSvcLogger,
+	userSvcOpts := []usersvc.Opt{
 		usersvc.WithReadTxTimeout(time.Duration(d.cfg.DB.ReadTxTimeout)),
 		usersvc.WithPrivateMode(d.cfg.RPC.Private),
 		usersvc.WithChallengeExpiry(time.Duration(d.cfg.RPC.ChallengeExpiry)),
 		usersvc.WithChallengeRateLimit(d.cfg.RPC.ChallengeRateLimit),
-		usersvc.WithBlockAgeHealth(6*time.Duration(max(d.cfg.Consensus.ProposeTimeout, d.cfg.Consensus.EmptyBlockTimeout))),
-	)
+		usersvc.WithBlockAgeHealth(6 * time.Duration(max(d.cfg.Consensus.ProposeTimeout, d.cfg.Consensus.EmptyBlockTimeout))),
+	}
+	rlCfg := d.cfg.RPC.RateLimit
+	if rlCfg.Enable && rlCfg.IdentityRate > 0 {
+		userSvcOpts = append(userSvcOpts, usersvc.WithIdentityRateLimit(&ratelimit.MethodLimiterConfig{
+			RPS:   rlCfg.IdentityRate,
+			Burst: rlCfg.IdentityBurst,
+			Costs: rlCfg.MethodCosts,
+		}))
+	}
+	if replica := buildReadReplica(ctx, d, db, closers); replica != nil {
+		userSvcOpts = append(userSvcOpts, usersvc.WithQueryDB(replica))
+	}
+	jsonRPCTxSvc := usersvc.NewService(db, e, node, bp, vs, migrator, rpcSvcLogger, userSvcOpts...)
 
 	rpcServerLogger := d.logger.New("RPC")
-	jsonRPCServer, err := rpcserver.NewServer(d.cfg.RPC.ListenAddress,
-		rpcServerLogger, rpcserver.WithTimeout(time.Duration(d.cfg.RPC.Timeout)),
+	rpcServerOpts := []rpcserver.Opt{rpcserver.WithTimeout(time.Duration(d.cfg.RPC.Timeout)),
 		rpcserver.WithReqSizeLimit(d.cfg.RPC.MaxReqSize),
-		rpcserver.WithCORS(), rpcserver.WithServerInfo(&usersvc.SpecInfo))
+		rpcserver.WithCORS(), rpcserver.WithServerInfo(&usersvc.SpecInfo),
+		rpcserver.WithTrustedProxyCount(d.cfg.RPC.TrustedProxyCount),
+	}
+	if d.cfg.RPC.REST {
+		rpcServerOpts = append(rpcServerOpts, rpcserver.WithREST())
+	}
+	if rlCfg.Enable {
+		rpcServerOpts = append(rpcServerOpts, rpcserver.WithRateLimiter(ratelimit.NewMethodLimiter(&ratelimit.MethodLimiterConfig{
+			RPS:   rlCfg.Rate,
+			Burst: rlCfg.Burst,
+			Costs: rlCfg.MethodCosts,
+		})))
+	}
+	if d.metricsHandler != nil && d.cfg.Telemetry.Prometheus == config.PrometheusOnRPC {
+		rpcServerOpts = append(rpcServerOpts, rpcserver.WithMetricsHandler(d.metricsHandler))
+	}
+	jsonRPCServer, err := rpcserver.NewServer(d.cfg.RPC.ListenAddress,
+		rpcServerLogger, rpcServerOpts...)
 	if err != nil {
 		failBuild(err, "unable to create json-rpc server")
 	}
@@ -137,6 +171,25 @@ func buildServer(ctx context.Context, d *coreDependencies) *server {
 		jsonRPCServer.RegisterSvc(jsonChainSvc)
 	}
 
+	reloader := &configReloader{
+		load:      d.loadConfig,
+		log:       d.logger.New("CONFIG"),
+		levelVar:  d.levelVar,
+		rpcServer: jsonRPCServer,
+		userSvc:   jsonRPCTxSvc,
+		whitelist: node.Whitelister(),
+		mempool:   mp,
+		maxBlockSize: func() int64 {
+			if params := bp.ConsensusParams(); params != nil {
+				return params.MaxBlockSize
+			}
+			return d.genesisCfg.MaxBlockSize
+		},
+		snapshots: snapshotStore,
+		loaded:    d.loadedCfg,
+		cfg:       d.cfg,
+	}
+
 	var jsonRPCAdminServer *rpcserver.Server
 	if d.cfg.Admin.Enable {
 		// admin service and server
@@ -145,9 +198,13 @@ func buildServer(ctx context.Context, d *coreDependencies) *server {
 		// key because it is used to sign transactions and provide an Identity for
 		// account information (nonce and balance).
 		txSigner := auth.GetNodeSigner(d.privKey)
-		jsonAdminSvc := adminsvc.NewService(db, node, bp, vs, node.Whitelister(),
-			txSigner, d.cfg, d.genesisCfg.ChainID, adminServerLogger)
-		jsonRPCAdminServer = buildJRPCAdminServer(d)
+		tokens, err := adminsvc.NewTokenStore(config.AdminTokensFilePath(d.rootDir), d.cfg.Admin.Pass)
+		if err != nil {
+			failBuild(err, "failed to load admin tokens")
+		}
+		jsonAdminSvc := adminsvc.NewService(db, node, bp, e, vs, node.Whitelister(), tokens,
+			txSigner, reloader, d.genesisCfg.ChainID, adminServerLogger)
+		jsonRPCAdminServer = buildJRPCAdminServer(d, tokens)
 		jsonRPCAdminServer.RegisterSvc(jsonAdminSvc)
 		jsonRPCAdminServer.RegisterSvc(jsonRPCTxSvc)
 		jsonRPCAdminServer.RegisterSvc(&funcsvc.Service{})
@@ -165,8 +222,11 @@ func buildServer(ctx context.Context, d *coreDependencies) *server {
 		jsonRPCServer:      jsonRPCServer,
 		jsonRPCAdminServer: jsonRPCAdminServer,
 		dbCtx:              db,
+		embeddedDB:         d.embeddedDB,
 		log:                d.logger,
 		erc20BridgeSigner:  erc20BridgeSignerMgr,
+		reloader:           reloader,
+		reloadSignals:      d.reloadSignals,
 	}
 
 	return s
@@ -262,6 +322,47 @@ func buildDB(ctx context.Context, d *coreDependencies, ss *node.StateSyncService
 	return db
 }
 
+// buildReadReplica connects to the configured read replica, if any, for the
+// user service's queries and calls. It returns nil if there is no replica, or
+// if it cannot be set up, in which case the primary is used.
+func buildReadReplica(ctx context.Context, d *coreDependencies, db *pg.DB, closers *closeFuncs) *pg.Replica {
+	rCfg := d.cfg.DB.ReadReplica
+	if rCfg.Host == "" {
+		return nil
+	}
+	user, pass := rCfg.User, rCfg.Pass
+	if user == "" {
+		user, pass = d.cfg.DB.User, d.cfg.DB.Pass
+	}
+
+	replica, err := pg.NewReplica(ctx, db, &pg.ReplicaConfig{
+		PoolConfig: pg.PoolConfig{
+			ConnConfig: pg.ConnConfig{
+				Host:   rCfg.Host,
+				Port:   rCfg.Port,
+				User:   user,
+				Pass:   pass,
+				DBName: d.cfg.DB.DBName,
+			},
+			MaxConns: rCfg.MaxConns,
+		},
+		MaxLag: rCfg.MaxLag,
+		Height: func(ctx context.Context, tx sql.Executor) (int64, error) {
+			height, _, _, err := meta.GetChainState(ctx, tx)
+			return height, err
+		},
+	})
+	if err != nil {
+		// The primary can serve all queries, so the node still starts.
+		d.logger.Warn("Failed to set up read replica, using primary", "host", rCfg.Host, "error", err)
+		return nil
+	}
+	closers.addCloser(replica.Close, "Closing read replica connections")
+	d.logger.Info("Routing user queries and calls to read replica", "host", rCfg.Host, "max_lag", rCfg.MaxLag)
+
+	return replica
+}
+
 // restoreDB restores the database from a snapshot if the genesis apphash is specified.
 // StateHash in the genesis config ensures that all the nodes in the network start from the same state.
 // StateHash in the genesis config should match the hash of the snapshot file.
@@ -634,7 +735,7 @@ func buildListenerManager(d *coreDependencies, ev *voting.EventStore, bp *blockp
 	return listeners.NewListenerManager(d.service("ListenerManager"), ev, bp, node)
 }
 
-func buildJRPCAdminServer(d *coreDependencies) *rpcserver.Server {
+func buildJRPCAdminServer(d *coreDependencies, auth rpcserver.Authenticator) *rpcserver.Server {
 	var wantTLS bool
 	addr := d.cfg.Admin.ListenAddress
 	host, port, err := net.SplitHostPort(addr)
@@ -656,9 +757,18 @@ func buildJRPCAdminServer(d *coreDependencies) *rpcserver.Server {
 
 	opts := []rpcserver.Opt{rpcserver.WithTimeout(10 * time.Minute)} // this is an administrator
 
+	// The authenticator checks the password, if set, and any API tokens and
+	// client certificates, and it limits each caller to its scopes.
 	adminPass := d.cfg.Admin.Pass
-	if adminPass != "" {
-		opts = append(opts, rpcserver.WithPass(adminPass))
+	opts = append(opts, rpcserver.WithAuthenticator(auth))
+	if d.cfg.Admin.AuditLog != "" {
+		auditFile := rootedPath(d.cfg.Admin.AuditLog, d.rootDir)
+		auditLog, err := adminsvc.OpenAuditLog(auditFile, d.logger.New("AUDIT"))
+		if err != nil {
+			failBuild(err, "failed to open admin audit log")
+		}
+		d.closers.addCloser(auditLog.Close, "Closing admin audit log")
+		opts = append(opts, rpcserver.WithAuditor(auditLog))
 	}
 
 	// Require TLS only if not UNIX or not loopback TCP interface.
@@ -693,6 +803,9 @@ func buildJRPCAdminServer(d *coreDependencies) *rpcserver.Server {
 	// general, only mutual TLS. It could be a simpler alternative to mutual
 	// TLS, or just coupled with TLS termination on a local reverse proxy.
 	opts = append(opts, rpcserver.WithServerInfo(&adminsvc.SpecInfo))
+	if d.metricsHandler != nil && d.cfg.Telemetry.Prometheus == config.PrometheusOnAdmin {
+		opts = append(opts, rpcserver.WithMetricsHandler(d.metricsHandler))
+	}
 	svcLogger := d.logger.New("ADMINRPC")
 	jsonRPCAdminServer, err := rpcserver.NewServer(addr, svcLogger, opts...)
 	if err != nil {
@@ -755,17 +868,33 @@ func loadTLSCertificate(keyFile, certFile, hostname string) (*tls.Certificate, e
 
 // tlsConfig returns a tls.Config to be used with the admin RPC service. If
 // withTransportClientAuth is true, the config will require client
-// authentication (mutual TLS), otherwise it is standard TLS for encryption and
-// server authentication.
+// authentication (mutual TLS). Otherwise it is standard TLS for encryption and
+// server authentication, but a client certificate is still verified if one is
+// given and there is a client CAs file, so that certificates granted scopes
+// with "kwild admin tokens mint" can be used along with the password.
 func tlsConfig(d *coreDependencies, withTransportClientAuth bool) *tls.Config {
 	if d.adminKey == nil {
 		return nil
 	}
 	if !withTransportClientAuth {
 		// TLS only for encryption and authentication of server to client.
-		return &tls.Config{
+		cfg := &tls.Config{
 			Certificates: []tls.Certificate{*d.adminKey},
 		}
+		if clientsFile := filepath.Join(d.rootDir, defaultAdminClients); fileExists(clientsFile) {
+			clientsCerts, err := os.ReadFile(clientsFile)
+			if err != nil {
+				failBuild(err, "failed to load client CAs file")
+			}
+			caCertPool := x509.NewCertPool()
+			if !caCertPool.AppendCertsFromPEM(clientsCerts) {
+				failBuild(errors.New("no certificates"), "invalid client CAs file")
+			}
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+			cfg.ClientCAs = caCertPool
+			d.logger.Infoln("loaded optional client CAs from", clientsFile)
+		}
+		return cfg
 	} // else try to load authorized client certs/pubkeys
 
 	var err error
@@ -42,6 +42,10 @@ var k = koanf.New(".")
 
 const koanfTag = "toml"
 
+// defaults is the struct bound with BindDefaults, which is the base of a
+// config built by Reload.
+var defaults any
+
 // ActiveConfig retrieves the current merged config. This is influenced by the
 // other functions in this package, including: BindDefaults,
 // SetFlagsFromStruct, PreRunBindFlags, PreRunBindEnvMatching,
@@ -61,10 +65,12 @@ func ActiveConfig() *config.Config {
 // BindDefaults binds a struct to the koanf instance. The field names should have
 // `koanf:"name"` tags to bind the correct name.
 func BindDefaults(cfg any) error {
+	defaults = cfg
 	return bind.BindDefaultsTo(cfg, koanfTag, k)
 }
 
 func BindDefaultsWithRootDir(cfg any, rootDir string) error {
+	defaults = cfg
 	if err := bind.BindDefaultsTo(cfg, koanfTag, k); err != nil {
 		return err
 	}
@@ -86,8 +92,11 @@ func RootDir() string {
 // in the command's flag set. See [bind.SetFlagsFromStruct] to automate defining
 // the flags from a default config struct.
 func PreRunBindFlags(cmd *cobra.Command, args []string) error {
-	// Load posix flags (posflag provider).
-	flagSet := cmd.Flags()
+	return loadFlags(k, cmd.Flags())
+}
+
+// loadFlags loads posix flags (posflag provider) into the koanf instance.
+func loadFlags(k *koanf.Koanf, flagSet *pflag.FlagSet) error {
 	err := k.Load(posflag.ProviderWithFlag(flagSet, ".", nil, /* <- k if we want defaults from the flags*/
 		func(f *pflag.Flag) (string, interface{}) {
 			// if !f.Changed { Debugf("not changed %v", f.Name) }
@@ -207,7 +216,12 @@ func preRunBindConfigFile(cmd *cobra.Command, args []string, parser koanf.Parser
 	//
 	// The above can be modified to standardize to "-".
 
-	// Load config from file
+	return loadConfigFile(k, rootDir, parser)
+}
+
+// loadConfigFile loads the config file in the root directory, if it exists,
+// into the koanf instance.
+func loadConfigFile(k *koanf.Koanf, rootDir string, parser koanf.Parser) error {
 	confPath, _ := filepath.Abs(config.ConfigFilePath(rootDir))
 
 	if err := k.Load(file.Provider(confPath), parser /*, mergeFn*/); err != nil {
@@ -273,6 +287,39 @@ func (stp *strictTOMLParser[T]) Marshal(o map[string]interface{}) ([]byte, error
 	return gotoml.Marshal(&o)
 }
 
+// Reload builds a new config by merging the defaults, the config file, the
+// flags, and the environment, in the same order as the kwild root command's
+// preruns, so that changes to the config file may be applied to a running
+// node. The flags should be those of the running command. The ActiveConfig is
+// not changed.
+func Reload(flagSet *pflag.FlagSet) (*config.Config, error) {
+	rootDir := RootDir()
+	nk := koanf.New(".")
+	if defaults != nil {
+		if err := bind.BindDefaultsTo(defaults, koanfTag, nk); err != nil {
+			return nil, err
+		}
+	}
+	if err := nk.Set(bind.RootFlagName, rootDir); err != nil {
+		return nil, err
+	}
+	if err := loadConfigFile(nk, rootDir, &strictTOMLParser[config.Config]{}); err != nil {
+		return nil, err
+	}
+	if err := loadFlags(nk, flagSet); err != nil {
+		return nil, err
+	}
+	if err := bind.PreRunBindEnvMatchingTo(nil, nil, "KWILD_", nk); err != nil {
+		return nil, err
+	}
+
+	var cfg config.Config
+	if err := nk.UnmarshalWithConf("", &cfg, koanf.UnmarshalConf{Tag: koanfTag}); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	return &cfg, nil
+}
+
 // PreRunPrintEffectiveConfig prints the effective config map if CLI debugging
 // is enabled (the `--debug` flag is set), otherwise this does nothing. It may
 // be specified multiple times in a PreRun chain.
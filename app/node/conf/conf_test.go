@@ -6,9 +6,11 @@ import (
 
 	"github.com/knadh/koanf/v2"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/kwilteam/kwil-db/config"
+	"github.com/kwilteam/kwil-db/core/log"
 )
 
 func TestPreRunBindConfigFile(t *testing.T) {
@@ -80,3 +82,33 @@ func TestPreRunBindFlags(t *testing.T) {
 	assert.Equal(t, "test-value", k.String("test_flag"))
 	assert.Equal(t, 42, k.Int("number_value"))
 }
+
+func TestReload(t *testing.T) {
+	k = koanf.New(".")
+	defer func() { defaults = nil }()
+
+	tmpDir := t.TempDir()
+	assert.NoError(t, BindDefaultsWithRootDir(config.DefaultConfig(), tmpDir))
+
+	configContent := `
+[log]
+level = "debug"
+
+[mempool]
+max_size = 1024
+`
+	err := os.WriteFile(config.ConfigFilePath(tmpDir), []byte(configContent), 0644)
+	assert.NoError(t, err)
+	t.Setenv("KWILD_RPC_MAX_REQ_SIZE", "1234")
+
+	cfg, err := Reload(pflag.NewFlagSet("test", pflag.ContinueOnError))
+	assert.NoError(t, err)
+
+	assert.Equal(t, log.LevelDebug, cfg.Log.Level)
+	assert.Equal(t, int64(1024), cfg.Mempool.MaxSize)
+	assert.Equal(t, 1234, cfg.RPC.MaxReqSize)
+	assert.Equal(t, config.DefaultConfig().RPC.ListenAddress, cfg.RPC.ListenAddress)
+
+	// the active config is unchanged
+	assert.Equal(t, config.DefaultConfig().Log.Level, ActiveConfig().Log.Level)
+}
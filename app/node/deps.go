@@ -6,7 +6,10 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
@@ -60,6 +63,35 @@ func newPoolBOpener(host, port, user, pass string) PoolOpener {
 	}
 }
 
+// startEmbeddedDB starts the embedded postgres server with its data directory
+// in the root directory. If the directory of the postgres binaries is
+// configured, it is also used for the pg_dump and psql binaries unless their
+// paths were changed from the defaults.
+func startEmbeddedDB(ctx context.Context, rootDir string, cfg *config.Config, logger log.Logger) (*pg.EmbeddedServer, error) {
+	pg.UseLogger(logger.New("PG"))
+
+	binDir := cfg.DB.Embedded.BinDir
+	if binDir != "" {
+		defaultCfg := config.DefaultConfig()
+		if cfg.PGDumpPath == defaultCfg.PGDumpPath {
+			cfg.PGDumpPath = filepath.Join(binDir, defaultCfg.PGDumpPath)
+		}
+		if cfg.StateSync.PsqlPath == defaultCfg.StateSync.PsqlPath {
+			cfg.StateSync.PsqlPath = filepath.Join(binDir, defaultCfg.StateSync.PsqlPath)
+		}
+	}
+
+	return pg.StartEmbedded(ctx, &pg.EmbeddedConfig{
+		DataDir: config.EmbeddedDBDir(rootDir),
+		BinDir:  binDir,
+		Port:    cfg.DB.Port,
+		User:    cfg.DB.User,
+		Pass:    cfg.DB.Pass,
+		DBName:  cfg.DB.DBName,
+		LogFile: config.EmbeddedDBLogFilePath(rootDir),
+	})
+}
+
 type coreDependencies struct {
 	rootDir    string
 	cfg        *config.Config
@@ -72,9 +104,18 @@ type coreDependencies struct {
 	autogen  bool
 
 	logger           log.Logger
+	levelVar         *log.LevelVar      // the logger's level, changed on config reload
+	metricsHandler   http.Handler       // serves Prometheus metrics, if enabled
+	embeddedDB       *pg.EmbeddedServer // nil unless running an embedded postgres server
 	dbOpener         dbOpener
 	namespaceManager *namespaceManager
 	poolOpener       PoolOpener
+
+	// loadConfig loads the config anew for a reload, and loadedCfg is what it
+	// returned on startup. Reloads are also requested on reloadSignals.
+	loadConfig    func() (*config.Config, error)
+	loadedCfg     *config.Config
+	reloadSignals <-chan os.Signal
 }
 
 // closeFuncs holds a list of closers
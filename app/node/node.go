@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -24,6 +25,7 @@ import (
 	"github.com/kwilteam/kwil-db/node/consensus"
 	"github.com/kwilteam/kwil-db/node/exts/erc20-bridge/signersvc"
 	"github.com/kwilteam/kwil-db/node/listeners"
+	"github.com/kwilteam/kwil-db/node/pg"
 	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
 	"github.com/kwilteam/kwil-db/version"
 )
@@ -37,6 +39,7 @@ type server struct {
 		Done() <-chan struct{}
 		Err() error
 	}
+	embeddedDB *pg.EmbeddedServer // nil unless running an embedded postgres server
 
 	// subsystems
 	node               *node.Node
@@ -45,9 +48,14 @@ type server struct {
 	jsonRPCServer      *rpcserver.Server
 	jsonRPCAdminServer *rpcserver.Server
 	erc20BridgeSigner  *signersvc.ServiceMgr
+
+	reloader      *configReloader
+	reloadSignals <-chan os.Signal
 }
 
-func runNode(ctx context.Context, rootDir string, cfg *config.Config, autogen bool, dbOwner string) (err error) {
+func runNode(ctx context.Context, rootDir string, cfg *config.Config, autogen bool, dbOwner string,
+	metricsHandler http.Handler, loadConfig func() (*config.Config, error),
+	reloadSignals <-chan os.Signal) (err error) {
 	logOutputPaths := slices.Clone(cfg.Log.Output)
 	var logWriters []io.Writer
 	if idx := slices.Index(cfg.Log.Output, "stdout"); idx != -1 {
@@ -74,10 +82,11 @@ func runNode(ctx context.Context, rootDir string, cfg *config.Config, autogen bo
 	}
 
 	logger := log.DiscardLogger
+	levelVar := log.NewLevelVar(cfg.Log.Level)
 	if len(logWriters) > 0 {
 		logWriter := io.MultiWriter(logWriters...)
 
-		logger = log.New(log.WithLevel(cfg.Log.Level), log.WithFormat(cfg.Log.Format),
+		logger = log.New(log.WithLevelVar(levelVar), log.WithFormat(cfg.Log.Format),
 			log.WithName("KWILD"), log.WithWriter(logWriter))
 		// NOTE: level and name can be set independently for different systems
 	}
@@ -85,8 +94,8 @@ func runNode(ctx context.Context, rootDir string, cfg *config.Config, autogen bo
 	logger.Infof("Starting kwild version %v", version.KwilVersion)
 
 	// sanity checks on config
-	if cfg.Consensus.ProposeTimeout < config.MinProposeTimeout {
-		return fmt.Errorf("propose timeout should be at least %s", config.MinProposeTimeout.String())
+	if err := cfg.Validate(); err != nil {
+		return err
 	}
 
 	genFile := config.GenesisFilePath(rootDir)
@@ -133,6 +142,16 @@ func runNode(ctx context.Context, rootDir string, cfg *config.Config, autogen bo
 		return fmt.Errorf("genesis configuration failed sanity checks: %w", err)
 	}
 
+	// The EVM chains must be registered before the engine starts extensions
+	// that listen to them, and before the bridge config that refers to them
+	// is validated.
+	if err := genConfig.RegisterEVMChains(); err != nil {
+		return fmt.Errorf("failed to register the genesis evm chains: %w", err)
+	}
+	if err := cfg.Erc20Bridge.Validate(); err != nil {
+		return fmt.Errorf("invalid erc20_bridge config: %w", err)
+	}
+
 	if cfg.GenesisState != "" {
 		cfg.GenesisState = rootedPath(cfg.GenesisState, rootDir)
 	}
@@ -153,6 +172,29 @@ func runNode(ctx context.Context, rootDir string, cfg *config.Config, autogen bo
 		}
 	}
 
+	// Reloaded configs are compared with the config file as it is now, rather
+	// than cfg, which was modified above.
+	loadedCfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var embeddedDB *pg.EmbeddedServer
+	if cfg.DB.Embedded.Enable {
+		embeddedDB, err = startEmbeddedDB(ctx, rootDir, cfg, logger)
+		if err != nil {
+			return fmt.Errorf("failed to start embedded postgres server: %w", err)
+		}
+		defer func() {
+			logger.Info("Stopping embedded postgres server")
+			if err := embeddedDB.Stop(); err != nil {
+				logger.Errorf("failed to stop embedded postgres server: %v", err)
+			}
+		}()
+		cfg.DB.Host = "127.0.0.1" // also for pg_dump and psql
+		host = cfg.DB.Host
+	}
+
 	nsmgr := newNamespaceManager()
 
 	d := &coreDependencies{
@@ -162,10 +204,16 @@ func runNode(ctx context.Context, rootDir string, cfg *config.Config, autogen bo
 		genesisCfg:       genConfig,
 		privKey:          privKey,
 		logger:           logger,
+		metricsHandler:   metricsHandler,
+		embeddedDB:       embeddedDB,
 		autogen:          autogen,
 		dbOpener:         newDBOpener(host, port, user, pass, nsmgr.Filter),
 		namespaceManager: nsmgr,
 		poolOpener:       newPoolBOpener(host, port, user, pass),
+		levelVar:         levelVar,
+		loadConfig:       loadConfig,
+		loadedCfg:        loadedCfg,
+		reloadSignals:    reloadSignals,
 	}
 
 	// Catch any panic from buildServer. We use a panic based build failure
@@ -224,6 +272,20 @@ func (s *server) Start(ctx context.Context) error {
 		}
 	})
 
+	if s.embeddedDB != nil {
+		group.Go(func() error {
+			// The embedded server is only stopped after Start returns, so it
+			// has crashed or been killed if it exits first.
+			select {
+			case <-s.embeddedDB.Done():
+				s.log.Error("Embedded postgres server exited unexpectedly, shutting down server.")
+				return fmt.Errorf("embedded postgres server stopped: %w", s.embeddedDB.Err())
+			case <-groupCtx.Done():
+				return nil
+			}
+		})
+	}
+
 	// start rpc services
 	group.Go(func() error {
 		s.log.Info("starting user json-rpc server", "listen", s.cfg.RPC.ListenAddress)
@@ -268,6 +330,14 @@ func (s *server) Start(ctx context.Context) error {
 		})
 	}
 
+	// Reload the config when signaled
+	if s.reloadSignals != nil {
+		group.Go(func() error {
+			reloadOnSignal(groupCtx, s.reloadSignals, s.reloader, s.log)
+			return nil
+		})
+	}
+
 	// TODO: node is starting the consensus engine for ease of testing
 	// Start the consensus engine
 
@@ -301,7 +371,9 @@ func readOrCreatePrivateKeyFile(rootDir string, autogen bool) (privKey crypto.Pr
 		return privKey, nil
 	}
 
-	if !autogen {
+	// Never replace a key file that exists, e.g. an encrypted key without a
+	// passphrase.
+	if !autogen || !errors.Is(err, os.ErrNotExist) {
 		return nil, fmt.Errorf("failed to load node key: %w", err)
 	}
 
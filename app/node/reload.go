@@ -0,0 +1,262 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kwilteam/kwil-db/config"
+	"github.com/kwilteam/kwil-db/core/log"
+	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
+	"github.com/kwilteam/kwil-db/node"
+	"github.com/kwilteam/kwil-db/node/mempool"
+	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/ratelimit"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/usersvc"
+	"github.com/kwilteam/kwil-db/node/snapshotter"
+)
+
+// reloadableConfig lists the config settings that may be changed without
+// restarting the node. An entry also covers the settings nested under it.
+var reloadableConfig = []string{
+	"log.level",
+	"rpc.timeout",
+	"rpc.max_req_size",
+	"rpc.rate_limit",
+	"p2p.whitelist",
+	"mempool.max_size",
+	"mempool.max_tx_bytes",
+	"snapshots.recurring_height",
+	"snapshots.max_snapshots",
+}
+
+func isReloadable(key string) bool {
+	return slices.ContainsFunc(reloadableConfig, func(r string) bool {
+		return key == r || strings.HasPrefix(key, r+".")
+	})
+}
+
+// configReloader applies changes to the config file to the running node. Only
+// the settings in reloadableConfig are applied, while changes to any others are
+// reported as requiring a restart.
+type configReloader struct {
+	load func() (*config.Config, error)
+	log  log.Logger
+
+	levelVar     *log.LevelVar
+	rpcServer    *rpcserver.Server
+	userSvc      *usersvc.Service
+	whitelist    *node.WhitelistMgr
+	mempool      *mempool.Mempool
+	maxBlockSize func() int64
+	snapshots    *snapshotter.SnapshotStore
+
+	mtx sync.Mutex
+	// loaded is the config loaded on startup with the changes applied since.
+	// Changes are detected relative to it, so a change that requires a
+	// restart is reported until the node is restarted.
+	loaded *config.Config
+	// cfg is the config in effect, which is never modified in place.
+	cfg *config.Config
+}
+
+// Config returns the config in effect.
+func (r *configReloader) Config() *config.Config {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.cfg
+}
+
+// ReloadConfig loads and validates the config, and applies the changed
+// settings that may be changed at runtime.
+func (r *configReloader) ReloadConfig(_ context.Context) (*adminTypes.ConfigReload, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	newCfg, err := r.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := newCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	res := &adminTypes.ConfigReload{
+		Applied:         []string{},
+		RequiresRestart: []string{},
+	}
+	changed := make(map[string]bool)
+	for _, key := range configChanges(r.loaded, newCfg) {
+		if isReloadable(key) {
+			changed[key] = true
+		} else {
+			res.RequiresRestart = append(res.RequiresRestart, key)
+		}
+	}
+	changedUnder := func(prefix string) bool {
+		for key := range changed {
+			if key == prefix || strings.HasPrefix(key, prefix+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Changes are made to copies, with the settings shared by reference (maps
+	// and slices) replaced rather than modified.
+	loaded, cfg := *r.loaded, *r.cfg
+
+	// The settings that may fail to apply go first so that an error leaves
+	// the node unchanged.
+	if changedUnder("p2p.whitelist") {
+		if err := r.whitelist.UpdateConfigured(cfg.P2P.Whitelist, newCfg.P2P.Whitelist); err != nil {
+			return nil, err
+		}
+		loaded.P2P.Whitelist, cfg.P2P.Whitelist = newCfg.P2P.Whitelist, newCfg.P2P.Whitelist
+	}
+
+	if changedUnder("rpc.timeout") {
+		if err := r.rpcServer.SetTimeout(time.Duration(newCfg.RPC.Timeout)); err != nil {
+			r.log.Warnf("Unable to apply rpc.timeout: %v", err)
+			delete(changed, "rpc.timeout")
+			res.RequiresRestart = append(res.RequiresRestart, "rpc.timeout")
+		} else {
+			loaded.RPC.Timeout, cfg.RPC.Timeout = newCfg.RPC.Timeout, newCfg.RPC.Timeout
+		}
+	}
+
+	if changedUnder("snapshots.recurring_height") || changedUnder("snapshots.max_snapshots") {
+		loaded.Snapshots.RecurringHeight, cfg.Snapshots.RecurringHeight = newCfg.Snapshots.RecurringHeight, newCfg.Snapshots.RecurringHeight
+		loaded.Snapshots.MaxSnapshots, cfg.Snapshots.MaxSnapshots = newCfg.Snapshots.MaxSnapshots, newCfg.Snapshots.MaxSnapshots
+		if err := r.snapshots.SetLimits(newCfg.Snapshots.RecurringHeight, int(newCfg.Snapshots.MaxSnapshots)); err != nil {
+			// The limits are set, but old snapshots remain.
+			r.log.Warnf("Failed to prune snapshots: %v", err)
+		}
+	}
+
+	if changedUnder("log.level") {
+		r.levelVar.Set(newCfg.Log.Level)
+		loaded.Log.Level, cfg.Log.Level = newCfg.Log.Level, newCfg.Log.Level
+	}
+
+	if changedUnder("rpc.max_req_size") {
+		r.rpcServer.SetReqSizeLimit(newCfg.RPC.MaxReqSize)
+		loaded.RPC.MaxReqSize, cfg.RPC.MaxReqSize = newCfg.RPC.MaxReqSize, newCfg.RPC.MaxReqSize
+	}
+
+	if changedUnder("rpc.rate_limit") {
+		rlCfg := newCfg.RPC.RateLimit
+		var limiter *ratelimit.MethodLimiter
+		var identityCfg *ratelimit.MethodLimiterConfig
+		if rlCfg.Enable {
+			limiter = ratelimit.NewMethodLimiter(&ratelimit.MethodLimiterConfig{
+				RPS:   rlCfg.Rate,
+				Burst: rlCfg.Burst,
+				Costs: rlCfg.MethodCosts,
+			})
+			if rlCfg.IdentityRate > 0 {
+				identityCfg = &ratelimit.MethodLimiterConfig{
+					RPS:   rlCfg.IdentityRate,
+					Burst: rlCfg.IdentityBurst,
+					Costs: rlCfg.MethodCosts,
+				}
+			}
+		}
+		r.rpcServer.SetRateLimiter(limiter)
+		r.userSvc.SetIdentityRateLimit(identityCfg)
+		loaded.RPC.RateLimit, cfg.RPC.RateLimit = rlCfg, rlCfg
+	}
+
+	if changedUnder("mempool") {
+		r.mempool.SetMaxSize(newCfg.Mempool.MaxSize)
+		r.mempool.SetMaxTxSize(min(newCfg.Mempool.MaxTxBytes, r.maxBlockSize()))
+		loaded.Mempool, cfg.Mempool = newCfg.Mempool, newCfg.Mempool
+	}
+
+	for key := range changed {
+		res.Applied = append(res.Applied, key)
+	}
+	slices.Sort(res.Applied)
+	slices.Sort(res.RequiresRestart)
+
+	r.loaded, r.cfg = &loaded, &cfg
+
+	return res, nil
+}
+
+// configChanges returns the keys of the settings that differ between two
+// configs, such as "rpc.timeout" or "extensions.my_ext.foo", in sorted order.
+func configChanges(a, b *config.Config) []string {
+	fa, fb := flattenConfig(a), flattenConfig(b)
+	var keys []string
+	for key, va := range fa {
+		if vb, ok := fb[key]; !ok || !reflect.DeepEqual(va, vb) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range fb {
+		if _, ok := fa[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// flattenConfig maps the keys of all of the settings in a config to their
+// values. The keys are formed from the toml tags of the fields, and from the
+// keys of any maps.
+func flattenConfig(cfg *config.Config) map[string]any {
+	m := make(map[string]any)
+	flattenValue("", reflect.ValueOf(cfg).Elem(), m)
+	return m
+}
+
+func flattenValue(key string, v reflect.Value, m map[string]any) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			flattenValue(joinKey(key, fieldKey(field)), v.Field(i), m)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			flattenValue(joinKey(key, fmt.Sprint(iter.Key().Interface())), iter.Value(), m)
+		}
+	case reflect.Slice:
+		if v.Len() == 0 { // nil and empty are the same
+			m[key] = nil
+			return
+		}
+		m[key] = v.Interface()
+	default:
+		m[key] = v.Interface()
+	}
+}
+
+// fieldKey returns the config key of a struct field, which is the name in the
+// toml tag, or the mapstructure tag, or else the field name.
+func fieldKey(field reflect.StructField) string {
+	for _, tag := range []string{"toml", "mapstructure"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
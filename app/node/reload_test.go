@@ -0,0 +1,85 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/config"
+	"github.com/kwilteam/kwil-db/core/log"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/mempool"
+	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
+)
+
+func Test_configChanges(t *testing.T) {
+	a := config.DefaultConfig()
+	b := config.DefaultConfig()
+	require.Empty(t, configChanges(a, b))
+
+	b.Log.Level = log.LevelDebug
+	b.P2P.ListenAddress = "127.0.0.1:7000"
+	b.P2P.Whitelist = []string{} // same as nil
+	b.Mempool.MaxTxBytes++
+	b.Extensions = map[string]map[string]string{"my_ext": {"foo": "bar"}}
+
+	require.Equal(t, []string{
+		"extensions.my_ext.foo",
+		"log.level",
+		"mempool.max_tx_bytes",
+		"p2p.listen",
+	}, configChanges(a, b))
+}
+
+func Test_configReloader(t *testing.T) {
+	loaded := config.DefaultConfig()
+	fileCfg := config.DefaultConfig()
+
+	srv, err := rpcserver.NewServer("127.0.0.1:0", log.DiscardLogger,
+		rpcserver.WithTimeout(time.Duration(loaded.RPC.Timeout)))
+	require.NoError(t, err)
+
+	levelVar := log.NewLevelVar(loaded.Log.Level)
+	r := &configReloader{
+		load:         func() (*config.Config, error) { return fileCfg, nil },
+		log:          log.DiscardLogger,
+		levelVar:     levelVar,
+		rpcServer:    srv,
+		mempool:      mempool.New(loaded.Mempool.MaxSize, loaded.Mempool.MaxTxBytes),
+		maxBlockSize: func() int64 { return 1 << 20 },
+		loaded:       loaded,
+		cfg:          loaded,
+	}
+
+	fileCfg.Log.Level = log.LevelDebug
+	fileCfg.RPC.Timeout = types.Duration(time.Second)
+	fileCfg.Mempool.MaxSize /= 2
+	fileCfg.DB.DBName = "other"
+
+	res, err := r.ReloadConfig(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"log.level", "mempool.max_size", "rpc.timeout"}, res.Applied)
+	require.Equal(t, []string{"db.dbname"}, res.RequiresRestart)
+
+	require.Equal(t, log.LevelDebug, levelVar.Level())
+	require.Equal(t, time.Second, srv.Timeout())
+	require.Equal(t, log.LevelDebug, r.Config().Log.Level)
+	require.NotEqual(t, "other", r.Config().DB.DBName)
+	require.Equal(t, log.LevelInfo, loaded.Log.Level) // not modified in place
+
+	// A restart is still required, and the timeout may not exceed the one the
+	// server was created with.
+	fileCfg.RPC.Timeout = loaded.RPC.Timeout * 2
+	res, err = r.ReloadConfig(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, res.Applied)
+	require.Equal(t, []string{"db.dbname", "rpc.timeout"}, res.RequiresRestart)
+	require.Equal(t, time.Second, srv.Timeout())
+
+	// An invalid config is rejected.
+	fileCfg.Consensus.ProposeTimeout = 0
+	_, err = r.ReloadConfig(context.Background())
+	require.Error(t, err)
+}
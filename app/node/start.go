@@ -2,8 +2,13 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -11,6 +16,8 @@ import (
 	"github.com/kwilteam/kwil-db/app/node/conf"
 	"github.com/kwilteam/kwil-db/app/shared/bind"
 	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/config"
+	"github.com/kwilteam/kwil-db/core/log"
 	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/version"
 )
@@ -20,7 +27,7 @@ const (
 )
 
 func StartCmd() *cobra.Command {
-	var autogen bool
+	var autogen, embeddedDB bool
 	var dbOwner string
 	cmd := &cobra.Command{
 		Use:               "start",
@@ -41,29 +48,53 @@ func StartCmd() *cobra.Command {
 				return err
 			}
 
-			cfg := conf.ActiveConfig()
-
-			// we don't need to worry about order of priority with applying the extension
-			// flag configs because flags are always highest priority
-
-			// we merge the flags here because we don't want to totally delete all
-			// other extension flags. For example, if we have the extension
-			// "my_ext" configured with key "foo" and value "bar" in the config file,
-			// and we pass the flag "--extension.erc20.rpc=http://localhost:8545",
-			// we want to keep the "foo" key in the "my_ext" extension.
-			for extName, extConf := range extConfs {
-				existing, ok := cfg.Extensions[extName]
-				if !ok {
-					existing = make(map[string]string)
+			// prepareConfig applies the settings that are not merged into the
+			// config by the root command's preruns. It is used on startup and
+			// when the config is reloaded.
+			prepareConfig := func(cfg *config.Config) {
+				// we don't need to worry about order of priority with applying the extension
+				// flag configs because flags are always highest priority
+
+				// we merge the flags here because we don't want to totally delete all
+				// other extension flags. For example, if we have the extension
+				// "my_ext" configured with key "foo" and value "bar" in the config file,
+				// and we pass the flag "--extension.erc20.rpc=http://localhost:8545",
+				// we want to keep the "foo" key in the "my_ext" extension.
+				for extName, extConf := range extConfs {
+					existing, ok := cfg.Extensions[extName]
+					if !ok {
+						existing = make(map[string]string)
+					}
+
+					for k, v := range extConf {
+						existing[k] = v
+					}
+
+					cfg.Extensions[extName] = existing
 				}
 
-				for k, v := range extConf {
-					existing[k] = v
+				// Set the empty block timeout to the propose timeout if not set
+				// if the node is running in autogen mode
+				if !cmd.Flags().Changed(emptyBlockTimeoutFlag) && autogen {
+					cfg.Consensus.EmptyBlockTimeout = cfg.Consensus.ProposeTimeout
 				}
 
-				cfg.Extensions[extName] = existing
+				if embeddedDB {
+					cfg.DB.Embedded.Enable = true
+				}
+			}
+			loadConfig := func() (*config.Config, error) {
+				cfg, err := conf.Reload(cmd.Flags())
+				if err != nil {
+					return nil, err
+				}
+				prepareConfig(cfg)
+				return cfg, nil
 			}
 
+			cfg := conf.ActiveConfig()
+			prepareConfig(cfg)
+
 			bind.Debugf("effective node config (toml):\n%s", bind.LazyPrinter(func() string {
 				rawToml, err := cfg.ToTOML()
 				if err != nil {
@@ -79,8 +110,27 @@ func StartCmd() *cobra.Command {
 			}
 			defer stopProfiler()
 
-			if cfg.Telemetry.Enable {
-				stopMetrics, err := metrics.StartOTEL(cmd.Context(), metrics.WithOTELEndpoint(cfg.Telemetry.OTLPEndpoint))
+			var metricsHandler http.Handler // Prometheus exporter, if enabled
+			if cfg.Telemetry.Enable || cfg.Telemetry.Prometheus != "" {
+				metricsOpts := []metrics.OTELOption{metrics.WithOTELEndpoint(cfg.Telemetry.OTLPEndpoint)}
+				if !cfg.Telemetry.Enable {
+					metricsOpts = append(metricsOpts, metrics.WithoutOTLP())
+				}
+				switch cfg.Telemetry.Prometheus {
+				case "":
+				case config.PrometheusOnRPC, config.PrometheusOnAdmin:
+					if cfg.Telemetry.Prometheus == config.PrometheusOnAdmin && !cfg.Admin.Enable {
+						return errors.New("telemetry.prometheus: the admin service is not enabled")
+					}
+					exp := metrics.NewPrometheusExporter()
+					metricsOpts = append(metricsOpts, metrics.WithPrometheus(exp))
+					metricsHandler = exp
+				default:
+					return fmt.Errorf("telemetry.prometheus: invalid listener %q (must be %q or %q)",
+						cfg.Telemetry.Prometheus, config.PrometheusOnRPC, config.PrometheusOnAdmin)
+				}
+
+				stopMetrics, err := metrics.StartOTEL(cmd.Context(), metricsOpts...)
 				if err != nil {
 					cmd.Usage()
 					return err
@@ -88,13 +138,13 @@ func StartCmd() *cobra.Command {
 				defer stopMetrics(context.Background())
 			}
 
-			// Set the empty block timeout to the propose timeout if not set
-			// if the node is running in autogen mode
-			if !cmd.Flags().Changed(emptyBlockTimeoutFlag) && autogen {
-				cfg.Consensus.EmptyBlockTimeout = cfg.Consensus.ProposeTimeout
-			}
+			// Reload the config on SIGHUP, like with `kwild admin reload-config`.
+			reloadSignals := make(chan os.Signal, 1)
+			signal.Notify(reloadSignals, syscall.SIGHUP)
+			defer signal.Stop(reloadSignals)
 
-			err = runNode(cmd.Context(), rootDir, cfg, autogen, dbOwner)
+			err = runNode(cmd.Context(), rootDir, cfg, autogen, dbOwner, metricsHandler,
+				loadConfig, reloadSignals)
 			if err != nil {
 				return display.PrintErr(cmd, fmt.Errorf("node stopped with error: %w", err))
 			}
@@ -110,11 +160,35 @@ func StartCmd() *cobra.Command {
 	cmd.SetVersionTemplate(custom.BinaryConfig.NodeCmd + " {{printf \"version %s\" .Version}}\n")
 	cmd.Flags().BoolVarP(&autogen, "autogen", "a", false,
 		"auto generate private key, genesis file, and config file if not exist")
+	cmd.Flags().BoolVar(&embeddedDB, "embedded-db", false,
+		"run an embedded postgres server with the locally installed binaries, with its data in the root directory (same as --db.embedded.enable)")
 	cmd.Flags().StringVarP(&dbOwner, "db-owner", "d", "", "owner of the database. This is either a hex pubkey or an address string")
 
 	return cmd
 }
 
+// reloadOnSignal reloads the config each time a signal is received, until the
+// context is canceled. The outcome is logged.
+func reloadOnSignal(ctx context.Context, signals <-chan os.Signal, reloader *configReloader, logger log.Logger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			logger.Infof("Reloading config on %v", sig)
+			res, err := reloader.ReloadConfig(ctx)
+			if err != nil {
+				logger.Errorf("Failed to reload config: %v", err)
+				continue
+			}
+			logger.Info("Reloaded config", "applied", res.Applied)
+			if len(res.RequiresRestart) > 0 {
+				logger.Warn("Changed config settings require a restart", "settings", res.RequiresRestart)
+			}
+		}
+	}
+}
+
 // parseExtensionFlags parses the extension flags from the command line and
 // returns a map of extension names to their configured values
 func parseExtensionFlags(args []string) (map[string]map[string]string, error) {
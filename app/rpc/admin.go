@@ -28,6 +28,7 @@ func BindRPCFlags(cmd *cobra.Command) {
 
 	cmd.PersistentFlags().String("authrpc-cert", "", "kwild's TLS server certificate, required for HTTPS server")
 	cmd.PersistentFlags().String("pass", "", "admin server password (alternative to mTLS with tlskey/tlscert)")
+	cmd.PersistentFlags().String("token", "", "admin API token, which may be limited in scope (alternative to pass)")
 	cmd.PersistentFlags().String("tlskey", "auth.key", "TLS client key file to establish a mTLS (authenticated) connection")
 	cmd.PersistentFlags().String("tlscert", "auth.cert", "TLS client certificate file for server to authenticate us")
 }
@@ -71,6 +72,12 @@ func AdminSvcClient(ctx context.Context, cmd *cobra.Command) (*adminclient.Admin
 		adminOpts = append(adminOpts, adminclient.WithPass(pass))
 	}
 
+	if token, err := cmd.Flags().GetString("token"); err != nil {
+		return nil, err
+	} else if token != "" {
+		adminOpts = append(adminOpts, adminclient.WithToken(token))
+	}
+
 	return adminclient.NewClient(ctx, rpcServer, adminOpts...)
 }
 
@@ -16,6 +16,8 @@ func NewAdminCmd() *cobra.Command {
 
 	adminCmd.AddCommand(
 		dumpCfgCmd(),
+		reloadCfgCmd(),
+		tokensCmd(),
 		versionCmd(),
 		statusCmd(),
 		peersCmd(),
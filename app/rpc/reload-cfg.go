@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reloadCfgLong = "The `reload-config` command instructs the running node to re-read its config file and apply the changed settings that do not require a restart. " +
+		"These are the log level, the RPC timeout, request size and rate limits, the P2P whitelist, the mempool limits, and the snapshot creation period and retention. " +
+		"Any other changed settings are listed as requiring a restart. The node also reloads its config when it receives SIGHUP."
+	reloadCfgExample = `# Apply changes to the config file to the running node.
+kwild admin reload-config --rpcserver /tmp/kwild.socket`
+)
+
+func reloadCfgCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "reload-config",
+		Short:   "Reload the node's config file, applying the settings that do not require a restart.",
+		Long:    reloadCfgLong,
+		Example: reloadCfgExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			client, err := AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			res, err := client.ReloadConfig(ctx)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, &reloadCfgMsg{res})
+		},
+	}
+
+	BindRPCFlags(cmd)
+
+	return cmd
+}
+
+type reloadCfgMsg struct {
+	*adminTypes.ConfigReload
+}
+
+var _ display.MsgFormatter = (*reloadCfgMsg)(nil)
+
+func (r *reloadCfgMsg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.ConfigReload)
+}
+
+func (r *reloadCfgMsg) MarshalText() ([]byte, error) {
+	var msg strings.Builder
+	if len(r.Applied) == 0 {
+		msg.WriteString("No changed settings were applied.\n")
+	} else {
+		fmt.Fprintf(&msg, "Applied: %s\n", strings.Join(r.Applied, ", "))
+	}
+	if len(r.RequiresRestart) > 0 {
+		fmt.Fprintf(&msg, "Requires a restart: %s\n", strings.Join(r.RequiresRestart, ", "))
+	}
+	return []byte(msg.String()), nil
+}
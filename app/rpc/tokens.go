@@ -0,0 +1,194 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	types "github.com/kwilteam/kwil-db/core/types/admin"
+)
+
+var (
+	tokensLong = "The `tokens` commands manage the named credentials for the admin service, which are each limited to a set of scopes. " +
+		"A credential is either an API token, used with the `--token` flag, or a TLS client certificate trusted by the node. " +
+		"The scopes are: " + strings.Join(types.Scopes, ", ") + ". The `" + types.ScopeAdmin + "` scope permits every method, including managing tokens. " +
+		"Once any token exists, requests without credentials are rejected unless they are on the admin service's UNIX socket."
+
+	mintTokenLong = "The `mint` command creates a named API token with the given scopes, and prints its secret, which cannot be retrieved again. " +
+		"With `--cert`, the scopes are granted to the TLS client certificate in the given PEM file instead. " +
+		"The certificate must also be in the node's clients.pem file to be accepted for mutual TLS."
+	mintTokenExample = `# Mint a token for monitoring the node.
+kwild admin tokens mint monitor --scopes status
+
+# Limit a TLS client certificate to peer management.
+kwild admin tokens mint peer-manager --scopes status,peers --cert ./peer-manager.cert`
+)
+
+func tokensCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Manage the admin service's scoped API tokens and client certificates.",
+		Long:  tokensLong,
+	}
+
+	cmd.AddCommand(
+		mintTokenCmd(),
+		revokeTokenCmd(),
+		listTokensCmd(),
+	)
+
+	return cmd
+}
+
+func mintTokenCmd() *cobra.Command {
+	var scopes []string
+	var certFile string
+	cmd := &cobra.Command{
+		Use:     "mint <name>",
+		Short:   "Create a named admin API token, or grant scopes to a client certificate.",
+		Long:    mintTokenLong,
+		Example: mintTokenExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			client, err := AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			var cert []byte
+			if certFile != "" {
+				cert, err = os.ReadFile(certFile)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+			}
+
+			token, err := client.MintToken(ctx, args[0], scopes, cert)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, &mintTokenMsg{name: args[0], token: token})
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&scopes, "scopes", nil, "scopes to grant (comma separated): "+strings.Join(types.Scopes, ", "))
+	cmd.Flags().StringVar(&certFile, "cert", "", "PEM file of a TLS client certificate to grant the scopes to, instead of minting an API token")
+	cmd.MarkFlagRequired("scopes")
+	BindRPCFlags(cmd)
+
+	return cmd
+}
+
+type mintTokenMsg struct {
+	name  string
+	token string
+}
+
+var _ display.MsgFormatter = (*mintTokenMsg)(nil)
+
+func (m *mintTokenMsg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name  string `json:"name"`
+		Token string `json:"token,omitempty"`
+	}{
+		Name:  m.name,
+		Token: m.token,
+	})
+}
+
+func (m *mintTokenMsg) MarshalText() ([]byte, error) {
+	if m.token == "" {
+		return []byte("Granted scopes to client certificate " + m.name), nil
+	}
+	return []byte(fmt.Sprintf("Minted token %s. Store the secret now, it will not be shown again:\n%s", m.name, m.token)), nil
+}
+
+func revokeTokenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "revoke <name>",
+		Short:   "Revoke a named admin API token or client certificate.",
+		Long:    "The `revoke` command deletes the named API token or client certificate scopes, so that it may no longer be used.",
+		Example: "kwild admin tokens revoke monitor",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			client, err := AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if err = client.RevokeToken(ctx, args[0]); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespString("Revoked token "+args[0]))
+		},
+	}
+
+	BindRPCFlags(cmd)
+
+	return cmd
+}
+
+func listTokensCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the admin API tokens and client certificates with their scopes.",
+		Long:    "The `list` command lists the admin API tokens and client certificates with their scopes. The secrets of the tokens are not known to the node.",
+		Example: "kwild admin tokens list",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			client, err := AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			tokens, err := client.ListTokens(ctx)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, &tokensMsg{tokens: tokens})
+		},
+	}
+
+	BindRPCFlags(cmd)
+
+	return cmd
+}
+
+type tokensMsg struct {
+	tokens []*types.Token
+}
+
+var _ display.MsgFormatter = (*tokensMsg)(nil)
+
+func (t *tokensMsg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.tokens)
+}
+
+func (t *tokensMsg) MarshalText() ([]byte, error) {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSCOPES\tCERTIFICATE\tCREATED")
+	for _, tok := range t.tokens {
+		cert := "-"
+		if tok.CertFingerprint != "" {
+			cert = tok.CertFingerprint[:16]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tok.Name, strings.Join(tok.Scopes, ","), cert,
+			tok.Created.Format(time.RFC3339))
+	}
+	w.Flush()
+	return []byte(strings.TrimSuffix(sb.String(), "\n")), nil
+}
@@ -18,24 +18,32 @@ import (
 	"github.com/kwilteam/kwil-db/core/crypto/auth"
 	"github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/node"
+	"github.com/kwilteam/kwil-db/node/exts/evm-sync/chains"
 )
 
 var (
 	genesisLong = `The ` + "`genesis`" + ` command creates a new ` + "`genesis.json`" + ` file with optionally specified modifications.
 
-Validators and balance allocations should have the format "pubkey:power", "address:balance" respectively.`
+Validators and balance allocations should have the format "pubkey:power", "address:balance" respectively.
+
+EVM chains declared with --evm-chain are part of the initial app hash, and cannot be added or changed once the
+network has started. A running network can only use a new chain by migrating to a new genesis.`
 
 	genesisExample = `# Create a new genesis.json file in a specific directory with a specific chain ID and a validator with 1 power
 kwild setup genesis --out /path/to/directory --chain-id mychainid --validator 890fe7ae9cb1fa6177555d5651e1b8451b4a9c64021c876236c700bc2690ff1d:1
 
 # Create a new genesis.json with the specified allocation
-kwild setup genesis --alloc 0x7f5f4552091a69125d5dfcb7b8c2659029395bdf:100`
+kwild setup genesis --alloc 0x7f5f4552091a69125d5dfcb7b8c2659029395bdf:100
+
+# Create a new genesis.json that supports a local anvil devnet as an EVM chain
+kwild setup genesis --evm-chain anvil:31337:1`
 )
 
 type genesisFlagConfig struct {
 	chainID    string
 	validators []string
 	allocs     []string
+	evmChains  []string
 	networkParams
 }
 
@@ -132,6 +140,7 @@ func bindGenesisFlags(cmd *cobra.Command, cfg *genesisFlagConfig) {
 	cmd.Flags().StringVar(&cfg.chainID, chainIDFlag, "", "chainID for the genesis.json file")
 	cmd.Flags().StringSliceVar(&cfg.validators, validatorsFlag, nil, "public key, keyType and power of initial validator(s), may be specified multiple times") // accept: [hexpubkey1#keyType1:power1]
 	cmd.Flags().StringSliceVar(&cfg.allocs, allocsFlag, nil, "address and initial balance allocation(s) in the format id#keyType:amount")
+	cmd.Flags().StringSliceVar(&cfg.evmChains, evmChainsFlag, nil, "additional EVM chain(s) that extensions may use, in the format name:chainID:requiredConfirmations")
 	bindNetworkParamsFlags(cmd, &cfg.networkParams)
 }
 
@@ -148,6 +157,7 @@ const (
 	chainIDFlag       = "chain-id"
 	validatorsFlag    = "validator"
 	allocsFlag        = "alloc"
+	evmChainsFlag     = "evm-chain"
 	withGasFlag       = "with-gas"
 	leaderFlag        = "leader"
 	dbOwnerFlag       = "db-owner"
@@ -216,6 +226,17 @@ func mergeGenesisFlags(conf *config.GenesisConfig, cmd *cobra.Command, flagCfg *
 		conf.Allocs = append(conf.Allocs, allocs...)
 	}
 
+	if cmd.Flags().Changed(evmChainsFlag) {
+		evmChains, err := parseEVMChains(flagCfg.evmChains)
+		if err != nil {
+			return nil, err
+		}
+		conf.EVMChains = evmChains
+		if err = conf.RegisterEVMChains(); err != nil {
+			return nil, err
+		}
+	}
+
 	return mergeNetworkParamFlags(conf, cmd, &flagCfg.networkParams)
 }
 
@@ -272,6 +293,32 @@ func parseAllocs(allocs []string) ([]config.GenesisAlloc, error) {
 	return res, nil
 }
 
+func parseEVMChains(evmChains []string) ([]chains.ChainInfo, error) {
+	var res []chains.ChainInfo
+	for _, c := range evmChains {
+		parts := strings.Split(c, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid format for evm chain, expected name:chainID:requiredConfirmations, received: %s", c)
+		}
+
+		confirmations, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid required confirmations for evm chain: %s", parts[2])
+		}
+
+		chain := chains.ChainInfo{
+			Name:                  chains.Chain(strings.ToLower(parts[0])),
+			ID:                    parts[1],
+			RequiredConfirmations: confirmations,
+		}
+		if err = chain.Validate(); err != nil {
+			return nil, err
+		}
+		res = append(res, chain)
+	}
+	return res, nil
+}
+
 func mergeNetworkParamFlags(conf *config.GenesisConfig, cmd *cobra.Command, flagCfg *networkParams) (*config.GenesisConfig, error) {
 	if cmd.Flags().Changed(withGasFlag) {
 		conf.DisabledGasCosts = !flagCfg.withGas
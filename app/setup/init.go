@@ -158,6 +158,9 @@ func InitCmd() *cobra.Command {
 				if err != nil {
 					return display.PrintErr(cmd, fmt.Errorf("failed to load genesis file: %w", err))
 				}
+				if err := genCfg.RegisterEVMChains(); err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("invalid evm chains in genesis file: %w", err))
+				}
 
 				if err := genCfg.SaveAs(genFile); err != nil {
 					return display.PrintErr(cmd, fmt.Errorf("failed to copy genesis file: %w", err))
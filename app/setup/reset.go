@@ -54,16 +54,26 @@ func ResetCmd() *cobra.Command {
 			}
 
 			dbCfg := conf.ActiveConfig().DB
-			pgConf, err := bind.GetPostgresFlags(cmd, &dbCfg)
-			if err != nil {
-				return display.PrintErr(cmd, fmt.Errorf("failed to get postgres flags: %v", err))
-			}
+			if dbCfg.Embedded.Enable {
+				// The embedded server is not running, so remove its data
+				// directory. It is initialized again on start.
+				dataDir := config.EmbeddedDBDir(rootDir)
+				if err := os.RemoveAll(dataDir); err != nil {
+					return err
+				}
+				fmt.Printf("Embedded postgres data directory removed: %s\n", dataDir)
+			} else {
+				pgConf, err := bind.GetPostgresFlags(cmd, &dbCfg)
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("failed to get postgres flags: %v", err))
+				}
 
-			err = resetPGState(cmd.Context(), pgConf)
-			if err != nil {
-				return err
+				err = resetPGState(cmd.Context(), pgConf)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Postgres state reset. Host: %s; Port: %s; Database: %s\n", pgConf.Host, pgConf.Port, pgConf.DBName)
 			}
-			fmt.Printf("Postgres state reset. Host: %s; Port: %s; Database: %s\n", pgConf.Host, pgConf.Port, pgConf.DBName)
 
 			if all {
 				// remove the blockstore if all is set
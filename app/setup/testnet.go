@@ -27,7 +27,7 @@ import (
 
 func TestnetCmd() *cobra.Command {
 	var numVals, numNVals int
-	var noPex, uniquePorts bool
+	var noPex, uniquePorts, embeddedDB bool
 	var startingPort uint64
 	var outDir, chainID string
 	var hostnames, allocs []string
@@ -38,7 +38,8 @@ func TestnetCmd() *cobra.Command {
 		Use:   "testnet",
 		Short: "Generate configuration for a new test network with multiple nodes",
 		Long: "The `testnet` command generates a configuration for a new test network with multiple nodes.\n\n" +
-			"For a configuration set that can be run on the same host, use the `--unique-ports` flag.",
+			"For a configuration set that can be run on the same host, use the `--unique-ports` flag. " +
+			"With the `--embedded-db` flag, each node runs its own postgres server, so no database needs to be provisioned.",
 		// Override the root command's PersistentPreRunE, so that we don't
 		// try to read the config from a ~/.kwild directory
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
@@ -67,6 +68,7 @@ func TestnetCmd() *cobra.Command {
 				StartingPort: startingPort,
 				Hostnames:    hostnames,
 				ChainID:      chainID,
+				EmbeddedDB:   embeddedDB,
 			}, &ConfigOpts{
 				UniquePorts: uniquePorts,
 				DnsHost:     false,
@@ -94,6 +96,7 @@ func TestnetCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&outDir, "out-dir", "o", ".testnet", "output directory for generated node root directories")
 	cmd.Flags().BoolVarP(&uniquePorts, "unique-ports", "u", false, "use unique ports for each node")
 	cmd.Flags().StringSliceVarP(&hostnames, "hostnames", "H", nil, "comma separated list of hostnames for the nodes")
+	cmd.Flags().BoolVar(&embeddedDB, "embedded-db", false, "run an embedded postgres server for each node, using the locally installed binaries")
 	cmd.Flags().StringVarP(&chainID, "chain-id", "c", "kwil-testnet", "chain ID for the network")
 	cmd.Flags().StringSliceVar(&allocs, allocsFlag, nil, "address and initial balance allocation(s) in the format id#keyType:amount")
 
@@ -112,6 +115,7 @@ type TestnetConfig struct {
 	DnsNamePrefix string // optional and only used if DnsHost is true (default: node)
 	Hostnames     []string
 	Allocs        []string
+	EmbeddedDB    bool // each node runs an embedded postgres server
 }
 
 type ConfigOpts struct {
@@ -228,6 +232,7 @@ func GenerateTestnetConfigs(cfg *TestnetConfig, opts *ConfigOpts, gencfg *config
 			Genesis:         gencfg,
 			BootNodes:       bootNodes,
 			ExternalAddress: externalAddress,
+			EmbeddedDB:      cfg.EmbeddedDB,
 		})
 		if err != nil {
 			return err
@@ -248,6 +253,7 @@ type NodeGenConfig struct {
 
 	BootNodes       []string
 	ExternalAddress string
+	EmbeddedDB      bool
 }
 
 func GenerateNodeRoot(ncfg *NodeGenConfig) error {
@@ -273,6 +279,7 @@ func GenerateNodeRoot(ncfg *NodeGenConfig) error {
 		dbPort = uint16(5432 + ncfg.PortOffset)
 	}
 	cfg.DB.Port = strconv.FormatUint(uint64(dbPort), 10)
+	cfg.DB.Embedded.Enable = ncfg.EmbeddedDB
 
 	// RPC
 	cfg.RPC.ListenAddress = net.JoinHostPort("0.0.0.0", strconv.FormatUint(uint64(8484+ncfg.PortOffset), 10))
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/kwilteam/kwil-db/core/types"
@@ -40,6 +41,33 @@ func (h TxHashAndExecResponse) MarshalText() ([]byte, error) {
 var _ MsgFormatter = (*TxHashAndExecResponse)(nil)
 var _ MsgFormatter = (*RespTxQuery)(nil)
 
+// RespSimulation is used to represent the result of a simulated transaction
+// in cli. It implements the MsgFormatter interface.
+type RespSimulation struct {
+	Result *types.TxResult `json:"result"`
+	Price  *big.Int        `json:"price"`
+}
+
+func (r *RespSimulation) MarshalJSON() ([]byte, error) {
+	type simulation RespSimulation // avoid recursion
+	return json.Marshal((*simulation)(r))
+}
+
+func (r *RespSimulation) MarshalText() ([]byte, error) {
+	status := "failed"
+	if r.Result.Code == uint32(types.CodeOk) {
+		status = "success"
+	}
+	msg := fmt.Sprintf("Status: %s (dry run)\nGas: %d\nPrice: %s", status, r.Result.Gas, r.Price)
+	if r.Result.Log != "" {
+		msg += "\nLogs:"
+		msg += "\n  " + strings.ReplaceAll(r.Result.Log, "\n", "\n  ")
+	}
+	return []byte(msg), nil
+}
+
+var _ MsgFormatter = (*RespSimulation)(nil)
+
 type TxHashResponse struct {
 	TxHash types.Hash `json:"tx_hash"`
 }
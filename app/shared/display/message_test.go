@@ -231,6 +231,26 @@ func Test_TxHashAndExecResponse(t *testing.T) {
 	assert.Equal(t, expectJSON, string(outJSON), "MarshalJSON should return expected json")
 }
 
+func Test_RespSimulation(t *testing.T) {
+	resp := &RespSimulation{
+		Result: &types.TxResult{
+			Code: uint32(types.CodeUnknownError),
+			Log:  "notice\nERROR: boom",
+		},
+		Price: big.NewInt(100),
+	}
+	expectJSON := `{"result":{"code":65535,"gas":0,"log":"notice\nERROR: boom"},"price":100}`
+	expectText := "Status: failed (dry run)\nGas: 0\nPrice: 100\nLogs:\n  notice\n  ERROR: boom"
+
+	outText, err := resp.MarshalText()
+	assert.NoError(t, err, "MarshalText should not return error")
+	assert.Equal(t, expectText, string(outText), "MarshalText should return expected text")
+
+	outJSON, err := resp.MarshalJSON()
+	assert.NoError(t, err, "MarshalJSON should not return error")
+	assert.Equal(t, expectJSON, string(outJSON), "MarshalJSON should return expected json")
+}
+
 func TestRespTxQuery_MarshalText(t *testing.T) {
 	tests := []struct {
 		name     string
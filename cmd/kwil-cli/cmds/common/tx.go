@@ -2,6 +2,8 @@ package common
 
 import (
 	"context"
+	"errors"
+	"math/big"
 
 	"github.com/kwilteam/kwil-db/app/shared/display"
 	client "github.com/kwilteam/kwil-db/core/client/types"
@@ -37,6 +39,39 @@ func GetTxFlags(cmd *cobra.Command) (*TxFlags, error) {
 	}, nil
 }
 
+// BindDryRunFlag binds the --dry-run flag, for commands that can simulate
+// their transaction instead of broadcasting it.
+func BindDryRunFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "simulate the transaction on the node without broadcasting it")
+}
+
+// GetDryRunFlag returns the value of the --dry-run flag.
+func GetDryRunFlag(cmd *cobra.Command) (bool, error) {
+	return cmd.Flags().GetBool("dry-run")
+}
+
+// Simulator is a client that can simulate transactions, such as a
+// core/client.Client.
+type Simulator interface {
+	Simulate(ctx context.Context, namespace string, action string, tuples [][]any, opts ...client.TxOpt) (*types.TxResult, *big.Int, error)
+	SimulateSQL(ctx context.Context, stmt string, params map[string]any, opts ...client.TxOpt) (*types.TxResult, *big.Int, error)
+}
+
+// GetSimulator returns the client as a Simulator, or an error if it cannot
+// simulate transactions.
+func GetSimulator(client1 client.Client) (Simulator, error) {
+	sim, ok := client1.(Simulator)
+	if !ok {
+		return nil, errors.New("client does not support simulating transactions")
+	}
+	return sim, nil
+}
+
+// DisplaySimulationResult displays the result of a simulated transaction.
+func DisplaySimulationResult(cmd *cobra.Command, res *types.TxResult, price *big.Int) error {
+	return display.PrintCmd(cmd, &display.RespSimulation{Result: res, Price: price})
+}
+
 // DisplayTxResult takes a tx hash and decides whether to wait for it and print the tx result,
 // or just print the tx hash. It will display the result of the transaction.
 func DisplayTxResult(ctx context.Context, client1 client.Client, txHash types.Hash, cmd *cobra.Command) error {
@@ -4,9 +4,13 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/kwilteam/kwil-db/app/key"
 	"github.com/kwilteam/kwil-db/app/shared/display"
 	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
 	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers/prompt"
 	"github.com/kwilteam/kwil-db/core/crypto"
 
@@ -19,7 +23,10 @@ This command will prompt you for the following settings:
 
 - Kwil RPC provider URL: the RPC URL of the Kwil node you wish to connect to.
 - Kwil Chain ID: the chain ID of the Kwil node you wish to connect to.  If left empty, the Kwil node will provide this value.
-- Private Key: the private key to use for signing transactions.  If left empty, the Kwil CLI will not sign transactions.`
+- Private Key File: a key file with the private key to use for signing transactions, such as an encrypted key file created by ` + "`kwild key gen --encrypt`" + `.  If left empty, you will be prompted for the private key itself.
+- Private Key: the private key to use for signing transactions.  If left empty, the Kwil CLI will not sign transactions.  An entered private key may be saved to an encrypted key file instead of the config file.
+
+The passphrase of an encrypted key file is read from the file named by the KWIL_KEY_PASSPHRASE_FILE environment variable, the KWIL_KEY_PASSPHRASE environment variable, or prompted for.`
 
 var configureExample = `kwil-cli configure`
 
@@ -43,7 +50,7 @@ func NewCmdConfigure() *cobra.Command {
 			err = runErrs(conf,
 				promptRPCProvider,
 				promptChainID,
-				promptPrivateKey,
+				promptPrivateKeyFile,
 			)
 			if err != nil {
 				return display.PrintErr(cmd, err)
@@ -98,6 +105,98 @@ func promptChainID(conf *config.KwilCliConfig) error {
 	return nil
 }
 
+func promptPrivateKeyFile(conf *config.KwilCliConfig) error {
+	pr := &prompt.Prompter{
+		Label:   "Private Key File (leave empty to enter a private key)",
+		Default: conf.PrivateKeyFile,
+	}
+	res, err := pr.Run()
+	if err != nil {
+		return err
+	}
+
+	if res == "" {
+		if conf.PrivateKeyFile != "" { // don't offer the key from the file as a default
+			conf.PrivateKey = nil
+			conf.PrivateKeyFile = ""
+		}
+		if err = promptPrivateKey(conf); err != nil {
+			return err
+		}
+		return promptEncryptPrivateKey(conf)
+	}
+
+	pk, err := config.LoadPrivateKeyFile(res)
+	if err != nil {
+		fmt.Printf("invalid private key file: %v\n", err)
+		promptAskAgain := &prompt.Prompter{
+			Label: "Would you like to enter another? (y/n)",
+		}
+		res2, err := promptAskAgain.Run()
+		if err != nil {
+			return err
+		}
+
+		if res2 == "y" || res2 == "yes" {
+			return promptPrivateKeyFile(conf)
+		}
+
+		return nil
+	}
+
+	conf.PrivateKey = pk
+	conf.PrivateKeyFile = res
+
+	return nil
+}
+
+// promptEncryptPrivateKey offers to save an entered private key to an
+// encrypted key file, so that it is not stored in the config file.
+func promptEncryptPrivateKey(conf *config.KwilCliConfig) error {
+	if conf.PrivateKey == nil {
+		return nil
+	}
+
+	pr := &prompt.Prompter{
+		Label: "Save the private key to an encrypted key file instead of the config file? (y/n)",
+	}
+	res, err := pr.Run()
+	if err != nil {
+		return err
+	}
+	if res != "y" && res != "yes" {
+		return nil
+	}
+
+	pr = &prompt.Prompter{
+		Label:   "Encrypted key file",
+		Default: filepath.Join(config.ConfigDir(), "private_key.json"),
+	}
+	keyFile, err := pr.Run()
+	if err != nil {
+		return err
+	}
+	keyFile, err = helpers.ExpandPath(keyFile)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := key.ReadPassphrase("", true)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return err
+	}
+	if err = key.SaveEncryptedNodeKey(keyFile, conf.PrivateKey, passphrase); err != nil {
+		return err
+	}
+
+	conf.PrivateKeyFile = keyFile
+
+	return nil
+}
+
 func promptPrivateKey(conf *config.KwilCliConfig) error {
 	var defaultPrivKeyHex string
 	if conf.PrivateKey != nil {
@@ -0,0 +1,189 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/dataio"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+var (
+	dataExportLong = `Export the result of a SELECT statement to a CSV, JSON Lines, or Parquet file.
+
+Rows are written to the file as they are received. By default the query is executed with a single
+request. With the --page-size flag, the query is instead executed in pages of that many rows, which
+limits the size of each response for large results. The statement should have an ORDER BY clause
+when paging so that the pages do not overlap.
+
+CSV values are written in the same form used for action parameters on the command line, so that an
+exported file may be imported with 'data import'. Null values are written as "null", bytea values
+are base64 encoded, and arrays are written in brackets, e.g. [1,2,3].
+
+If --out is omitted or "-", the result is written to standard output, as CSV unless the --format
+flag is used.`
+
+	dataExportExample = `# Export a table to a Parquet file
+kwil-cli data export "SELECT * FROM users ORDER BY id" --out users.parquet
+
+# Export the result of a query with a parameter to JSON Lines in pages of 10000 rows
+kwil-cli data export "SELECT * FROM posts WHERE author = $author ORDER BY id" --param author:text=satoshi --out posts.jsonl --page-size 10000`
+)
+
+func dataExportCmd() *cobra.Command {
+	var namedParams []string
+	var outPath, formatName string
+	var pageSize int64
+	var rpcAuth bool
+
+	cmd := &cobra.Command{
+		Use:     "export <select statement>",
+		Short:   "Export the result of a SELECT statement to a file",
+		Long:    dataExportLong,
+		Example: dataExportExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stmt := args[0]
+			if _, err := parse.Parse(stmt); err != nil {
+				return display.PrintErr(cmd, fmt.Errorf("failed to parse SQL statement: %s", err))
+			}
+
+			params, err := parseParams(namedParams)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			format := dataio.FormatCSV
+			switch {
+			case formatName != "":
+				format, err = dataio.ParseFormat(formatName)
+			case outPath != "" && outPath != "-":
+				format, err = dataio.FormatOf(outPath)
+			}
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			var out io.Writer = cmd.OutOrStdout()
+			if outPath != "" && outPath != "-" {
+				path, err := helpers.ExpandPath(outPath)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				f, err := os.Create(path)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				defer f.Close()
+				out = f
+			}
+
+			dialFlags := client.WithoutPrivateKey
+			if rpcAuth {
+				dialFlags = client.AuthenticatedCalls
+			}
+
+			return client.DialClient(cmd.Context(), cmd, dialFlags, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				n, err := exportQuery(ctx, cl, stmt, params, !rpcAuth, pageSize, out, format)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				if out == cmd.OutOrStdout() {
+					return nil // don't mix the summary with the data
+				}
+				return display.PrintCmd(cmd, &respExport{Rows: n, File: outPath, Format: string(format)})
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", `the file to write, or "-" for standard output`)
+	cmd.Flags().StringVar(&formatName, "format", "", "the file format: csv, jsonl, or parquet (default is inferred from the file extension)")
+	cmd.Flags().StringArrayVarP(&namedParams, "param", "p", nil, `named parameters that will be used in the query. format: "key:type=value"`)
+	cmd.Flags().Int64Var(&pageSize, "page-size", 0, "the number of rows to request at a time (0 requests the entire result at once)")
+	cmd.Flags().BoolVar(&rpcAuth, "rpc-auth", false, "signals that the query is being made to a kwil node and should be authenticated with the private key")
+	return cmd
+}
+
+// exportQuery executes the query, writing the result to out. If pageSize is
+// positive, the result is requested in pages of that many rows.
+func exportQuery(ctx context.Context, cl clientType.Client, stmt string, params map[string]any,
+	skipAuth bool, pageSize int64, out io.Writer, format dataio.Format) (int64, error) {
+	var w dataio.Writer
+	var n int64
+	for offset := int64(0); ; offset += pageSize {
+		query, queryParams := stmt, params
+		if pageSize > 0 {
+			query, queryParams = pageQuery(stmt, params, pageSize, offset)
+		}
+		res, err := cl.Query(ctx, query, queryParams, skipAuth)
+		if err != nil {
+			return n, err
+		}
+
+		if w == nil {
+			w, err = dataio.NewWriter(out, format, res.ColumnNames, res.ColumnTypes)
+			if err != nil {
+				return n, err
+			}
+		}
+		for _, row := range res.Values {
+			if err = w.Write(row); err != nil {
+				return n, fmt.Errorf("row %d: %w", n+1, err)
+			}
+			n++
+		}
+
+		if pageSize <= 0 || int64(len(res.Values)) < pageSize {
+			break
+		}
+	}
+	return n, w.Close()
+}
+
+// pageQuery wraps a SELECT statement so that it returns one page of rows. Any
+// namespace prefix is moved to the outer statement.
+func pageQuery(stmt string, params map[string]any, limit, offset int64) (string, map[string]any) {
+	stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+	var prefix string
+	if strings.HasPrefix(stmt, "{") {
+		if end := strings.IndexByte(stmt, '}'); end > 0 {
+			prefix, stmt = stmt[:end+1], stmt[end+1:]
+		}
+	}
+
+	pageParams := make(map[string]any, len(params)+2)
+	for k, v := range params {
+		pageParams[k] = v
+	}
+	pageParams["export_page_limit"] = limit
+	pageParams["export_page_offset"] = offset
+
+	return prefix + "SELECT * FROM (" + stmt + ") AS export_page LIMIT $export_page_limit OFFSET $export_page_offset",
+		pageParams
+}
+
+type respExport struct {
+	Rows   int64  `json:"rows"`
+	File   string `json:"file"`
+	Format string `json:"format"`
+}
+
+func (r *respExport) MarshalJSON() ([]byte, error) {
+	type resp respExport // avoid recursive call of MarshalJSON
+	return json.Marshal((*resp)(r))
+}
+
+func (r *respExport) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("Exported %d rows to %s (%s).", r.Rows, r.File, r.Format)), nil
+}
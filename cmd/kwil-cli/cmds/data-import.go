@@ -0,0 +1,601 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/dataio"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	chainrpc "github.com/kwilteam/kwil-db/core/rpc/client/chain"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var (
+	dataImportLong = `Import rows from a CSV, JSON Lines, or Parquet file by executing an action with each row.
+
+Rows are batched into transactions that execute the action once per row, with each transaction
+sized to fit within the network's maximum block size. Transactions are broadcast with sequential
+nonces, and the import waits for each to be included in a block. Broadcasts that fail for transient
+reasons, such as a full mempool or an unreachable node, are retried.
+
+By default, each action parameter is read from the column of the same name, without the leading "$".
+Use --map to read a parameter from a differently named column, in the form "column:parameter", where
+the parameter is a name or a 1-based position. Parameters given with --param have the same value for
+every row. Any other parameters are null.
+
+CSV values use the same form as action parameters on the command line (see 'exec-action'). For JSON
+Lines and Parquet files, the values are converted to the types of the action parameters.
+
+With --checkpoint, the rows that have been committed are recorded in the checkpoint file after each
+transaction is confirmed. If the import is interrupted or a transaction fails, running the same
+command again skips the committed rows.`
+
+	dataImportExample = `# Import users.csv with the 'create_user($id, $name)' action, where the CSV header is "id,name"
+kwil-cli data import create_user --file users.csv --namespace mydb
+
+# Import a Parquet file, mapping the user_name column to the $name parameter and resuming from a checkpoint
+kwil-cli data import create_user --file users.parquet --map user_name:name --checkpoint users.checkpoint
+
+# Import JSON Lines with a constant parameter value
+kwil-cli data import create_post --file posts.jsonl --param created_at:int=1700000000`
+)
+
+const (
+	// defaultMaxTxBytes is the default transaction size limit of a node's
+	// mempool, which applies in addition to the network's maximum block size.
+	defaultMaxTxBytes = 4 * 1024 * 1024
+	// txOverheadBytes is reserved in each batch for the transaction's
+	// signature, fee, and other fields besides the action arguments.
+	txOverheadBytes = 16 * 1024
+)
+
+func dataImportCmd() *cobra.Command {
+	var namespace, filePath, formatName, checkpointPath string
+	var mapping, namedParams []string
+	var batchBytes, batchRows, nonce int64
+	var retries, maxPending int
+
+	cmd := &cobra.Command{
+		Use:     "import <action>",
+		Short:   "Import rows from a file by executing an action with each row",
+		Long:    dataImportLong,
+		Example: dataImportExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			action := strings.ToLower(args[0])
+
+			path, err := helpers.ExpandPath(filePath)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			format, err := dataio.FormatOf(path)
+			if formatName != "" {
+				format, err = dataio.ParseFormat(formatName)
+			}
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			var cp *dataio.Checkpoint
+			if checkpointPath != "" {
+				checkpointPath, err = helpers.ExpandPath(checkpointPath)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				cp, err = dataio.LoadCheckpoint(checkpointPath, path, namespace, action)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+			}
+
+			return client.DialClient(cmd.Context(), cmd, 0, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				paramList, err := GetParamList(ctx, func(ctx context.Context, query string, args map[string]any) (*types.QueryResult, error) {
+					return cl.Query(ctx, query, args, false)
+				}, namespace, action)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				r, err := dataio.OpenReader(path, format)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				defer r.Close()
+
+				rm, err := newRowMapper(paramList, r.Columns(), format, mapping, namedParams)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				if batchBytes <= 0 {
+					batchBytes = defaultBatchBytes(ctx, cl)
+				}
+
+				imp := &importer{
+					cl:             cl,
+					namespace:      namespace,
+					action:         action,
+					batchBytes:     batchBytes,
+					batchRows:      batchRows,
+					retries:        retries,
+					maxPending:     max(maxPending, 1),
+					nonce:          nonce,
+					checkpoint:     cp,
+					checkpointPath: checkpointPath,
+					progress:       cmd.ErrOrStderr(),
+				}
+				if display.ShouldSilence(cmd) {
+					imp.progress = io.Discard
+				}
+
+				res, err := imp.run(ctx, r, rm)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				return display.PrintCmd(cmd, res)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&filePath, "file", "f", "", "the file to import")
+	cmd.Flags().StringVar(&formatName, "format", "", "the file format: csv, jsonl, or parquet (default is inferred from the file extension)")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "namespace of the action")
+	cmd.Flags().StringSliceVarP(&mapping, "map", "m", nil, `mapping of file columns to action parameters. format: "column:param_name" OR "column:param_position"`)
+	// this has to be StringArrayVar because if the user is passing an array, it will contain a comma, but it itself is a single parameter.
+	cmd.Flags().StringArrayVarP(&namedParams, "param", "p", nil, `named parameters with the same value for every row. format: "name:type=value"`)
+	cmd.Flags().StringVar(&checkpointPath, "checkpoint", "", "file in which to record progress, allowing an interrupted import to be resumed")
+	cmd.Flags().Int64Var(&batchBytes, "batch-bytes", 0, "the maximum size of the action arguments in each transaction (default is based on the network's maximum block size)")
+	cmd.Flags().Int64Var(&batchRows, "batch-rows", 0, "the maximum number of rows in each transaction (0 is unlimited)")
+	cmd.Flags().IntVar(&retries, "retries", 5, "the number of times to retry broadcasting a transaction")
+	cmd.Flags().IntVar(&maxPending, "max-pending", 4, "the number of unconfirmed transactions to allow before waiting")
+	cmd.Flags().Int64VarP(&nonce, "nonce", "N", -1, "nonce of the first transaction (-1 means request from server)")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+// defaultBatchBytes determines the batch size from the network's maximum
+// block size, if the node provides it.
+func defaultBatchBytes(ctx context.Context, cl clientType.Client) int64 {
+	maxBytes := int64(defaultMaxTxBytes)
+	if cc, ok := cl.(interface{ ChainClient() chainrpc.Client }); ok {
+		if params, err := cc.ChainClient().ConsensusParams(ctx); err == nil && params.MaxBlockSize > 0 {
+			maxBytes = min(maxBytes, params.MaxBlockSize)
+		}
+	}
+	return max(maxBytes-txOverheadBytes, txOverheadBytes)
+}
+
+// rowMapper converts records into action arguments.
+type rowMapper struct {
+	numParams int
+	columns   map[string]*actionParamInfo // column name => parameter
+	constants map[int]any                 // parameter position => value
+	strValues bool                        // values are strings in the CLI parameter format
+}
+
+func newRowMapper(paramList []NamedParameter, fileColumns []string, format dataio.Format, mapping, named []string) (*rowMapper, error) {
+	rm := &rowMapper{
+		numParams: len(paramList),
+		columns:   make(map[string]*actionParamInfo),
+		constants: make(map[int]any),
+		strValues: format == dataio.FormatCSV,
+	}
+
+	_, values, positions, err := getNamedParams(paramList, named)
+	if err != nil {
+		return nil, fmt.Errorf("error getting named parameters: %w", err)
+	}
+	for i, pos := range positions {
+		rm.constants[pos] = values[i]
+	}
+
+	paramInfo := actionParamInfoMap(paramList)
+	if len(mapping) == 0 {
+		// Map parameters to columns of the same name, excluding constants.
+		for name, info := range paramInfo {
+			if _, isConst := rm.constants[info.pos]; !isConst {
+				rm.columns[name] = info
+			}
+		}
+	} else {
+		splitMapping, err := splitMapping(mapping)
+		if err != nil {
+			return nil, err
+		}
+		for col, param := range splitMapping {
+			info, ok := paramInfo[strings.TrimPrefix(param, "$")]
+			if pos, err := strconv.Atoi(param); err == nil {
+				if pos < 1 || pos > len(paramList) {
+					return nil, fmt.Errorf("invalid parameter position %d", pos)
+				}
+				info, ok = &actionParamInfo{datatype: paramList[pos-1].Type, pos: pos - 1}, true
+			}
+			if !ok {
+				return nil, fmt.Errorf(`action does not have a parameter named "%s"`, param)
+			}
+			if _, isConst := rm.constants[info.pos]; isConst {
+				return nil, fmt.Errorf(`parameter "%s" cannot be both mapped to a column and a named parameter`, param)
+			}
+			rm.columns[col] = info
+		}
+	}
+
+	if fileColumns != nil {
+		have := make(map[string]bool, len(fileColumns))
+		for _, col := range fileColumns {
+			have[col] = true
+		}
+		for col := range rm.columns {
+			if have[col] {
+				continue
+			}
+			if len(mapping) > 0 {
+				return nil, fmt.Errorf("column %s not found in file", col)
+			}
+			delete(rm.columns, col) // parameter is null
+		}
+	}
+
+	if len(rm.columns) == 0 {
+		return nil, errors.New("no columns in the file are mapped to action parameters")
+	}
+	return rm, nil
+}
+
+// args converts a record into the arguments for one execution of the action.
+func (rm *rowMapper) args(rec dataio.Record) ([]any, error) {
+	args := make([]any, rm.numParams)
+	for pos, val := range rm.constants {
+		args[pos] = val
+	}
+	for col, info := range rm.columns {
+		v, ok := rec[col]
+		if !ok || v == nil {
+			continue
+		}
+		var err error
+		if rm.strValues {
+			args[info.pos], err = stringAndTypeToVal(v.(string), info.datatype)
+		} else {
+			args[info.pos], err = importValue(v, info.datatype)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col, err)
+		}
+	}
+	return args, nil
+}
+
+// importValue converts a value decoded from a JSON Lines or Parquet file to the
+// data type. Values are converted to the string form of CLI parameters, except
+// for text and bytea values that need no conversion.
+func importValue(v any, dt *types.DataType) (any, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if *dt == *types.TextType {
+			return v, nil
+		}
+	case []byte:
+		if *dt == *types.ByteaType {
+			return v, nil
+		}
+		v2 := string(v) // Parquet strings without a logical type
+		if *dt == *types.TextType {
+			return v2, nil
+		}
+		return stringAndTypeToVal(v2, dt)
+	}
+	s, err := importString(v, dt.IsArray, false)
+	if err != nil {
+		return nil, err
+	}
+	return stringAndTypeToVal(s, dt)
+}
+
+// importString formats a decoded value as a CLI parameter string.
+func importString(v any, isArray, inArray bool) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return NullLiteral, nil
+	case string:
+		if inArray {
+			return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`, nil
+		}
+		return v, nil
+	case []any:
+		if !isArray || inArray {
+			return "", errors.New("unexpected array value")
+		}
+		elems := make([]string, len(v))
+		for i, e := range v {
+			var err error
+			if elems[i], err = importString(e, isArray, true); err != nil {
+				return "", err
+			}
+		}
+		return "[" + strings.Join(elems, ",") + "]", nil
+	case json.Number, bool, int64, int32, float64, float32:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("unsupported value type %T", v)
+}
+
+// importer executes batches of rows in transactions.
+type importer struct {
+	cl         clientType.Client
+	namespace  string
+	action     string
+	batchBytes int64
+	batchRows  int64
+	retries    int
+	maxPending int
+	nonce      int64 // next nonce, or -1 before it is known
+
+	checkpoint     *dataio.Checkpoint // nil if not checkpointing
+	checkpointPath string
+	progress       io.Writer
+
+	pending []*importBatch
+	res     respImport
+}
+
+type importBatch struct {
+	rows   dataio.Range
+	tuples [][]any
+	size   int64
+	txHash types.Hash
+}
+
+// run reads all records from r, executes them in batches, and waits for the
+// transactions to be confirmed.
+func (imp *importer) run(ctx context.Context, r dataio.Reader, rm *rowMapper) (*respImport, error) {
+	batch := &importBatch{}
+	for row := int64(0); ; row++ {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row+1, err)
+		}
+
+		if imp.checkpoint != nil && imp.checkpoint.IsDone(row) {
+			imp.res.Skipped++
+			if len(batch.tuples) == 0 {
+				batch.rows.Start = row + 1
+			}
+			continue
+		}
+
+		args, err := rm.args(rec)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row+1, err)
+		}
+		size, err := argsSize(args)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row+1, err)
+		}
+		if size > imp.batchBytes {
+			return nil, fmt.Errorf("row %d: arguments are larger than the batch size", row+1)
+		}
+
+		full := batch.size+size > imp.batchBytes ||
+			(imp.batchRows > 0 && int64(len(batch.tuples)) >= imp.batchRows)
+		if full {
+			if err = imp.send(ctx, batch); err != nil {
+				return nil, err
+			}
+			batch = &importBatch{rows: dataio.Range{Start: row}}
+		}
+		batch.tuples = append(batch.tuples, args)
+		batch.size += size
+		batch.rows.End = row + 1
+	}
+
+	if len(batch.tuples) > 0 {
+		if err := imp.send(ctx, batch); err != nil {
+			return nil, err
+		}
+	}
+	if err := imp.drain(ctx); err != nil {
+		return nil, err
+	}
+	imp.res.Checkpoint = imp.checkpointPath
+	return &imp.res, nil
+}
+
+// argsSize estimates the encoded size of the arguments in a transaction.
+func argsSize(args []any) (int64, error) {
+	var size int64
+	for _, arg := range args {
+		ev, err := types.EncodeValue(arg)
+		if err != nil {
+			return 0, err
+		}
+		bts, err := ev.MarshalBinary()
+		if err != nil {
+			return 0, err
+		}
+		size += int64(len(bts)) + 4 // length prefix
+	}
+	return size, nil
+}
+
+// send broadcasts a batch, first waiting for the oldest pending transaction
+// if there are too many.
+func (imp *importer) send(ctx context.Context, batch *importBatch) error {
+	if len(imp.pending) >= imp.maxPending {
+		if err := imp.waitOldest(ctx); err != nil {
+			return errors.Join(err, imp.drain(ctx))
+		}
+	}
+	if err := imp.broadcast(ctx, batch); err != nil {
+		err = fmt.Errorf("rows %d to %d: %w", batch.rows.Start+1, batch.rows.End, err)
+		return errors.Join(err, imp.drain(ctx))
+	}
+	imp.pending = append(imp.pending, batch)
+	imp.res.Transactions++
+	fmt.Fprintf(imp.progress, "broadcast rows %d to %d in tx %s\n", batch.rows.Start+1, batch.rows.End, batch.txHash)
+	return nil
+}
+
+// errMaybeBroadcast is returned when a batch's transaction may have been
+// accepted by the node, but its hash is unknown, so it cannot be confirmed.
+var errMaybeBroadcast = errors.New("the transaction may have been accepted; check the imported rows before resuming")
+
+// broadcast broadcasts the batch, retrying transient failures. A retry after
+// a failure that the node may not have seen, such as a network error, uses
+// the same nonce, so that at most one of the transactions can be included. If
+// that nonce was then used, the batch fails rather than being sent again with
+// a new nonce, since the first attempt may have been accepted.
+func (imp *importer) broadcast(ctx context.Context, batch *importBatch) error {
+	backoff := time.Second
+	var maybeSent bool // a previous attempt may have reached the node
+	for attempt := 0; ; attempt++ {
+		if imp.nonce < 0 {
+			if err := imp.fetchNonce(ctx); err != nil {
+				return err
+			}
+		}
+
+		txHash, err := imp.cl.Execute(ctx, imp.namespace, imp.action, batch.tuples,
+			clientType.WithNonce(imp.nonce))
+		if err == nil {
+			batch.txHash = txHash
+			imp.nonce++
+			return nil
+		}
+		if errors.Is(err, types.ErrInvalidNonce) && maybeSent {
+			err = fmt.Errorf("nonce %d was used after a failed broadcast: %w", imp.nonce, errMaybeBroadcast)
+			imp.nonce = -1
+			return err
+		}
+		if attempt >= imp.retries || !retryableBroadcastErr(err) {
+			return err
+		}
+		if errors.Is(err, types.ErrInvalidNonce) {
+			imp.nonce = -1 // get the next nonce from the node
+		}
+		if maybeDelivered(err) {
+			maybeSent = true
+		}
+
+		fmt.Fprintf(imp.progress, "broadcast failed, retrying in %v: %v\n", backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, 30*time.Second)
+	}
+}
+
+func retryableBroadcastErr(err error) bool {
+	return errors.Is(err, types.ErrInvalidNonce) || errors.Is(err, types.ErrMempoolFull) ||
+		maybeDelivered(err)
+}
+
+// maybeDelivered reports if a broadcast that failed with err may still have
+// been accepted by the node.
+func maybeDelivered(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, types.ErrTxTimeout) || errors.As(err, &netErr)
+}
+
+// fetchNonce gets the next nonce for the signer, including any transactions
+// in the node's mempool.
+func (imp *importer) fetchNonce(ctx context.Context) error {
+	ident, err := types.GetSignerAccount(imp.cl.Signer())
+	if err != nil {
+		return err
+	}
+	acct, err := imp.cl.GetAccount(ctx, ident, types.AccountStatusPending)
+	if err != nil {
+		return err
+	}
+	imp.nonce = 1
+	if acct.ID != nil && len(acct.ID.Identifier) > 0 {
+		imp.nonce = acct.Nonce + 1
+	}
+	return nil
+}
+
+// drain waits for all pending transactions. Transactions broadcast after one
+// that failed may still succeed, and the checkpoint must record them.
+func (imp *importer) drain(ctx context.Context) error {
+	var errs []error
+	for len(imp.pending) > 0 {
+		if err := imp.waitOldest(ctx); err != nil {
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// waitOldest waits for the oldest pending transaction to be confirmed, and
+// records its rows in the checkpoint if it succeeded.
+func (imp *importer) waitOldest(ctx context.Context) error {
+	batch := imp.pending[0]
+	imp.pending = imp.pending[1:]
+
+	res, err := imp.cl.WaitTx(ctx, batch.txHash, time.Second)
+	if err != nil {
+		return fmt.Errorf("waiting for tx %s: %w", batch.txHash, err)
+	}
+	if res.Result == nil || res.Result.Code != uint32(types.CodeOk) {
+		var log string
+		if res.Result != nil {
+			log = res.Result.Log
+		}
+		imp.nonce = -1
+		return fmt.Errorf("tx %s with rows %d to %d failed: %s", batch.txHash,
+			batch.rows.Start+1, batch.rows.End, log)
+	}
+
+	imp.res.Rows += int64(len(batch.tuples))
+	if imp.checkpoint != nil {
+		imp.checkpoint.MarkDone(batch.rows)
+		if err = imp.checkpoint.Save(imp.checkpointPath); err != nil {
+			return fmt.Errorf("saving checkpoint: %w", err)
+		}
+	}
+	return nil
+}
+
+type respImport struct {
+	Rows         int64  `json:"rows"`
+	Skipped      int64  `json:"skipped"`
+	Transactions int    `json:"transactions"`
+	Checkpoint   string `json:"checkpoint,omitempty"`
+}
+
+func (r *respImport) MarshalJSON() ([]byte, error) {
+	type resp respImport // avoid recursive call of MarshalJSON
+	return json.Marshal((*resp)(r))
+}
+
+func (r *respImport) MarshalText() ([]byte, error) {
+	msg := fmt.Sprintf("Imported %d rows in %d transactions.", r.Rows, r.Transactions)
+	if r.Skipped > 0 {
+		msg += fmt.Sprintf(" Skipped %d rows committed previously.", r.Skipped)
+	}
+	return []byte(msg), nil
+}
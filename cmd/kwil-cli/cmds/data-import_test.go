@@ -0,0 +1,110 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/dataio"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rowMapper(t *testing.T) {
+	params := []NamedParameter{
+		{Name: "$id", Type: types.IntType},
+		{Name: "$name", Type: types.TextType},
+		{Name: "$tags", Type: types.TextArrayType},
+		{Name: "$data", Type: types.ByteaType},
+	}
+
+	t.Run("default mapping, csv", func(t *testing.T) {
+		rm, err := newRowMapper(params, []string{"id", "name", "extra"}, dataio.FormatCSV, nil, nil)
+		require.NoError(t, err)
+		args, err := rm.args(dataio.Record{"id": "1", "name": "alice", "extra": "x"})
+		require.NoError(t, err)
+		assert.Equal(t, []any{ptr(int64(1)), ptr("alice"), nil, nil}, args)
+	})
+
+	t.Run("explicit mapping and constant, jsonl", func(t *testing.T) {
+		rm, err := newRowMapper(params, nil, dataio.FormatJSONL,
+			[]string{"user_id:id", "labels:3"}, []string{"name:text=bob"})
+		require.NoError(t, err)
+		args, err := rm.args(dataio.Record{"user_id": json.Number("7"), "labels": []any{"a", nil, `b"c`}})
+		require.NoError(t, err)
+		assert.Equal(t, []any{ptr(int64(7)), ptr("bob"), ptr([]*string{ptr("a"), nil, ptr(`b"c`)}), nil}, args)
+	})
+
+	t.Run("parquet values", func(t *testing.T) {
+		rm, err := newRowMapper(params, []string{"id", "name", "tags", "data"}, dataio.FormatParquet, nil, nil)
+		require.NoError(t, err)
+		args, err := rm.args(dataio.Record{"id": int64(3), "name": "carol", "tags": []any{}, "data": []byte{1, 2}})
+		require.NoError(t, err)
+		assert.Equal(t, []any{ptr(int64(3)), "carol", ptr([]*string{}), []byte{1, 2}}, args)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := newRowMapper(params, []string{"id"}, dataio.FormatCSV, []string{"missing:id"}, nil)
+		assert.Error(t, err) // column not in file
+		_, err = newRowMapper(params, nil, dataio.FormatJSONL, []string{"x:nope"}, nil)
+		assert.Error(t, err) // unknown parameter
+		_, err = newRowMapper(params, nil, dataio.FormatJSONL, []string{"x:9"}, nil)
+		assert.Error(t, err) // bad position
+		_, err = newRowMapper(params, nil, dataio.FormatJSONL, []string{"x:id"}, []string{"id:int=1"})
+		assert.Error(t, err) // mapped and constant
+		_, err = newRowMapper(params, []string{"other"}, dataio.FormatCSV, nil, nil)
+		assert.Error(t, err) // nothing mapped
+
+		rm, err := newRowMapper(params, nil, dataio.FormatJSONL, nil, nil)
+		require.NoError(t, err)
+		_, err = rm.args(dataio.Record{"id": "not a number"})
+		assert.Error(t, err)
+		_, err = rm.args(dataio.Record{"id": []any{json.Number("1")}})
+		assert.Error(t, err)
+	})
+}
+
+func Test_pageQuery(t *testing.T) {
+	q, params := pageQuery("{ns}SELECT * FROM t ORDER BY id;", map[string]any{"a": 1}, 10, 20)
+	assert.Equal(t, "{ns}SELECT * FROM (SELECT * FROM t ORDER BY id) AS export_page LIMIT $export_page_limit OFFSET $export_page_offset", q)
+	assert.Equal(t, map[string]any{"a": 1, "export_page_limit": int64(10), "export_page_offset": int64(20)}, params)
+}
+
+// executeClient is a client whose Execute returns the next of its errors,
+// recording the nonce of each call.
+type executeClient struct {
+	clientType.Client
+	errs   []error
+	nonces []int64
+}
+
+func (c *executeClient) Execute(_ context.Context, _, _ string, _ [][]any, opts ...clientType.TxOpt) (types.Hash, error) {
+	c.nonces = append(c.nonces, clientType.GetTxOpts(opts).Nonce)
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	return types.Hash{1}, err
+}
+
+func Test_importerBroadcast(t *testing.T) {
+	netErr := &net.OpError{Op: "write", Err: io.ErrUnexpectedEOF}
+
+	// A retry after a network error uses the same nonce.
+	cl := &executeClient{errs: []error{netErr, nil}}
+	imp := &importer{cl: cl, retries: 3, nonce: 5, progress: io.Discard}
+	batch := &importBatch{}
+	require.NoError(t, imp.broadcast(context.Background(), batch))
+	assert.Equal(t, []int64{5, 5}, cl.nonces)
+	assert.Equal(t, int64(6), imp.nonce)
+
+	// If the nonce was used, the first attempt may have been accepted, so the
+	// batch is not sent again with a new nonce.
+	cl = &executeClient{errs: []error{netErr, types.ErrInvalidNonce, nil}}
+	imp = &importer{cl: cl, retries: 3, nonce: 5, progress: io.Discard}
+	err := imp.broadcast(context.Background(), batch)
+	require.ErrorIs(t, err, errMaybeBroadcast)
+	assert.Equal(t, []int64{5, 5}, cl.nonces)
+}
@@ -0,0 +1,22 @@
+package cmds
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func dataCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "data",
+		Short: "Bulk import and export data using CSV, JSON Lines, or Parquet files",
+		Long: `The data command is a parent command containing subcommands for bulk importing data with an action,
+and exporting the results of a query. CSV, JSON Lines, and Parquet files are supported, and the format
+is inferred from the file extension unless the --format flag is used.`,
+	}
+
+	cmd.AddCommand(
+		dataImportCmd(),
+		dataExportCmd(),
+	)
+
+	return cmd
+}
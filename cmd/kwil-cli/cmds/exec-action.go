@@ -53,7 +53,10 @@ kwil-cli exec-action register --csv /path/to/file.csv --csv-mapping name:0 --csv
 
 # Execute the action 'register' with a CSV file, but override all ages to be 10
 # Assume the same action signature and CSV file as above
-kwil-cli exec-action register --csv /path/to/file.csv --csv-mapping name:0 --param age:int=10`
+kwil-cli exec-action register --csv /path/to/file.csv --csv-mapping name:0 --param age:int=10
+
+# Simulate the action 'register' without broadcasting the transaction
+kwil-cli exec-action register text:satoshi --dry-run`
 )
 
 func execActionCmd() *cobra.Command {
@@ -74,6 +77,10 @@ func execActionCmd() *cobra.Command {
 			if err != nil {
 				return display.PrintErr(cmd, err)
 			}
+			dryRun, err := common.GetDryRunFlag(cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
 
 			if len(args) > 1 && csvFile != "" {
 				return display.PrintErr(cmd, fmt.Errorf("cannot specify both CSV file and positional parameters"))
@@ -115,6 +122,10 @@ func execActionCmd() *cobra.Command {
 						return display.PrintErr(cmd, err)
 					}
 
+					if dryRun {
+						return simulateAction(ctx, cmd, cl, namespace, args[0], inputs, txFlags)
+					}
+
 					tx, err := cl.Execute(ctx, namespace, args[0], inputs, clientType.WithNonce(txFlags.NonceOverride), clientType.WithSyncBroadcast(txFlags.SyncBroadcast))
 					if err != nil {
 						return display.PrintErr(cmd, err)
@@ -161,6 +172,10 @@ func execActionCmd() *cobra.Command {
 					}
 				}
 
+				if dryRun {
+					return simulateAction(ctx, cmd, cl, namespace, args[0], [][]any{params}, txFlags)
+				}
+
 				tx, err := cl.Execute(ctx, namespace, args[0], [][]any{params}, clientType.WithNonce(txFlags.NonceOverride), clientType.WithSyncBroadcast(txFlags.SyncBroadcast))
 				if err != nil {
 					return display.PrintErr(cmd, err)
@@ -178,9 +193,26 @@ func execActionCmd() *cobra.Command {
 	cmd.Flags().StringVar(&csvFile, "csv", "", "CSV file containing the parameters to pass to the action")
 	cmd.Flags().StringSliceVarP(&csvParams, "csv-mapping", "m", nil, `mapping of CSV columns to action parameters. format: "csv_column:action_param_name" OR "csv_column:action_param_position"`)
 	common.BindTxFlags(cmd)
+	common.BindDryRunFlag(cmd)
 	return cmd
 }
 
+// simulateAction simulates the execution of an action and displays the
+// result, instead of broadcasting the transaction.
+func simulateAction(ctx context.Context, cmd *cobra.Command, cl clientType.Client, namespace, action string, inputs [][]any, txFlags *common.TxFlags) error {
+	sim, err := common.GetSimulator(cl)
+	if err != nil {
+		return display.PrintErr(cmd, err)
+	}
+
+	res, price, err := sim.Simulate(ctx, namespace, action, inputs, clientType.WithNonce(txFlags.NonceOverride))
+	if err != nil {
+		return display.PrintErr(cmd, err)
+	}
+
+	return common.DisplaySimulationResult(cmd, res, price)
+}
+
 type actionParamInfo struct {
 	datatype *types.DataType
 	pos      int
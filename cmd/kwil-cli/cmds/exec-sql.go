@@ -32,7 +32,10 @@ kwil-cli exec-sql "CREATE TABLE my_table (id int primary key, name text)"
 kwil-cli exec-sql --file /path/to/file.sql
 
 # Execute an insert statement with parameters
-kwil-cli exec-sql "INSERT INTO my_table (id, name) VALUES ($id, $name)" --param id:int=1 --param name:text=foo`
+kwil-cli exec-sql "INSERT INTO my_table (id, name) VALUES ($id, $name)" --param id:int=1 --param name:text=foo
+
+# Simulate an insert statement without broadcasting the transaction
+kwil-cli exec-sql "INSERT INTO my_table (id, name) VALUES (1, 'foo')" --dry-run`
 )
 
 func execSQLCmd() *cobra.Command {
@@ -50,6 +53,10 @@ func execSQLCmd() *cobra.Command {
 			if err != nil {
 				return display.PrintErr(cmd, err)
 			}
+			dryRun, err := common.GetDryRunFlag(cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
 
 			params, err := parseParams(params)
 			if err != nil {
@@ -94,6 +101,20 @@ func execSQLCmd() *cobra.Command {
 			}
 
 			return client.DialClient(cmd.Context(), cmd, 0, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				if dryRun {
+					sim, err := common.GetSimulator(cl)
+					if err != nil {
+						return display.PrintErr(cmd, err)
+					}
+
+					res, price, err := sim.SimulateSQL(ctx, stmt, params, clientType.WithNonce(txFlags.NonceOverride))
+					if err != nil {
+						return display.PrintErr(cmd, err)
+					}
+
+					return common.DisplaySimulationResult(cmd, res, price)
+				}
+
 				txHash, err := cl.ExecuteSQL(ctx, stmt, params, clientType.WithNonce(txFlags.NonceOverride), clientType.WithSyncBroadcast(txFlags.SyncBroadcast))
 				if err != nil {
 					return display.PrintErr(cmd, err)
@@ -110,5 +131,6 @@ func execSQLCmd() *cobra.Command {
 	// If we use StringSliceVar, it will split the array into multiple parameters.
 	cmd.Flags().StringArrayVarP(&params, "param", "p", nil, `the parameters to pass to the SQL statement. format: "key:type=value"`)
 	common.BindTxFlags(cmd)
+	common.BindDryRunFlag(cmd)
 	return cmd
 }
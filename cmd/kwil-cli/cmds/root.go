@@ -80,6 +80,9 @@ func NewRootCmd() *cobra.Command {
 		execActionCmd(),
 		callActionCmd(),
 		queryCmd(),
+		shellCmd(),
+		dataCmd(),
+		schemaCmd(),
 	)
 
 	shared.ApplySanitizedHelpFuncRecursively(rootCmd)
@@ -0,0 +1,257 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/schemadiff"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+)
+
+var (
+	schemaLong = `The schema command compares a schema file with the live schema of a namespace, and applies the
+differences.
+
+A schema file declares the desired schema of a namespace with CREATE TABLE, CREATE INDEX,
+CREATE ACTION, CREATE ROLE, and GRANT statements. Tables, columns, constraints, indexes, and actions
+in the namespace that are not declared in the file are dropped. Roles are only ever created, and
+the privileges of a role are only managed if the file creates the role or grants it privileges.
+
+Statements that may delete data, fail on existing data, or break existing callers are flagged as
+unsafe. Some differences, such as a changed primary key, cannot be migrated and must be handled
+manually.`
+
+	schemaDiffExample = `# Show the DDL needed to migrate the "main" namespace to the schema in schema.sql
+kwil-cli schema diff --file schema.sql
+
+# Print only the DDL, to review or edit before running it with exec-sql
+kwil-cli schema diff --file schema.sql --namespace my_ns --sql > migration.sql`
+
+	schemaApplyExample = `# Migrate the "main" namespace to the schema in schema.sql
+kwil-cli schema apply --file schema.sql
+
+# Migrate without confirmation, allowing unsafe statements such as dropped columns
+kwil-cli schema apply --file schema.sql --allow-unsafe --assume-yes --sync`
+)
+
+func schemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Compare a schema file with a namespace and apply the differences",
+		Long:  schemaLong,
+	}
+
+	cmd.AddCommand(
+		schemaDiffCmd(),
+		schemaApplyCmd(),
+	)
+
+	return cmd
+}
+
+func schemaDiffCmd() *cobra.Command {
+	var file, namespace string
+	var sqlOnly bool
+
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   "Show the DDL needed to migrate a namespace to a schema file",
+		Long:    schemaLong,
+		Example: schemaDiffExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			declared, err := readSchemaFile(file)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return client.DialClient(cmd.Context(), cmd, client.WithoutPrivateKey, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				plan, err := planSchema(ctx, cl, declared, namespace)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				if sqlOnly {
+					if !plan.Empty() {
+						fmt.Fprint(cmd.OutOrStdout(), plan.SQL())
+					}
+					return nil
+				}
+				return display.PrintCmd(cmd, &respSchemaPlan{plan})
+			})
+		},
+	}
+
+	bindSchemaFlags(cmd, &file, &namespace)
+	cmd.Flags().BoolVar(&sqlOnly, "sql", false, "print only the DDL, without comments")
+	return cmd
+}
+
+func schemaApplyCmd() *cobra.Command {
+	var file, namespace string
+	var allowUnsafe bool
+
+	cmd := &cobra.Command{
+		Use:     "apply",
+		Short:   "Migrate a namespace to a schema file in a single transaction",
+		Long:    schemaLong,
+		Example: schemaApplyExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			txFlags, err := common.GetTxFlags(cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			assumeYes, err := helpers.GetAssumeYesFlag(cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			declared, err := readSchemaFile(file)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return client.DialClient(cmd.Context(), cmd, 0, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				plan, err := planSchema(ctx, cl, declared, namespace)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				if plan.Empty() {
+					return display.PrintCmd(cmd, display.RespString(fmt.Sprintf("Namespace %s is up to date.", namespace)))
+				}
+				if len(plan.Unsupported) > 0 {
+					return display.PrintErr(cmd, fmt.Errorf("the schema cannot be migrated automatically:\n%s",
+						strings.Join(plan.Unsupported, "\n")))
+				}
+				if unsafe := plan.Unsafe(); len(unsafe) > 0 && !allowUnsafe {
+					reasons := make([]string, len(unsafe))
+					for i, s := range unsafe {
+						reasons[i] = s.SQL + ": " + s.Reason
+					}
+					return display.PrintErr(cmd, fmt.Errorf("the migration has unsafe statements, use --allow-unsafe to apply them:\n%s",
+						strings.Join(reasons, "\n")))
+				}
+
+				if !assumeYes {
+					text, _ := (&respSchemaPlan{plan}).MarshalText()
+					fmt.Fprintf(cmd.ErrOrStderr(), "%s\n\n", text)
+					res, err := (&promptui.Prompt{
+						Label:   "Apply this migration? (y/n)",
+						Default: "N",
+					}).Run()
+					if err != nil {
+						return display.PrintErr(cmd, err)
+					}
+					if res != "y" && res != "Y" {
+						return display.PrintErr(cmd, errors.New("migration cancelled"))
+					}
+				}
+
+				txHash, err := cl.ExecuteSQL(ctx, plan.SQL(), nil, clientType.WithNonce(txFlags.NonceOverride),
+					clientType.WithSyncBroadcast(txFlags.SyncBroadcast))
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				return common.DisplayTxResult(ctx, cl, txHash, cmd)
+			})
+		},
+	}
+
+	bindSchemaFlags(cmd, &file, &namespace)
+	cmd.Flags().BoolVar(&allowUnsafe, "allow-unsafe", false, "apply statements that may delete data, fail on existing data, or break existing callers")
+	common.BindTxFlags(cmd)
+	return cmd
+}
+
+func bindSchemaFlags(cmd *cobra.Command, file, namespace *string) {
+	cmd.Flags().StringVarP(file, "file", "f", "", "the schema file")
+	cmd.Flags().StringVarP(namespace, "namespace", "n", "main", "the namespace to migrate")
+	cmd.MarkFlagRequired("file")
+}
+
+func readSchemaFile(file string) (*schemadiff.Schema, error) {
+	path, err := helpers.ExpandPath(file)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := schemadiff.Parse(string(bts))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema file %s: %w", file, err)
+	}
+	return s, nil
+}
+
+func planSchema(ctx context.Context, cl clientType.Client, declared *schemadiff.Schema, namespace string) (*schemadiff.Plan, error) {
+	live, err := schemadiff.Live(ctx, cl, namespace, declared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema of namespace %s: %w", namespace, err)
+	}
+	return schemadiff.Diff(live, declared, namespace), nil
+}
+
+type respSchemaPlan struct {
+	plan *schemadiff.Plan
+}
+
+func (r *respSchemaPlan) MarshalJSON() ([]byte, error) {
+	type step struct {
+		SQL    string `json:"sql"`
+		Unsafe bool   `json:"unsafe"`
+		Reason string `json:"reason,omitempty"`
+	}
+	steps := make([]step, len(r.plan.Steps))
+	for i, s := range r.plan.Steps {
+		steps[i] = step{s.SQL, s.Unsafe, s.Reason}
+	}
+	return json.Marshal(struct {
+		Namespace       string   `json:"namespace"`
+		CreateNamespace bool     `json:"create_namespace"`
+		Steps           []step   `json:"steps"`
+		Unsupported     []string `json:"unsupported"`
+		UpToDate        bool     `json:"up_to_date"`
+	}{r.plan.Namespace, r.plan.CreateNamespace, steps, r.plan.Unsupported, r.plan.Empty()})
+}
+
+func (r *respSchemaPlan) MarshalText() ([]byte, error) {
+	if r.plan.Empty() {
+		return []byte(fmt.Sprintf("Namespace %s is up to date.", r.plan.Namespace)), nil
+	}
+
+	var b strings.Builder
+	for _, u := range r.plan.Unsupported {
+		fmt.Fprintf(&b, "-- UNSUPPORTED: %s\n", u)
+	}
+	if r.plan.CreateNamespace {
+		fmt.Fprintf(&b, "CREATE NAMESPACE %s;\n", r.plan.Namespace)
+	}
+	fmt.Fprintf(&b, "SET CURRENT NAMESPACE TO %s;\n", r.plan.Namespace)
+	for _, s := range r.plan.Steps {
+		b.WriteString("\n")
+		if s.Unsafe {
+			fmt.Fprintf(&b, "-- UNSAFE: %s\n", s.Reason)
+		}
+		b.WriteString(s.SQL)
+		b.WriteString(";\n")
+	}
+	if n := len(r.plan.Unsafe()); n > 0 {
+		fmt.Fprintf(&b, "\n-- %d of %d statements are unsafe", n, len(r.plan.Steps))
+	}
+	return []byte(strings.TrimSuffix(b.String(), "\n")), nil
+}
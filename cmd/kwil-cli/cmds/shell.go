@@ -0,0 +1,617 @@
+package cmds
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+var (
+	shellLong = `Start an interactive SQL shell.
+
+The shell keeps a single connection to the node for the whole session. SQL statements may span
+multiple lines, and are executed when terminated with a semicolon. SELECT statements are executed
+as queries, while all other statements are authored as transactions using the configured private key.
+
+Namespaces, tables, columns, and actions are completed with the tab key. Lines beginning with a
+backslash are meta-commands, which are listed with '\?'. Input history is saved between sessions.`
+
+	shellExample = `# Start a shell using the configured provider
+kwil-cli shell
+
+# Start a shell in the "users" namespace that waits for transactions to be mined
+kwil-cli shell --namespace users --sync`
+)
+
+func shellCmd() *cobra.Command {
+	var namespace, format string
+	var sync, rpcAuth bool
+
+	cmd := &cobra.Command{
+		Use:     "shell",
+		Short:   "Start an interactive SQL shell",
+		Long:    shellLong,
+		Example: shellExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !validShellFormat(format) {
+				return display.PrintErr(cmd, fmt.Errorf("invalid format %q", format))
+			}
+
+			conf, err := config.ActiveConfig()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			// Writes require a private key, but a read-only session does not.
+			var dialFlags uint8
+			if conf.PrivateKey == nil {
+				dialFlags = client.WithoutPrivateKey
+			}
+			if rpcAuth {
+				dialFlags |= client.AuthenticatedCalls
+			}
+
+			return client.DialClient(cmd.Context(), cmd, dialFlags, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				sh := &shell{
+					cmd:       cmd,
+					cl:        cl,
+					canSign:   conf.PrivateKey != nil,
+					skipAuth:  !rpcAuth,
+					namespace: namespace,
+					sync:      sync,
+					format:    format,
+					comp:      newShellCompleter(),
+				}
+				sh.comp.namespace = sh.currentNamespace
+
+				rl, err := readline.NewEx(&readline.Config{
+					Prompt:            sh.prompt(false),
+					HistoryFile:       filepath.Join(config.ConfigDir(), "shell_history"),
+					AutoComplete:      sh.comp,
+					InterruptPrompt:   "^C",
+					EOFPrompt:         `\q`,
+					HistorySearchFold: true,
+				})
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				defer rl.Close()
+				sh.out = rl.Stdout()
+
+				if err = sh.refresh(ctx); err != nil {
+					fmt.Fprintf(rl.Stderr(), "unable to load completions: %v\n", err)
+				}
+				fmt.Fprintf(sh.out, "Connected to %s. Type \\? for help.\n", conf.Provider)
+
+				return sh.run(ctx, rl)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "the namespace to start in")
+	cmd.Flags().StringVar(&format, "format", shellFormatTable, "the result format: 'table', 'json', or 'csv'")
+	cmd.Flags().BoolVar(&sync, "sync", false, "wait for transactions to be included in a block")
+	cmd.Flags().BoolVar(&rpcAuth, "rpc-auth", false, "signals that queries are being made to a kwil node and should be authenticated with the private key")
+	display.BindTableFlags(cmd)
+	return cmd
+}
+
+const (
+	shellFormatTable = "table"
+	shellFormatJSON  = "json"
+	shellFormatCSV   = "csv"
+)
+
+func validShellFormat(f string) bool {
+	return f == shellFormatTable || f == shellFormatJSON || f == shellFormatCSV
+}
+
+var errShellQuit = errors.New("quit")
+
+// shell is an interactive SQL session.
+type shell struct {
+	cmd      *cobra.Command
+	cl       clientType.Client
+	out      io.Writer
+	canSign  bool
+	skipAuth bool
+	comp     *shellCompleter
+
+	namespace string
+	sync      bool
+	format    string
+}
+
+func (sh *shell) currentNamespace() string {
+	if sh.namespace == "" {
+		return engine.DefaultNamespace
+	}
+	return sh.namespace
+}
+
+func (sh *shell) prompt(continuation bool) string {
+	if continuation {
+		return strings.Repeat(" ", len(sh.currentNamespace())) + "-> "
+	}
+	return sh.currentNamespace() + "=> "
+}
+
+// run reads and executes input until the user quits or input ends.
+func (sh *shell) run(ctx context.Context, rl *readline.Instance) error {
+	var buf stmtBuffer
+	for {
+		rl.SetPrompt(sh.prompt(!buf.empty()))
+		line, err := rl.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			buf.reset() // discard the pending statement, like psql
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if buf.empty() && strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			err = sh.meta(ctx, strings.TrimSpace(line))
+			if errors.Is(err, errShellQuit) {
+				return nil
+			}
+			sh.printErr(err)
+			continue
+		}
+
+		for _, stmt := range buf.add(line) {
+			sh.printErr(sh.exec(ctx, stmt))
+		}
+	}
+}
+
+func (sh *shell) printErr(err error) {
+	if err != nil {
+		fmt.Fprintf(sh.out, "error: %v\n", err)
+	}
+}
+
+// exec executes a single SQL statement. SELECT statements are executed as
+// queries, and all others are executed in a transaction.
+func (sh *shell) exec(ctx context.Context, stmt string) error {
+	if !strings.HasPrefix(stmt, "{") && sh.namespace != "" {
+		stmt = "{" + sh.namespace + "}" + stmt
+	}
+
+	isQuery, err := isSelect(stmt)
+	if err != nil {
+		return err
+	}
+
+	if isQuery {
+		res, err := sh.cl.Query(ctx, stmt, nil, sh.skipAuth)
+		if err != nil {
+			return err
+		}
+		return sh.printResult(res)
+	}
+
+	if !sh.canSign {
+		return errors.New("a private key is required to execute statements that modify the database")
+	}
+	txHash, err := sh.cl.ExecuteSQL(ctx, stmt, nil, clientType.WithSyncBroadcast(sh.sync))
+	if err != nil {
+		return err
+	}
+	if !sh.sync {
+		fmt.Fprintf(sh.out, "tx hash: %s\n", txHash)
+		return nil
+	}
+
+	res, err := sh.cl.TxQuery(ctx, txHash)
+	if err != nil {
+		return err
+	}
+	if res.Result == nil {
+		return fmt.Errorf("tx %s has no result", txHash)
+	}
+	if res.Result.Code != uint32(types.CodeOk) {
+		return fmt.Errorf("tx %s failed: %s", txHash, res.Result.Log)
+	}
+	fmt.Fprintf(sh.out, "tx %s succeeded in block %d\n", txHash, res.Height)
+
+	// DDL may have changed the completions.
+	if err = sh.refresh(ctx); err != nil {
+		fmt.Fprintf(sh.out, "unable to refresh completions: %v\n", err)
+	}
+	return nil
+}
+
+// isSelect parses the statement, reporting whether it is a SELECT.
+func isSelect(stmt string) (bool, error) {
+	res, err := parse.Parse(stmt)
+	if err != nil {
+		return false, err
+	}
+	if len(res) != 1 {
+		return false, fmt.Errorf("expected one statement, got %d", len(res))
+	}
+	sqlStmt, ok := res[0].(*parse.SQLStatement)
+	if !ok {
+		return false, nil
+	}
+	_, ok = sqlStmt.SQL.(*parse.SelectStatement)
+	return ok, nil
+}
+
+func (sh *shell) printResult(res *types.QueryResult) error {
+	switch sh.format {
+	case shellFormatJSON:
+		bts, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(sh.out, string(bts))
+	case shellFormatCSV:
+		w := csv.NewWriter(sh.out)
+		if err := w.Write(res.ColumnNames); err != nil {
+			return err
+		}
+		if err := w.WriteAll(getStringRows(res.Values)); err != nil {
+			return err
+		}
+	default:
+		bts, err := display.FormatTable(sh.cmd, res.ColumnNames, getStringRows(res.Values))
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(sh.out, string(bts))
+		fmt.Fprintf(sh.out, "(%d rows)\n", len(res.Values))
+	}
+	return nil
+}
+
+const shellHelp = `Meta-commands:
+  \q                 quit the shell
+  \?                 show this help
+  \use [namespace]   set the namespace for statements (the default namespace if omitted)
+  \dn                list namespaces
+  \dt                list tables in the current namespace
+  \d <table>         describe the columns of a table in the current namespace
+  \actions [ns]      list the actions in a namespace
+  \sync [on|off]     toggle waiting for transactions to be included in a block
+  \format <fmt>      set the result format: table, json, or csv
+  \refresh           reload the completion data
+
+SQL statements are executed when terminated with a semicolon.
+`
+
+// meta executes a meta-command.
+func (sh *shell) meta(ctx context.Context, line string) error {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+
+	switch name {
+	case `\q`, `\quit`:
+		return errShellQuit
+	case `\?`, `\h`, `\help`:
+		fmt.Fprint(sh.out, shellHelp)
+	case `\use`:
+		if len(args) > 1 {
+			return errors.New(`usage: \use [namespace]`)
+		}
+		sh.namespace = ""
+		if len(args) == 1 {
+			sh.namespace = args[0]
+		}
+	case `\dn`:
+		return sh.infoQuery(ctx, "SELECT name, type FROM namespaces", nil)
+	case `\dt`:
+		return sh.infoQuery(ctx, "SELECT name FROM tables WHERE namespace = $namespace",
+			map[string]any{"namespace": sh.currentNamespace()})
+	case `\d`:
+		if len(args) != 1 {
+			return errors.New(`usage: \d <table>`)
+		}
+		return sh.infoQuery(ctx, "SELECT name, data_type, is_nullable, default_value, is_primary_key FROM columns WHERE namespace = $namespace AND table_name = $table ORDER BY ordinal_position",
+			map[string]any{"namespace": sh.currentNamespace(), "table": args[0]})
+	case `\actions`:
+		if len(args) > 1 {
+			return errors.New(`usage: \actions [namespace]`)
+		}
+		ns := sh.currentNamespace()
+		if len(args) == 1 {
+			ns = args[0]
+		}
+		return sh.infoQuery(ctx, "SELECT name, parameter_names, parameter_types, access_modifiers FROM actions WHERE namespace = $namespace AND built_in = false",
+			map[string]any{"namespace": ns})
+	case `\sync`:
+		switch {
+		case len(args) == 0:
+			sh.sync = !sh.sync
+		case len(args) == 1 && (args[0] == "on" || args[0] == "off"):
+			sh.sync = args[0] == "on"
+		default:
+			return errors.New(`usage: \sync [on|off]`)
+		}
+		fmt.Fprintf(sh.out, "sync broadcast is %s\n", onOff(sh.sync))
+	case `\format`:
+		if len(args) != 1 || !validShellFormat(args[0]) {
+			return errors.New(`usage: \format table|json|csv`)
+		}
+		sh.format = args[0]
+	case `\refresh`:
+		return sh.refresh(ctx)
+	default:
+		return fmt.Errorf(`unknown command %s, try \?`, name)
+	}
+	return nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func (sh *shell) infoQuery(ctx context.Context, query string, params map[string]any) error {
+	res, err := sh.cl.Query(ctx, "{info}"+query, params, sh.skipAuth)
+	if err != nil {
+		return err
+	}
+	return sh.printResult(res)
+}
+
+// refresh reloads the completion data from the info namespace.
+func (sh *shell) refresh(ctx context.Context) error {
+	query := func(q string) ([][]any, error) {
+		res, err := sh.cl.Query(ctx, "{info}"+q, nil, sh.skipAuth)
+		if err != nil {
+			return nil, err
+		}
+		return res.Values, nil
+	}
+
+	var data completionData
+	rows, err := query("SELECT name FROM namespaces")
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		data.namespaces = append(data.namespaces, fmt.Sprint(row[0]))
+	}
+
+	data.tables = make(map[string][]string)
+	rows, err = query("SELECT namespace, name FROM tables")
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		ns := fmt.Sprint(row[0])
+		data.tables[ns] = append(data.tables[ns], fmt.Sprint(row[1]))
+	}
+
+	data.columns = make(map[string][]string)
+	rows, err = query("SELECT DISTINCT namespace, name FROM columns")
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		ns := fmt.Sprint(row[0])
+		data.columns[ns] = append(data.columns[ns], fmt.Sprint(row[1]))
+	}
+
+	data.actions = make(map[string][]string)
+	rows, err = query("SELECT namespace, name FROM actions WHERE built_in = false")
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		ns := fmt.Sprint(row[0])
+		data.actions[ns] = append(data.actions[ns], fmt.Sprint(row[1]))
+	}
+
+	sh.comp.set(&data)
+	return nil
+}
+
+// stmtBuffer accumulates input lines until one or more complete statements,
+// terminated by semicolons, are available. Semicolons in string literals,
+// quoted identifiers, and comments do not terminate a statement.
+type stmtBuffer struct {
+	sb strings.Builder
+}
+
+func (b *stmtBuffer) empty() bool {
+	return strings.TrimSpace(b.sb.String()) == ""
+}
+
+func (b *stmtBuffer) reset() {
+	b.sb.Reset()
+}
+
+// add appends a line to the buffer and returns any complete statements, which
+// are removed from the buffer.
+func (b *stmtBuffer) add(line string) []string {
+	if !b.empty() {
+		b.sb.WriteByte('\n')
+	}
+	b.sb.WriteString(line)
+
+	stmts, rest := splitStatements(b.sb.String())
+	b.sb.Reset()
+	b.sb.WriteString(rest)
+	return stmts
+}
+
+// splitStatements splits the input into complete statements, each including
+// its terminating semicolon, and the incomplete remainder.
+func splitStatements(s string) (stmts []string, rest string) {
+	var quote byte // ' or " when in a literal or quoted identifier
+	var lineComment, blockComment bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case lineComment:
+			if c == '\n' {
+				lineComment = false
+			}
+		case blockComment:
+			if c == '*' && i+1 < len(s) && s[i+1] == '/' {
+				blockComment = false
+				i++
+			}
+		case quote != 0:
+			if c == quote {
+				quote = 0 // a doubled quote is an escape, which re-enters the quote next
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			lineComment = true
+			i++
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			blockComment = true
+			i++
+		case c == ';':
+			if stmt := strings.TrimSpace(s[start : i+1]); stmt != ";" {
+				stmts = append(stmts, stmt)
+			}
+			start = i + 1
+		}
+	}
+	return stmts, strings.TrimLeft(s[start:], " \t\n")
+}
+
+// completionData is the schema information used for tab completion.
+type completionData struct {
+	namespaces []string
+	tables     map[string][]string // namespace => tables
+	columns    map[string][]string // namespace => distinct column names
+	actions    map[string][]string // namespace => actions
+}
+
+var shellKeywords = []string{"SELECT", "FROM", "WHERE", "INSERT", "INTO", "VALUES",
+	"UPDATE", "SET", "DELETE", "CREATE", "TABLE", "ACTION", "NAMESPACE", "DROP",
+	"ALTER", "INDEX", "PRIMARY", "KEY", "JOIN", "LEFT", "INNER", "ON", "GROUP",
+	"BY", "ORDER", "LIMIT", "OFFSET", "AND", "OR", "NOT", "NULL", "AS", "DISTINCT",
+	"COUNT", "HAVING", "UNION", "RETURNS", "PUBLIC", "PRIVATE", "VIEW", "OWNER"}
+
+var shellMetaCommands = []string{`\q`, `\?`, `\use`, `\dn`, `\dt`, `\d`, `\actions`,
+	`\sync`, `\format`, `\refresh`}
+
+// shellCompleter implements readline.AutoCompleter.
+type shellCompleter struct {
+	namespace func() string
+
+	// data is replaced as a whole by set, and never modified. Completion is
+	// requested from the readline goroutine.
+	data atomic.Pointer[completionData]
+}
+
+func newShellCompleter() *shellCompleter {
+	c := &shellCompleter{
+		namespace: func() string { return engine.DefaultNamespace },
+	}
+	c.data.Store(&completionData{})
+	return c
+}
+
+func (c *shellCompleter) set(data *completionData) {
+	c.data.Store(data)
+}
+
+// candidates returns the possible completions for the word at the end of the
+// line before the cursor. before is the text preceding that word, and meta is
+// true if the line is a meta-command.
+func (c *shellCompleter) candidates(before string, meta bool) []string {
+	data, ns := c.data.Load(), c.namespace()
+
+	fields := strings.Fields(before)
+	trailingSpace := before == "" || strings.HasSuffix(before, " ")
+	if meta {
+		switch {
+		case len(fields) == 0:
+			return shellMetaCommands
+		case len(fields) == 1 && trailingSpace:
+			switch fields[0] {
+			case `\use`, `\actions`:
+				return data.namespaces
+			case `\d`:
+				return data.tables[ns]
+			case `\format`:
+				return []string{shellFormatTable, shellFormatJSON, shellFormatCSV}
+			case `\sync`:
+				return []string{"on", "off"}
+			}
+		}
+		return nil
+	}
+
+	switch {
+	case strings.HasSuffix(before, "{"): // a {namespace} prefix
+		return data.namespaces
+	case strings.HasSuffix(before, "."):
+		// A namespace qualified table or action, otherwise a column of a
+		// table or alias.
+		qual := before[strings.LastIndexAny(before[:len(before)-1], " \t\n(),.{}")+1 : len(before)-1]
+		if slices.Contains(data.namespaces, qual) {
+			return slices.Concat(data.tables[qual], data.actions[qual])
+		}
+		return data.columns[ns]
+	}
+
+	cands := slices.Concat(shellKeywords, data.namespaces, data.tables[ns],
+		data.columns[ns], data.actions[ns])
+	slices.Sort(cands)
+	return slices.Compact(cands)
+}
+
+// Do implements readline.AutoCompleter. It returns the suffixes of the
+// candidates that complete the current word, and the length of that word.
+func (c *shellCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	before := string(line[:pos])
+	seps := " \t\n(),.{}"
+	meta := strings.HasPrefix(strings.TrimSpace(before), `\`)
+	if meta {
+		seps = " \t"
+	}
+	wordStart := strings.LastIndexAny(before, seps) + 1
+	word := before[wordStart:]
+
+	var matches [][]rune
+	for _, cand := range c.candidates(before[:wordStart], meta) {
+		if len(cand) <= len(word) || !strings.EqualFold(cand[:len(word)], word) {
+			continue
+		}
+		suffix := cand[len(word):]
+		if word != "" && !isUpper(word) && slices.Contains(shellKeywords, cand) {
+			suffix = strings.ToLower(suffix) // match the case the user is typing keywords in
+		}
+		matches = append(matches, []rune(suffix))
+	}
+	return matches, len([]rune(word))
+}
+
+func isUpper(s string) bool {
+	return s == strings.ToUpper(s)
+}
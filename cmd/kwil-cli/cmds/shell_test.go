@@ -0,0 +1,81 @@
+package cmds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitStatements(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		stmts []string
+		rest  string
+	}{
+		{"incomplete", "SELECT * FROM t", nil, "SELECT * FROM t"},
+		{"one", "SELECT 1;", []string{"SELECT 1;"}, ""},
+		{"two and rest", "SELECT 1; SELECT 2;\nSELECT", []string{"SELECT 1;", "SELECT 2;"}, "SELECT"},
+		{"empty statements", " ; ;", nil, ""},
+		{"string literal", "SELECT 'a;b';", []string{"SELECT 'a;b';"}, ""},
+		{"escaped quote", "SELECT 'it''s;';", []string{"SELECT 'it''s;';"}, ""},
+		{"open literal", "SELECT 'a;", nil, "SELECT 'a;"},
+		{"quoted identifier", `SELECT "x;y" FROM t;`, []string{`SELECT "x;y" FROM t;`}, ""},
+		{"line comment", "SELECT 1 -- one; two\n;", []string{"SELECT 1 -- one; two\n;"}, ""},
+		{"block comment", "SELECT /* ; */ 1;", []string{"SELECT /* ; */ 1;"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, rest := splitStatements(tt.input)
+			assert.Equal(t, tt.stmts, stmts)
+			assert.Equal(t, tt.rest, rest)
+		})
+	}
+}
+
+func Test_stmtBuffer(t *testing.T) {
+	var buf stmtBuffer
+	require.True(t, buf.empty())
+
+	require.Empty(t, buf.add("SELECT *"))
+	require.False(t, buf.empty())
+	require.Empty(t, buf.add("FROM t"))
+	stmts := buf.add("WHERE id = 1; SELECT")
+	require.Equal(t, []string{"SELECT *\nFROM t\nWHERE id = 1;"}, stmts)
+	require.False(t, buf.empty())
+
+	buf.reset()
+	require.True(t, buf.empty())
+}
+
+func Test_shellCompleter(t *testing.T) {
+	c := newShellCompleter()
+	c.set(&completionData{
+		namespaces: []string{"info", "main", "users"},
+		tables:     map[string][]string{"main": {"accounts", "posts"}},
+		columns:    map[string][]string{"main": {"id", "author"}},
+		actions:    map[string][]string{"main": {"add_post"}},
+	})
+
+	complete := func(line string) []string {
+		matches, _ := c.Do([]rune(line), len([]rune(line)))
+		var strs []string
+		for _, m := range matches {
+			strs = append(strs, string(m))
+		}
+		return strs
+	}
+
+	assert.Equal(t, []string{"ction", "lter", "nd", "s", "ccounts", "dd_post", "uthor"}, complete("SELECT * FROM a"))
+	assert.Equal(t, []string{"ain"}, complete("{m"))
+	assert.Equal(t, []string{"uthor"}, complete("SELECT p.a"))
+	assert.Equal(t, []string{"ect"}, complete("sel"))
+	assert.Equal(t, []string{"ECT"}, complete("SEL"))
+	assert.Equal(t, []string{"osts"}, complete("SELECT id FROM main.p"))
+	assert.Equal(t, []string{"sers"}, complete(`\use u`))
+	assert.Equal(t, []string{"ctions"}, complete(`\a`))
+	assert.Equal(t, []string{"ccounts"}, complete(`\d a`))
+	assert.Equal(t, []string{"son"}, complete(`\format j`))
+	assert.Empty(t, complete(`\d accounts x`))
+}
@@ -59,13 +59,15 @@ func Example_respKwilCliConfig_text() {
 
 	display.Print(&respKwilCliConfig{
 		cfg: &config.KwilCliConfig{
-			PrivateKey: pk.(*crypto.Secp256k1PrivateKey),
-			ChainID:    "chainid123",
-			Provider:   "localhost:9090",
+			PrivateKey:     pk.(*crypto.Secp256k1PrivateKey),
+			PrivateKeyFile: "/home/kwil/.kwil-cli/private_key.json",
+			ChainID:        "chainid123",
+			Provider:       "localhost:9090",
 		},
 	}, nil, "text")
 	// Output:
 	// PrivateKey: ***
+	// PrivateKeyFile: /home/kwil/.kwil-cli/private_key.json
 	// Provider: localhost:9090
 	// ChainID: chainid123
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag" // with providers/posflag
 
+	"github.com/kwilteam/kwil-db/app/key"
 	"github.com/kwilteam/kwil-db/app/shared/bind"
 	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
 	"github.com/kwilteam/kwil-db/core/crypto"
@@ -173,8 +174,11 @@ func PreRunBindEnv(cmd *cobra.Command, args []string) error {
 
 type KwilCliConfig struct {
 	PrivateKey *crypto.Secp256k1PrivateKey
-	Provider   string
-	ChainID    string
+	// PrivateKeyFile is the key file that PrivateKey was loaded from, if any.
+	// If set, the key is persisted as the path rather than the key itself.
+	PrivateKeyFile string
+	Provider       string
+	ChainID        string
 }
 
 // Identity returns the account ID, or nil if no private key is set. These are
@@ -189,13 +193,14 @@ type KwilCliConfig struct {
 
 func (c *KwilCliConfig) ToPersistedConfig() *kwilCliPersistedConfig {
 	var privKeyHex string
-	if c.PrivateKey != nil {
+	if c.PrivateKey != nil && c.PrivateKeyFile == "" {
 		privKeyHex = hex.EncodeToString(c.PrivateKey.Bytes())
 	}
 	return &kwilCliPersistedConfig{
-		PrivateKey: privKeyHex,
-		Provider:   c.Provider,
-		ChainID:    c.ChainID,
+		PrivateKey:     privKeyHex,
+		PrivateKeyFile: c.PrivateKeyFile,
+		Provider:       c.Provider,
+		ChainID:        c.ChainID,
 	}
 }
 
@@ -207,9 +212,10 @@ func DefaultKwilCliPersistedConfig() *kwilCliPersistedConfig {
 
 // kwilCliPersistedConfig is the config that is used to persist the config file
 type kwilCliPersistedConfig struct {
-	PrivateKey string `json:"private_key,omitempty" comment:"the private key of the wallet that will be used for signing"`
-	Provider   string `json:"provider,omitempty" comment:"the Kwil provider RPC endpoint"`
-	ChainID    string `json:"chain_id,omitempty" comment:"the expected/intended Kwil Chain ID"`
+	PrivateKey     string `json:"private_key,omitempty" comment:"the private key of the wallet that will be used for signing"`
+	PrivateKeyFile string `json:"private_key_file,omitempty" comment:"a (possibly encrypted) key file with the private key, used if private_key is not set"`
+	Provider       string `json:"provider,omitempty" comment:"the Kwil provider RPC endpoint"`
+	ChainID        string `json:"chain_id,omitempty" comment:"the expected/intended Kwil Chain ID"`
 }
 
 func (c *kwilCliPersistedConfig) toKwilCliConfig() (*KwilCliConfig, error) {
@@ -218,6 +224,16 @@ func (c *kwilCliPersistedConfig) toKwilCliConfig() (*KwilCliConfig, error) {
 		ChainID:  c.ChainID,
 	}
 
+	if c.PrivateKey == "" && c.PrivateKeyFile != "" {
+		privateKey, err := LoadPrivateKeyFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		kwilConfig.PrivateKey = privateKey
+		kwilConfig.PrivateKeyFile = c.PrivateKeyFile
+		return kwilConfig, nil
+	}
+
 	// NOTE: so non private_key required cmds could be run
 	if c.PrivateKey == "" {
 		return kwilConfig, nil
@@ -237,6 +253,26 @@ func (c *kwilCliPersistedConfig) toKwilCliConfig() (*KwilCliConfig, error) {
 	return kwilConfig, nil
 }
 
+// LoadPrivateKeyFile reads a secp256k1 private key from a key file, as
+// written by `kwild key gen`. If the key file is encrypted, the passphrase is
+// read from the KWIL_KEY_PASSPHRASE_FILE or KWIL_KEY_PASSPHRASE environment
+// variables, or prompted for.
+func LoadPrivateKeyFile(path string) (*crypto.Secp256k1PrivateKey, error) {
+	path, err := helpers.ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := key.LoadNodeKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load private key file: %w", err)
+	}
+	secpKey, ok := pk.(*crypto.Secp256k1PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key file contains a %s key, expected secp256k1", pk.Type())
+	}
+	return secpKey, nil
+}
+
 func PersistConfig(conf *KwilCliConfig) error {
 	file, err := helpers.CreateOrOpenFile(configFile)
 	if err != nil {
@@ -0,0 +1,127 @@
+package dataio
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Checkpoint records the progress of an import so that an interrupted import
+// may be resumed without executing any rows twice. Rows are identified by
+// their zero-based position in the source file, and the checkpoint holds the
+// ranges of rows that were executed in successful transactions.
+type Checkpoint struct {
+	// Source is the path of the imported file.
+	Source string `json:"source"`
+	// Namespace and Action identify the action the rows were executed with.
+	Namespace string `json:"namespace"`
+	Action    string `json:"action"`
+	// Done are the sorted, non-overlapping, half-open [start, end) ranges of
+	// rows that have been committed.
+	Done []Range `json:"done"`
+}
+
+// Range is a half-open range of row numbers.
+type Range struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// LoadCheckpoint reads a checkpoint file. If the file does not exist, it
+// returns a new, empty Checkpoint for the source and action.
+func LoadCheckpoint(path, source, namespace, action string) (*Checkpoint, error) {
+	cp := &Checkpoint{
+		Source:    source,
+		Namespace: namespace,
+		Action:    action,
+	}
+	bts, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved Checkpoint
+	if err = json.Unmarshal(bts, &saved); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %w", path, err)
+	}
+	if saved.Source != source || saved.Namespace != namespace || saved.Action != action {
+		return nil, fmt.Errorf("checkpoint file %s is for importing %s with action %s.%s",
+			path, saved.Source, saved.Namespace, saved.Action)
+	}
+	cp.Done = saved.Done
+	return cp, nil
+}
+
+// Save atomically writes the checkpoint to a file.
+func (cp *Checkpoint) Save(path string) error {
+	bts, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(bts); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// IsDone reports whether the row was committed.
+func (cp *Checkpoint) IsDone(row int64) bool {
+	_, found := slices.BinarySearchFunc(cp.Done, row, func(r Range, row int64) int {
+		switch {
+		case row < r.Start:
+			return 1
+		case row >= r.End:
+			return -1
+		}
+		return 0
+	})
+	return found
+}
+
+// Rows returns the number of committed rows.
+func (cp *Checkpoint) Rows() int64 {
+	var n int64
+	for _, r := range cp.Done {
+		n += r.End - r.Start
+	}
+	return n
+}
+
+// MarkDone records that the rows in the range were committed, merging it with
+// any adjacent or overlapping ranges.
+func (cp *Checkpoint) MarkDone(r Range) {
+	if r.End <= r.Start {
+		return
+	}
+	done := append(cp.Done, r)
+	slices.SortFunc(done, func(a, b Range) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	merged := done[:1]
+	for _, r := range done[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			last.End = max(last.End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	cp.Done = merged
+}
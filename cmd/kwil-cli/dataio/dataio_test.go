@@ -0,0 +1,174 @@
+package dataio
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+func TestFormatOf(t *testing.T) {
+	for path, want := range map[string]Format{
+		"a.csv":          FormatCSV,
+		"a.JSONL":        FormatJSONL,
+		"a.ndjson":       FormatJSONL,
+		"dir/b.parquet":  FormatParquet,
+		"weird.csv.gz":   "",
+		"no_extension":   "",
+		"dir.csv/file.x": "",
+	} {
+		f, err := FormatOf(path)
+		if want == "" {
+			assert.Error(t, err, path)
+			continue
+		}
+		require.NoError(t, err, path)
+		assert.Equal(t, want, f, path)
+	}
+}
+
+// queryResult is a query result as decoded from JSON by the client.
+func queryResult(t *testing.T) *types.QueryResult {
+	numeric, err := types.NewNumericType(10, 2)
+	require.NoError(t, err)
+	res := &types.QueryResult{
+		ColumnNames: []string{"id", "name", "ok", "data", "amount", "tags", "nums"},
+		ColumnTypes: []*types.DataType{types.IntType, types.TextType, types.BoolType,
+			types.ByteaType, numeric, types.TextArrayType,
+			types.IntArrayType},
+		Values: [][]any{
+			{float64(1), "alice", true, "AQID", "1.50", []any{"a", `b,"c"`}, []any{float64(1), nil}},
+			{float64(2), nil, false, nil, nil, []any{}, nil},
+		},
+	}
+	// round trip through JSON to be sure the values are as the client has them
+	bts, err := json.Marshal(res)
+	require.NoError(t, err)
+	var decoded types.QueryResult
+	require.NoError(t, json.Unmarshal(bts, &decoded))
+	return &decoded
+}
+
+func writeFile(t *testing.T, format Format, res *types.QueryResult) string {
+	path := filepath.Join(t.TempDir(), "out."+string(format))
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w, err := NewWriter(f, format, res.ColumnNames, res.ColumnTypes)
+	require.NoError(t, err)
+	for _, row := range res.Values {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+	return path
+}
+
+func readAll(t *testing.T, path string, format Format) ([]string, []Record) {
+	r, err := OpenReader(path, format)
+	require.NoError(t, err)
+	defer r.Close()
+
+	var recs []Record
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		recs = append(recs, rec)
+	}
+	return r.Columns(), recs
+}
+
+func TestCSV(t *testing.T) {
+	res := queryResult(t)
+	path := writeFile(t, FormatCSV, res)
+
+	bts, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `id,name,ok,data,amount,tags,nums
+1,alice,true,AQID,1.50,"[""a"",""b,\""c\""""]","[1,null]"
+2,null,false,null,null,[],null
+`, string(bts))
+
+	cols, recs := readAll(t, path, FormatCSV)
+	assert.Equal(t, res.ColumnNames, cols)
+	require.Len(t, recs, 2)
+	assert.Equal(t, Record{"id": "1", "name": "alice", "ok": "true", "data": "AQID",
+		"amount": "1.50", "tags": `["a","b,\"c\""]`, "nums": "[1,null]"}, recs[0])
+}
+
+func TestJSONL(t *testing.T) {
+	res := queryResult(t)
+	path := writeFile(t, FormatJSONL, res)
+
+	bts, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1,"name":"alice","ok":true,"data":"AQID","amount":"1.50","tags":["a","b,\"c\""],"nums":[1,null]}
+{"id":2,"name":null,"ok":false,"data":null,"amount":null,"tags":[],"nums":null}
+`, string(bts))
+
+	cols, recs := readAll(t, path, FormatJSONL)
+	assert.Nil(t, cols)
+	require.Len(t, recs, 2)
+	assert.Equal(t, json.Number("1"), recs[0]["id"])
+	assert.Equal(t, []any{"a", `b,"c"`}, recs[0]["tags"])
+	assert.Nil(t, recs[1]["name"])
+}
+
+func TestParquet(t *testing.T) {
+	res := queryResult(t)
+	path := writeFile(t, FormatParquet, res)
+
+	cols, recs := readAll(t, path, FormatParquet)
+	assert.ElementsMatch(t, res.ColumnNames, cols)
+	require.Len(t, recs, 2)
+
+	assert.Equal(t, int64(1), recs[0]["id"])
+	assert.Equal(t, "alice", recs[0]["name"])
+	assert.Equal(t, true, recs[0]["ok"])
+	assert.Equal(t, []byte{1, 2, 3}, recs[0]["data"])
+	assert.Equal(t, "1.50", recs[0]["amount"])
+	assert.Equal(t, []any{"a", `b,"c"`}, recs[0]["tags"])
+	assert.Equal(t, []any{int64(1), nil}, recs[0]["nums"])
+
+	assert.Equal(t, int64(2), recs[1]["id"])
+	assert.Nil(t, recs[1]["name"])
+	assert.Nil(t, recs[1]["data"])
+	assert.Nil(t, recs[1]["nums"])
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cp.json")
+
+	cp, err := LoadCheckpoint(path, "in.csv", "main", "add")
+	require.NoError(t, err)
+	assert.Empty(t, cp.Done)
+
+	cp.MarkDone(Range{10, 20})
+	cp.MarkDone(Range{0, 5})
+	cp.MarkDone(Range{5, 8})
+	cp.MarkDone(Range{15, 25})
+	cp.MarkDone(Range{30, 30}) // empty
+	assert.Equal(t, []Range{{0, 8}, {10, 25}}, cp.Done)
+	assert.Equal(t, int64(23), cp.Rows())
+
+	for row, want := range map[int64]bool{0: true, 7: true, 8: false, 9: false, 10: true, 24: true, 25: false} {
+		assert.Equal(t, want, cp.IsDone(row), row)
+	}
+
+	require.NoError(t, cp.Save(path))
+	cp2, err := LoadCheckpoint(path, "in.csv", "main", "add")
+	require.NoError(t, err)
+	assert.Equal(t, cp, cp2)
+
+	_, err = LoadCheckpoint(path, "in.csv", "main", "other")
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+// Package dataio reads and writes the tabular data files used by the kwil-cli
+// data import and export commands. CSV, JSON Lines, and Parquet files are
+// supported.
+package dataio
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a data file format.
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatJSONL   Format = "jsonl"
+	FormatParquet Format = "parquet"
+)
+
+// Formats are the supported formats.
+var Formats = []Format{FormatCSV, FormatJSONL, FormatParquet}
+
+// ParseFormat parses a format name, which is case insensitive. "ndjson" is an
+// alias for JSON Lines.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatJSONL, FormatParquet:
+		return f, nil
+	case "ndjson":
+		return FormatJSONL, nil
+	}
+	return "", fmt.Errorf("unsupported format %q (must be csv, jsonl, or parquet)", s)
+}
+
+// FormatOf infers the format of a file from its extension.
+func FormatOf(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", fmt.Errorf("cannot infer the format of %s without a file extension", path)
+	}
+	return ParseFormat(ext)
+}
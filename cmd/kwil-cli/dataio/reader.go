@@ -0,0 +1,283 @@
+package dataio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Record is a row read from a data file, keyed by column name. The value types
+// depend on the format of the file:
+//   - CSV values are strings.
+//   - JSON Lines values are as decoded by encoding/json, except that numbers
+//     are json.Number so that large integers and decimals are not rounded.
+//   - Parquet values are int64, int32, float64, float32, bool, string,
+//     []byte, or []any for list columns.
+//
+// A column that is null or absent has a nil value.
+type Record map[string]any
+
+// Reader reads records from a data file.
+type Reader interface {
+	// Columns returns the names of the columns in the file. For JSON Lines
+	// files, where each record may have different fields, it returns nil.
+	Columns() []string
+	// Next returns the next record, or io.EOF when there are none.
+	Next() (Record, error)
+	// Close closes the file.
+	Close() error
+}
+
+// OpenReader opens a data file of the given format for reading.
+func OpenReader(path string, format Format) (Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r Reader
+	switch format {
+	case FormatCSV:
+		r, err = newCSVReader(f)
+	case FormatJSONL:
+		r = newJSONLReader(f)
+	case FormatParquet:
+		r, err = newParquetReader(f)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+type csvReader struct {
+	f      *os.File
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVReader(f *os.File) (*csvReader, error) {
+	r := csv.NewReader(f)
+	r.LazyQuotes = true
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV file has no header")
+		}
+		return nil, err
+	}
+	cols := make([]string, len(header))
+	for i, h := range header {
+		// strip any byte order mark and surrounding quotes or space
+		cols[i] = strings.TrimSpace(strings.Trim(strings.TrimPrefix(h, "\ufeff"), `"`))
+	}
+	return &csvReader{f: f, r: r, header: cols}, nil
+}
+
+func (c *csvReader) Columns() []string { return c.header }
+
+func (c *csvReader) Next() (Record, error) {
+	row, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	rec := make(Record, len(c.header))
+	for i, col := range c.header {
+		if i < len(row) {
+			rec[col] = row[i]
+		}
+	}
+	return rec, nil
+}
+
+func (c *csvReader) Close() error { return c.f.Close() }
+
+type jsonlReader struct {
+	f    *os.File
+	dec  *json.Decoder
+	line int
+}
+
+func newJSONLReader(f *os.File) *jsonlReader {
+	dec := json.NewDecoder(bufio.NewReader(f))
+	dec.UseNumber()
+	return &jsonlReader{f: f, dec: dec}
+}
+
+func (j *jsonlReader) Columns() []string { return nil }
+
+func (j *jsonlReader) Next() (Record, error) {
+	j.line++
+	var rec Record
+	if err := j.dec.Decode(&rec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("record %d: %w", j.line, err)
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("record %d: not a JSON object", j.line)
+	}
+	return rec, nil
+}
+
+func (j *jsonlReader) Close() error { return j.f.Close() }
+
+type parquetReader struct {
+	f      *os.File
+	r      *parquet.Reader
+	fields []parquetField
+	rows   []parquet.Row
+}
+
+// parquetField describes how to decode a top level field of a Parquet file.
+// Only flat schemas are supported, where each field is a primitive column or
+// a list of primitives.
+type parquetField struct {
+	name     string
+	col      parquet.LeafColumn
+	optional bool // the field may be null
+	list     bool
+	// elemDef is the definition level of a list value with an element, which
+	// may be null if less than the column's maximum definition level.
+	elemDef int
+}
+
+func newParquetReader(f *os.File) (*parquetReader, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	pf, err := parquet.OpenFile(f, st.Size())
+	if err != nil {
+		return nil, err
+	}
+	schema := pf.Schema()
+
+	leaves := make(map[string][]string) // field name => leaf path
+	for _, path := range schema.Columns() {
+		if _, have := leaves[path[0]]; have {
+			return nil, fmt.Errorf("unsupported nested field %s in Parquet file", path[0])
+		}
+		leaves[path[0]] = path
+	}
+
+	var fields []parquetField
+	for _, node := range schema.Fields() {
+		col, _ := schema.Lookup(leaves[node.Name()]...)
+		field := parquetField{
+			name:     node.Name(),
+			col:      col,
+			optional: node.Optional(),
+			list:     col.MaxRepetitionLevel > 0,
+			elemDef:  col.MaxDefinitionLevel,
+		}
+		if col.MaxRepetitionLevel > 1 {
+			return nil, fmt.Errorf("unsupported nested list %s in Parquet file", field.name)
+		}
+		if field.list && col.Node.Optional() {
+			field.elemDef-- // optional list elements
+		}
+		fields = append(fields, field)
+	}
+
+	return &parquetReader{
+		f:      f,
+		r:      parquet.NewReader(pf),
+		fields: fields,
+		rows:   make([]parquet.Row, 1),
+	}, nil
+}
+
+func (p *parquetReader) Columns() []string {
+	cols := make([]string, len(p.fields))
+	for i, field := range p.fields {
+		cols[i] = field.name
+	}
+	return cols
+}
+
+func (p *parquetReader) Next() (Record, error) {
+	p.rows[0] = p.rows[0][:0]
+	n, err := p.r.ReadRows(p.rows)
+	if n == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return nil, err
+	}
+
+	byCol := make(map[int][]parquet.Value, len(p.fields))
+	for _, v := range p.rows[0] {
+		byCol[v.Column()] = append(byCol[v.Column()], v)
+	}
+
+	rec := make(Record, len(p.fields))
+	for _, field := range p.fields {
+		vals := byCol[field.col.ColumnIndex]
+		if len(vals) == 0 {
+			continue
+		}
+		if !field.list {
+			rec[field.name] = parquetValue(vals[0], field.col)
+			continue
+		}
+
+		if vals[0].DefinitionLevel() < field.elemDef {
+			if field.optional && vals[0].DefinitionLevel() == 0 {
+				continue // null list
+			}
+			rec[field.name] = []any{}
+			continue
+		}
+		list := make([]any, len(vals))
+		for i, v := range vals {
+			list[i] = parquetValue(v, field.col)
+		}
+		rec[field.name] = list
+	}
+	return rec, nil
+}
+
+// parquetValue converts a Parquet value to a Go value. Byte arrays are strings
+// if the column has a string logical type.
+func parquetValue(v parquet.Value, col parquet.LeafColumn) any {
+	if v.IsNull() || v.DefinitionLevel() < col.MaxDefinitionLevel {
+		return nil
+	}
+	switch v.Kind() {
+	case parquet.Boolean:
+		return v.Boolean()
+	case parquet.Int32:
+		return v.Int32()
+	case parquet.Int64:
+		return v.Int64()
+	case parquet.Float:
+		return v.Float()
+	case parquet.Double:
+		return v.Double()
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		if lt := col.Node.Type().LogicalType(); lt != nil && lt.UTF8 != nil {
+			return string(v.ByteArray())
+		}
+		return bytes.Clone(v.ByteArray())
+	}
+	return v.String()
+}
+
+func (p *parquetReader) Close() error {
+	err := p.r.Close()
+	return errors.Join(err, p.f.Close())
+}
@@ -0,0 +1,317 @@
+package dataio
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// NullLiteral is the CSV representation of a null value, which is the same
+// literal used for parameters on the kwil-cli command line.
+const NullLiteral = "null"
+
+// Writer writes rows of a query result to a data file.
+type Writer interface {
+	// Write writes a row of values in column order, as returned in a
+	// types.QueryResult.
+	Write(row []any) error
+	// Close flushes any buffered data. It does not close the underlying
+	// io.Writer.
+	Close() error
+}
+
+// NewWriter creates a Writer for the columns of a query result. The column
+// types determine how the values are encoded, and in particular the schema of
+// a Parquet file. If a column type is unknown, values are written as they are
+// for CSV and JSON Lines, and as strings for Parquet.
+func NewWriter(w io.Writer, format Format, columns []string, colTypes []*types.DataType) (Writer, error) {
+	cols := make([]column, len(columns))
+	for i, name := range columns {
+		cols[i].name = name
+		if i < len(colTypes) {
+			cols[i].dt = colTypes[i]
+		}
+	}
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw, cols: cols}, nil
+	case FormatJSONL:
+		return &jsonlWriter{w: bufio.NewWriter(w), cols: cols}, nil
+	case FormatParquet:
+		group := make(parquet.Group, len(cols))
+		for _, col := range cols {
+			group[col.name] = parquetNode(col.dt)
+		}
+		schema := parquet.NewSchema("query_result", group)
+
+		// The group orders the fields by name, and each has one column.
+		pw := &parquetWriter{w: parquet.NewWriter(w, schema), cols: cols}
+		pos := make(map[string]int, len(cols))
+		for i, col := range cols {
+			pos[col.name] = i
+		}
+		for _, field := range schema.Fields() {
+			pw.order = append(pw.order, pos[field.Name()])
+		}
+		return pw, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+type column struct {
+	name string
+	dt   *types.DataType // nil if unknown
+}
+
+type csvWriter struct {
+	w    *csv.Writer
+	cols []column
+	rec  []string
+}
+
+func (c *csvWriter) Write(row []any) error {
+	c.rec = c.rec[:0]
+	for i, col := range c.cols {
+		v, err := Normalize(valueAt(row, i), col.dt)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", col.name, err)
+		}
+		c.rec = append(c.rec, csvString(v, false))
+	}
+	return c.w.Write(c.rec)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// csvString formats a normalized value in the form accepted by kwil-cli
+// parameters, so that exported files may be imported again. Array elements
+// are comma separated in brackets, with strings double quoted.
+func csvString(v any, inArray bool) string {
+	switch v := v.(type) {
+	case nil:
+		return NullLiteral
+	case string:
+		if inArray {
+			return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+		}
+		return v
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		elems := make([]string, len(v))
+		for i, e := range v {
+			elems[i] = csvString(e, true)
+		}
+		return "[" + strings.Join(elems, ",") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type jsonlWriter struct {
+	w    *bufio.Writer
+	cols []column
+}
+
+func (j *jsonlWriter) Write(row []any) error {
+	j.w.WriteByte('{')
+	for i, col := range j.cols {
+		v, err := Normalize(valueAt(row, i), col.dt)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", col.name, err)
+		}
+		name, _ := json.Marshal(col.name)
+		val, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", col.name, err)
+		}
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		j.w.Write(name)
+		j.w.WriteByte(':')
+		j.w.Write(val)
+	}
+	_, err := j.w.WriteString("}\n")
+	return err
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
+
+type parquetWriter struct {
+	w     *parquet.Writer
+	cols  []column
+	order []int // query result column of each Parquet column
+}
+
+// Write writes the row with the definition and repetition levels of the
+// schema created by parquetNode.
+func (p *parquetWriter) Write(row []any) error {
+	var prow parquet.Row
+	for colIdx, i := range p.order {
+		col := p.cols[i]
+		v, err := Normalize(valueAt(row, i), col.dt)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", col.name, err)
+		}
+
+		if col.dt == nil || !col.dt.IsArray {
+			if v == nil {
+				prow = append(prow, parquet.NullValue().Level(0, 0, colIdx))
+				continue
+			}
+			if col.dt == nil {
+				v = csvString(v, false)
+			}
+			prow = append(prow, parquet.ValueOf(v).Level(0, 1, colIdx))
+			continue
+		}
+
+		// optional list (1), repeated group (2), optional element (3)
+		arr, _ := v.([]any)
+		switch {
+		case v == nil:
+			prow = append(prow, parquet.NullValue().Level(0, 0, colIdx))
+		case len(arr) == 0:
+			prow = append(prow, parquet.NullValue().Level(0, 1, colIdx))
+		}
+		for j, elem := range arr {
+			rep := min(j, 1)
+			if elem == nil {
+				prow = append(prow, parquet.NullValue().Level(rep, 2, colIdx))
+				continue
+			}
+			prow = append(prow, parquet.ValueOf(elem).Level(rep, 3, colIdx))
+		}
+	}
+	_, err := p.w.WriteRows([]parquet.Row{prow})
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
+
+// parquetNode returns the Parquet schema node for a column type. All columns
+// are optional, since any value may be null.
+func parquetNode(dt *types.DataType) parquet.Node {
+	if dt == nil {
+		return parquet.Optional(parquet.String())
+	}
+	var node parquet.Node
+	switch dt.Name {
+	case types.IntType.Name:
+		node = parquet.Int(64)
+	case types.BoolType.Name:
+		node = parquet.Leaf(parquet.BooleanType)
+	case types.ByteaType.Name:
+		node = parquet.Leaf(parquet.ByteArrayType)
+	default: // text, uuid, and numeric
+		node = parquet.String()
+	}
+	if dt.IsArray {
+		node = parquet.List(parquet.Optional(node))
+	}
+	return parquet.Optional(node)
+}
+
+func valueAt(row []any, i int) any {
+	if i < len(row) {
+		return row[i]
+	}
+	return nil
+}
+
+// Normalize converts a value from a query result, as decoded from JSON, to the
+// Go type for the column's data type: int64 for int8, bool, []byte for bytea,
+// and string for text, uuid, and numeric. Array values are []any. If the type
+// is nil, the value is returned as is.
+func Normalize(v any, dt *types.DataType) (any, error) {
+	if v == nil || dt == nil {
+		return v, nil
+	}
+
+	if dt.IsArray {
+		arr, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an array for type %s, got %T", dt, v)
+		}
+		elemType := dt.Copy()
+		elemType.IsArray = false
+		res := make([]any, len(arr))
+		for i, e := range arr {
+			var err error
+			if res[i], err = Normalize(e, elemType); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+
+	switch dt.Name {
+	case types.IntType.Name:
+		switch v := v.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if v != math.Trunc(v) || math.Abs(v) > math.MaxInt64 {
+				return nil, fmt.Errorf("invalid integer %v", v)
+			}
+			return int64(v), nil
+		case json.Number:
+			return v.Int64()
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+	case types.BoolType.Name:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case types.ByteaType.Name:
+		switch v := v.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return base64.StdEncoding.DecodeString(v)
+		}
+	default:
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %T value for type %s", v, dt)
+}
@@ -0,0 +1,526 @@
+package schemadiff
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// Plan is the DDL that migrates a namespace from its live schema to a
+// declared schema.
+type Plan struct {
+	Namespace string
+	// CreateNamespace is true if the namespace does not exist.
+	CreateNamespace bool
+	// Steps are the statements to execute, in order.
+	Steps []*Step
+	// Unsupported describes differences that cannot be migrated with DDL, such
+	// as a changed primary key. A plan with unsupported differences should
+	// not be applied, since the result would not match the declared schema.
+	Unsupported []string
+}
+
+// Step is a single DDL statement of a Plan.
+type Step struct {
+	SQL string
+	// Unsafe is true if the statement may destroy data, fail on existing
+	// data, or break existing callers.
+	Unsafe bool
+	// Reason explains why the step is unsafe.
+	Reason string
+}
+
+// Empty reports whether the live schema already matches the declared schema.
+func (p *Plan) Empty() bool {
+	return !p.CreateNamespace && len(p.Steps) == 0 && len(p.Unsupported) == 0
+}
+
+// Unsafe returns the unsafe steps of the plan.
+func (p *Plan) Unsafe() []*Step {
+	var unsafe []*Step
+	for _, s := range p.Steps {
+		if s.Unsafe {
+			unsafe = append(unsafe, s)
+		}
+	}
+	return unsafe
+}
+
+// SQL returns the plan as a single SQL script that can be executed in one
+// transaction.
+func (p *Plan) SQL() string {
+	var b strings.Builder
+	if p.CreateNamespace {
+		fmt.Fprintf(&b, "CREATE NAMESPACE %s;\n", p.Namespace)
+	}
+	fmt.Fprintf(&b, "SET CURRENT NAMESPACE TO %s;\n", p.Namespace)
+	for _, s := range p.Steps {
+		b.WriteString(s.SQL)
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+// Diff plans the migration of a namespace from its live schema to the
+// declared schema. Tables, columns, constraints, indexes, and actions that are
+// not declared are dropped, while roles are only ever created.
+func Diff(live, declared *Schema, namespace string) *Plan {
+	d := &differ{
+		live:     live,
+		declared: declared,
+		plan:     &Plan{Namespace: namespace, CreateNamespace: !live.Exists},
+	}
+
+	d.roles()
+	d.dropActions()
+	d.dropIndexes()
+	d.dropConstraints()
+	d.dropTables()
+	d.createTables()
+	d.alterTables()
+	d.addConstraints()
+	d.createIndexes()
+	d.createActions()
+	d.grants()
+
+	return d.plan
+}
+
+type differ struct {
+	live, declared *Schema
+	plan           *Plan
+}
+
+func (d *differ) add(sql string) {
+	d.plan.Steps = append(d.plan.Steps, &Step{SQL: sql})
+}
+
+func (d *differ) addUnsafe(sql, reason string, args ...any) {
+	d.plan.Steps = append(d.plan.Steps, &Step{SQL: sql, Unsafe: true, Reason: fmt.Sprintf(reason, args...)})
+}
+
+func (d *differ) unsupported(msg string, args ...any) {
+	d.plan.Unsupported = append(d.plan.Unsupported, fmt.Sprintf(msg, args...))
+}
+
+func (d *differ) roles() {
+	for _, role := range d.declared.Roles {
+		if !slices.Contains(d.live.Roles, role) {
+			d.add("CREATE ROLE " + role)
+		}
+	}
+}
+
+func (d *differ) dropActions() {
+	for _, a := range d.live.Actions {
+		if !slices.ContainsFunc(d.declared.Actions, func(b *Action) bool { return b.Name == a.Name }) {
+			d.addUnsafe("DROP ACTION "+a.Name, "callers of action %s will fail", a.Name)
+		}
+	}
+}
+
+func (d *differ) dropIndexes() {
+	for _, idx := range d.live.Indexes {
+		if d.declared.Table(idx.Table) == nil {
+			continue // dropped with the table
+		}
+		if !slices.ContainsFunc(d.declared.Indexes, idx.equal) {
+			d.add("DROP INDEX " + idx.Name)
+		}
+	}
+}
+
+// dropConstraints drops the constraints of kept tables that are not declared.
+// This is done before tables are dropped, since a foreign key may reference a
+// dropped table.
+func (d *differ) dropConstraints() {
+	for _, lt := range d.live.Tables {
+		dt := d.declared.Table(lt.Name)
+		if dt == nil {
+			continue
+		}
+		for _, u := range lt.Uniques {
+			if !slices.ContainsFunc(dt.Uniques, u.equal) {
+				d.add(dropConstraint(lt.Name, u.Name))
+			}
+		}
+		for _, c := range lt.Checks {
+			if !slices.ContainsFunc(dt.Checks, c.equal) {
+				d.add(dropConstraint(lt.Name, c.Name))
+			}
+		}
+		for _, fk := range lt.ForeignKeys {
+			if !slices.ContainsFunc(dt.ForeignKeys, fk.equal) {
+				d.add(dropConstraint(lt.Name, fk.Name))
+			}
+		}
+	}
+}
+
+func (d *differ) dropTables() {
+	var dropped []*Table
+	for _, t := range d.live.Tables {
+		if d.declared.Table(t.Name) == nil {
+			dropped = append(dropped, t)
+		}
+	}
+	// drop referencing tables before the tables they reference
+	dropped = dependencyOrder(dropped)
+	slices.Reverse(dropped)
+	for _, t := range dropped {
+		d.addUnsafe("DROP TABLE "+t.Name, "all rows of table %s are deleted", t.Name)
+	}
+}
+
+func (d *differ) createTables() {
+	var created []*Table
+	for _, t := range d.declared.Tables {
+		if d.live.Table(t.Name) == nil {
+			created = append(created, t)
+		}
+	}
+	for _, t := range dependencyOrder(created) {
+		d.add(createTable(t))
+	}
+}
+
+func (d *differ) alterTables() {
+	for _, dt := range d.declared.Tables {
+		lt := d.live.Table(dt.Name)
+		if lt == nil {
+			continue
+		}
+
+		if !slices.Equal(lt.PrimaryKey, dt.PrimaryKey) {
+			d.unsupported("the primary key of table %s changes from (%s) to (%s), which requires recreating the table",
+				dt.Name, strings.Join(lt.PrimaryKey, ", "), strings.Join(dt.PrimaryKey, ", "))
+		}
+
+		for _, lc := range lt.Columns {
+			if dt.Column(lc.Name) == nil {
+				d.addUnsafe(alterTable(dt.Name, "DROP COLUMN "+lc.Name), "the data in column %s.%s is deleted", dt.Name, lc.Name)
+			}
+		}
+
+		for _, dc := range dt.Columns {
+			lc := lt.Column(dc.Name)
+			switch {
+			case lc == nil:
+				d.addColumn(dt.Name, dc, "")
+			case !lc.Type.EqualsStrict(dc.Type):
+				d.addUnsafe(alterTable(dt.Name, "DROP COLUMN "+dc.Name),
+					"the type of column %s.%s changes from %s to %s, so its data is deleted", dt.Name, dc.Name, lc.Type, dc.Type)
+				d.addColumn(dt.Name, dc, fmt.Sprintf("column %s.%s is recreated with no data", dt.Name, dc.Name))
+			default:
+				d.alterColumn(dt.Name, lc, dc)
+			}
+		}
+	}
+}
+
+// addColumn adds a column. If the column is NOT NULL without a default, the
+// statement fails if the table has rows, unless the column is being recreated,
+// in which case it always fails if the table has rows.
+func (d *differ) addColumn(table string, c *Column, recreated string) {
+	d.add(alterTable(table, fmt.Sprintf("ADD COLUMN %s %s", c.Name, c.Type)))
+	if c.Default != "" {
+		d.add(alterTable(table, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", c.Name, c.Default)))
+	}
+	if c.NotNull {
+		reason := fmt.Sprintf("fails if table %s has rows, since column %s has no default", table, c.Name)
+		if recreated != "" {
+			reason = "fails if the table has rows, since " + recreated
+		} else if c.Default != "" {
+			reason = fmt.Sprintf("fails if table %s has rows, since the default of column %s is only used for new rows", table, c.Name)
+		}
+		d.addUnsafe(alterTable(table, fmt.Sprintf("ALTER COLUMN %s SET NOT NULL", c.Name)), "%s", reason)
+	}
+}
+
+func (d *differ) alterColumn(table string, lc, dc *Column) {
+	if normalizeExpr(lc.Default) != normalizeExpr(dc.Default) {
+		if dc.Default == "" {
+			d.add(alterTable(table, fmt.Sprintf("ALTER COLUMN %s DROP DEFAULT", dc.Name)))
+		} else {
+			d.add(alterTable(table, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", dc.Name, dc.Default)))
+		}
+	}
+	switch {
+	case dc.NotNull && !lc.NotNull:
+		d.addUnsafe(alterTable(table, fmt.Sprintf("ALTER COLUMN %s SET NOT NULL", dc.Name)),
+			"fails if any row of table %s has a null %s", table, dc.Name)
+	case !dc.NotNull && lc.NotNull:
+		d.add(alterTable(table, fmt.Sprintf("ALTER COLUMN %s DROP NOT NULL", dc.Name)))
+	}
+}
+
+// addConstraints adds the declared constraints of existing tables.
+func (d *differ) addConstraints() {
+	for _, dt := range d.declared.Tables {
+		lt := d.live.Table(dt.Name)
+		if lt == nil {
+			continue
+		}
+		for _, u := range dt.Uniques {
+			if !slices.ContainsFunc(lt.Uniques, u.equal) {
+				d.addUnsafe(alterTable(dt.Name, "ADD "+u.sql()), "fails if existing rows of table %s are not unique", dt.Name)
+			}
+		}
+		for _, c := range dt.Checks {
+			if !slices.ContainsFunc(lt.Checks, c.equal) {
+				d.addUnsafe(alterTable(dt.Name, "ADD "+c.sql()), "fails if existing rows of table %s do not pass the check", dt.Name)
+			}
+		}
+		for _, fk := range dt.ForeignKeys {
+			if !slices.ContainsFunc(lt.ForeignKeys, fk.equal) {
+				d.addUnsafe(alterTable(dt.Name, "ADD "+fk.sql()), "fails if existing rows of table %s reference missing rows of %s", dt.Name, fk.RefTable)
+			}
+		}
+	}
+}
+
+func (d *differ) createIndexes() {
+	for _, idx := range d.declared.Indexes {
+		lt := d.live.Table(idx.Table)
+		if lt != nil && slices.ContainsFunc(d.live.Indexes, idx.equal) {
+			continue
+		}
+		if idx.Unique && lt != nil {
+			d.addUnsafe(idx.sql(), "fails if existing rows of table %s are not unique", idx.Table)
+			continue
+		}
+		d.add(idx.sql())
+	}
+}
+
+func (d *differ) createActions() {
+	for _, a := range d.declared.Actions {
+		i := slices.IndexFunc(d.live.Actions, func(b *Action) bool { return b.Name == a.Name })
+		raw := strings.TrimSuffix(strings.TrimSpace(a.Raw), ";")
+		switch {
+		case i == -1:
+			d.add(raw)
+		case normalizeAction(d.live.Actions[i].Raw) == normalizeAction(a.Raw):
+			// unchanged
+		case a.OrReplace:
+			d.add(raw)
+		default:
+			d.addUnsafe("DROP ACTION "+a.Name, "callers of action %s will fail if its parameters or returns changed", a.Name)
+			d.add(raw)
+		}
+	}
+}
+
+func (d *differ) grants() {
+	for _, g := range d.declared.Grants {
+		if !slices.ContainsFunc(d.live.Grants, g.equal) {
+			d.add("GRANT " + g.sql("TO"))
+		}
+	}
+	for _, g := range d.live.Grants {
+		if !slices.ContainsFunc(d.declared.Grants, g.equal) {
+			d.addUnsafe("REVOKE "+g.sql("FROM"), "members of role %s lose the %s privilege", g.Role, g.Privilege)
+		}
+	}
+}
+
+// dependencyOrder orders tables so that tables are after the tables they
+// reference. Tables in a reference cycle are left in their original order.
+func dependencyOrder(tables []*Table) []*Table {
+	pending := slices.Clone(tables)
+	ordered := make([]*Table, 0, len(tables))
+	isPending := func(name string) bool {
+		return slices.ContainsFunc(pending, func(t *Table) bool { return t.Name == name })
+	}
+	for len(pending) > 0 {
+		next := slices.IndexFunc(pending, func(t *Table) bool {
+			return !slices.ContainsFunc(t.ForeignKeys, func(fk *ForeignKey) bool {
+				return fk.RefTable != t.Name && isPending(fk.RefTable)
+			})
+		})
+		if next == -1 {
+			next = 0 // cycle
+		}
+		ordered = append(ordered, pending[next])
+		pending = slices.Delete(pending, next, next+1)
+	}
+	return ordered
+}
+
+func createTable(t *Table) string {
+	var defs []string
+	for _, c := range t.Columns {
+		def := c.Name + " " + c.Type.String()
+		if c.NotNull && !slices.Contains(t.PrimaryKey, c.Name) {
+			def += " NOT NULL"
+		}
+		if c.Default != "" {
+			def += " DEFAULT " + c.Default
+		}
+		defs = append(defs, def)
+	}
+	defs = append(defs, "PRIMARY KEY ("+strings.Join(t.PrimaryKey, ", ")+")")
+	for _, u := range t.Uniques {
+		defs = append(defs, u.sql())
+	}
+	for _, c := range t.Checks {
+		defs = append(defs, c.sql())
+	}
+	for _, fk := range t.ForeignKeys {
+		defs = append(defs, fk.sql())
+	}
+	return "CREATE TABLE " + t.Name + " (\n    " + strings.Join(defs, ",\n    ") + "\n)"
+}
+
+func alterTable(table, action string) string {
+	return "ALTER TABLE " + table + " " + action
+}
+
+func dropConstraint(table, name string) string {
+	return alterTable(table, "DROP CONSTRAINT "+name)
+}
+
+func constraintName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return "CONSTRAINT " + name + " "
+}
+
+func (u *Unique) sql() string {
+	return constraintName(u.Name) + "UNIQUE (" + strings.Join(u.Columns, ", ") + ")"
+}
+
+func (u *Unique) equal(other *Unique) bool {
+	return slices.Equal(u.Columns, other.Columns)
+}
+
+func (c *Check) sql() string {
+	return constraintName(c.Name) + "CHECK (" + c.Expr + ")"
+}
+
+func (c *Check) equal(other *Check) bool {
+	return normalizeExpr(c.Expr) == normalizeExpr(other.Expr)
+}
+
+func (fk *ForeignKey) sql() string {
+	s := constraintName(fk.Name) + "FOREIGN KEY (" + strings.Join(fk.Columns, ", ") + ") REFERENCES " +
+		fk.RefTable + "(" + strings.Join(fk.RefColumns, ", ") + ")"
+	if fk.OnUpdate != string(parse.DO_NO_ACTION) {
+		s += " ON UPDATE " + fk.OnUpdate
+	}
+	if fk.OnDelete != string(parse.DO_NO_ACTION) {
+		s += " ON DELETE " + fk.OnDelete
+	}
+	return s
+}
+
+func (fk *ForeignKey) equal(other *ForeignKey) bool {
+	return slices.Equal(fk.Columns, other.Columns) && fk.RefTable == other.RefTable &&
+		slices.Equal(fk.RefColumns, other.RefColumns) &&
+		fk.OnUpdate == other.OnUpdate && fk.OnDelete == other.OnDelete
+}
+
+func (idx *Index) sql() string {
+	s := "CREATE "
+	if idx.Unique {
+		s += "UNIQUE "
+	}
+	s += "INDEX "
+	if idx.Name != "" {
+		s += idx.Name + " "
+	}
+	return s + "ON " + idx.Table + "(" + strings.Join(idx.Columns, ", ") + ")"
+}
+
+// equal compares indexes by what they index, since unnamed indexes are named
+// by Postgres.
+func (idx *Index) equal(other *Index) bool {
+	return idx.Table == other.Table && idx.Unique == other.Unique && slices.Equal(idx.Columns, other.Columns)
+}
+
+func (g *Grant) sql(toFrom string) string {
+	s := g.Privilege
+	if g.Namespace != "" {
+		s += " ON " + g.Namespace
+	}
+	return s + " " + toFrom + " " + g.Role
+}
+
+var actionHeader = regexp.MustCompile(`(?is)^\s*create\s+(or\s+replace\s+)?action\s+(if\s+not\s+exists\s+)?`)
+
+// normalizeAction normalizes a CREATE ACTION statement for comparison,
+// ignoring the OR REPLACE and IF NOT EXISTS clauses and whitespace.
+func normalizeAction(raw string) string {
+	raw = actionHeader.ReplaceAllString(raw, "")
+	raw = strings.TrimSuffix(strings.TrimSpace(raw), ";")
+	return strings.Join(strings.Fields(raw), " ")
+}
+
+// normalizeExpr normalizes a default or check expression for comparison with
+// the form Postgres reports it in. Whitespace, parentheses, and type casts are
+// removed outside of string literals, and quoted numbers are unquoted. This is
+// a textual comparison, so it may not recognize some equivalent expressions.
+func normalizeExpr(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if len(expr) >= 5 && strings.EqualFold(expr[:5], "check") {
+		expr = expr[5:]
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '\'':
+			j := i + 1
+			for ; j < len(expr); j++ {
+				if expr[j] == '\'' {
+					if j+1 < len(expr) && expr[j+1] == '\'' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteString(expr[i:min(j+1, len(expr))])
+			i = j
+		case c == ':' && i+1 < len(expr) && expr[i+1] == ':':
+			j := i + 2
+			for j < len(expr) && isIdentChar(expr[j]) {
+				j++
+			}
+			if j < len(expr) && expr[j] == '(' {
+				if end := strings.IndexByte(expr[j:], ')'); end > 0 {
+					j += end + 1
+				}
+			}
+			for strings.HasPrefix(expr[j:], "[]") {
+				j += 2
+			}
+			i = j - 1
+		case unicode.IsSpace(rune(c)) || c == '(' || c == ')':
+		default:
+			b.WriteByte(byte(unicode.ToLower(rune(c))))
+		}
+	}
+
+	s := b.String()
+	if len(s) > 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		if _, err := strconv.ParseFloat(s[1:len(s)-1], 64); err == nil {
+			s = s[1 : len(s)-1]
+		}
+	}
+	if s == "null" {
+		return ""
+	}
+	return s
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
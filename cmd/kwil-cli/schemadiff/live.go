@@ -0,0 +1,243 @@
+package schemadiff
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// Querier executes read-only queries, such as a kwil client.
+type Querier interface {
+	Query(ctx context.Context, query string, params map[string]any, skipAuth bool) (*types.QueryResult, error)
+}
+
+// Live reads the schema of a namespace from the info namespace. Only the roles
+// and grants named in declared are read, since roles are global and may be
+// managed elsewhere. If the namespace does not exist, the returned Schema has
+// Exists set to false.
+func Live(ctx context.Context, q Querier, namespace string, declared *Schema) (*Schema, error) {
+	l := &liveReader{q: q, ns: namespace}
+	s := &Schema{}
+
+	rows, err := l.query(ctx, `SELECT name FROM namespaces WHERE name = $ns`)
+	if err != nil {
+		return nil, err
+	}
+	s.Exists = len(rows) > 0
+
+	if s.Exists {
+		if err = l.readTables(ctx, s); err != nil {
+			return nil, err
+		}
+		if err = l.readActions(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+	if err = l.readRoles(ctx, s, declared); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+type liveReader struct {
+	q  Querier
+	ns string
+}
+
+// row is a query result row, keyed by column name.
+type row map[string]any
+
+func (r row) str(col string) string {
+	s, _ := r[col].(string)
+	return s
+}
+
+func (r row) bool(col string) bool {
+	b, _ := r[col].(bool)
+	return b
+}
+
+func (r row) strs(col string) []string {
+	arr, _ := r[col].([]any)
+	strs := make([]string, 0, len(arr))
+	for _, v := range arr {
+		s, _ := v.(string)
+		strs = append(strs, s)
+	}
+	return strs
+}
+
+// query queries the info namespace, with the $ns parameter set to the
+// namespace being read.
+func (l *liveReader) query(ctx context.Context, stmt string) ([]row, error) {
+	res, err := l.q.Query(ctx, "{info}"+stmt, map[string]any{"ns": l.ns}, true)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]row, len(res.Values))
+	for i, vals := range res.Values {
+		r := make(row, len(vals))
+		for j, v := range vals {
+			r[res.ColumnNames[j]] = v
+		}
+		rows[i] = r
+	}
+	return rows, nil
+}
+
+func (l *liveReader) readTables(ctx context.Context, s *Schema) error {
+	cols, err := l.query(ctx, `SELECT table_name, name, data_type, is_nullable, default_value
+		FROM columns WHERE namespace = $ns ORDER BY table_name, ordinal_position`)
+	if err != nil {
+		return err
+	}
+	for _, r := range cols {
+		t := s.Table(r.str("table_name"))
+		if t == nil {
+			t = &Table{Name: r.str("table_name")}
+			s.Tables = append(s.Tables, t)
+		}
+		dt, err := types.ParseDataType(r.str("data_type"))
+		if err != nil {
+			return fmt.Errorf("column %s.%s: %w", t.Name, r.str("name"), err)
+		}
+		t.Columns = append(t.Columns, &Column{
+			Name:    r.str("name"),
+			Type:    dt,
+			NotNull: !r.bool("is_nullable"),
+			Default: r.str("default_value"),
+		})
+	}
+
+	cons, err := l.query(ctx, `SELECT table_name, name, constraint_type, columns, expression
+		FROM constraints WHERE namespace = $ns`)
+	if err != nil {
+		return err
+	}
+	var constraintNames []string
+	for _, r := range cons {
+		t := s.Table(r.str("table_name"))
+		if t == nil {
+			continue
+		}
+		constraintNames = append(constraintNames, r.str("name"))
+		switch r.str("constraint_type") {
+		case "UNIQUE":
+			t.Uniques = append(t.Uniques, &Unique{Name: r.str("name"), Columns: r.strs("columns")})
+		case "CHECK":
+			t.Checks = append(t.Checks, &Check{Name: r.str("name"), Expr: r.str("expression")})
+		}
+	}
+
+	fks, err := l.query(ctx, `SELECT table_name, name, columns, ref_table, ref_columns, on_update, on_delete
+		FROM foreign_keys WHERE namespace = $ns`)
+	if err != nil {
+		return err
+	}
+	for _, r := range fks {
+		t := s.Table(r.str("table_name"))
+		if t == nil {
+			continue
+		}
+		t.ForeignKeys = append(t.ForeignKeys, &ForeignKey{
+			Name:       r.str("name"),
+			Columns:    r.strs("columns"),
+			RefTable:   r.str("ref_table"),
+			RefColumns: r.strs("ref_columns"),
+			OnUpdate:   r.str("on_update"),
+			OnDelete:   r.str("on_delete"),
+		})
+	}
+
+	idxs, err := l.query(ctx, `SELECT table_name, name, is_primary_key, is_unique, columns
+		FROM indexes WHERE namespace = $ns`)
+	if err != nil {
+		return err
+	}
+	for _, r := range idxs {
+		t := s.Table(r.str("table_name"))
+		if t == nil {
+			continue
+		}
+		if r.bool("is_primary_key") {
+			t.PrimaryKey = r.strs("columns")
+			continue
+		}
+		if slices.Contains(constraintNames, r.str("name")) {
+			continue // backs a unique constraint
+		}
+		s.Indexes = append(s.Indexes, &Index{
+			Name:    r.str("name"),
+			Table:   t.Name,
+			Columns: r.strs("columns"),
+			Unique:  r.bool("is_unique"),
+		})
+	}
+
+	return nil
+}
+
+func (l *liveReader) readActions(ctx context.Context, s *Schema) error {
+	rows, err := l.query(ctx, `SELECT name, raw_statement FROM actions
+		WHERE namespace = $ns AND NOT built_in ORDER BY name`)
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		s.Actions = append(s.Actions, &Action{Name: r.str("name"), Raw: r.str("raw_statement")})
+	}
+	return nil
+}
+
+func (l *liveReader) readRoles(ctx context.Context, s *Schema, declared *Schema) error {
+	managed := declared.managedRoles()
+	if len(managed) == 0 {
+		return nil
+	}
+
+	roles, err := l.query(ctx, `SELECT name FROM roles`)
+	if err != nil {
+		return err
+	}
+	for _, r := range roles {
+		if slices.Contains(managed, r.str("name")) {
+			s.Roles = append(s.Roles, r.str("name"))
+		}
+	}
+
+	privs, err := l.query(ctx, `SELECT role_name, privilege, namespace FROM role_privileges WHERE granted`)
+	if err != nil {
+		return err
+	}
+	for _, r := range privs {
+		g := &Grant{Role: r.str("role_name"), Privilege: r.str("privilege"), Namespace: r.str("namespace")}
+		if slices.Contains(managed, g.Role) && declared.managesScope(g.Namespace, l.ns) {
+			s.Grants = append(s.Grants, g)
+		}
+	}
+	return nil
+}
+
+// managedRoles returns the roles that are created or granted privileges by
+// the schema.
+func (s *Schema) managedRoles() []string {
+	roles := slices.Clone(s.Roles)
+	for _, g := range s.Grants {
+		if !slices.Contains(roles, g.Role) {
+			roles = append(roles, g.Role)
+		}
+	}
+	return roles
+}
+
+// managesScope reports whether the privileges of managed roles in the scope,
+// either a namespace or "" for global privileges, are declared by the schema.
+// That is the global scope, the namespace the schema is applied to, and any
+// other namespace that the schema grants privileges on.
+func (s *Schema) managesScope(scope, namespace string) bool {
+	return scope == "" || scope == namespace ||
+		slices.ContainsFunc(s.Grants, func(g *Grant) bool { return g.Namespace == scope })
+}
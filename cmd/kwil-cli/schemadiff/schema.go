@@ -0,0 +1,314 @@
+// Package schemadiff compares a declarative schema, written as a file of
+// Kuneiform DDL statements, with the live schema of a namespace, and plans the
+// DDL needed to migrate the namespace to the declared schema.
+package schemadiff
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+	pggenerate "github.com/kwilteam/kwil-db/node/engine/pg_generate"
+)
+
+// Schema is the schema of a namespace: its tables, indexes, and actions, and
+// the roles and privileges it declares.
+type Schema struct {
+	// Exists is false if the namespace does not exist. It is always true for
+	// a parsed schema.
+	Exists  bool
+	Tables  []*Table
+	Indexes []*Index
+	Actions []*Action
+	// Roles are the roles that must exist.
+	Roles []string
+	// Grants are the privileges that are granted to roles.
+	Grants []*Grant
+}
+
+// Table is a table and its constraints.
+type Table struct {
+	Name       string
+	Columns    []*Column
+	PrimaryKey []string
+	// Uniques are the unique constraints. Unique indexes are Indexes.
+	Uniques     []*Unique
+	Checks      []*Check
+	ForeignKeys []*ForeignKey
+}
+
+// Column is a table column.
+type Column struct {
+	Name    string
+	Type    *types.DataType
+	NotNull bool
+	// Default is the SQL expression of the default value, or empty if there
+	// is none.
+	Default string
+}
+
+// Unique is a unique constraint.
+type Unique struct {
+	// Name is the constraint name. It is empty for unnamed local constraints.
+	Name    string
+	Columns []string
+}
+
+// Check is a check constraint.
+type Check struct {
+	Name string
+	// Expr is the SQL expression of the check, without the CHECK keyword.
+	Expr string
+}
+
+// ForeignKey is a foreign key constraint.
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	// OnUpdate and OnDelete are the referential actions, e.g. "CASCADE".
+	OnUpdate string
+	OnDelete string
+}
+
+// Index is an index that is not backing a primary key or unique constraint.
+type Index struct {
+	Name    string
+	Table   string
+	Columns []string
+	Unique  bool
+}
+
+// Action is an action, identified by name.
+type Action struct {
+	Name string
+	// Raw is the CREATE ACTION statement.
+	Raw string
+	// OrReplace is true if Raw is a CREATE OR REPLACE ACTION statement.
+	OrReplace bool
+}
+
+// Grant is a privilege granted to a role, either globally or on a namespace.
+type Grant struct {
+	Role      string
+	Privilege string
+	// Namespace is empty for a global privilege.
+	Namespace string
+}
+
+// Table returns the table with the given name, or nil.
+func (s *Schema) Table(name string) *Table {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Column returns the column with the given name, or nil.
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Parse parses a schema file. The file may contain CREATE TABLE, CREATE
+// INDEX, CREATE ACTION, CREATE ROLE, and GRANT statements. Statements may not
+// have a namespace prefix, since the namespace is chosen when the schema is
+// compared or applied.
+func Parse(sql string) (*Schema, error) {
+	stmts, err := parse.Parse(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Schema{Exists: true}
+	for i, stmt := range stmts {
+		if ns, ok := stmt.(parse.Namespaceable); ok && ns.GetNamespacePrefix() != "" {
+			return nil, fmt.Errorf("statement %d: namespace prefixes are not allowed in a schema file", i+1)
+		}
+
+		switch stmt := stmt.(type) {
+		case *parse.CreateTableStatement:
+			if s.Table(stmt.Name) != nil {
+				return nil, fmt.Errorf("table %s is declared more than once", stmt.Name)
+			}
+			t, err := parseTable(stmt)
+			if err != nil {
+				return nil, fmt.Errorf("table %s: %w", stmt.Name, err)
+			}
+			s.Tables = append(s.Tables, t)
+		case *parse.CreateIndexStatement:
+			s.Indexes = append(s.Indexes, &Index{
+				Name:    stmt.Name,
+				Table:   stmt.On,
+				Columns: stmt.Columns,
+				Unique:  stmt.Type == parse.IndexTypeUnique,
+			})
+		case *parse.CreateActionStatement:
+			if slices.ContainsFunc(s.Actions, func(a *Action) bool { return a.Name == stmt.Name }) {
+				return nil, fmt.Errorf("action %s is declared more than once", stmt.Name)
+			}
+			s.Actions = append(s.Actions, &Action{
+				Name:      stmt.Name,
+				Raw:       stmt.Raw,
+				OrReplace: stmt.OrReplace,
+			})
+		case *parse.CreateRoleStatement:
+			if !slices.Contains(s.Roles, stmt.Role) {
+				s.Roles = append(s.Roles, stmt.Role)
+			}
+		case *parse.GrantOrRevokeStatement:
+			if !stmt.IsGrant || stmt.ToRole == "" {
+				return nil, fmt.Errorf("statement %d: only GRANT statements of privileges to roles are allowed in a schema file", i+1)
+			}
+			var ns string
+			if stmt.Namespace != nil {
+				ns = *stmt.Namespace
+			}
+			for _, p := range stmt.Privileges {
+				g := &Grant{Role: stmt.ToRole, Privilege: strings.ToUpper(p), Namespace: ns}
+				if !slices.ContainsFunc(s.Grants, g.equal) {
+					s.Grants = append(s.Grants, g)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("statement %d: %T is not allowed in a schema file", i+1, stmt)
+		}
+	}
+
+	for _, idx := range s.Indexes {
+		if s.Table(idx.Table) == nil {
+			return nil, fmt.Errorf("index %s is on undeclared table %s", idx.Name, idx.Table)
+		}
+	}
+
+	return s, nil
+}
+
+func parseTable(stmt *parse.CreateTableStatement) (*Table, error) {
+	t := &Table{Name: stmt.Name}
+	for _, col := range stmt.Columns {
+		c := &Column{Name: col.Name, Type: col.Type}
+		for _, con := range col.Constraints {
+			switch con := con.(type) {
+			case *parse.PrimaryKeyInlineConstraint:
+				if t.PrimaryKey != nil {
+					return nil, errors.New("multiple primary keys")
+				}
+				t.PrimaryKey = []string{col.Name}
+			case *parse.NotNullConstraint:
+				c.NotNull = true
+			case *parse.DefaultConstraint:
+				expr, err := exprSQL(con.Value)
+				if err != nil {
+					return nil, fmt.Errorf("default of column %s: %w", col.Name, err)
+				}
+				c.Default = expr
+			case *parse.UniqueInlineConstraint:
+				t.Uniques = append(t.Uniques, &Unique{Columns: []string{col.Name}})
+			case *parse.CheckConstraint:
+				expr, err := exprSQL(con.Expression)
+				if err != nil {
+					return nil, fmt.Errorf("check of column %s: %w", col.Name, err)
+				}
+				t.Checks = append(t.Checks, &Check{Expr: expr})
+			case *parse.ForeignKeyReferences:
+				fk, err := foreignKey([]string{col.Name}, con)
+				if err != nil {
+					return nil, err
+				}
+				t.ForeignKeys = append(t.ForeignKeys, fk)
+			default:
+				return nil, fmt.Errorf("unsupported constraint %T", con)
+			}
+		}
+		t.Columns = append(t.Columns, c)
+	}
+
+	for _, con := range stmt.Constraints {
+		switch clause := con.Constraint.(type) {
+		case *parse.PrimaryKeyOutOfLineConstraint:
+			if t.PrimaryKey != nil {
+				return nil, errors.New("multiple primary keys")
+			}
+			t.PrimaryKey = clause.Columns
+		case *parse.UniqueOutOfLineConstraint:
+			t.Uniques = append(t.Uniques, &Unique{Name: con.Name, Columns: clause.Columns})
+		case *parse.CheckConstraint:
+			expr, err := exprSQL(clause.Expression)
+			if err != nil {
+				return nil, fmt.Errorf("check: %w", err)
+			}
+			t.Checks = append(t.Checks, &Check{Name: con.Name, Expr: expr})
+		case *parse.ForeignKeyOutOfLineConstraint:
+			fk, err := foreignKey(clause.Columns, clause.References)
+			if err != nil {
+				return nil, err
+			}
+			fk.Name = con.Name
+			t.ForeignKeys = append(t.ForeignKeys, fk)
+		default:
+			return nil, fmt.Errorf("unsupported constraint %T", clause)
+		}
+	}
+
+	if t.PrimaryKey == nil {
+		return nil, errors.New("no primary key")
+	}
+	for _, pk := range t.PrimaryKey {
+		c := t.Column(pk)
+		if c == nil {
+			return nil, fmt.Errorf("primary key column %s does not exist", pk)
+		}
+		c.NotNull = true // implied, and reported as such by Postgres
+	}
+
+	return t, nil
+}
+
+func foreignKey(cols []string, ref *parse.ForeignKeyReferences) (*ForeignKey, error) {
+	if ref.RefTableNamespace != "" {
+		return nil, fmt.Errorf("foreign key to %s.%s: references to other namespaces are not supported",
+			ref.RefTableNamespace, ref.RefTable)
+	}
+	fk := &ForeignKey{
+		Columns:    cols,
+		RefTable:   ref.RefTable,
+		RefColumns: ref.RefColumns,
+		OnUpdate:   string(parse.DO_NO_ACTION),
+		OnDelete:   string(parse.DO_NO_ACTION),
+	}
+	for _, a := range ref.Actions {
+		switch a.On {
+		case parse.ON_UPDATE:
+			fk.OnUpdate = string(a.Do)
+		case parse.ON_DELETE:
+			fk.OnDelete = string(a.Do)
+		}
+	}
+	return fk, nil
+}
+
+// exprSQL formats an expression as SQL.
+func exprSQL(expr parse.Expression) (string, error) {
+	sql, _, err := pggenerate.GenerateSQL(expr, "", func(varName string) (*types.DataType, error) {
+		return nil, fmt.Errorf("variable %s is not allowed here", varName)
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sql, ";"), nil
+}
+
+func (g *Grant) equal(other *Grant) bool {
+	return *g == *other
+}
@@ -0,0 +1,305 @@
+package schemadiff
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+const testSchema = `
+CREATE TABLE posts (
+	id int PRIMARY KEY,
+	author_id int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	body text NOT NULL DEFAULT '',
+	score int DEFAULT 0 CHECK (score >= 0)
+);
+
+CREATE TABLE users (
+	id int PRIMARY KEY,
+	name text NOT NULL,
+	email text UNIQUE
+);
+
+CREATE INDEX posts_author ON posts(author_id);
+
+CREATE ROLE writer;
+GRANT INSERT, update ON main TO writer;
+
+CREATE ACTION get_user($id int) public view returns (name text) {
+	for $row in SELECT name FROM users WHERE id = $id {
+		return $row.name;
+	}
+};
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse(testSchema)
+	require.NoError(t, err)
+
+	require.Len(t, s.Tables, 2)
+	posts := s.Table("posts")
+	require.NotNil(t, posts)
+	assert.Equal(t, []string{"id"}, posts.PrimaryKey)
+	assert.Equal(t, &Column{Name: "body", Type: types.TextType, NotNull: true, Default: "''"}, posts.Column("body"))
+	assert.True(t, posts.Column("id").NotNull)
+	assert.Equal(t, []*ForeignKey{{Columns: []string{"author_id"}, RefTable: "users", RefColumns: []string{"id"},
+		OnUpdate: "NO ACTION", OnDelete: "CASCADE"}}, posts.ForeignKeys)
+	require.Len(t, posts.Checks, 1)
+	assert.Equal(t, []*Unique{{Columns: []string{"email"}}}, s.Table("users").Uniques)
+
+	assert.Equal(t, []*Index{{Name: "posts_author", Table: "posts", Columns: []string{"author_id"}}}, s.Indexes)
+	assert.Equal(t, []string{"writer"}, s.Roles)
+	assert.Equal(t, []*Grant{{"writer", "INSERT", "main"}, {"writer", "UPDATE", "main"}}, s.Grants)
+	require.Len(t, s.Actions, 1)
+	assert.Equal(t, "get_user", s.Actions[0].Name)
+
+	for _, bad := range []string{
+		"INSERT INTO users VALUES (1, 'a', null);",
+		"{other}CREATE TABLE t (id int PRIMARY KEY);",
+		"CREATE TABLE t (id int);",
+		"CREATE TABLE t (id int PRIMARY KEY); CREATE TABLE t (id int PRIMARY KEY);",
+		"CREATE INDEX i ON missing(id);",
+		"GRANT writer TO '0x01';",
+	} {
+		_, err = Parse(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestDiffNewNamespace(t *testing.T) {
+	declared, err := Parse(testSchema)
+	require.NoError(t, err)
+
+	plan := Diff(&Schema{}, declared, "blog")
+	assert.True(t, plan.CreateNamespace)
+	assert.Empty(t, plan.Unsafe())
+	assert.Empty(t, plan.Unsupported)
+
+	sql := plan.SQL()
+	assert.True(t, strings.HasPrefix(sql, "CREATE NAMESPACE blog;\nSET CURRENT NAMESPACE TO blog;\n"), sql)
+	// users is created before posts, which references it
+	assert.Less(t, strings.Index(sql, "CREATE TABLE users"), strings.Index(sql, "CREATE TABLE posts"))
+	assert.Contains(t, sql, `CREATE TABLE posts (
+    id int8,
+    author_id int8 NOT NULL,
+    body text NOT NULL DEFAULT '',
+    score int8 DEFAULT 0,
+    PRIMARY KEY (id),
+    CHECK (score >= 0),
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+);`)
+	assert.Contains(t, sql, "CREATE INDEX posts_author ON posts(author_id);")
+	assert.Contains(t, sql, "CREATE ROLE writer;")
+	assert.Contains(t, sql, "GRANT INSERT ON main TO writer;")
+	assert.Contains(t, sql, "CREATE ACTION get_user")
+
+	// the generated DDL must be valid
+	_, err = parse.Parse(sql)
+	require.NoError(t, err)
+}
+
+// liveSchema is the schema of testSchema as reported by the info namespace.
+func liveSchema(t *testing.T) *Schema {
+	s, err := Parse(testSchema)
+	require.NoError(t, err)
+	posts, users := s.Table("posts"), s.Table("users")
+	posts.ForeignKeys[0].Name = "posts_author_id_fkey"
+	posts.Checks[0] = &Check{Name: "posts_score_check", Expr: "CHECK ((score >= 0))"}
+	posts.Column("body").Default = "''::text"
+	users.Uniques[0].Name = "users_email_key"
+	s.Actions[0].Raw = strings.ReplaceAll(s.Actions[0].Raw, "\t", "    ")
+	return s
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	declared, err := Parse(testSchema)
+	require.NoError(t, err)
+
+	plan := Diff(liveSchema(t), declared, "main")
+	assert.True(t, plan.Empty(), plan.SQL())
+}
+
+func TestDiffChanges(t *testing.T) {
+	declared, err := Parse(`
+CREATE TABLE users (
+	id int PRIMARY KEY,
+	name text,
+	email text NOT NULL,
+	age int NOT NULL DEFAULT 0,
+	UNIQUE (name, email)
+);
+
+CREATE TABLE tags (
+	id int PRIMARY KEY,
+	owner int REFERENCES users(id)
+);
+
+CREATE UNIQUE INDEX ON users(email);
+
+CREATE ROLE writer;
+GRANT SELECT ON main TO writer;
+
+CREATE OR REPLACE ACTION get_user($id int) public view returns (name text) {
+	return 'x';
+};
+`)
+	require.NoError(t, err)
+
+	plan := Diff(liveSchema(t), declared, "main")
+	assert.Empty(t, plan.Unsupported)
+
+	type step struct {
+		sql    string
+		unsafe bool
+	}
+	var got []step
+	for _, s := range plan.Steps {
+		if s.Unsafe {
+			assert.NotEmpty(t, s.Reason, s.SQL)
+		}
+		got = append(got, step{s.SQL, s.Unsafe})
+	}
+	assert.Equal(t, []step{
+		{"ALTER TABLE users DROP CONSTRAINT users_email_key", false},
+		{"DROP TABLE posts", true},
+		{"CREATE TABLE tags (\n    id int8,\n    owner int8,\n    PRIMARY KEY (id),\n    FOREIGN KEY (owner) REFERENCES users(id)\n)", false},
+		{"ALTER TABLE users ALTER COLUMN name DROP NOT NULL", false},
+		{"ALTER TABLE users ALTER COLUMN email SET NOT NULL", true},
+		{"ALTER TABLE users ADD COLUMN age int8", false},
+		{"ALTER TABLE users ALTER COLUMN age SET DEFAULT 0", false},
+		{"ALTER TABLE users ALTER COLUMN age SET NOT NULL", true},
+		{"ALTER TABLE users ADD UNIQUE (name, email)", true},
+		{"CREATE UNIQUE INDEX ON users(email)", true},
+		{"CREATE OR REPLACE ACTION get_user($id int) public view returns (name text) {\n\treturn 'x';\n}", false},
+		{"GRANT SELECT ON main TO writer", false},
+		{"REVOKE INSERT ON main FROM writer", true},
+		{"REVOKE UPDATE ON main FROM writer", true},
+	}, got)
+
+	_, err = parse.Parse(plan.SQL())
+	require.NoError(t, err)
+}
+
+func TestDiffReplaceAction(t *testing.T) {
+	declared, err := Parse(`CREATE ACTION a($id int) public {};`)
+	require.NoError(t, err)
+	live := &Schema{Exists: true, Actions: []*Action{{Name: "a", Raw: "CREATE ACTION a() public {}"}}}
+
+	plan := Diff(live, declared, "main")
+	require.Len(t, plan.Steps, 2)
+	assert.Equal(t, "DROP ACTION a", plan.Steps[0].SQL)
+	assert.True(t, plan.Steps[0].Unsafe)
+	assert.Equal(t, "CREATE ACTION a($id int) public {}", plan.Steps[1].SQL)
+}
+
+func TestDiffUnsupported(t *testing.T) {
+	declared, err := Parse(`CREATE TABLE users (id int, name text, PRIMARY KEY (id, name));`)
+	require.NoError(t, err)
+	live := &Schema{Exists: true, Tables: []*Table{{
+		Name:       "users",
+		Columns:    []*Column{{Name: "id", Type: types.IntType, NotNull: true}, {Name: "name", Type: types.IntType}},
+		PrimaryKey: []string{"id"},
+	}}}
+
+	plan := Diff(live, declared, "main")
+	require.Len(t, plan.Unsupported, 1)
+	assert.Contains(t, plan.Unsupported[0], "primary key of table users")
+	// the changed type recreates the column
+	require.Len(t, plan.Steps, 3)
+	assert.Equal(t, "ALTER TABLE users DROP COLUMN name", plan.Steps[0].SQL)
+	assert.True(t, plan.Steps[0].Unsafe)
+	assert.Equal(t, "ALTER TABLE users ADD COLUMN name text", plan.Steps[1].SQL)
+	assert.Equal(t, "ALTER TABLE users ALTER COLUMN name SET NOT NULL", plan.Steps[2].SQL)
+}
+
+func TestNormalizeExpr(t *testing.T) {
+	for _, tc := range []struct{ a, b string }{
+		{"''::text", "''"},
+		{"'it''s'::text", "'it''s'"},
+		{"CHECK ((score >= 0))", "score >= 0"},
+		{"CHECK (((a > 0) AND (b < 5)))", "a > 0 and b < 5"},
+		{"'-1'::bigint", "-1"},
+		{"'{}'::text[]", "'{}'"},
+		{"NULL::text", ""},
+		{"(1.5)::numeric(10,2)", "1.5"},
+	} {
+		assert.Equal(t, normalizeExpr(tc.a), normalizeExpr(tc.b), tc.a)
+	}
+	assert.NotEqual(t, normalizeExpr("'A'"), normalizeExpr("'a'"))
+	assert.NotEqual(t, normalizeExpr("'1'"), normalizeExpr("'1'::text || 'x'"))
+}
+
+type fakeQuerier map[string]*types.QueryResult
+
+func (f fakeQuerier) Query(_ context.Context, query string, params map[string]any, _ bool) (*types.QueryResult, error) {
+	for prefix, res := range f {
+		if strings.HasPrefix(query, "{info}SELECT "+prefix) {
+			return res, nil
+		}
+	}
+	return &types.QueryResult{}, nil
+}
+
+func TestLive(t *testing.T) {
+	q := fakeQuerier{
+		"name FROM namespaces": {ColumnNames: []string{"name"}, Values: [][]any{{"main"}}},
+		"table_name, name, data_type": {
+			ColumnNames: []string{"table_name", "name", "data_type", "is_nullable", "default_value"},
+			Values: [][]any{
+				{"users", "id", "int8", false, nil},
+				{"users", "name", "text", true, "'anon'::text"},
+				{"users", "tags", "text[]", true, nil},
+			},
+		},
+		"table_name, name, constraint_type": {
+			ColumnNames: []string{"table_name", "name", "constraint_type", "columns", "expression"},
+			Values:      [][]any{{"users", "users_name_key", "UNIQUE", []any{"name"}, "UNIQUE (name)"}},
+		},
+		"table_name, name, is_primary_key": {
+			ColumnNames: []string{"table_name", "name", "is_primary_key", "is_unique", "columns"},
+			Values: [][]any{
+				{"users", "users_pkey", true, true, []any{"id"}},
+				{"users", "users_name_key", false, true, []any{"name"}},
+				{"users", "users_tags", false, false, []any{"tags"}},
+			},
+		},
+		"name, raw_statement": {
+			ColumnNames: []string{"name", "raw_statement"},
+			Values:      [][]any{{"a", "CREATE ACTION a() public {}"}},
+		},
+		"name FROM roles": {ColumnNames: []string{"name"}, Values: [][]any{{"default"}, {"owner"}, {"writer"}}},
+		"role_name, privilege": {
+			ColumnNames: []string{"role_name", "privilege", "namespace"},
+			Values: [][]any{
+				{"default", "SELECT", nil},
+				{"writer", "INSERT", "main"},
+				{"writer", "INSERT", "other"},
+			},
+		},
+	}
+
+	declared := &Schema{Grants: []*Grant{{Role: "writer", Privilege: "SELECT", Namespace: "main"}}}
+	s, err := Live(context.Background(), q, "main", declared)
+	require.NoError(t, err)
+
+	assert.True(t, s.Exists)
+	require.Len(t, s.Tables, 1)
+	users := s.Tables[0]
+	assert.Equal(t, []string{"id"}, users.PrimaryKey)
+	assert.Equal(t, []*Column{
+		{Name: "id", Type: types.IntType, NotNull: true},
+		{Name: "name", Type: types.TextType, Default: "'anon'::text"},
+		{Name: "tags", Type: types.TextArrayType},
+	}, users.Columns)
+	assert.Equal(t, []*Unique{{Name: "users_name_key", Columns: []string{"name"}}}, users.Uniques)
+	assert.Equal(t, []*Index{{Name: "users_tags", Table: "users", Columns: []string{"tags"}}}, s.Indexes)
+	assert.Equal(t, []*Action{{Name: "a", Raw: "CREATE ACTION a() public {}"}}, s.Actions)
+	assert.Equal(t, []string{"writer"}, s.Roles)
+	assert.Equal(t, []*Grant{{Role: "writer", Privilege: "INSERT", Namespace: "main"}}, s.Grants)
+}
@@ -131,11 +131,28 @@ type GenesisConfig struct {
 	// Migration specifies the migration configuration required for zero downtime migration.
 	Migration MigrationParams `json:"migration"`
 
+	// EVMChains are the EVM chains that extensions such as the ERC20 bridge
+	// may use, in addition to the built-in chains (ethereum, sepolia, and
+	// base-sepolia). They are part of the genesis config so that all nodes on
+	// the network support the same chains, and they are mixed into the initial
+	// app hash. They cannot be changed after the network starts: there is no
+	// network parameter or vote to add a chain, so a running network can only
+	// use a new chain by migrating to a new genesis.
+	EVMChains []chains.ChainInfo `json:"evm_chains,omitempty"`
+
 	// NetworkParameters are network level configurations that can be
 	// evolved over the lifetime of a network.
 	types.NetworkParameters
 }
 
+// RegisterEVMChains registers the EVM chains declared in the genesis config,
+// in addition to the built-in chains. It fails if a chain conflicts with a
+// registered chain. It must be called before any extension uses the chains,
+// and before the erc20_bridge node config is validated.
+func (gc *GenesisConfig) RegisterEVMChains() error {
+	return chains.Register(gc.EVMChains...)
+}
+
 func (gc *GenesisConfig) SanityChecks() error {
 	switch len(gc.StateHash) {
 	case 0, types.HashLen:
@@ -180,6 +197,18 @@ func (gc *GenesisConfig) SanityChecks() error {
 		return errors.New("both start and end height should be set or unset")
 	}
 
+	names := make(map[chains.Chain]bool, len(gc.EVMChains))
+	ids := make(map[string]bool, len(gc.EVMChains))
+	for _, chain := range gc.EVMChains {
+		if err := chain.Validate(); err != nil {
+			return fmt.Errorf("invalid evm chain: %w", err)
+		}
+		if names[chain.Name] || ids[chain.ID] {
+			return fmt.Errorf("evm chain %s or its ID %s is declared more than once", chain.Name, chain.ID)
+		}
+		names[chain.Name], ids[chain.ID] = true, true
+	}
+
 	// ensure that the leader is part of the validator set
 	isValidator := slices.ContainsFunc(gc.Validators, func(v *types.Validator) bool {
 		if v.KeyType != gc.Leader.Type() {
@@ -323,6 +352,11 @@ func DefaultConfig() *Config {
 			DBName:        "kwild",
 			ReadTxTimeout: types.Duration(45 * time.Second),
 			MaxConns:      60,
+			ReadReplica: DBReadReplicaConfig{
+				Port:     "5432",
+				MaxConns: 30,
+				MaxLag:   2,
+			},
 		},
 		RPC: RPCConfig{
 			ListenAddress:      "0.0.0.0:8484",
@@ -333,12 +367,26 @@ func DefaultConfig() *Config {
 			ChallengeExpiry:    types.Duration(30 * time.Second),
 			ChallengeRateLimit: 10,
 			DisableServices:    []string{}, // e.g. "chain", see ServiceDisabled
+			RateLimit: RPCRateLimitConfig{
+				Enable: false,
+				Rate:   50,
+				Burst:  200,
+				MethodCosts: map[string]int{
+					"user.call":                5,
+					"user.query":               5,
+					"user.authenticated_query": 5,
+					"user.broadcast":           2,
+				},
+				IdentityRate:  0,
+				IdentityBurst: 100,
+			},
 		},
 		Admin: AdminConfig{
 			Enable:        true,
 			ListenAddress: DefaultAdminRPCAddr,
 			Pass:          "",
 			NoTLS:         false,
+			AuditLog:      "admin-audit.log",
 		},
 		Snapshots: SnapshotConfig{
 			Enable:          false,
@@ -404,10 +452,17 @@ type Logging struct {
 }
 
 type Telemetry struct {
-	Enable       bool   `toml:"enable" comment:"enable telemetry"`
+	Enable       bool   `toml:"enable" comment:"enable telemetry export to an OTLP collector"`
 	OTLPEndpoint string `toml:"otlp_endpoint" comment:"open telemetry protocol collector endpoint"` // "127.0.0.1:4318"
+	Prometheus   string `toml:"prometheus" comment:"serve Prometheus metrics at /metrics on the 'rpc' or 'admin' listener (disabled if empty)"`
 }
 
+// Listeners that may serve the Prometheus metrics endpoint.
+const (
+	PrometheusOnRPC   = "rpc"
+	PrometheusOnAdmin = "admin"
+)
+
 type MempoolConfig struct {
 	// MaxSize is the maximum size of the mempool in bytes.
 	MaxSize int64 `toml:"max_size" comment:"maximum size of the mempool in bytes"`
@@ -459,6 +514,46 @@ type DBConfig struct {
 	DBName        string         `toml:"dbname" comment:"postgres database name"`
 	ReadTxTimeout types.Duration `toml:"read_timeout" comment:"timeout on read transactions from user RPC calls and queries"`
 	MaxConns      uint32         `toml:"max_connections" comment:"maximum number of DB connections to permit"`
+
+	ReadReplica DBReadReplicaConfig `toml:"read_replica" comment:"read replica for user RPC queries and calls"`
+	Embedded    EmbeddedDBConfig    `toml:"embedded" comment:"embedded PostgreSQL server managed by kwild"`
+}
+
+// EmbeddedDBConfig corresponds to the [db.embedded] section of the config.
+// When enabled, kwild runs a PostgreSQL server with the locally installed
+// binaries, using a database cluster in the root directory that is initialized
+// on first start. The server listens on 127.0.0.1 at db.port, with db.user as
+// the superuser. This is intended for development and single-node setups.
+type EmbeddedDBConfig struct {
+	Enable bool   `toml:"enable" comment:"run an embedded PostgreSQL server with its data in the root directory; db.host is ignored"`
+	BinDir string `toml:"bin_dir" comment:"directory of the PostgreSQL binaries (initdb, postgres, pg_dump, psql); they are found on the PATH if empty"`
+}
+
+// DBReadReplicaConfig corresponds to the [db.read_replica] section of the
+// config. The replica is a streaming standby of the node's own database, which
+// serves the read transactions of the user query, authenticated_query, and call
+// methods while its replay lag is within MaxLag blocks of the committed height.
+type DBReadReplicaConfig struct {
+	Host     string `toml:"host" comment:"postgres host name of a streaming standby of the node's database (IP or UNIX socket path); empty disables the read replica"`
+	Port     string `toml:"port" comment:"postgres TCP port of the standby (leave empty for UNIX socket)"`
+	User     string `toml:"user" comment:"postgres role/user name on the standby (db.user and db.pass are used if empty)"`
+	Pass     string `toml:"pass" comment:"postgres password if required for the user and host"`
+	MaxConns uint32 `toml:"max_connections" comment:"maximum number of connections to the standby"`
+	MaxLag   int64  `toml:"max_lag" comment:"maximum number of blocks that the standby may be behind the committed height before queries fall back to the primary"`
+}
+
+// Validate checks the read replica settings if it is enabled.
+func (c *DBReadReplicaConfig) Validate() error {
+	if c.Host == "" {
+		return nil
+	}
+	if c.MaxConns < 1 {
+		return errors.New("db.read_replica.max_connections must be positive")
+	}
+	if c.MaxLag < 0 {
+		return errors.New("db.read_replica.max_lag may not be negative")
+	}
+	return nil
 }
 
 type ConsensusConfig struct {
@@ -487,6 +582,50 @@ type RPCConfig struct {
 	ChallengeExpiry    types.Duration `toml:"challenge_expiry" comment:"lifetime of a server-generated challenge"`
 	ChallengeRateLimit float64        `toml:"challenge_rate_limit" comment:"maximum number of challenges per second that a user can request"`
 	DisableServices    []string       `toml:"disabled_services" comment:"services to disable on the RPC server e.g. 'chain'"`
+	REST               bool           `toml:"rest" comment:"serve a REST facade over the RPC services (under /v1) with an OpenAPI document at /openapi.json"`
+	TrustedProxyCount  int            `toml:"trusted_proxy_count" comment:"number of trusted reverse proxies in front of the RPC server whose X-Forwarded-For entries identify the client IP (0 means direct connections)"`
+
+	RateLimit RPCRateLimitConfig `toml:"rate_limit" comment:"user RPC request rate limiting"`
+}
+
+// RPCRateLimitConfig corresponds to the [rpc.rate_limit] section of the config.
+// Requests are charged to a client according to the cost of the method, and a
+// client that has exhausted its allowance receives an error with a retry hint.
+type RPCRateLimitConfig struct {
+	Enable        bool           `toml:"enable" comment:"enable rate limiting of user RPC requests by client IP address"`
+	Rate          float64        `toml:"rate" comment:"sustained request cost units per second permitted for each client IP"`
+	Burst         int            `toml:"burst" comment:"maximum request cost units that a client IP may use at once"`
+	MethodCosts   map[string]int `toml:"method_costs" comment:"cost of specific methods e.g. 'user.call'=5; unlisted methods cost 1, and a negative cost disables the method"`
+	IdentityRate  float64        `toml:"identity_rate" comment:"sustained request cost units per second permitted for each authenticated identity in private mode (0 disables identity quotas)"`
+	IdentityBurst int            `toml:"identity_burst" comment:"maximum request cost units that an authenticated identity may use at once"`
+}
+
+// Validate checks the rate limit settings for consistency.
+func (c *RPCRateLimitConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Rate <= 0 {
+		return errors.New("rpc.rate_limit.rate must be positive")
+	}
+	if c.Burst <= 0 {
+		return errors.New("rpc.rate_limit.burst must be positive")
+	}
+	if c.IdentityRate < 0 {
+		return errors.New("rpc.rate_limit.identity_rate may not be negative")
+	}
+	if c.IdentityRate > 0 && c.IdentityBurst <= 0 {
+		return errors.New("rpc.rate_limit.identity_burst must be positive")
+	}
+	for method, cost := range c.MethodCosts {
+		if cost > c.Burst {
+			return fmt.Errorf("rpc.rate_limit.method_costs: cost of %s exceeds the burst", method)
+		}
+		if c.IdentityRate > 0 && cost > c.IdentityBurst {
+			return fmt.Errorf("rpc.rate_limit.method_costs: cost of %s exceeds the identity burst", method)
+		}
+	}
+	return nil
 }
 
 func (c *RPCConfig) ServiceDisabled(svc string) bool {
@@ -500,6 +639,7 @@ type AdminConfig struct {
 	ListenAddress string `toml:"listen" comment:"address in host:port format or UNIX socket path on which the admin RPC server will listen"`
 	Pass          string `toml:"pass" comment:"optional password for the admin service"`
 	NoTLS         bool   `toml:"notls" comment:"disable TLS when the listen address is not a loopback IP or UNIX socket"`
+	AuditLog      string `toml:"audit_log" comment:"path of the append-only log of admin method calls, relative to the root directory (disabled if empty)"`
 }
 
 type SnapshotConfig struct {
@@ -559,6 +699,27 @@ func (cfg ERC20BridgeConfig) Validate() error {
 	return nil
 }
 
+// Validate performs the sanity checks on the node config that do not depend on
+// the node's state, such as the genesis config or the files in the root
+// directory.
+func (nc *Config) Validate() error {
+	if nc.Consensus.ProposeTimeout < MinProposeTimeout {
+		return fmt.Errorf("propose timeout should be at least %s", MinProposeTimeout.String())
+	}
+	if err := nc.DB.ReadReplica.Validate(); err != nil {
+		return err
+	}
+	if err := nc.RPC.RateLimit.Validate(); err != nil {
+		return err
+	}
+	for _, ns := range nc.RPC.DisableServices {
+		if !isValidRPCNamespace(ns) {
+			return fmt.Errorf("rpc.disable_services: invalid namespace %s", ns)
+		}
+	}
+	return nil
+}
+
 // ToTOML marshals the config to TOML. The `toml` struct field tag
 // specifies the field names. For example:
 //
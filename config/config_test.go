@@ -158,6 +158,66 @@ func TestLoadConfigErrors(t *testing.T) {
 	}
 }
 
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{
+			name:   "default",
+			modify: func(*Config) {},
+		},
+		{
+			name: "short propose timeout",
+			modify: func(cfg *Config) {
+				cfg.Consensus.ProposeTimeout = MinProposeTimeout - 1
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid rate limit",
+			modify: func(cfg *Config) {
+				cfg.RPC.RateLimit.Enable = true
+				cfg.RPC.RateLimit.Rate = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "read replica",
+			modify: func(cfg *Config) {
+				cfg.DB.ReadReplica.Host = "10.0.0.2"
+			},
+		},
+		{
+			name: "invalid read replica lag",
+			modify: func(cfg *Config) {
+				cfg.DB.ReadReplica.Host = "10.0.0.2"
+				cfg.DB.ReadReplica.MaxLag = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid disabled service",
+			modify: func(cfg *Config) {
+				cfg.RPC.DisableServices = []string{"nope"}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestEncodeDecodePubKeyType(t *testing.T) {
 	testCases := []struct {
 		name          string
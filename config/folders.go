@@ -24,6 +24,11 @@ const (
 	genesisFileName      = "genesis.json"
 
 	leaderUpdatesFileName = "leader-updates.json"
+
+	adminTokensFileName = "admin-tokens.json"
+
+	embeddedDBDirName = "pgdata"
+	embeddedDBLogName = "postgres.log"
 )
 
 // BlockstoreDir returns the blockstore directory in the root directory.
@@ -67,3 +72,20 @@ func NodeKeyFilePath(rootDir string) string {
 func LeaderUpdatesFilePath(rootDir string) string {
 	return filepath.Join(rootDir, leaderUpdatesFileName)
 }
+
+// AdminTokensFilePath returns the path to the file that stores the admin
+// service's API tokens and client certificate scopes.
+func AdminTokensFilePath(rootDir string) string {
+	return filepath.Join(rootDir, adminTokensFileName)
+}
+
+// EmbeddedDBDir returns the data directory of the embedded PostgreSQL server.
+func EmbeddedDBDir(rootDir string) string {
+	return filepath.Join(rootDir, embeddedDBDirName)
+}
+
+// EmbeddedDBLogFilePath returns the path of the embedded PostgreSQL server's
+// log file.
+func EmbeddedDBLogFilePath(rootDir string) string {
+	return filepath.Join(rootDir, embeddedDBLogName)
+}
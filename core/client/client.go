@@ -8,9 +8,12 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
 	"net/url"
 	"time"
 
+	"go.opentelemetry.io/otel/propagation"
+
 	clientType "github.com/kwilteam/kwil-db/core/client/types"
 	"github.com/kwilteam/kwil-db/core/crypto/auth"
 	"github.com/kwilteam/kwil-db/core/log"
@@ -96,11 +99,23 @@ func NewClient(ctx context.Context, target string, options *clientType.Options)
 	if options != nil && options.Conn != nil {
 		jsonrpcClientOpts = append(jsonrpcClientOpts, rpcclient.WithHTTPClient(options.Conn))
 	}
+	injectHeaders := injectTraceContext
+	if options != nil && options.InjectHeaders != nil {
+		injectHeaders = options.InjectHeaders
+	}
+	jsonrpcClientOpts = append(jsonrpcClientOpts, rpcclient.WithHeaderInjector(injectHeaders))
 	client := userClient.NewClient(parsedURL, jsonrpcClientOpts...)
 
 	return WrapClient(ctx, client, options)
 }
 
+// injectTraceContext sets the W3C traceparent and tracestate headers from the
+// span context of ctx, if it has one, so that the node's spans for the request
+// are part of the caller's trace.
+func injectTraceContext(ctx context.Context, header http.Header) {
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
 // WrapClient wraps a TxSvcClient with a Kwil client.
 // It provides a way to use a custom rpc client with the Kwil client.
 func WrapClient(ctx context.Context, client RPCClient, options *clientType.Options) (*Client, error) {
@@ -307,6 +322,78 @@ func (c *Client) ExecuteSQL(ctx context.Context, stmt string, params map[string]
 	return c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast))
 }
 
+// Simulate executes an action as if the transaction were included in the next
+// block, without broadcasting it. It returns the result the transaction would
+// have, and its estimated fee. Unless a nonce is provided, the transaction
+// uses the next nonce of the account's confirmed state, ignoring any pending
+// transactions.
+func (c *Client) Simulate(ctx context.Context, namespace string, action string, tuples [][]any, opts ...clientType.TxOpt) (*types.TxResult, *big.Int, error) {
+	encodedTuples := make([][]*types.EncodedValue, len(tuples))
+	for i, tuple := range tuples {
+		encoded, err := EncodeInputs(tuple)
+		if err != nil {
+			return nil, nil, err
+		}
+		encodedTuples[i] = encoded
+	}
+
+	executionBody := &types.ActionExecution{
+		Action:    action,
+		Namespace: namespace,
+		Arguments: encodedTuples,
+	}
+
+	return c.simulate(ctx, executionBody, opts)
+}
+
+// SimulateSQL executes a SQL statement as if the transaction were included in
+// the next block, without broadcasting it. See Simulate.
+func (c *Client) SimulateSQL(ctx context.Context, stmt string, params map[string]any, opts ...clientType.TxOpt) (*types.TxResult, *big.Int, error) {
+	execTx := &types.RawStatement{}
+	execTx.Statement = stmt
+
+	for k, v := range params {
+		encoded, err := types.EncodeValue(v)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		execTx.Parameters = append(execTx.Parameters, &types.NamedValue{
+			Name:  k,
+			Value: encoded,
+		})
+	}
+
+	return c.simulate(ctx, execTx, opts)
+}
+
+// simulate creates a signed transaction with the payload and simulates it.
+func (c *Client) simulate(ctx context.Context, data types.Payload, opts []clientType.TxOpt) (*types.TxResult, *big.Int, error) {
+	txOpts := *clientType.GetTxOpts(opts)
+	if txOpts.Nonce <= 0 && c.Signer() != nil {
+		ident, err := types.GetSignerAccount(c.Signer())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get signer account: %w", err)
+		}
+		acc, err := c.txClient.GetAccount(ctx, ident, types.AccountStatusLatest)
+		if err != nil {
+			return nil, nil, err
+		}
+		txOpts.Nonce = acc.Nonce + 1
+	}
+
+	tx, err := c.newTx(ctx, data, &txOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.logger.Debug("simulate transaction",
+		"payload_type", tx.Body.PayloadType,
+		"fee", tx.Body.Fee.String(), "nonce", tx.Body.Nonce)
+
+	return c.txClient.Simulate(ctx, tx)
+}
+
 // Call calls an action. It returns the result records.
 func (c *Client) Call(ctx context.Context, namespace string, action string, inputs []any) (*types.CallResult, error) {
 	encoded, err := EncodeInputs(inputs)
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"math/big"
 	"net/http"
 
@@ -36,6 +37,11 @@ type Options struct {
 
 	// Conn is the http client to use.
 	Conn *http.Client
+
+	// InjectHeaders is called to add headers to each HTTP request with the
+	// request's context. If nil, the W3C trace context of the request's span,
+	// if any, is propagated to the node in the traceparent header.
+	InjectHeaders func(ctx context.Context, header http.Header)
 }
 
 // Apply applies the passed options to the receiver.
@@ -60,6 +66,10 @@ func (c *Options) Apply(opts *Options) {
 		c.Conn = opts.Conn
 	}
 
+	if opts.InjectHeaders != nil {
+		c.InjectHeaders = opts.InjectHeaders
+	}
+
 	c.SkipVerifyChainID = opts.SkipVerifyChainID
 
 	c.SkipHealthcheck = opts.SkipHealthcheck
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	sublog "github.com/decred/slog"
 )
@@ -151,10 +152,57 @@ func ParseLevel(s string) (Level, error) {
 	}
 }
 
+// LevelVar is a log level that may be changed while the loggers that use it
+// are running, such as when the node's config is reloaded. The zero value is
+// LevelDebug. See WithLevelVar.
+type LevelVar struct {
+	level atomic.Int64
+}
+
+// NewLevelVar creates a LevelVar with the initial level.
+func NewLevelVar(lvl Level) *LevelVar {
+	lv := &LevelVar{}
+	lv.level.Store(int64(lvl))
+	return lv
+}
+
+// Level returns the current level.
+func (lv *LevelVar) Level() Level {
+	return Level(lv.level.Load())
+}
+
+// Set changes the level of all loggers that use the LevelVar.
+func (lv *LevelVar) Set(lvl Level) {
+	lv.level.Store(int64(lvl))
+}
+
+// slogLeveler is a slog.Leveler for the structured loggers that use a
+// LevelVar.
+type slogLeveler struct {
+	lv *LevelVar
+}
+
+func (l slogLeveler) Level() slog.Level {
+	return levelToSlog(l.lv.Level())
+}
+
 // plainLogger is a plain text logger (not structured)
 type plainLogger struct {
 	be  *sublog.Backend
 	log sublog.Logger
+	lv  *LevelVar // nil unless created with WithLevelVar
+}
+
+// sl returns the sublog logger. If the logger uses a LevelVar, its level is
+// first updated from it, so no reference to the logger is kept by the
+// LevelVar.
+func (l *plainLogger) sl() sublog.Logger {
+	if l.lv != nil {
+		if lvl := levelToSublog(l.lv.Level()); l.log.Level() != lvl {
+			l.log.SetLevel(lvl)
+		}
+	}
+	return l.log
 }
 
 // args ...any, this must become arg[0]=arg[1] etc in the printed message
@@ -191,27 +239,27 @@ func (l *plainLogger) Debug(msg string, args ...any) {
 	// Avoid malloc and memmove with formatArgs and string concatenation. Since
 	// Debug is typically used liberally (it would be display very often) on
 	// frequently used paths, we want to avoid this unless the level is low enough.
-	if l.log.Level() > sublog.LevelDebug {
+	if l.sl().Level() > sublog.LevelDebug {
 		return
 	}
 	// args are pairs of key-values, so we will print them in pairs after the message.
 	msg += formatArgs(args...)
-	l.log.Debugf(msg)
+	l.sl().Debugf(msg)
 }
 
 func (l *plainLogger) Info(msg string, args ...any) {
 	msg += formatArgs(args...)
-	l.log.Infof(msg)
+	l.sl().Infof(msg)
 }
 
 func (l *plainLogger) Warn(msg string, args ...any) {
 	msg += formatArgs(args...)
-	l.log.Warnf(msg)
+	l.sl().Warnf(msg)
 }
 
 func (l *plainLogger) Error(msg string, args ...any) {
 	msg += formatArgs(args...)
-	l.log.Errorf(msg)
+	l.sl().Errorf(msg)
 }
 
 func (l *plainLogger) Log(level Level, msg string, args ...any) {
@@ -230,19 +278,19 @@ func (l *plainLogger) Log(level Level, msg string, args ...any) {
 var _ Loggerln = (*plainLogger)(nil)
 
 func (l *plainLogger) Debugln(a ...any) {
-	l.log.Debug(a...)
+	l.sl().Debug(a...)
 }
 
 func (l *plainLogger) Infoln(a ...any) {
-	l.log.Info(a...)
+	l.sl().Info(a...)
 }
 
 func (l *plainLogger) Warnln(a ...any) {
-	l.log.Warn(a...)
+	l.sl().Warn(a...)
 }
 
 func (l *plainLogger) Errorln(a ...any) {
-	l.log.Error(a...)
+	l.sl().Error(a...)
 }
 
 func (l *plainLogger) Logln(level Level, a ...any) {
@@ -261,19 +309,19 @@ func (l *plainLogger) Logln(level Level, a ...any) {
 var _ Loggerf = (*plainLogger)(nil)
 
 func (l *plainLogger) Debugf(msg string, args ...any) {
-	l.log.Debugf(msg, args...)
+	l.sl().Debugf(msg, args...)
 }
 
 func (l *plainLogger) Infof(msg string, args ...any) {
-	l.log.Infof(msg, args...)
+	l.sl().Infof(msg, args...)
 }
 
 func (l *plainLogger) Warnf(msg string, args ...any) {
-	l.log.Warnf(msg, args...)
+	l.sl().Warnf(msg, args...)
 }
 
 func (l *plainLogger) Errorf(msg string, args ...any) {
-	l.log.Errorf(msg, args...)
+	l.sl().Errorf(msg, args...)
 }
 
 func (l *plainLogger) Logf(level Level, msg string, args ...any) {
@@ -302,10 +350,11 @@ func (l *plainLogger) NewWithLevel(lvl Level, name string) Logger {
 
 func (l *plainLogger) New(name string) Logger {
 	logger := l.be.Logger(name)
-	logger.SetLevel(l.log.Level())
+	logger.SetLevel(l.sl().Level())
 	return &plainLogger{
 		be:  l.be,
 		log: logger,
+		lv:  l.lv,
 	}
 }
 
@@ -426,6 +475,7 @@ func (l *logger) NewWithLevel(lvl Level, name string) Logger {
 	opts := l.opts
 	opts.name = name
 	opts.level = lvl
+	opts.levelVar = nil
 	return newLogger(&opts)
 }
 
@@ -500,10 +550,15 @@ func newLogger(options *options) Logger {
 	if options.format == FormatUnstructured {
 		be := sublog.NewBackend(options.writer)
 		logger := be.Logger(options.name)
-		logger.SetLevel(levelToSublog(options.level))
+		if options.levelVar != nil {
+			logger.SetLevel(levelToSublog(options.levelVar.Level()))
+		} else {
+			logger.SetLevel(levelToSublog(options.level))
+		}
 		return &plainLogger{
 			be:  be,
 			log: logger,
+			lv:  options.levelVar,
 		}
 	}
 
@@ -511,9 +566,14 @@ func newLogger(options *options) Logger {
 		options.format = "text"
 	}
 
+	var level slog.Leveler = levelToSlog(options.level)
+	if options.levelVar != nil {
+		level = slogLeveler{options.levelVar}
+	}
+
 	handlerOpts := &slog.HandlerOptions{
 		AddSource: options.addSource,
-		Level:     levelToSlog(options.level),
+		Level:     level,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 			if a.Key == slog.TimeKey { // reformat the "time" attribute
 				t := a.Value.Time() // time.Now().UTC()
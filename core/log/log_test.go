@@ -532,3 +532,32 @@ func TestLazyHex(t *testing.T) {
 		})
 	}
 }
+
+func TestLevelVar(t *testing.T) {
+	for _, format := range []Format{FormatText, FormatJSON, FormatUnstructured} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			lv := NewLevelVar(LevelInfo)
+			logger := New(WithLevelVar(lv), WithFormat(format), WithWriter(&buf))
+			child := logger.New("child")
+			fixed := logger.NewWithLevel(LevelInfo, "fixed")
+
+			child.Debug("before")
+			if strings.Contains(buf.String(), "before") {
+				t.Fatalf("debug message logged at info level")
+			}
+
+			lv.Set(LevelDebug)
+			logger.Debug("parent after")
+			child.Debug("child after")
+			fixed.Debug("fixed after")
+			out := buf.String()
+			if !strings.Contains(out, "parent after") || !strings.Contains(out, "child after") {
+				t.Fatalf("debug messages not logged after level change: %q", out)
+			}
+			if strings.Contains(out, "fixed after") {
+				t.Fatalf("logger with a fixed level used the level var")
+			}
+		})
+	}
+}
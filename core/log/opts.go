@@ -30,6 +30,7 @@ type options struct {
 	addSource bool
 	writer    io.Writer
 	format    Format
+	levelVar  *LevelVar
 	// group     string // slog group for WithGroup, like a namespace
 }
 
@@ -45,6 +46,15 @@ func WithLevel(level Level) Option {
 	}
 }
 
+// WithLevelVar uses a level that may be changed while the logger is in use,
+// instead of a fixed level. Loggers created from the logger with New also use
+// the LevelVar, but those created with NewWithLevel do not.
+func WithLevelVar(lv *LevelVar) Option {
+	return func(o *options) {
+		o.levelVar = lv
+	}
+}
+
 func WithSource(enabled bool) Option {
 	return func(o *options) {
 		o.addSource = enabled
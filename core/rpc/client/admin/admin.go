@@ -25,11 +25,19 @@ type AdminClient interface {
 	// GetConfig gets the current config from the node.
 	// It returns the config serialized as JSON.
 	GetConfig(ctx context.Context) ([]byte, error)
+	// ReloadConfig has the node re-read its config file and apply the
+	// settings that may be changed without a restart.
+	ReloadConfig(ctx context.Context) (*adminTypes.ConfigReload, error)
 
 	AddPeer(ctx context.Context, peerID string) error
 	RemovePeer(ctx context.Context, peerID string) error
 	ListPeers(ctx context.Context) ([]string, error)
 
+	// Access tokens
+	MintToken(ctx context.Context, name string, scopes []string, cert []byte) (string, error)
+	RevokeToken(ctx context.Context, name string) error
+	ListTokens(ctx context.Context) ([]*adminTypes.Token, error)
+
 	// Resolutions
 	CreateResolution(ctx context.Context, resolution []byte, resolutionType string) (types.Hash, error)
 	ApproveResolution(ctx context.Context, resolutionID *types.UUID) (types.Hash, error)
@@ -39,4 +47,8 @@ type AdminClient interface {
 	// Block Execution
 	BlockExecStatus(ctx context.Context) (*adminTypes.BlockExecutionStatus, error)
 	AbortBlockExecution(ctx context.Context, height int64, discardTxs []string) error
+
+	// Debugging
+	TraceTx(ctx context.Context, txHash types.Hash) (*adminTypes.TxTrace, error)
+	TraceCall(ctx context.Context, namespace, action string, args []any, sender []byte, authType string) (*adminTypes.TraceCall, error)
 }
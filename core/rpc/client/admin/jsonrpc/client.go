@@ -2,6 +2,7 @@ package adminclient
 
 import (
 	"context"
+	"errors"
 	"net/url"
 	"time"
 
@@ -205,6 +206,55 @@ func (cl *Client) GetConfig(ctx context.Context) ([]byte, error) {
 	return res.Config, err
 }
 
+// ReloadConfig instructs the node to re-read its config file and apply the
+// settings that may be changed at runtime.
+func (cl *Client) ReloadConfig(ctx context.Context) (*adminTypes.ConfigReload, error) {
+	cmd := &adminjson.ReloadConfigRequest{}
+	res := &adminjson.ReloadConfigResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodReloadConfig), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// MintToken creates a named admin API token with the given scopes, and returns
+// its secret. If a PEM encoded TLS client certificate is given, the certificate
+// is granted the scopes instead, and no secret is returned.
+func (cl *Client) MintToken(ctx context.Context, name string, scopes []string, cert []byte) (string, error) {
+	cmd := &adminjson.MintTokenRequest{
+		Name:   name,
+		Scopes: scopes,
+		Cert:   cert,
+	}
+	res := &adminjson.MintTokenResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodMintToken), cmd, res)
+	if err != nil {
+		return "", err
+	}
+	return res.Token, nil
+}
+
+// RevokeToken revokes the named admin API token or client certificate.
+func (cl *Client) RevokeToken(ctx context.Context, name string) error {
+	cmd := &adminjson.RevokeTokenRequest{
+		Name: name,
+	}
+	res := &adminjson.RevokeTokenResponse{}
+	return cl.CallMethod(ctx, string(adminjson.MethodRevokeToken), cmd, res)
+}
+
+// ListTokens lists the admin API tokens and client certificates.
+func (cl *Client) ListTokens(ctx context.Context) ([]*adminTypes.Token, error) {
+	cmd := &adminjson.ListTokensRequest{}
+	res := &adminjson.ListTokensResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodListTokens), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Tokens, nil
+}
+
 // Ping just tests RPC connectivity. The expected response is "pong".
 func (cl *Client) Ping(ctx context.Context) (string, error) {
 	cmd := &userjson.PingRequest{
@@ -317,3 +367,61 @@ func (cl *Client) AbortBlockExecution(ctx context.Context, height int64, discard
 	res := &adminjson.AbortBlockExecResponse{}
 	return cl.CallMethod(ctx, string(adminjson.MethodAbortBlockExecution), cmd, res)
 }
+
+// TraceTx traces the execution of a committed transaction, returning a trace
+// for each action call it made. The transaction is executed again against the
+// node's current state, which is then rolled back.
+func (cl *Client) TraceTx(ctx context.Context, txHash types.Hash) (*adminTypes.TxTrace, error) {
+	cmd := &adminjson.TraceTxRequest{
+		TxHash: &txHash,
+	}
+	res, err := cl.traceTx(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &adminTypes.TxTrace{
+		Traces:       res.Traces,
+		StateDiffers: res.StateDiffers,
+		Height:       res.Height,
+	}, nil
+}
+
+// TraceCall traces the execution of an action with the given arguments, as if
+// it were executed in a transaction from sender. The node's state is not
+// modified. The sender may be nil.
+func (cl *Client) TraceCall(ctx context.Context, namespace, action string, args []any,
+	sender []byte, authType string) (*adminTypes.TraceCall, error) {
+	encArgs := make([]*types.EncodedValue, len(args))
+	for i, arg := range args {
+		var err error
+		encArgs[i], err = types.EncodeValue(arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := &adminjson.TraceTxRequest{
+		Namespace: namespace,
+		Action:    action,
+		Args:      encArgs,
+		Sender:    sender,
+		AuthType:  authType,
+	}
+	res, err := cl.traceTx(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return res.Traces[0], nil
+}
+
+func (cl *Client) traceTx(ctx context.Context, cmd *adminjson.TraceTxRequest) (*adminjson.TraceTxResponse, error) {
+	res := &adminjson.TraceTxResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodTraceTx), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Traces) == 0 {
+		return nil, errors.New("no traces returned")
+	}
+	return res, nil
+}
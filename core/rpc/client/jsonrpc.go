@@ -47,7 +47,8 @@ type JSONRPCClient struct {
 	endpoint string
 	log      log.Logger
 
-	basicAuthHdr string
+	authHdr       string
+	injectHeaders func(context.Context, http.Header)
 
 	reqID atomic.Uint64
 }
@@ -71,25 +72,30 @@ func NewJSONRPCClient(url *url.URL, opts ...RPCClientOpts) *JSONRPCClient {
 		opt(clientOpts)
 	}
 
-	var basicAuthHdr string
-	if clientOpts.pass != "" { // user is ignored on server verification
-		basicAuthHdr = "Basic " + base64.StdEncoding.EncodeToString([]byte("user:"+clientOpts.pass))
+	var authHdr string
+	if clientOpts.token != "" {
+		authHdr = "Bearer " + clientOpts.token
+	} else if clientOpts.pass != "" { // user is ignored on server verification
+		authHdr = "Basic " + base64.StdEncoding.EncodeToString([]byte("user:"+clientOpts.pass))
 	}
 
 	return &JSONRPCClient{
-		endpoint:     url.String(),
-		conn:         clientOpts.client,
-		log:          clientOpts.log,
-		basicAuthHdr: basicAuthHdr,
+		endpoint:      url.String(),
+		conn:          clientOpts.client,
+		log:           clientOpts.log,
+		authHdr:       authHdr,
+		injectHeaders: clientOpts.injectHeaders,
 	}
 }
 
 type RPCClientOpts func(*clientOptions)
 
 type clientOptions struct {
-	client *http.Client
-	log    log.Logger
-	pass   string
+	client        *http.Client
+	log           log.Logger
+	pass          string
+	token         string
+	injectHeaders func(context.Context, http.Header)
 }
 
 func WithLogger(log log.Logger) RPCClientOpts {
@@ -104,12 +110,28 @@ func WithPass(pass string) RPCClientOpts {
 	}
 }
 
+// WithAuthToken sets a bearer token to use in the Authorization header of each
+// request, instead of any password set with WithPass.
+func WithAuthToken(token string) RPCClientOpts {
+	return func(c *clientOptions) {
+		c.token = token
+	}
+}
+
 func WithHTTPClient(client *http.Client) RPCClientOpts {
 	return func(c *clientOptions) {
 		c.client = client
 	}
 }
 
+// WithHeaderInjector sets a function that adds headers to each HTTP request,
+// such as the headers that propagate trace context to the server.
+func WithHeaderInjector(fn func(ctx context.Context, header http.Header)) RPCClientOpts {
+	return func(c *clientOptions) {
+		c.injectHeaders = fn
+	}
+}
+
 func (cl *JSONRPCClient) nextReqID() string {
 	id := cl.reqID.Add(1)
 	return strconv.FormatUint(id, 10)
@@ -150,8 +172,11 @@ func (cl *JSONRPCClient) CallMethod(ctx context.Context, method string, cmd, res
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	if cl.basicAuthHdr != "" {
-		httpReq.Header.Set("Authorization", cl.basicAuthHdr) // httpReq.SetBasicAuth("user", cl.pass)
+	if cl.authHdr != "" {
+		httpReq.Header.Set("Authorization", cl.authHdr) // httpReq.SetBasicAuth("user", cl.pass)
+	}
+	if cl.injectHeaders != nil {
+		cl.injectHeaders(ctx, httpReq.Header)
 	}
 
 	httpResponse, err := cl.conn.Do(httpReq)
@@ -110,6 +110,27 @@ func (cl *Client) EstimateCost(ctx context.Context, tx *types.Transaction) (*big
 	return price, nil
 }
 
+// Simulate executes the transaction as if it were included in the next block,
+// without broadcasting it. It returns the result the transaction would have,
+// and its estimated price. The transaction need not be signed.
+func (cl *Client) Simulate(ctx context.Context, tx *types.Transaction) (*types.TxResult, *big.Int, error) {
+	cmd := &userjson.SimulateRequest{
+		Tx: tx,
+	}
+	res := &userjson.SimulateResponse{}
+	err := cl.CallMethod(ctx, string(userjson.MethodSimulate), cmd, res)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	price, ok := new(big.Int).SetString(res.Price, 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("failed to parse price to big.Int. received: %s", res.Price)
+	}
+
+	return res.Result, price, nil
+}
+
 func (cl *Client) GetAccount(ctx context.Context, account *types.AccountID, status types.AccountStatus) (*types.Account, error) {
 	cmd := &userjson.AccountRequest{
 		ID:     account,
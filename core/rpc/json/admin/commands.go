@@ -10,6 +10,23 @@ import (
 type StatusRequest struct{}
 type PeersRequest struct{}
 type GetConfigRequest struct{}
+
+type ReloadConfigRequest struct{}
+
+// MintTokenRequest contains the request parameters for MethodMintToken. If a
+// PEM encoded TLS client certificate is given, the certificate is granted the
+// scopes rather than a new API token.
+type MintTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	Cert   []byte   `json:"cert,omitempty"`
+}
+
+type RevokeTokenRequest struct {
+	Name string `json:"name"`
+}
+
+type ListTokensRequest struct{}
 type ApproveRequest struct {
 	PubKey     []byte         `json:"pubkey"`
 	PubKeyType crypto.KeyType `json:"pubkey_type"`
@@ -62,3 +79,22 @@ type PromoteRequest struct {
 	PubKeyType crypto.KeyType `json:"pubkey_type"`
 	Height     int64          `json:"height"`
 }
+
+// TraceTxRequest contains the request parameters for MethodTraceTx. Either the
+// hash of a committed transaction, an action to call, or a SQL statement is
+// given. Sender and AuthType set the caller of an action or statement. A
+// committed transaction must be in the latest block, since the state before
+// older blocks is not kept.
+type TraceTxRequest struct {
+	TxHash *types.Hash `json:"tx_hash,omitempty"`
+
+	Namespace string                `json:"namespace,omitempty"`
+	Action    string                `json:"action,omitempty"`
+	Args      []*types.EncodedValue `json:"args,omitempty"`
+
+	Statement string                         `json:"statement,omitempty"`
+	Params    map[string]*types.EncodedValue `json:"params,omitempty"`
+
+	Sender   types.HexBytes `json:"sender,omitempty"`
+	AuthType string         `json:"auth_type,omitempty"`
+}
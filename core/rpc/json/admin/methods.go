@@ -10,6 +10,7 @@ const (
 	MethodStatus            jsonrpc.Method = "admin.status"
 	MethodPeers             jsonrpc.Method = "admin.peers"
 	MethodConfig            jsonrpc.Method = "admin.config"
+	MethodReloadConfig      jsonrpc.Method = "admin.reload_config"
 	MethodValApprove        jsonrpc.Method = "admin.val_approve"
 	MethodValJoin           jsonrpc.Method = "admin.val_join"
 	MethodValRemove         jsonrpc.Method = "admin.val_remove"
@@ -21,10 +22,14 @@ const (
 	MethodAddPeer           jsonrpc.Method = "admin.add_peer"
 	MethodRemovePeer        jsonrpc.Method = "admin.remove_peer"
 	MethodListPeers         jsonrpc.Method = "admin.list_peers"
+	MethodMintToken         jsonrpc.Method = "admin.mint_token"
+	MethodRevokeToken       jsonrpc.Method = "admin.revoke_token"
+	MethodListTokens        jsonrpc.Method = "admin.list_tokens"
 	MethodCreateResolution  jsonrpc.Method = "admin.create_resolution"
 	MethodApproveResolution jsonrpc.Method = "admin.approve_resolution"
 	MethodResolutionStatus  jsonrpc.Method = "admin.resolution_status"
 	// MethodDeleteResolution  jsonrpc.Method = "admin.delete_resolution"
 	MethodBlockExecStatus     jsonrpc.Method = "admin.block_exec_status"
 	MethodAbortBlockExecution jsonrpc.Method = "admin.abort_block_execution"
+	MethodTraceTx             jsonrpc.Method = "debug.trace_tx"
 )
@@ -92,6 +92,20 @@ type GetConfigResponse struct {
 	Config []byte `json:"config,omitempty"`
 }
 
+type ReloadConfigResponse = adminTypes.ConfigReload
+
+// MintTokenResponse contains the secret of a new API token, which cannot be
+// retrieved later. It is empty when a client certificate was granted scopes.
+type MintTokenResponse struct {
+	Token string `json:"token,omitempty"`
+}
+
+type RevokeTokenResponse struct{}
+
+type ListTokensResponse struct {
+	Tokens []*adminTypes.Token `json:"tokens"`
+}
+
 type PeerResponse struct{}
 
 // List of peers in the node's whitelist.
@@ -111,3 +125,17 @@ type BlockExecStatusResponse struct {
 type AbortBlockExecResponse struct{}
 
 type PromoteResponse struct{}
+
+// TraceTxResponse is the response for MethodTraceTx. There is a trace for each
+// call made by the transaction, which is one unless it is an action execution
+// with several sets of arguments.
+type TraceTxResponse struct {
+	Traces []*adminTypes.TraceCall `json:"traces"`
+	// StateDiffers is set when tracing a committed transaction, which is
+	// executed against the state after its block, at Height, rather than the
+	// state before it. The trace may then differ from the transaction's
+	// execution in its block, e.g. if it depends on changes that the block
+	// made.
+	StateDiffers bool  `json:"state_differs,omitempty"`
+	Height       int64 `json:"height,omitempty"`
+}
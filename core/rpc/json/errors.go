@@ -16,6 +16,9 @@ const (
 	// error, but a result structure fails to encode to JSON.
 	ErrorResultEncoding ErrorCode = -32000
 	ErrorTimeout        ErrorCode = -32001
+	// ErrorUnauthorized is when the authenticated caller does not have the
+	// permission to call the method.
+	ErrorUnauthorized ErrorCode = -32002
 
 	// Application errors get the rest of the code space.
 
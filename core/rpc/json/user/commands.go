@@ -88,6 +88,14 @@ type EstimatePriceRequest struct {
 	Tx *types.Transaction `json:"tx"`
 }
 
+// SimulateRequest contains the request parameters for MethodSimulate. The
+// sender and signature type of the transaction must be set. A valid signature
+// is required for raw statements, and for any transaction if the node is in
+// private mode.
+type SimulateRequest struct {
+	Tx *types.Transaction `json:"tx"`
+}
+
 // QueryRequest contains the request parameters for MethodQuery.
 type QueryRequest struct {
 	Query  string                         `json:"query"`
@@ -58,6 +58,14 @@ type EstimatePriceResponse struct {
 	Price string `json:"price,omitempty"`
 }
 
+// SimulateResponse contains the response object for MethodSimulate. Result is
+// the result the transaction would have if it were executed in the next block,
+// and Price is its estimated fee.
+type SimulateResponse struct {
+	Result *types.TxResult `json:"result"`
+	Price  string          `json:"price,omitempty"`
+}
+
 // TxQueryResponse contains the response object for MethodTxQuery.
 type TxQueryResponse = types.TxQueryResponse
 
@@ -0,0 +1,77 @@
+package types
+
+// TraceStepType is the kind of a step in an execution trace.
+type TraceStepType string
+
+const (
+	// TraceStepStatement is an action statement, or a top-level statement,
+	// that was executed.
+	TraceStepStatement TraceStepType = "statement"
+	// TraceStepVariable is an assignment of a value to a variable.
+	TraceStepVariable TraceStepType = "variable"
+	// TraceStepQuery is a SQL query generated for Postgres.
+	TraceStepQuery TraceStepType = "query"
+	// TraceStepNotice is a message from the notice function.
+	TraceStepNotice TraceStepType = "notice"
+	// TraceStepCall is a nested action call.
+	TraceStepCall TraceStepType = "call"
+)
+
+// TraceCall is the structured trace of an action call, or of a top-level
+// statement, in the order the steps were executed.
+type TraceCall struct {
+	Namespace string `json:"namespace,omitempty"`
+	Action    string `json:"action,omitempty"`
+	// Args are the arguments of an action call, formatted as text.
+	Args []string `json:"args,omitempty"`
+	// Statement is the top-level statement, if this is not an action call.
+	Statement string `json:"statement,omitempty"`
+
+	Steps []*TraceStep `json:"steps"`
+	// Error is the error that ended the call, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// TxTrace is the trace of a committed transaction.
+type TxTrace struct {
+	// Traces has a trace for each call made by the transaction, which is one
+	// unless it is an action execution with several sets of arguments.
+	Traces []*TraceCall `json:"traces"`
+	// StateDiffers is true since the transaction was executed against the
+	// state after its block, at Height, rather than the state before it, so its
+	// trace may differ from its execution in the block.
+	StateDiffers bool  `json:"state_differs"`
+	Height       int64 `json:"height"`
+}
+
+// TraceStep is a step in an execution trace. The fields that are set depend on
+// the Type.
+type TraceStep struct {
+	Type TraceStepType `json:"type"`
+
+	// Statement is the kind of statement, e.g. "assign" or "sql", and Line
+	// and Column are its position in the action body or statement.
+	Statement string `json:"statement,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+
+	// Variable and Value are the name and new value of a variable. Values are
+	// formatted as text, and a NULL value is nil.
+	Variable string  `json:"variable,omitempty"`
+	Value    *string `json:"value,omitempty"`
+
+	// SQL and Params are a generated Postgres query and its parameters, and
+	// Rows is the number of rows it returned or affected.
+	SQL    string    `json:"sql,omitempty"`
+	Params []*string `json:"params,omitempty"`
+	Rows   *int64    `json:"rows,omitempty"`
+
+	// Notice is the message from a notice.
+	Notice string `json:"notice,omitempty"`
+
+	// Call is the trace of a nested action call.
+	Call *TraceCall `json:"call,omitempty"`
+
+	// Error is the error of a failed query.
+	Error string `json:"error,omitempty"`
+}
@@ -66,3 +66,36 @@ type TxInfo struct {
 	ID     types.Hash `json:"id"`
 	Status bool       `json:"status"`
 }
+
+// ConfigReload describes the outcome of reloading a node's config file. The
+// changed settings that were applied at runtime are listed in Applied, while
+// changed settings that only take effect after a restart are listed in
+// RequiresRestart. Both list config keys such as "rpc.timeout".
+type ConfigReload struct {
+	Applied         []string `json:"applied"`
+	RequiresRestart []string `json:"requires_restart"`
+}
+
+// Scopes of access to the admin service that may be granted to an API token or
+// a TLS client certificate.
+const (
+	ScopeStatus     = "status"     // read-only node status, config, and listings
+	ScopePeers      = "peers"      // peer whitelist management
+	ScopeValidators = "validators" // validator and resolution transactions
+	ScopeBlocks     = "blocks"     // block execution control
+	ScopeAdmin      = "admin"      // all methods, including token management
+)
+
+// Scopes lists all of the admin service scopes.
+var Scopes = []string{ScopeStatus, ScopePeers, ScopeValidators, ScopeBlocks, ScopeAdmin}
+
+// Token describes a named credential for the admin service and the scopes
+// that it is granted. It is either an API token, whose secret is only revealed
+// when it is minted, or a TLS client certificate identified by the SHA-256
+// fingerprint of the certificate.
+type Token struct {
+	Name            string    `json:"name"`
+	Scopes          []string  `json:"scopes"`
+	CertFingerprint string    `json:"cert_fingerprint,omitempty"`
+	Created         time.Time `json:"created"`
+}
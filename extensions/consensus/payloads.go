@@ -18,7 +18,9 @@ type Payload struct {
 	Route Route // must be from here or common, not an internal
 }
 
-// Route is type that TxApp requires to execute externally defined routes.
+// Route is type that TxApp requires to execute externally defined routes. A
+// route that is a pointer to a struct is copied for each transaction, so it may
+// store state in its fields in PreTx for use in InTx.
 type Route interface {
 	// Name returns a string that identifies the route.
 	Name() string
@@ -297,3 +297,28 @@ func RegisterInitializer(name string, init Initializer) error {
 	registeredPrecompiles[name] = init
 	return nil
 }
+
+var namespaceMetadataKeys = make(map[string]string)
+
+// RegisterNamespaceMetadata makes `USE` statements for the named precompile
+// set the metadata field key to the namespace that the statement is executed
+// in, if the statement does not set the field itself. The field is stored with
+// the rest of the metadata, so the initializer receives the same value when
+// the node restarts. It is used by precompiles that call back into the
+// namespace that used them.
+func RegisterNamespaceMetadata(name, key string) error {
+	name = strings.ToLower(name)
+	if _, ok := registeredPrecompiles[name]; !ok {
+		return fmt.Errorf("precompile %s is not registered", name)
+	}
+
+	namespaceMetadataKeys[name] = key
+	return nil
+}
+
+// NamespaceMetadataKey returns the metadata field that is set to the namespace
+// of the `USE` statement for the named precompile, if any.
+func NamespaceMetadataKey(name string) (string, bool) {
+	key, ok := namespaceMetadataKeys[strings.ToLower(name)]
+	return key, ok
+}
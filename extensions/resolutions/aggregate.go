@@ -0,0 +1,219 @@
+package resolutions
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// Aggregated resolutions let each validator vote with its own value for the
+// same question, instead of all validators voting on an identical body. They
+// are useful for data that validators can observe slightly differently, such
+// as prices read from different sources.
+//
+// Each validator stores an event whose body is an Outcome, with the key
+// identifying the question and the value being the validator's answer.
+// Validators with the same answer vote on the same resolution, and validators
+// with different answers create separate resolutions. Once the validators
+// that voted for any value of a key hold enough power to meet the
+// ConfirmationThreshold, the resolution type's AggregateFunc combines their
+// votes into a single value, and ResolveFunc is called once with an Outcome
+// of the key and the aggregated value. Validators that voted for more than one
+// value of the same key are ignored.
+
+// AggregateFunc combines the votes of the validators on an aggregated
+// resolution into the value that it resolves to. It is called as part of
+// block execution, so it must be deterministic, and its result must not
+// depend on the order of the votes. If it returns an error, the votes for the
+// key are discarded without calling ResolveFunc.
+type AggregateFunc func(votes []*WeightedVote) ([]byte, error)
+
+// WeightedVote is a validator's vote on an aggregated resolution.
+type WeightedVote struct {
+	// Value is the value that the validator voted for, as given in the
+	// Outcome of its event.
+	Value []byte
+	// Power is the power of the validator.
+	Power int64
+}
+
+// Outcome is the body of an event for an aggregated resolution.
+type Outcome struct {
+	// Key identifies what is being voted on. All validators must use the
+	// same key for the same question.
+	Key []byte
+	// Value is the validator's answer.
+	Value []byte
+}
+
+const outcomeVersion = 0
+
+// MarshalBinary encodes the outcome, to be used as the body of an event.
+func (o *Outcome) MarshalBinary() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := binary.Write(buf, binary.BigEndian, uint16(outcomeVersion)); err != nil {
+		return nil, err
+	}
+	types.WriteBytes(buf, o.Key)
+	types.WriteBytes(buf, o.Value)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes an outcome from the body of an event.
+func (o *Outcome) UnmarshalBinary(b []byte) error {
+	buf := bytes.NewBuffer(b)
+	var version uint16
+	if err := binary.Read(buf, binary.BigEndian, &version); err != nil {
+		return err
+	}
+	if version != outcomeVersion {
+		return fmt.Errorf("invalid outcome version: %d", version)
+	}
+	key, err := types.ReadBytes(buf)
+	if err != nil {
+		return err
+	}
+	value, err := types.ReadBytes(buf)
+	if err != nil {
+		return err
+	}
+	if buf.Len() != 0 {
+		return errors.New("unexpected trailing data in outcome")
+	}
+	o.Key = key
+	o.Value = value
+	return nil
+}
+
+// OutcomeID returns the ID of the aggregated resolution for a key. It is the
+// ID given to ResolveFunc, and it is marked as processed once the key has
+// been resolved, so that late votes for the key are ignored.
+func OutcomeID(resType string, key []byte) *types.UUID {
+	return types.NewUUIDV5(append([]byte("outcome:"+resType+":"), key...))
+}
+
+// Mode resolves to the value voted for by the most power. Ties are broken in
+// favor of the lexicographically smallest value.
+func Mode(votes []*WeightedVote) ([]byte, error) {
+	if len(votes) == 0 {
+		return nil, errors.New("no votes")
+	}
+
+	powers := make(map[string]int64)
+	for _, v := range votes {
+		powers[string(v.Value)] += v.Power
+	}
+
+	var best string
+	var bestPower int64 = -1
+	for value, power := range powers {
+		if power > bestPower || (power == bestPower && value < best) {
+			best, bestPower = value, power
+		}
+	}
+	return []byte(best), nil
+}
+
+// Median resolves to the power-weighted median of votes whose values are
+// decimal strings, such as "1.25". If the median falls between two values,
+// the lower one is used. The value is returned as it was voted for.
+func Median(votes []*WeightedVote) ([]byte, error) {
+	nums, err := parseDecimalVotes(votes)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(nums, func(a, b *decimalVote) int {
+		if c := a.num.Cmp(b.num); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.Value, b.Value)
+	})
+
+	var total, cumulative int64
+	for _, n := range nums {
+		total += n.Power
+	}
+	for _, n := range nums {
+		cumulative += n.Power
+		if 2*cumulative >= total {
+			return slices.Clone(n.Value), nil
+		}
+	}
+	return slices.Clone(nums[len(nums)-1].Value), nil // only reached if all power is zero
+}
+
+// WeightedAverage returns an AggregateFunc that resolves to the
+// power-weighted average of votes whose values are decimal strings. The
+// result is a decimal string rounded to the given number of digits after
+// the decimal point.
+func WeightedAverage(scale int) AggregateFunc {
+	return func(votes []*WeightedVote) ([]byte, error) {
+		nums, err := parseDecimalVotes(votes)
+		if err != nil {
+			return nil, err
+		}
+
+		sum := new(big.Rat)
+		var total int64
+		for _, n := range nums {
+			sum.Add(sum, new(big.Rat).Mul(n.num, big.NewRat(n.Power, 1)))
+			total += n.Power
+		}
+		if total == 0 {
+			return nil, errors.New("votes have no power")
+		}
+		sum.Quo(sum, big.NewRat(total, 1))
+
+		return []byte(sum.FloatString(scale)), nil
+	}
+}
+
+type decimalVote struct {
+	*WeightedVote
+	num *big.Rat
+}
+
+func parseDecimalVotes(votes []*WeightedVote) ([]*decimalVote, error) {
+	if len(votes) == 0 {
+		return nil, errors.New("no votes")
+	}
+
+	nums := make([]*decimalVote, len(votes))
+	for i, v := range votes {
+		if v.Power < 0 {
+			return nil, fmt.Errorf("negative vote power %d", v.Power)
+		}
+		num, ok := new(big.Rat).SetString(string(v.Value))
+		if !ok || !isDecimal(v.Value) {
+			return nil, fmt.Errorf("vote value %q is not a decimal", v.Value)
+		}
+		nums[i] = &decimalVote{WeightedVote: v, num: num}
+	}
+	return nums, nil
+}
+
+// isDecimal reports whether b is a plain decimal number, rejecting the
+// fractions and exponents that big.Rat also accepts.
+func isDecimal(b []byte) bool {
+	if len(b) > 0 && (b[0] == '-' || b[0] == '+') {
+		b = b[1:]
+	}
+	var digits, dots int
+	for _, c := range b {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.':
+			dots++
+		default:
+			return false
+		}
+	}
+	return digits > 0 && dots <= 1
+}
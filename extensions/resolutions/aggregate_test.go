@@ -0,0 +1,95 @@
+package resolutions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func votes(vals ...any) []*WeightedVote {
+	var vs []*WeightedVote
+	for i := 0; i < len(vals); i += 2 {
+		vs = append(vs, &WeightedVote{Value: []byte(vals[i].(string)), Power: int64(vals[i+1].(int))})
+	}
+	return vs
+}
+
+func TestOutcomeEncoding(t *testing.T) {
+	o := &Outcome{Key: []byte("eth/usd@100"), Value: []byte("2500.10")}
+	b, err := o.MarshalBinary()
+	require.NoError(t, err)
+
+	var got Outcome
+	require.NoError(t, got.UnmarshalBinary(b))
+	require.Equal(t, o.Key, got.Key)
+	require.Equal(t, o.Value, got.Value)
+
+	require.Error(t, got.UnmarshalBinary(append(b, 0)))
+	require.Error(t, got.UnmarshalBinary(b[:len(b)-1]))
+
+	require.NotEqual(t, OutcomeID("price", []byte("a")), OutcomeID("price", []byte("b")))
+	require.NotEqual(t, OutcomeID("price", []byte("a")), OutcomeID("other", []byte("a")))
+}
+
+func TestMode(t *testing.T) {
+	v, err := Mode(votes("a", 1, "b", 3, "a", 1))
+	require.NoError(t, err)
+	require.Equal(t, "b", string(v))
+
+	// ties go to the smallest value
+	v, err = Mode(votes("c", 2, "b", 1, "a", 1, "b", 1))
+	require.NoError(t, err)
+	require.Equal(t, "b", string(v))
+
+	_, err = Mode(nil)
+	require.Error(t, err)
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name    string
+		votes   []*WeightedVote
+		want    string
+		wantErr bool
+	}{
+		{"single", votes("1.5", 1), "1.5", false},
+		{"odd", votes("3", 1, "1", 1, "2", 1), "2", false},
+		{"lower median", votes("1", 1, "2", 1), "1", false},
+		{"weighted", votes("1", 1, "2", 1, "10", 5), "10", false},
+		{"negative", votes("-2.5", 2, "1", 1), "-2.5", false},
+		{"numeric order", votes("9", 1, "10", 1, "11", 1), "10", false},
+		{"not a number", votes("1", 1, "abc", 1), "", true},
+		{"fraction", votes("1/2", 1), "", true},
+		{"exponent", votes("1e3", 1), "", true},
+		{"no votes", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Median(tt.votes)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(v))
+		})
+	}
+}
+
+func TestWeightedAverage(t *testing.T) {
+	v, err := WeightedAverage(2)(votes("1", 1, "2", 3))
+	require.NoError(t, err)
+	require.Equal(t, "1.75", string(v))
+
+	v, err = WeightedAverage(0)(votes("1", 1, "2", 1))
+	require.NoError(t, err)
+	require.Equal(t, "2", string(v))
+
+	v, err = WeightedAverage(3)(votes("-1.5", 1, "0.5", 1))
+	require.NoError(t, err)
+	require.Equal(t, "-0.500", string(v))
+
+	_, err = WeightedAverage(2)(votes("1", 0))
+	require.Error(t, err)
+}
@@ -116,6 +116,13 @@ type ResolutionConfig struct {
 	// block execution. It is therefore expected that the function is
 	// deterministic, regardless of a node's local configuration.
 	ResolveFunc ResolveFunc
+	// Aggregate, if set, makes this an aggregated resolution type, where
+	// each validator votes with its own value for a key, and the votes are
+	// combined by the function once enough power has voted on the key. The
+	// bodies of its events must be encoded Outcomes. Mode, Median and
+	// WeightedAverage are provided for common cases. If nil, validators must
+	// vote on identical bodies.
+	Aggregate AggregateFunc
 }
 
 // ResolveFunc is a function that is called once a resolution has
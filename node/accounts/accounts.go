@@ -20,6 +20,7 @@ import (
 
 const (
 	AccountsLRUCacheSize = 4000
+	scratchLRUCacheSize  = 16
 )
 
 // Accounts represents an in-memory cache of accounts stored in a PostgreSQL database.
@@ -123,6 +124,18 @@ func InitializeAccountStore(ctx context.Context, db sql.DB, logger log.Logger) (
 	}, nil
 }
 
+// Scratch returns an account store for executing transactions whose changes
+// are discarded, such as when simulating a transaction. It reads accounts from
+// the database, but its cache and updates are separate from a's, so nothing it
+// does affects the accounts of the current block.
+func (a *Accounts) Scratch() *Accounts {
+	return &Accounts{
+		records: lru.NewMap[string, *types.Account](scratchLRUCacheSize),
+		updates: make(map[string]*types.Account),
+		log:     a.log,
+	}
+}
+
 func (*Accounts) NumAccounts(ctx context.Context, tx sql.Executor) (int64, error) {
 	return numAccounts(ctx, tx)
 }
@@ -30,7 +30,8 @@ type AdminClient struct {
 
 	log log.Logger
 
-	pass string
+	pass  string
+	token string
 
 	// optional TLS files
 	kwildCertFile  string
@@ -193,6 +194,7 @@ func NewClient(ctx context.Context, target string, opts ...Opt) (*AdminClient, e
 		}),
 		rpcclient.WithLogger(c.log),
 		rpcclient.WithPass(c.pass),
+		rpcclient.WithAuthToken(c.token),
 	)
 	c.adminSvcClient = cl
 
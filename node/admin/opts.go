@@ -21,6 +21,14 @@ func WithPass(pass string) Opt {
 	}
 }
 
+// WithToken specifies an API token to use for authentication, instead of a
+// password. The token's scopes limit the methods that may be called.
+func WithToken(token string) Opt {
+	return func(c *AdminClient) {
+		c.token = token
+	}
+}
+
 // WithTLS provides the required files for the admin client to use TLS, and
 // possibly client authenticated TLS. kwildCertFile may be omitted if the
 // service is issued a TLS certificate by a root CA. The client files may be
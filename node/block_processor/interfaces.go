@@ -45,6 +45,7 @@ type Mempool interface {
 type TxApp interface {
 	Begin(ctx context.Context, height int64) error
 	Execute(ctx *common.TxContext, db sql.DB, tx *ktypes.Transaction) *txapp.TxResponse
+	Simulate(ctx *common.TxContext, db sql.DB, tx *ktypes.Transaction) *txapp.TxResponse
 	Finalize(ctx context.Context, db sql.DB, block *common.BlockContext) (approvedJoins, expiredJoins []*ktypes.AccountID, err error)
 	Commit() error
 	Rollback()
@@ -84,6 +85,10 @@ type EventStore interface {
 	// that are not previously broadcasted.
 	GetUnbroadcastedEvents(ctx context.Context) ([]*ktypes.UUID, error)
 
+	// GetUnbroadcastedNewEvents returns the events observed by the validator
+	// that have no resolutions yet and are not previously broadcasted.
+	GetUnbroadcastedNewEvents(ctx context.Context) ([]*ktypes.VotableEvent, error)
+
 	// MarkBroadcasted marks list of events as broadcasted.
 	MarkBroadcasted(ctx context.Context, ids []*ktypes.UUID) error
 
@@ -16,6 +16,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/config"
 	"github.com/kwilteam/kwil-db/core/crypto"
@@ -24,10 +26,13 @@ import (
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	authExt "github.com/kwilteam/kwil-db/extensions/auth"
 	"github.com/kwilteam/kwil-db/node/meta"
+	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/node/types"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 )
 
+var mets metrics.BlockMetrics = metrics.Block
+
 // This package will be equivalent to the ABCI application in Tendermint.
 // This is responsible for processing blocks, managing consensus state, and
 // handling transactions and mempool state.
@@ -226,7 +231,11 @@ func (bp *BlockProcessor) loadNetworkParams(ctx context.Context, readTx sql.Tx)
 	return networkParams, nil
 }
 
-func (bp *BlockProcessor) CheckTx(ctx context.Context, ntx *types.Tx, height int64, blockTime time.Time, recheck bool) error {
+func (bp *BlockProcessor) CheckTx(ctx context.Context, ntx *types.Tx, height int64, blockTime time.Time, recheck bool) (err error) {
+	ctx, span := metrics.StartSpan(ctx, metrics.BlockProcessorTracer, "CheckTx",
+		attribute.String("tx", ntx.Hash().String()), attribute.Bool("recheck", recheck))
+	defer func() { metrics.EndSpan(span, err) }()
+
 	readTx := bp.db.BeginDelayedReadTx()
 	defer readTx.Rollback(ctx)
 
@@ -317,7 +326,8 @@ func (bp *BlockProcessor) InitChain(ctx context.Context) (int64, []byte, error)
 		return -1, nil, err
 	}
 
-	if err := meta.SetChainState(ctx, genesisTx, genCfg.InitialHeight, genCfg.StateHash, false); err != nil {
+	appHash := genesisAppHash(genCfg)
+	if err := meta.SetChainState(ctx, genesisTx, genCfg.InitialHeight, appHash, false); err != nil {
 		return -1, nil, fmt.Errorf("error storing the genesis state: %w", err)
 	}
 
@@ -337,18 +347,46 @@ func (bp *BlockProcessor) InitChain(ctx context.Context) (int64, []byte, error)
 	bp.announceValidators()
 
 	bp.height.Store(genCfg.InitialHeight)
-	if genCfg.StateHash != nil { // TODO: make it a *types.Hash
-		copy(bp.appHash[:], genCfg.StateHash)
+	if appHash != nil { // TODO: make it a *types.Hash
+		copy(bp.appHash[:], appHash)
 	} else {
 		bp.appHash = ktypes.Hash{}
 	}
 
-	bp.log.Infof("Initialized chain: height %d, appHash: %s", genCfg.InitialHeight, hex.EncodeToString(genCfg.StateHash))
+	bp.log.Infof("Initialized chain: height %d, appHash: %s", genCfg.InitialHeight, hex.EncodeToString(appHash))
 
-	return genCfg.InitialHeight, genCfg.StateHash, nil
+	return genCfg.InitialHeight, appHash, nil
+}
+
+// genesisAppHash returns the app hash that the chain starts from. It is the
+// genesis state hash, combined with the EVM chains declared in the genesis
+// config if there are any, so that a node that does not declare the same
+// chains as the rest of the network fails on the first block, rather than
+// diverging when an extension first uses one of the chains. This also means
+// the chains cannot be changed without a migration to a new genesis.
+func genesisAppHash(genCfg *config.GenesisConfig) []byte {
+	if len(genCfg.EVMChains) == 0 {
+		return genCfg.StateHash
+	}
+
+	hasher := ktypes.NewHasher()
+	hasher.Write(genCfg.StateHash)
+	for _, chain := range genCfg.EVMChains {
+		for _, field := range []string{chain.Name.String(), chain.ID} {
+			binary.Write(hasher, ktypes.SerializationByteOrder, uint32(len(field)))
+			hasher.Write([]byte(field))
+		}
+		binary.Write(hasher, ktypes.SerializationByteOrder, chain.RequiredConfirmations)
+	}
+	appHash := hasher.Sum(nil)
+	return appHash[:]
 }
 
 func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExecRequest, syncing bool) (blkResult *ktypes.BlockExecResult, err error) {
+	ctx, span := metrics.StartSpan(ctx, metrics.BlockProcessorTracer, "ExecuteBlock",
+		attribute.Int64("height", req.Height), attribute.Int("num_txns", len(req.Block.Txns)))
+	defer func() { metrics.EndSpan(span, err) }()
+
 	bp.mtx.Lock()
 	defer bp.mtx.Unlock()
 
@@ -394,6 +432,15 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 		Hash:         req.BlockID,
 	}
 
+	// phaseDone records the time spent in each phase of the block execution.
+	tPhase := time.Now()
+	phaseDone := func(phase string) {
+		now := time.Now()
+		mets.RecordPhase(ctx, phase, now.Sub(tPhase))
+		span.AddEvent(phase + " done")
+		tPhase = now
+	}
+
 	// Begin executing transactions. The chain context may be updated during the block execution.
 	txResults := make([]ktypes.TxResult, len(req.Block.Txns))
 
@@ -466,19 +513,25 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 
 	// record the end time of the block execution
 	bp.recordBlockExecEndTime()
+	phaseDone("transactions")
 
-	// Broadcast any voteID events that have not been broadcasted yet
+	// Broadcast any vote bodies and voteID events that have not been broadcasted yet
 	if bp.broadcastTxFn != nil {
+		if err = bp.BroadcastVoteBodyTx(ctx, bp.consensusTx); err != nil {
+			return nil, fmt.Errorf("failed to broadcast the vote body transactions: %w", err)
+		}
 		if err = bp.BroadcastVoteIDTx(ctx, bp.consensusTx); err != nil {
 			return nil, fmt.Errorf("failed to broadcast the voteID transactions: %w", err)
 		}
 	}
+	phaseDone("broadcast_votes")
 
 	// Process resolutions and end-block hooks.
 	approvedJoins, expiredJoins, err := bp.txapp.Finalize(ctx, bp.consensusTx, blockCtx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to finalize the block execution: %w", err)
 	}
+	phaseDone("finalize")
 
 	// migrator can be updated here within notify height
 	err = bp.migrator.NotifyHeight(ctx, blockCtx, bp.db, bp.consensusTx) // can modify bp.chainCtx.NetworkParameters.MigrationStatus !!!
@@ -525,6 +578,7 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 	if err != nil {
 		return nil, fmt.Errorf("failed to precommit the changeset: %w", err)
 	}
+	phaseDone("precommit")
 
 	valUpdates := bp.validators.ValidatorUpdates()
 	valUpdatesHash, valUpdatesList := validatorUpdatesHash(valUpdates)
@@ -576,6 +630,7 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 	}
 
 	success = true
+	phaseDone("state_hash")
 
 	// The CE will log the same thing, so this is a Debug message.
 	bp.log.Debug("Executed Block", "height", req.Height, "blockID", req.BlockID, "appHash", nextHash, "numTxs", req.Block.Header.NumTxns)
@@ -646,7 +701,10 @@ func (bp *BlockProcessor) updatePeers(valUpdates []*ktypes.Validator, approvedJo
 
 // Commit method commits the block to the blockstore and postgres database.
 // It also updates the txIndexer and mempool with the transactions in the block.
-func (bp *BlockProcessor) Commit(ctx context.Context, req *ktypes.CommitRequest) error {
+func (bp *BlockProcessor) Commit(ctx context.Context, req *ktypes.CommitRequest) (err error) {
+	ctx, span := metrics.StartSpan(ctx, metrics.BlockProcessorTracer, "Commit", attribute.Int64("height", req.Height))
+	defer func() { metrics.EndSpan(span, err) }()
+
 	bp.mtx.Lock()
 	defer bp.mtx.Unlock()
 
@@ -728,6 +786,9 @@ func (bp *BlockProcessor) Commit(ctx context.Context, req *ktypes.CommitRequest)
 // validator vote transactions for events observed by the leader. This function is
 // used exclusively by the leader node to prepare the proposal block.
 func (bp *BlockProcessor) PrepareProposal(ctx context.Context, txs []*types.Tx) (finalTxs []*ktypes.Transaction, invalidTxs []*ktypes.Transaction, err error) {
+	ctx, span := metrics.StartSpan(ctx, metrics.BlockProcessorTracer, "PrepareProposal", attribute.Int("num_txns", len(txs)))
+	defer func() { metrics.EndSpan(span, err) }()
+
 	// Use a reserved read transaction for any DB access, to prevent contention
 	// with rpc requests and other non-consensus operations.
 	readTx, err := bp.db.BeginReservedReadTx(ctx)
@@ -906,6 +967,71 @@ func (bp *BlockProcessor) Price(ctx context.Context, dbTx sql.DB, tx *ktypes.Tra
 	return bp.txapp.Price(ctx, dbTx, tx, bp.chainCtx)
 }
 
+// SimulateTx executes a transaction as if it were included in the next block,
+// without affecting the state used by consensus, and returns its result. The db
+// must be a read-write transaction that the caller rolls back. The transaction
+// need not be signed, but its sender and authenticator type must be set.
+func (bp *BlockProcessor) SimulateTx(ctx context.Context, db sql.DB, tx *ktypes.Transaction) (*ktypes.TxResult, error) {
+	if tx.Signature == nil {
+		return nil, errors.New("transaction authenticator type is required")
+	}
+	ident, err := authExt.GetIdentifier(tx.Signature.Type, tx.Sender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx sender identifier: %w", err)
+	}
+	if len(tx.Signature.Data) > 0 {
+		if err := verifyTransaction(tx); err != nil {
+			return nil, fmt.Errorf("failed to verify the transaction: %w", err)
+		}
+	}
+
+	// The chain context is copied since a transaction may update the network
+	// parameters.
+	bp.mtx.RLock()
+	chainCtx := &common.ChainContext{
+		ChainID:           bp.chainCtx.ChainID,
+		NetworkParameters: bp.chainCtx.NetworkParameters.Clone(),
+		NetworkUpdates:    ktypes.ParamUpdates{},
+	}
+	if bp.chainCtx.MigrationParams != nil {
+		mp := *bp.chainCtx.MigrationParams
+		chainCtx.MigrationParams = &mp
+	}
+	bp.mtx.RUnlock()
+
+	txHash := tx.Hash()
+	res := bp.txapp.Simulate(&common.TxContext{
+		Ctx: ctx,
+		BlockContext: &common.BlockContext{
+			ChainContext: chainCtx,
+			Height:       bp.height.Load() + 1,
+			Timestamp:    time.Now().Unix(),
+			Proposer:     chainCtx.NetworkParameters.Leader,
+		},
+		TxID:          txHash.String(),
+		Signer:        tx.Sender,
+		Caller:        ident,
+		Authenticator: tx.Signature.Type,
+	}, db, tx)
+
+	txResult := &ktypes.TxResult{
+		Code: uint32(res.ResponseCode),
+		Gas:  res.Spend,
+		Log:  res.Log,
+	}
+	if res.Error != nil {
+		if txResult.Log != "" {
+			txResult.Log += "\n"
+		}
+		resErr := res.Error.Error()
+		if !strings.HasPrefix(resErr, "ERROR: ") {
+			resErr = "ERROR: " + resErr
+		}
+		txResult.Log += resErr
+	}
+	return txResult, nil
+}
+
 func (bp *BlockProcessor) AccountInfo(ctx context.Context, db sql.DB, identifier *ktypes.AccountID, pending bool) (balance *big.Int, nonce int64, err error) {
 	return bp.txapp.AccountInfo(ctx, db, identifier, pending)
 }
@@ -0,0 +1,38 @@
+package blockprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/config"
+	"github.com/kwilteam/kwil-db/node/exts/evm-sync/chains"
+)
+
+func TestGenesisAppHash(t *testing.T) {
+	stateHash := []byte{1, 2, 3}
+
+	// Without EVM chains, the genesis state hash is used as is.
+	genCfg := &config.GenesisConfig{StateHash: stateHash}
+	require.Equal(t, stateHash, genesisAppHash(genCfg))
+	require.Nil(t, genesisAppHash(&config.GenesisConfig{}))
+
+	anvil := chains.ChainInfo{Name: "anvil", ID: "31337", RequiredConfirmations: 1}
+	genCfg.EVMChains = []chains.ChainInfo{anvil}
+	withChain := genesisAppHash(genCfg)
+	require.Len(t, withChain, 32)
+	require.NotEqual(t, stateHash, withChain)
+
+	// Nodes that disagree on any field of a chain start from different hashes.
+	for _, other := range []chains.ChainInfo{
+		{Name: "anvil2", ID: "31337", RequiredConfirmations: 1},
+		{Name: "anvil", ID: "31338", RequiredConfirmations: 1},
+		{Name: "anvil", ID: "31337", RequiredConfirmations: 2},
+	} {
+		genCfg.EVMChains = []chains.ChainInfo{other}
+		require.NotEqual(t, withChain, genesisAppHash(genCfg), other)
+	}
+
+	genCfg.EVMChains = []chains.ChainInfo{anvil}
+	require.Equal(t, withChain, genesisAppHash(genCfg))
+}
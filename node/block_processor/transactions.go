@@ -9,6 +9,7 @@ import (
 
 	"github.com/kwilteam/kwil-db/core/types"
 	authExt "github.com/kwilteam/kwil-db/extensions/auth"
+	"github.com/kwilteam/kwil-db/extensions/resolutions"
 	"github.com/kwilteam/kwil-db/node/txapp"
 	nodetypes "github.com/kwilteam/kwil-db/node/types"
 	"github.com/kwilteam/kwil-db/node/types/sql"
@@ -344,6 +345,96 @@ func (bp *BlockProcessor) emptyVoteBodyTxSize() (int64, error) {
 	return int64(len(bts)), nil
 }
 
+// BroadcastVoteBodyTx broadcasts the vote bodies of the aggregated resolution
+// events observed by the validator, for which no resolution exists yet. Unlike
+// other events, these are not proposed by the leader, since each validator
+// may have observed a different value.
+func (bp *BlockProcessor) BroadcastVoteBodyTx(ctx context.Context, db sql.DB) error {
+	tx, ids, err := bp.PrepareValidatorVoteBodyTx(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if tx == nil || len(ids) == 0 { // no vote bodies to broadcast
+		return nil
+	}
+
+	_, _, err = bp.broadcastTxFn(ctx, tx, 0)
+	if err != nil {
+		return err
+	}
+
+	return bp.events.MarkBroadcasted(ctx, ids)
+}
+
+// PrepareValidatorVoteBodyTx authors a ValidatorVoteBodies transaction with
+// the aggregated resolution events observed by a non-leader validator. It
+// returns the IDs of the included events.
+func (bp *BlockProcessor) PrepareValidatorVoteBodyTx(ctx context.Context, db sql.DB) (*types.Transaction, []*types.UUID, error) {
+	if !bp.isVoter() {
+		return nil, nil, nil
+	}
+
+	newEvents, err := bp.events.GetUnbroadcastedNewEvents(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get unbroadcasted events: %w", err)
+	}
+
+	var events []*types.VotableEvent
+	var ids []*types.UUID
+	for _, evt := range newEvents {
+		cfg, err := resolutions.GetResolution(evt.Type)
+		if err != nil || cfg.Aggregate == nil {
+			continue // proposed by the leader
+		}
+
+		events = append(events, evt)
+		ids = append(ids, evt.ID())
+		if len(events) == int(bp.chainCtx.NetworkParameters.MaxVotesPerTx) {
+			break
+		}
+	}
+
+	if len(events) == 0 {
+		bp.log.Debug("no vote bodies to broadcast")
+		return nil, nil, nil
+	}
+
+	acctID, err := types.GetSignerAccount(bp.signer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get signer account: %w", err)
+	}
+
+	bal, nonce, err := bp.AccountInfo(ctx, db, acctID, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get account info: %w", err)
+	}
+
+	tx, err := types.CreateTransaction(&types.ValidatorVoteBodies{Events: events}, bp.chainCtx.ChainID, uint64(nonce)+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Fee estimation
+	fee, err := bp.Price(ctx, db, tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to estimate fee: %w", err)
+	}
+	tx.Body.Fee = fee
+
+	// check if the node has enough balance to propose the transaction
+	if bal.Cmp(fee) < 0 {
+		bp.log.Warnf("skipping vote body broadcast: not enough balance to pay for the tx fee, balance: %s, fee: %s", bal.String(), fee.String())
+		return nil, nil, nil
+	}
+
+	if err = tx.Sign(bp.signer); err != nil {
+		return nil, nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	return tx, ids, nil
+}
+
 func (bp *BlockProcessor) BroadcastVoteIDTx(ctx context.Context, db sql.DB) error {
 	tx, ids, err := bp.PrepareValidatorVoteIDTx(ctx, db)
 	if err != nil {
@@ -362,28 +453,34 @@ func (bp *BlockProcessor) BroadcastVoteIDTx(ctx context.Context, db sql.DB) erro
 	return bp.events.MarkBroadcasted(ctx, ids)
 }
 
-func (bp *BlockProcessor) PrepareValidatorVoteIDTx(ctx context.Context, db sql.DB) (*types.Transaction, []*types.UUID, error) {
-	// Only validators can issue voteID transactions not the leader or sentry nodes
+// isVoter returns true if the node is a validator that votes with vote
+// transactions. The leader votes by proposing vote bodies in its blocks instead,
+// and sentry nodes do not vote.
+func (bp *BlockProcessor) isVoter() bool {
 	myPubKey := bp.signer.PubKey()
 
 	// check if the node is a leader
 	if myPubKey.Equals(bp.chainCtx.NetworkParameters.Leader) {
-		bp.log.Debug("Leader node is not allowed to propose voteID transactions")
-		return nil, nil, nil
+		bp.log.Debug("Leader node is not allowed to broadcast vote transactions")
+		return false
 	}
 
 	// check if the node is a sentry node
 	vals := bp.GetValidators()
-	found := false
 	for _, val := range vals {
 		if bytes.Equal(val.Identifier, myPubKey.Bytes()) &&
 			val.KeyType == myPubKey.Type() {
-			found = true
+			return true
 		}
 	}
 
-	if !found {
-		bp.log.Debug("Sentry node is not allowed to propose voteID transactions")
+	bp.log.Debug("Sentry node is not allowed to broadcast vote transactions")
+	return false
+}
+
+func (bp *BlockProcessor) PrepareValidatorVoteIDTx(ctx context.Context, db sql.DB) (*types.Transaction, []*types.UUID, error) {
+	// Only validators can issue voteID transactions not the leader or sentry nodes
+	if !bp.isVoter() {
 		return nil, nil, nil
 	}
 
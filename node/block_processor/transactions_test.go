@@ -703,6 +703,10 @@ func (m *mockTxApp) Execute(ctx *common.TxContext, db sql.DB, tx *types.Transact
 	return nil
 }
 
+func (m *mockTxApp) Simulate(ctx *common.TxContext, db sql.DB, tx *types.Transaction) *txapp.TxResponse {
+	return nil
+}
+
 func (m *mockTxApp) Finalize(ctx context.Context, db sql.DB, block *common.BlockContext) (aJ, eJ []*types.AccountID, err error) {
 	return nil, nil, nil
 }
@@ -829,6 +833,16 @@ func (m *mockEventStore) GetUnbroadcastedEvents(ctx context.Context) ([]*types.U
 	return ids, nil
 }
 
+func (m *mockEventStore) GetUnbroadcastedNewEvents(ctx context.Context) ([]*types.VotableEvent, error) {
+	var events []*types.VotableEvent
+	for _, e := range m.events {
+		if !e.broadcasted {
+			events = append(events, e.evt)
+		}
+	}
+	return events, nil
+}
+
 func (m *mockEventStore) MarkBroadcasted(ctx context.Context, ids []*types.UUID) error {
 	for _, id := range ids {
 		if e, ok := m.events[id.String()]; ok {
@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/node/types"
 )
 
@@ -229,7 +232,12 @@ func (ce *ConsensusEngine) ConsensusParams() *ktypes.NetworkParameters {
 
 // executeBlock uses the block processor to execute the block and stores the
 // results in the state field.
-func (ce *ConsensusEngine) executeBlock(ctx context.Context, blkProp *blockProposal, syncing bool) error {
+func (ce *ConsensusEngine) executeBlock(ctx context.Context, blkProp *blockProposal, syncing bool) (err error) {
+	ctx, span := metrics.StartSpan(ctx, metrics.ConsensusTracer, "executeBlock",
+		attribute.Int64("height", blkProp.height), attribute.String("block", blkProp.blkHash.String()),
+		attribute.Bool("syncing", syncing))
+	defer func() { metrics.EndSpan(span, err) }()
+
 	t0 := time.Now()
 	defer func() {
 		ce.stateInfo.mtx.Lock()
@@ -272,7 +280,10 @@ func (ce *ConsensusEngine) executeBlock(ctx context.Context, blkProp *blockPropo
 
 // Commit method commits the block to the blockstore and postgres database.
 // It also updates the txIndexer and mempool with the transactions in the block.
-func (ce *ConsensusEngine) commit(ctx context.Context, syncing bool) error {
+func (ce *ConsensusEngine) commit(ctx context.Context, syncing bool) (err error) {
+	ctx, span := metrics.StartSpan(ctx, metrics.ConsensusTracer, "commit", attribute.Bool("syncing", syncing))
+	defer func() { metrics.EndSpan(span, err) }()
+
 	ce.mempoolMtx.PriorityLock()
 	defer ce.mempoolMtx.Unlock()
 
@@ -1059,6 +1059,10 @@ func (d *dummyTxApp) Execute(ctx *common.TxContext, db sql.DB, tx *ktypes.Transa
 	return &txapp.TxResponse{}
 }
 
+func (d *dummyTxApp) Simulate(ctx *common.TxContext, db sql.DB, tx *ktypes.Transaction) *txapp.TxResponse {
+	return &txapp.TxResponse{}
+}
+
 func (d *dummyTxApp) Finalize(ctx context.Context, db sql.DB, block *common.BlockContext) (aj, ej []*ktypes.AccountID, err error) {
 	return nil, nil, nil
 }
@@ -1169,6 +1173,10 @@ func (m *mockEventStore) GetUnbroadcastedEvents(ctx context.Context) ([]*ktypes.
 	return ids, nil
 }
 
+func (m *mockEventStore) GetUnbroadcastedNewEvents(ctx context.Context) ([]*ktypes.VotableEvent, error) {
+	return nil, nil
+}
+
 func (m *mockEventStore) HasEvents() bool {
 	return true
 }
@@ -12,6 +12,7 @@ import (
 
 	"github.com/kwilteam/kwil-db/config"
 	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/node/types"
 )
 
@@ -110,7 +111,10 @@ func (ce *ConsensusEngine) newBlockRound(ctx context.Context) {
 
 // proposeBlock used by the leader to propose a new block to the network.
 // Any non-nil error should be considered fatal to the node.
-func (ce *ConsensusEngine) proposeBlock(ctx context.Context) error {
+func (ce *ConsensusEngine) proposeBlock(ctx context.Context) (err error) {
+	ctx, span := metrics.StartSpan(ctx, metrics.ConsensusTracer, "proposeBlock")
+	defer func() { metrics.EndSpan(span, err) }()
+
 	ce.state.mtx.Lock()
 	defer ce.state.mtx.Unlock()
 
@@ -121,6 +121,26 @@ var (
 			},
 			PGFormatFunc: defaultFormat("uuid_generate_v5"),
 		},
+		// sequence_next allocates the next value of a sequence created with
+		// the sequence extension, e.g. sequence_next('order_ids'). The function
+		// that it calls is created by the extension.
+		"sequence_next": &ScalarFunctionDefinition{
+			ValidateArgsFunc: func(args []*types.DataType) (*types.DataType, error) {
+				if len(args) != 1 {
+					return nil, wrapErrArgumentNumber(1, len(args))
+				}
+
+				if !args[0].Equals(types.TextType) {
+					return nil, wrapErrArgumentType(types.TextType, args[0])
+				}
+
+				return types.IntType, nil
+			},
+			PGFormatFunc: func(inputs []string) (string, error) {
+				return "kwil_sequence.next_value(" + inputs[0] + ")", nil
+			},
+			MutatesState: true,
+		},
 		// a special function that generates a UUID using a fixed namespace
 		"uuid_generate_kwil": &ScalarFunctionDefinition{
 			ValidateArgsFunc: func(args []*types.DataType) (*types.DataType, error) {
@@ -1018,6 +1038,10 @@ type FunctionDefinition interface {
 type ScalarFunctionDefinition struct {
 	ValidateArgsFunc func(args []*types.DataType) (*types.DataType, error)
 	PGFormatFunc     func(inputs []string) (string, error)
+	// MutatesState is true if the function writes to the database. It cannot
+	// be used in ad-hoc SELECT statements, which only require the SELECT
+	// privilege.
+	MutatesState bool
 }
 
 func (s *ScalarFunctionDefinition) ValidateArgs(args []*types.DataType) (*types.DataType, error) {
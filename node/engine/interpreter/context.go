@@ -186,7 +186,8 @@ func (e *executionContext) query(sql string, fn func(*row) error) error {
 		cols[i] = field.Name
 	}
 
-	return query(e.engineCtx.TxContext.Ctx, e.db, generatedSQL, scanValues, func() error {
+	ctx, traced := e.interpreter.tracer.query(e.engineCtx.TxContext.Ctx, generatedSQL, args)
+	err = query(ctx, e.db, generatedSQL, scanValues, func() error {
 		if len(scanValues) != len(cols) {
 			// should never happen, but just in case
 			return fmt.Errorf("node bug: scan values and columns are not the same length")
@@ -204,6 +205,8 @@ func (e *executionContext) query(sql string, fn func(*row) error) error {
 			Values:  vals,
 		})
 	}, args)
+	traced(err)
+	return err
 }
 
 func fromScanValues(scanVals []any) ([]value, error) {
@@ -234,8 +237,36 @@ func (e *executionContext) getValues(names []string) ([]value, error) {
 // prepareQuery prepares a query for execution.
 // It will check the cache for a prepared statement, and if it does not exist,
 // it will parse the SQL, create a logical plan, and cache the statement.
+// checkNoMutatingFunctions returns an error if a query uses a function that
+// writes to the database.
+func (e *executionContext) checkNoMutatingFunctions(sql string) error {
+	_, analyzed, _, err := e.prepareQuery(sql)
+	if err != nil {
+		return err
+	}
+
+	var name string
+	check := func(node logical.Traversable) bool {
+		if fn, ok := node.(*logical.ScalarFunctionCall); ok {
+			if def, ok := engine.Functions[fn.FunctionName].(*engine.ScalarFunctionDefinition); ok && def.MutatesState {
+				name = fn.FunctionName
+			}
+		}
+		return name == ""
+	}
+	logical.Traverse(analyzed.Plan, check)
+	for _, cte := range analyzed.CTEs {
+		logical.Traverse(cte, check)
+	}
+
+	if name != "" {
+		return fmt.Errorf(`function "%s" writes to the database, so it can only be used in actions and in INSERT, UPDATE, and DELETE statements`, name)
+	}
+	return nil
+}
+
 func (e *executionContext) prepareQuery(sql string) (pgSql string, plan *logical.AnalyzedPlan, args []value, err error) {
-	cached, ok := statementCache.get(e.scope.namespace, sql)
+	cached, ok := e.interpreter.statements.get(e.scope.namespace, sql)
 	if ok {
 		// if it is mutating state it must be deterministic
 		if e.canMutateState {
@@ -282,7 +313,7 @@ func (e *executionContext) prepareQuery(sql string) (pgSql string, plan *logical
 		return "", nil, nil, fmt.Errorf("%w: %w", engine.ErrPGGen, err)
 	}
 
-	statementCache.set(e.scope.namespace, sql, &preparedStatement{
+	e.interpreter.statements.set(e.scope.namespace, sql, &preparedStatement{
 		deterministicPlan:      deterministicPlan,
 		deterministicSQL:       deterministicSQL,
 		deterministicParams:    deterministicParams,
@@ -406,8 +437,12 @@ func (p *preparedStatements) clear() {
 	p.cache.Clear()
 }
 
-var statementCache = &preparedStatements{
-	cache: lru.NewMap[[2]string, *preparedStatement](1000),
+var statementCache = newPreparedStatements()
+
+func newPreparedStatements() *preparedStatements {
+	return &preparedStatements{
+		cache: lru.NewMap[[2]string, *preparedStatement](1000),
+	}
 }
 
 // executable is the interface and function to call a built-in Postgres function,
@@ -471,6 +506,7 @@ func (e *executionContext) setVariable(name string, value value) error {
 	}
 
 	foundScope.variables[name] = value
+	e.interpreter.tracer.variable(name, value)
 	return nil
 }
 
@@ -482,6 +518,7 @@ func (e *executionContext) allocateVariable(name string, value value) error {
 	}
 
 	e.scope.variables[name] = value
+	e.interpreter.tracer.variable(name, value)
 	return nil
 }
 
@@ -572,7 +609,7 @@ func (e *executionContext) reloadNamespaceCache() error {
 		ns.tables[table.Name] = table
 	}
 
-	statementCache.clear()
+	e.interpreter.statements.clear()
 
 	return nil
 }
@@ -617,6 +654,15 @@ func (e *executionContext) canExecute(newNamespace, actionName string, modifiers
 	return e.checkPrivilege(_CALL_PRIVILEGE)
 }
 
+// checkNotScratch returns an error if the interpreter is a scratch copy, for
+// operations on extensions whose in-memory state would not be discarded.
+func (e *executionContext) checkNotScratch(operation string) error {
+	if e.interpreter.scratch {
+		return fmt.Errorf("%w: %s is not allowed in a simulated or traced transaction", engine.ErrCannotMutateState, operation)
+	}
+	return nil
+}
+
 func (e *executionContext) app() *common.App {
 	// we need to wait until we make changes to the engine interface for extensions before we can implement this
 	return &common.App{
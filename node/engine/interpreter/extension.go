@@ -48,6 +48,11 @@ func initializeExtension(ctx context.Context, svc *common.Service, db sql.DB, i
 				if err := exec.canExecute(alias, lowerName, method.AccessModifiers); err != nil {
 					return err
 				}
+				if !precompiles.Modifiers(method.AccessModifiers).Has(precompiles.VIEW) {
+					if err := exec.checkNotScratch(`extension method "` + lowerName + `"`); err != nil {
+						return err
+					}
+				}
 
 				if len(args) != len(method.Parameters) {
 					return fmt.Errorf(`%w: extension method "%s" expected %d arguments, but got %d`, engine.ErrExtensionImplementation, lowerName, len(method.Parameters), len(args))
@@ -10,8 +10,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/decred/dcrd/container/lru"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/core/types"
@@ -21,10 +24,13 @@ import (
 	"github.com/kwilteam/kwil-db/extensions/precompiles"
 	"github.com/kwilteam/kwil-db/node/engine"
 	"github.com/kwilteam/kwil-db/node/engine/parse"
+	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/node/pg"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 )
 
+var mets metrics.EngineMetrics = metrics.Engine
+
 // ThreadSafeInterpreter is a thread-safe interpreter.
 // It is defined as a separate struct because there are time where
 // the interpreter recursively calls itself, and we need to avoid
@@ -245,6 +251,7 @@ func NewInterpreter(ctx context.Context, db sql.DB, service *common.Service, acc
 		validators:        validators,
 		accounts:          accounts,
 		namespaceRegister: nsr,
+		statements:        statementCache,
 	}
 
 	namespaces, err := listNamespaces(ctx, db)
@@ -444,6 +451,18 @@ type baseInterpreter struct {
 	accounts common.Accounts
 	// namespaceRegister is used to register and unregister namespaces
 	namespaceRegister engine.NamespaceRegister
+	// statements caches the prepared statements of the namespaces. It is
+	// shared by copies of the interpreter, except for scratch copies.
+	statements *preparedStatements
+	// scratch is true if the interpreter is a copy whose changes are
+	// discarded, made by Scratch, TraceCall or TraceExecute. Extensions may
+	// keep in-memory state that would not be discarded, so a scratch
+	// interpreter cannot call extension methods that are not views, or use
+	// or unuse extensions.
+	scratch bool
+	// tracer records the execution if the interpreter is a copy made by
+	// TraceCall or TraceExecute. It is nil otherwise.
+	tracer *tracer
 }
 
 // copy deep copies the state of the interpreter.
@@ -462,6 +481,7 @@ func (i *baseInterpreter) copy() *baseInterpreter {
 		service:    i.service,
 		validators: i.validators,
 		accounts:   i.accounts,
+		statements: i.statements,
 	}
 }
 
@@ -586,6 +606,7 @@ func (i *baseInterpreter) execute(ctx *common.EngineContext, db sql.DB, statemen
 	interpPlanner := interpreterPlanner{}
 
 	for _, stmt := range ast {
+		i.tracer.statement(stmt)
 		err = stmt.Accept(&interpPlanner).(stmtFunc)(execCtx, func(row *row) error {
 			return fn(rowToCommonRow(row))
 		})
@@ -620,6 +641,16 @@ func isValidVarName(s string) error {
 // Call executes an action against the database.
 // The resultFn is called with the result of the action, if any.
 func (i *baseInterpreter) call(ctx *common.EngineContext, db sql.DB, namespace, action string, args []any, resultFn func(*common.Row) error, toplevel bool) (callRes *common.CallResult, err error) {
+	// t0 is set once the action is found, so that calls to unknown actions
+	// are not recorded. This is deferred first so that it sees recovered panics.
+	var t0 time.Time
+	defer func() {
+		if toplevel && !t0.IsZero() && i.tracer == nil {
+			mets.ActionExecuted(ctx.TxContext.Ctx, namespace, action, time.Since(t0),
+				err != nil || (callRes != nil && callRes.Error != nil))
+		}
+	}()
+
 	copied := i.copy()
 	defer func() {
 		// if there is either an error or a panic, then we need
@@ -687,6 +718,22 @@ func (i *baseInterpreter) call(ctx *common.EngineContext, db sql.DB, namespace,
 		return nil, fmt.Errorf(`node bug: unknown executable type "%s"`, exec.Type)
 	}
 
+	t0 = time.Now()
+
+	// Queries and nested calls made by the action use the span's context.
+	parent := ctx.TxContext.Ctx
+	var span trace.Span
+	ctx.TxContext.Ctx, span = metrics.StartSpan(parent, metrics.EngineTracer, "action "+namespace+"."+action,
+		attribute.Bool("toplevel", toplevel))
+	defer func() {
+		ctx.TxContext.Ctx = parent
+		if err == nil && callRes != nil && callRes.Error != nil {
+			metrics.EndSpan(span, callRes.Error)
+		} else {
+			metrics.EndSpan(span, err)
+		}
+	}()
+
 	argVals := make([]value, len(args))
 
 	if exec.ExpectedArgs != nil {
@@ -846,6 +893,7 @@ func funcDefToExecutable(funcName string, funcDef *engine.ScalarFunctionDefiniti
 					log = args[0].RawValue().(string)
 				}
 				*e.logs = append(*e.logs, log)
+				e.interpreter.tracer.notice(log)
 				return nil
 			}
 
@@ -1150,3 +1198,29 @@ func oneLengthArray(v value) (arrayValue, error) {
 
 	return arrVal, nil
 }
+
+// Scratch returns an engine for executing transactions whose changes are
+// discarded, such as when simulating a transaction. It uses a copy of the
+// interpreter's state, so changes to namespaces and the statement cache are
+// not visible to t, and the given accounts and validators. The database
+// transaction used with it must be rolled back.
+func (t *ThreadSafeInterpreter) Scratch(accounts common.Accounts, validators common.Validators) common.Engine {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return &ThreadSafeInterpreter{
+		i: t.i.scratchCopy(accounts, validators),
+	}
+}
+
+// scratchCopy returns a copy of the interpreter that does not modify the
+// registered namespaces or the shared statement cache.
+func (i *baseInterpreter) scratchCopy(accounts common.Accounts, validators common.Validators) *baseInterpreter {
+	copied := i.copy()
+	copied.namespaceRegister = nilNamespaceRegister{}
+	copied.statements = newPreparedStatements()
+	copied.accounts = accounts
+	copied.validators = validators
+	copied.scratch = true
+	return copied
+}
@@ -20,7 +20,7 @@ func makeActionToExecutable(namespace string, act *action) *executable {
 	planner := &interpreterPlanner{}
 	stmtFns := make([]stmtFunc, len(act.Body))
 	for j, stmt := range act.Body {
-		stmtFns[j] = stmt.Accept(planner).(stmtFunc)
+		stmtFns[j] = planner.planActionStmt(stmt)
 	}
 
 	var expectedArgs []*types.DataType
@@ -53,13 +53,13 @@ func makeActionToExecutable(namespace string, act *action) *executable {
 	return &executable{
 		Name:         act.Name,
 		ExpectedArgs: &expectedArgs,
-		Func: func(exec *executionContext, args []value, fn resultFunc) error {
+		Func: func(exec *executionContext, args []value, fn resultFunc) (err error) {
 			if err := exec.canExecute(namespace, act.Name, act.Modifiers); err != nil {
 				return err
 			}
 
 			// validate the args
-			args, err := validateArgs(args)
+			args, err = validateArgs(args)
 			if err != nil {
 				return err
 			}
@@ -78,6 +78,9 @@ func makeActionToExecutable(namespace string, act *action) *executable {
 				}
 			}
 
+			exec.interpreter.tracer.enterAction(namespace, act.Name, args)
+			defer func() { exec.interpreter.tracer.exit(err) }()
+
 			exec2 := exec.subscope(namespace)
 
 			for j, param := range act.Parameters {
@@ -519,7 +522,7 @@ func executeBlock(exec *executionContext, fn resultFunc,
 func (i *interpreterPlanner) VisitActionStmtForLoop(p0 *parse.ActionStmtForLoop) any {
 	stmtFns := make([]stmtFunc, len(p0.Body))
 	for j, stmt := range p0.Body {
-		stmtFns[j] = stmt.Accept(i).(stmtFunc)
+		stmtFns[j] = i.planActionStmt(stmt)
 	}
 
 	loopFn := p0.LoopTerm.Accept(i).(loopTermFunc)
@@ -715,7 +718,7 @@ func (i *interpreterPlanner) VisitActionStmtIf(p0 *parse.ActionStmtIf) any {
 		ifFn := ifThen.If.Accept(i).(exprFunc)
 		var thenFns []stmtFunc
 		for _, stmt := range ifThen.Then {
-			thenFns = append(thenFns, stmt.Accept(i).(stmtFunc))
+			thenFns = append(thenFns, i.planActionStmt(stmt))
 		}
 
 		ifThenFns = append(ifThenFns, struct {
@@ -730,7 +733,7 @@ func (i *interpreterPlanner) VisitActionStmtIf(p0 *parse.ActionStmtIf) any {
 	var elseFns []stmtFunc
 	if p0.Else != nil {
 		for _, stmt := range p0.Else {
-			elseFns = append(elseFns, stmt.Accept(i).(stmtFunc))
+			elseFns = append(elseFns, i.planActionStmt(stmt))
 		}
 	}
 
@@ -1576,6 +1579,14 @@ func (i *interpreterPlanner) VisitSQLStatement(p0 *parse.SQLStatement) any {
 			return err
 		}
 
+		// functions that write to the database can be used by actions, and in
+		// ad-hoc SQL that requires a privilege to write.
+		if !mutatesState && !exec.inAction && !exec.engineCtx.OverrideAuthz {
+			if err := exec.checkNoMutatingFunctions(raw); err != nil {
+				return err
+			}
+		}
+
 		// if the query is trying to mutate state but the exec ctx cant then we should error
 		if mutatesState && !exec.canMutateState {
 			return fmt.Errorf("%w: SQL statement mutates state, but the execution context is read-only: %s", engine.ErrCannotMutateState, raw)
@@ -1759,6 +1770,9 @@ func (i *interpreterPlanner) VisitUseExtensionStatement(p0 *parse.UseExtensionSt
 		if err := exec.checkPrivilege(_USE_PRIVILEGE); err != nil {
 			return err
 		}
+		if err := exec.checkNotScratch("USE"); err != nil {
+			return err
+		}
 
 		// see if the extension is already initialized
 		if existing, exists := exec.interpreter.namespaces[p0.Alias]; exists {
@@ -1789,6 +1803,12 @@ func (i *interpreterPlanner) VisitUseExtensionStatement(p0 *parse.UseExtensionSt
 			return fmt.Errorf(`extension "%s" does not exist`, p0.ExtName)
 		}
 
+		if key, ok := precompiles.NamespaceMetadataKey(p0.ExtName); ok {
+			if _, set := config[key]; !set {
+				config[key] = makeText(exec.scope.namespace)
+			}
+		}
+
 		extNamespace, inst, err := initializeExtension(exec.engineCtx.TxContext.Ctx, exec.interpreter.service, exec.db, initializer, p0.Alias, config)
 		if err != nil {
 			return err
@@ -1828,6 +1848,9 @@ func (i *interpreterPlanner) VisitUnuseExtensionStatement(p0 *parse.UnuseExtensi
 		if err := exec.checkPrivilege(_USE_PRIVILEGE); err != nil {
 			return err
 		}
+		if err := exec.checkNotScratch("UNUSE"); err != nil {
+			return err
+		}
 
 		ns, exists := exec.interpreter.namespaces[p0.Alias]
 		if !exists {
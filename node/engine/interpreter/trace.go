@@ -0,0 +1,270 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/types"
+	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+	"github.com/kwilteam/kwil-db/node/pg"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+// ErrTraceReadOnly is returned when a traced action attempts to modify
+// accounts or validators, which are not part of the traced transaction.
+var ErrTraceReadOnly = errors.New("cannot modify accounts or validators while tracing")
+
+// TraceCall executes an action in the same way as Call, and returns a trace of
+// the statements it executed, the variables it assigned, and the queries and
+// notices it made, including those of any actions it called. The db should be
+// a transaction that the caller rolls back, since the action is executed
+// outside of consensus. Accounts and validators cannot be modified while
+// tracing, and schema changes are not supported.
+func (t *ThreadSafeInterpreter) TraceCall(ctx *common.EngineContext, db sql.DB, namespace string, action string, args []any) (*adminTypes.TraceCall, *common.CallResult, error) {
+	// As with Scratch, the lock is only held to copy the interpreter, whose
+	// schema changes are not visible to t.
+	t.mu.RLock()
+	i, tr := t.i.tracingCopy()
+	t.mu.RUnlock()
+
+	res, err := i.call(ctx, db, namespace, action, args, nil, true)
+	if tr.root == nil {
+		// a precompile method, or an action that was not called
+		tr.root = &adminTypes.TraceCall{Namespace: namespace, Action: action}
+		if err != nil {
+			tr.root.Error = err.Error()
+		}
+	}
+	return tr.root, res, err
+}
+
+// TraceExecute executes an ad-hoc SQL statement in the same way as Execute,
+// and returns a trace of its execution. Only SQL statements are supported.
+// See TraceCall.
+func (t *ThreadSafeInterpreter) TraceExecute(ctx *common.EngineContext, db sql.DB, statement string, params map[string]any) (*adminTypes.TraceCall, error) {
+	ast, err := parseAdhoc(statement)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error in top-level statement %s: %w", engine.ErrParse, statement, err)
+	}
+	for _, stmt := range ast {
+		if _, ok := stmt.(*parse.SQLStatement); !ok {
+			return nil, fmt.Errorf("cannot trace %s statement, only SQL statements are supported", stmtKind(stmt))
+		}
+	}
+
+	t.mu.RLock()
+	i, tr := t.i.tracingCopy()
+	t.mu.RUnlock()
+
+	tr.enter(&adminTypes.TraceCall{Statement: statement})
+	err = i.execute(ctx, db, statement, params, nil, true)
+	tr.exit(err)
+	return tr.root, err
+}
+
+// tracingCopy returns a scratch copy of the interpreter that records a trace,
+// and cannot modify accounts or validators.
+func (i *baseInterpreter) tracingCopy() (*baseInterpreter, *tracer) {
+	tr := &tracer{}
+	copied := i.scratchCopy(readOnlyAccounts{i.accounts}, readOnlyValidators{i.validators})
+	copied.tracer = tr
+	return copied, tr
+}
+
+// tracer records the execution of an action or statement. All of its methods
+// are no-ops on a nil tracer, which is the case unless tracing.
+type tracer struct {
+	root *adminTypes.TraceCall
+	// calls is the stack of action calls being executed.
+	calls []*adminTypes.TraceCall
+}
+
+// record adds a step to the current call.
+func (t *tracer) record(step *adminTypes.TraceStep) {
+	if t == nil || len(t.calls) == 0 {
+		return
+	}
+	cur := t.calls[len(t.calls)-1]
+	cur.Steps = append(cur.Steps, step)
+}
+
+// enter starts a nested call, or the root call if none has been started.
+func (t *tracer) enter(call *adminTypes.TraceCall) {
+	if t == nil {
+		return
+	}
+	if t.root == nil {
+		t.root = call
+	} else {
+		t.record(&adminTypes.TraceStep{
+			Type: adminTypes.TraceStepCall,
+			Call: call,
+		})
+	}
+	t.calls = append(t.calls, call)
+}
+
+// exit ends the current call.
+func (t *tracer) exit(err error) {
+	if t == nil || len(t.calls) == 0 {
+		return
+	}
+	if err != nil {
+		t.calls[len(t.calls)-1].Error = err.Error()
+	}
+	t.calls = t.calls[:len(t.calls)-1]
+}
+
+// enterAction starts the call of an action.
+func (t *tracer) enterAction(namespace, action string, args []value) {
+	if t == nil {
+		return
+	}
+	call := &adminTypes.TraceCall{
+		Namespace: namespace,
+		Action:    action,
+		Args:      make([]string, len(args)),
+	}
+	for i, arg := range args {
+		call.Args[i] = formatTraceValue(arg)
+	}
+	t.enter(call)
+}
+
+// statement records the execution of a statement.
+func (t *tracer) statement(node parse.Node) {
+	if t == nil {
+		return
+	}
+	step := &adminTypes.TraceStep{
+		Type:      adminTypes.TraceStepStatement,
+		Statement: stmtKind(node),
+	}
+	if pos := node.GetPosition(); pos != nil && pos.StartLine != nil && pos.StartCol != nil {
+		step.Line = *pos.StartLine
+		step.Column = *pos.StartCol + 1
+	}
+	t.record(step)
+}
+
+// variable records the assignment of a variable.
+func (t *tracer) variable(name string, val value) {
+	if t == nil {
+		return
+	}
+	step := &adminTypes.TraceStep{
+		Type:     adminTypes.TraceStepVariable,
+		Variable: name,
+	}
+	if !val.Null() {
+		s := formatTraceValue(val)
+		step.Value = &s
+	}
+	t.record(step)
+}
+
+// notice records a notice.
+func (t *tracer) notice(msg string) {
+	t.record(&adminTypes.TraceStep{
+		Type:   adminTypes.TraceStepNotice,
+		Notice: msg,
+	})
+}
+
+// query records a generated query. It returns a context that records the
+// number of rows returned or affected by the query, and a function that must
+// be called with the query's error.
+func (t *tracer) query(ctx context.Context, stmt string, args []value) (context.Context, func(error)) {
+	if t == nil {
+		return ctx, func(error) {}
+	}
+	step := &adminTypes.TraceStep{
+		Type:   adminTypes.TraceStepQuery,
+		SQL:    stmt,
+		Params: make([]*string, len(args)),
+	}
+	for i, arg := range args {
+		if !arg.Null() {
+			s := formatTraceValue(arg)
+			step.Params[i] = &s
+		}
+	}
+	t.record(step)
+
+	ctx = pg.WithCommandTagRecorder(ctx, func(tag sql.CommandTag) {
+		rows := tag.RowsAffected
+		step.Rows = &rows
+	})
+	return ctx, func(err error) {
+		if err != nil {
+			step.Error = err.Error()
+		}
+	}
+}
+
+// formatTraceValue formats a value as text for a trace.
+func formatTraceValue(v value) string {
+	if v.Null() {
+		return "NULL"
+	}
+	switch raw := v.RawValue().(type) {
+	case []byte:
+		return fmt.Sprintf("0x%x", raw)
+	default:
+		return fmt.Sprint(raw)
+	}
+}
+
+// stmtKind returns the kind of a statement from its AST node, e.g. "assign"
+// for an *parse.ActionStmtAssign, or "sql" for a *parse.SQLStatement.
+func stmtKind(node parse.Node) string {
+	kind := strings.TrimPrefix(fmt.Sprintf("%T", node), "*parse.")
+	kind = strings.TrimPrefix(kind, "ActionStmt")
+	kind = strings.TrimSuffix(kind, "Statement")
+	return strings.ToLower(kind)
+}
+
+// planActionStmt plans an action statement, recording its execution if
+// tracing.
+func (i *interpreterPlanner) planActionStmt(stmt parse.ActionStmt) stmtFunc {
+	fn := stmt.Accept(i).(stmtFunc)
+	return func(exec *executionContext, rf resultFunc) error {
+		exec.interpreter.tracer.statement(stmt)
+		return fn(exec, rf)
+	}
+}
+
+// readOnlyAccounts is used while tracing, since changes to accounts are cached
+// in memory until the block is committed, and would not be rolled back with
+// the traced transaction.
+type readOnlyAccounts struct {
+	common.Accounts
+}
+
+func (readOnlyAccounts) Credit(context.Context, sql.Executor, *types.AccountID, *big.Int) error {
+	return ErrTraceReadOnly
+}
+
+func (readOnlyAccounts) Transfer(context.Context, sql.TxMaker, *types.AccountID, *types.AccountID, *big.Int) error {
+	return ErrTraceReadOnly
+}
+
+func (readOnlyAccounts) ApplySpend(context.Context, sql.Executor, *types.AccountID, *big.Int, int64) error {
+	return ErrTraceReadOnly
+}
+
+// readOnlyValidators is used while tracing. See readOnlyAccounts.
+type readOnlyValidators struct {
+	common.Validators
+}
+
+func (readOnlyValidators) SetValidatorPower(context.Context, sql.Executor, []byte, crypto.KeyType, int64) error {
+	return ErrTraceReadOnly
+}
@@ -0,0 +1,60 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+func Test_Tracer(t *testing.T) {
+	mustValue := func(v any) value {
+		val, err := newValue(v)
+		require.NoError(t, err)
+		return val
+	}
+	null, err := makeNull(types.TextType)
+	require.NoError(t, err)
+
+	tr := &tracer{}
+	tr.enterAction("main", "outer", []value{mustValue(int64(1)), mustValue([]byte{0xab})})
+	tr.variable("$a", mustValue("x"))
+	tr.variable("$b", null)
+	tr.enterAction("main", "inner", nil)
+	tr.notice("hello")
+	tr.exit(errors.New("boom"))
+	tr.exit(nil)
+
+	root := tr.root
+	require.Equal(t, "outer", root.Action)
+	require.Equal(t, []string{"1", "0xab"}, root.Args)
+	require.Empty(t, root.Error)
+	require.Len(t, root.Steps, 3)
+
+	require.Equal(t, adminTypes.TraceStepVariable, root.Steps[0].Type)
+	require.Equal(t, "x", *root.Steps[0].Value)
+	require.Nil(t, root.Steps[1].Value)
+
+	inner := root.Steps[2].Call
+	require.Equal(t, adminTypes.TraceStepCall, root.Steps[2].Type)
+	require.Equal(t, "inner", inner.Action)
+	require.Equal(t, "boom", inner.Error)
+	require.Equal(t, "hello", inner.Steps[0].Notice)
+
+	// a nil tracer does nothing
+	var nilTracer *tracer
+	nilTracer.enterAction("main", "outer", nil)
+	nilTracer.variable("$a", mustValue("x"))
+	nilTracer.exit(nil)
+}
+
+func Test_StmtKind(t *testing.T) {
+	require.Equal(t, "assign", stmtKind(&parse.ActionStmtAssign{}))
+	require.Equal(t, "forloop", stmtKind(&parse.ActionStmtForLoop{}))
+	require.Equal(t, "sql", stmtKind(&parse.SQLStatement{}))
+	require.Equal(t, "createtable", stmtKind(&parse.CreateTableStatement{}))
+}
@@ -143,6 +143,15 @@ func Test_PgGenerate(t *testing.T) {
 			},
 			params: []string{"$pwd"},
 		},
+		{
+			name: "sequence_next",
+			sql:  "INSERT INTO tbl (id, col) VALUES (sequence_next('ids'), $col);",
+			want: "INSERT INTO kwil.tbl (id, col) VALUES (kwil_sequence.next_value('ids'), $1::TEXT);",
+			variables: map[string]*types.DataType{
+				"$col": types.TextType,
+			},
+			params: []string{"$col"},
+		},
 		{
 			name: "Parameter in JOIN condition",
 			sql:  "SELECT t1.col, t2.col FROM t1 JOIN t2 ON t1.id = t2.id AND t1.name = $name;",
@@ -10,6 +10,11 @@
 // Internally, the node will start another event listener which is responsible for tracking
 // the erc20's Transfer event. When a transfer event is detected, the node will update the
 // reward balance of the recipient.
+//
+// Only ERC20 tokens are supported. ERC721 and ERC1155 escrow is not implemented yet: the
+// RewardDistributor claim leaf is (recipient, amount, contract, block hash), with no token
+// standard or token ID, so epoch roots cannot cover NFT claims until an NFT escrow and
+// distributor contract, and their bindings in ../abigen, exist.
 package erc20
 
 import (
@@ -2,6 +2,11 @@
 // It simply fetches the new Epoch from Kwil network and verify&sign it, then
 // upload the signature back to the Kwil network. Each bridgeSigner targets one registered
 // erc20 Reward instance.
+//
+// Each validator signs an epoch independently, and the Safe verifies one ECDSA signature
+// per owner. Threshold (FROST/TSS) signing is not supported yet. An aggregate Schnorr
+// signature needs a verifier contract, and threshold ECDSA needs a DKG, a p2p signing
+// protocol, and key share storage, none of which exist yet.
 package signersvc
 
 import (
@@ -0,0 +1,335 @@
+package evmevents
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// eventSig is a parsed event signature, such as
+// "Transfer(address indexed from, address indexed to, uint256 value)".
+type eventSig struct {
+	event abi.Event
+	// explicitIndexed is true if the signature marks its indexed parameters.
+	// If it does not, the leading parameters are assumed to be indexed, one
+	// for each topic after the event ID.
+	explicitIndexed bool
+}
+
+// parseEventSig parses an event signature. Each parameter is a type, an
+// optional "indexed" keyword, and an optional name. Tuples and nested arrays
+// are not supported.
+func parseEventSig(sig string) (*eventSig, error) {
+	sig = strings.TrimSpace(sig)
+	open := strings.Index(sig, "(")
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return nil, fmt.Errorf("invalid event signature %q: expected Name(type, ...)", sig)
+	}
+	name := strings.TrimSpace(sig[:open])
+	if !isIdentifier(name) {
+		return nil, fmt.Errorf("invalid event name %q", name)
+	}
+	body := sig[open+1 : len(sig)-1]
+	if strings.ContainsAny(body, "()") {
+		return nil, errors.New("tuple parameters are not supported")
+	}
+
+	var args abi.Arguments
+	var numIndexed int
+	if strings.TrimSpace(body) != "" {
+		for i, param := range strings.Split(body, ",") {
+			fields := strings.Fields(param)
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("parameter %d: missing type", i+1)
+			}
+
+			typ, err := abi.NewType(canonicalType(fields[0]), "", nil)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+			}
+			if _, err = kwilType(typ); err != nil {
+				return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+			}
+
+			arg := abi.Argument{Name: fmt.Sprintf("arg%d", i), Type: typ}
+			rest := fields[1:]
+			if len(rest) > 0 && rest[0] == "indexed" {
+				arg.Indexed = true
+				numIndexed++
+				rest = rest[1:]
+			}
+			switch {
+			case len(rest) > 1:
+				return nil, fmt.Errorf("parameter %d: unexpected %q", i+1, strings.Join(rest[1:], " "))
+			case len(rest) == 1 && !isIdentifier(rest[0]):
+				return nil, fmt.Errorf("parameter %d: invalid name %q", i+1, rest[0])
+			}
+			args = append(args, arg)
+		}
+	}
+	if numIndexed > 3 {
+		return nil, errors.New("an event can have at most 3 indexed parameters")
+	}
+
+	return &eventSig{
+		event:           abi.NewEvent(name, name, false, args),
+		explicitIndexed: numIndexed > 0,
+	}, nil
+}
+
+// canonicalType expands the uint and int aliases, which are accepted in
+// Solidity signatures but not by the abi package.
+func canonicalType(typ string) string {
+	for _, alias := range []string{"uint", "int"} {
+		if rest, ok := strings.CutPrefix(typ, alias); ok && (rest == "" || rest[0] == '[') {
+			return alias + "256" + rest
+		}
+	}
+	return typ
+}
+
+// ID returns the event ID, which is the first topic of the event's logs.
+func (e *eventSig) ID() ethcommon.Hash {
+	return e.event.ID
+}
+
+// arguments returns the event parameters, marked as indexed for a log with
+// the given number of indexed topics.
+func (e *eventSig) arguments(numTopics int) (abi.Arguments, error) {
+	if numTopics > len(e.event.Inputs) {
+		return nil, fmt.Errorf("log has %d indexed topics, but event %s has %d parameters", numTopics, e.event.Sig, len(e.event.Inputs))
+	}
+	if e.explicitIndexed {
+		if n := len(e.event.Inputs) - len(e.event.Inputs.NonIndexed()); n != numTopics {
+			return nil, fmt.Errorf("log has %d indexed topics, but event %s has %d indexed parameters", numTopics, e.event.Sig, n)
+		}
+		return e.event.Inputs, nil
+	}
+
+	args := make(abi.Arguments, len(e.event.Inputs))
+	copy(args, e.event.Inputs)
+	for i := range numTopics {
+		args[i].Indexed = true
+	}
+	return args, nil
+}
+
+// decode decodes the parameters of a log into values that can be passed to
+// an action. Indexed parameters of dynamic types (strings, bytes, and arrays)
+// are decoded as the keccak256 hash stored in the topic.
+func (e *eventSig) decode(log *ethtypes.Log) ([]any, error) {
+	if len(log.Topics) == 0 || log.Topics[0] != e.event.ID {
+		return nil, fmt.Errorf("log is not a %s event", e.event.Sig)
+	}
+
+	args, err := e.arguments(len(log.Topics) - 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexed abi.Arguments
+	for _, arg := range args {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	topicVals := make(map[string]any, len(indexed))
+	if err = abi.ParseTopicsIntoMap(topicVals, indexed, log.Topics[1:]); err != nil {
+		return nil, fmt.Errorf("failed to decode topics: %w", err)
+	}
+
+	dataVals, err := args.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+
+	vals := make([]any, 0, len(args))
+	for _, arg := range args {
+		var v any
+		if arg.Indexed {
+			v = topicVals[arg.Name]
+			if h, ok := v.(ethcommon.Hash); ok && isHashedInTopic(arg.Type) {
+				vals = append(vals, h.Bytes())
+				continue
+			}
+		} else {
+			v, dataVals = dataVals[0], dataVals[1:]
+		}
+
+		kv, err := kwilValue(arg.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", arg.Name, err)
+		}
+		vals = append(vals, kv)
+	}
+
+	return vals, nil
+}
+
+// isHashedInTopic reports whether an indexed parameter of the type is stored
+// as its keccak256 hash.
+func isHashedInTopic(t abi.Type) bool {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy:
+		return true
+	}
+	return false
+}
+
+// uint256Numeric is a numeric that is big enough to hold any int256 or uint256.
+var uint256Numeric = func() *types.DataType {
+	dt, err := types.NewNumericType(78, 0)
+	if err != nil {
+		panic(err)
+	}
+	return dt
+}()
+
+// kwilType returns the Kwil type that an ABI type is decoded as.
+func kwilType(t abi.Type) (*types.DataType, error) {
+	switch t.T {
+	case abi.AddressTy, abi.StringTy:
+		return types.TextType, nil
+	case abi.BoolTy:
+		return types.BoolType, nil
+	case abi.BytesTy, abi.FixedBytesTy:
+		return types.ByteaType, nil
+	case abi.IntTy, abi.UintTy:
+		if fitsInt64(t) {
+			return types.IntType, nil
+		}
+		return uint256Numeric, nil
+	case abi.SliceTy, abi.ArrayTy:
+		if t.Elem.T == abi.SliceTy || t.Elem.T == abi.ArrayTy {
+			return nil, errors.New("nested arrays are not supported")
+		}
+		elem, err := kwilType(*t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		arr := elem.Copy()
+		arr.IsArray = true
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("type %s is not supported", t.String())
+	}
+}
+
+// fitsInt64 reports whether all values of an integer type fit in an int8.
+func fitsInt64(t abi.Type) bool {
+	if t.T == abi.UintTy {
+		return t.Size < 64
+	}
+	return t.Size <= 64
+}
+
+// kwilValue converts a value decoded by the abi package to its Kwil type.
+func kwilValue(t abi.Type, v any) (any, error) {
+	switch t.T {
+	case abi.AddressTy:
+		addr, ok := v.(ethcommon.Address)
+		if !ok {
+			return nil, fmt.Errorf("unexpected address value %T", v)
+		}
+		return addr.Hex(), nil
+	case abi.StringTy, abi.BoolTy, abi.BytesTy:
+		return v, nil
+	case abi.FixedBytesTy:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Array {
+			return nil, fmt.Errorf("unexpected fixed bytes value %T", v)
+		}
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return b, nil
+	case abi.IntTy, abi.UintTy:
+		bi, err := toBigInt(v)
+		if err != nil {
+			return nil, err
+		}
+		if fitsInt64(t) {
+			return bi.Int64(), nil
+		}
+		dec, err := types.NewDecimalFromBigInt(bi, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err = dec.SetPrecisionAndScale(78, 0); err != nil {
+			return nil, err
+		}
+		return dec, nil
+	case abi.SliceTy, abi.ArrayTy:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, fmt.Errorf("unexpected array value %T", v)
+		}
+		elemType, err := kwilType(*t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		arr := newArray(elemType, rv.Len())
+		for i := range rv.Len() {
+			elem, err := kwilValue(*t.Elem, rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			arr.Index(i).Set(reflect.ValueOf(elem))
+		}
+		return arr.Interface(), nil
+	default:
+		return nil, fmt.Errorf("type %s is not supported", t.String())
+	}
+}
+
+// newArray makes a slice of the Go type that holds values of a scalar Kwil type.
+func newArray(elem *types.DataType, n int) reflect.Value {
+	var typ reflect.Type
+	switch elem.Name {
+	case types.TextType.Name:
+		typ = reflect.TypeOf([]string(nil))
+	case types.BoolType.Name:
+		typ = reflect.TypeOf([]bool(nil))
+	case types.ByteaType.Name:
+		typ = reflect.TypeOf([][]byte(nil))
+	case types.IntType.Name:
+		typ = reflect.TypeOf([]int64(nil))
+	default:
+		typ = reflect.TypeOf([]*types.Decimal(nil))
+	}
+	return reflect.MakeSlice(typ, n, n)
+}
+
+func toBigInt(v any) (*big.Int, error) {
+	if bi, ok := v.(*big.Int); ok {
+		return bi, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	default:
+		return nil, fmt.Errorf("unexpected integer value %T", v)
+	}
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+	for _, r := range s {
+		if r != '_' && r != '$' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package evmevents
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+func TestParseEventSig(t *testing.T) {
+	tests := []struct {
+		name     string
+		sig      string
+		wantSig  string
+		explicit bool
+		wantErr  bool
+	}{
+		{
+			name:    "types only",
+			sig:     "Transfer(address,address,uint256)",
+			wantSig: "Transfer(address,address,uint256)",
+		},
+		{
+			name:     "indexed and named",
+			sig:      " Transfer(address indexed from, address indexed to, uint value) ",
+			wantSig:  "Transfer(address,address,uint256)",
+			explicit: true,
+		},
+		{
+			name:    "no parameters",
+			sig:     "Paused()",
+			wantSig: "Paused()",
+		},
+		{
+			name:    "arrays",
+			sig:     "Batch(uint64[] ids, bytes32[2] roots)",
+			wantSig: "Batch(uint64[],bytes32[2])",
+		},
+		{name: "missing parens", sig: "Transfer", wantErr: true},
+		{name: "invalid name", sig: "1Transfer(address)", wantErr: true},
+		{name: "unknown type", sig: "Transfer(addr)", wantErr: true},
+		{name: "tuple", sig: "Order((address,uint256))", wantErr: true},
+		{name: "nested array", sig: "Grid(uint8[][])", wantErr: true},
+		{name: "too many indexed", sig: "E(uint8 indexed, uint8 indexed, uint8 indexed, uint8 indexed)", wantErr: true},
+		{name: "trailing words", sig: "E(uint8 indexed a b)", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := parseEventSig(tt.sig)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSig, ev.event.Sig)
+			require.Equal(t, tt.explicit, ev.explicitIndexed)
+			require.Equal(t, crypto.Keccak256Hash([]byte(tt.wantSig)), ev.ID())
+		})
+	}
+}
+
+func TestDecodeTransfer(t *testing.T) {
+	from := ethcommon.HexToAddress("0x00000000000000000000000000000000000000a1")
+	to := ethcommon.HexToAddress("0x00000000000000000000000000000000000000b2")
+	value, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	uint256Type, err := abi.NewType("uint256", "", nil)
+	require.NoError(t, err)
+	data, err := abi.Arguments{{Type: uint256Type}}.Pack(value)
+	require.NoError(t, err)
+
+	log := &ethtypes.Log{
+		Topics: []ethcommon.Hash{
+			crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")),
+			ethcommon.BytesToHash(from.Bytes()),
+			ethcommon.BytesToHash(to.Bytes()),
+		},
+		Data: data,
+	}
+
+	wantValue, err := types.ParseDecimal("123456789012345678901234567890")
+	require.NoError(t, err)
+
+	// both with and without indexed markers
+	for _, sig := range []string{
+		"Transfer(address,address,uint256)",
+		"Transfer(address indexed from, address indexed to, uint256 value)",
+	} {
+		ev, err := parseEventSig(sig)
+		require.NoError(t, err)
+
+		vals, err := ev.decode(log)
+		require.NoError(t, err)
+		require.Len(t, vals, 3)
+		require.Equal(t, from.Hex(), vals[0])
+		require.Equal(t, to.Hex(), vals[1])
+		dec, ok := vals[2].(*types.Decimal)
+		require.True(t, ok)
+		require.Equal(t, wantValue.String(), dec.String())
+		require.Equal(t, uint16(78), dec.Precision())
+	}
+
+	// the indexed markers must match the log
+	ev, err := parseEventSig("Transfer(address indexed from, address to, uint256 value)")
+	require.NoError(t, err)
+	_, err = ev.decode(log)
+	require.Error(t, err)
+
+	// a log of another event is rejected
+	ev, err = parseEventSig("Approval(address,address,uint256)")
+	require.NoError(t, err)
+	_, err = ev.decode(log)
+	require.Error(t, err)
+}
+
+func TestDecodeTypes(t *testing.T) {
+	ev, err := parseEventSig("E(string indexed tag, int32 a, uint64 b, bool c, bytes d, bytes4 e, string f, address[] g, int64[] h)")
+	require.NoError(t, err)
+
+	args, err := ev.arguments(1)
+	require.NoError(t, err)
+	addrs := []ethcommon.Address{ethcommon.HexToAddress("0x01"), ethcommon.HexToAddress("0x02")}
+	data, err := args.NonIndexed().Pack(int32(-5), uint64(1<<63), true, []byte{1, 2}, [4]byte{9, 8, 7, 6}, "hello", addrs, []int64{3, -4})
+	require.NoError(t, err)
+
+	tagHash := crypto.Keccak256Hash([]byte("tag"))
+	vals, err := ev.decode(&ethtypes.Log{
+		Topics: []ethcommon.Hash{ev.ID(), tagHash},
+		Data:   data,
+	})
+	require.NoError(t, err)
+
+	b, err := types.ParseDecimal("9223372036854775808")
+	require.NoError(t, err)
+
+	require.Equal(t, tagHash.Bytes(), vals[0])
+	require.Equal(t, int64(-5), vals[1])
+	require.Equal(t, b.String(), vals[2].(*types.Decimal).String())
+	require.Equal(t, true, vals[3])
+	require.Equal(t, []byte{1, 2}, vals[4])
+	require.Equal(t, []byte{9, 8, 7, 6}, vals[5])
+	require.Equal(t, "hello", vals[6])
+	require.Equal(t, []string{addrs[0].Hex(), addrs[1].Hex()}, vals[7])
+	require.Equal(t, []int64{3, -4}, vals[8])
+}
+
+func TestKwilType(t *testing.T) {
+	tests := []struct {
+		abiType string
+		want    *types.DataType
+	}{
+		{"address", types.TextType},
+		{"string", types.TextType},
+		{"bool", types.BoolType},
+		{"bytes", types.ByteaType},
+		{"bytes32", types.ByteaType},
+		{"int64", types.IntType},
+		{"uint32", types.IntType},
+		{"uint64", uint256Numeric},
+		{"int256", uint256Numeric},
+		{"address[]", types.TextArrayType},
+		{"uint256[3]", types.NumericArrayType},
+	}
+
+	for _, tt := range tests {
+		typ, err := abi.NewType(tt.abiType, "", nil)
+		require.NoError(t, err)
+		got, err := kwilType(typ)
+		require.NoError(t, err)
+		require.Equal(t, tt.want.Name, got.Name, tt.abiType)
+		require.Equal(t, tt.want.IsArray, got.IsArray, tt.abiType)
+	}
+}
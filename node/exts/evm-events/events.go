@@ -0,0 +1,300 @@
+// package evmevents implements the evm_events precompile, which syncs the logs
+// of an EVM contract event and calls an action for each of them.
+//
+// It is used as:
+//
+//	USE evm_events {
+//		chain: 'ethereum',
+//		contract: '0x...',
+//		abi_event: 'Transfer(address indexed from, address indexed to, uint256 value)',
+//		action: 'on_transfer'
+//	} AS transfers;
+//
+// Logs are synced through the evm-sync and ordered-sync extensions, so they
+// are only resolved once they are final on the EVM chain and agreed upon by
+// the network. For each log, in order, the action is called with the event
+// parameters, followed by the block number ($int8), transaction hash ($bytea)
+// and log index ($int8) of the log. Event parameters are passed as:
+//   - address, string: text
+//   - bool: bool
+//   - bytes, bytesN: bytea
+//   - intN (N <= 64), uintN (N < 64): int8
+//   - other integers: numeric(78,0)
+//   - one-dimensional arrays of the above: arrays of the above
+//   - indexed strings, bytes, and arrays: bytea, the keccak256 hash stored in the topic
+//
+// The action is called by the network, bypassing access modifiers, so it
+// should be SYSTEM to prevent users from calling it directly. It is called
+// with an empty @caller. If the action fails, the log is skipped. The action is
+// in the namespace that the USE statement is executed in, unless a namespace
+// is given in the metadata.
+//
+// The node reads the chain from the RPC configured for it in the
+// erc20_bridge.rpc section of the node config.
+package evmevents
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/log"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/precompiles"
+	"github.com/kwilteam/kwil-db/node/exts/erc20-bridge/utils"
+	evmsync "github.com/kwilteam/kwil-db/node/exts/evm-sync"
+	"github.com/kwilteam/kwil-db/node/exts/evm-sync/chains"
+	orderedsync "github.com/kwilteam/kwil-db/node/exts/ordered-sync"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+const (
+	// ExtensionName is the name used to USE the precompile.
+	ExtensionName = "evm_events"
+	// resolutionName is the name of the evm-sync resolution for all instances.
+	resolutionName = "evm_events_sync"
+	// evmMaxRetries is the number of times an RPC request to the EVM chain is retried.
+	evmMaxRetries = 10
+)
+
+// instances tracks the configuration of each instance of the precompile,
+// keyed by its listener's unique name. It is used by the resolution, which
+// is shared by all instances.
+var instances = struct {
+	mu sync.RWMutex
+	m  map[string]*instance
+}{m: make(map[string]*instance)}
+
+// instance is a configured instance of the precompile.
+type instance struct {
+	chain     chains.ChainInfo
+	contract  ethcommon.Address
+	event     *eventSig
+	namespace string
+	action    string
+}
+
+func init() {
+	err := precompiles.RegisterInitializer(ExtensionName, func(ctx context.Context, service *common.Service,
+		db sql.DB, alias string, metadata map[string]any) (precompiles.Precompile, error) {
+		inst, err := newInstance(metadata)
+		if err != nil {
+			return precompiles.Precompile{}, err
+		}
+
+		uniqueName := listenerUniqueName(alias)
+		instances.mu.Lock()
+		instances.m[uniqueName] = inst
+		instances.mu.Unlock()
+
+		return precompiles.Precompile{
+			OnStart: func(ctx context.Context, app *common.App) error {
+				// OnStart is called both when the node starts and when the
+				// extension is first used, so the listener may already exist.
+				_ = evmsync.EventSyncer.UnregisterListener(uniqueName)
+				return inst.startListener(uniqueName)
+			},
+			OnUse: func(ctx *common.EngineContext, app *common.App) error {
+				return evmsync.EventSyncer.RegisterNewTopic(ctx.TxContext.Ctx, app.DB, app.Engine, uniqueName, resolutionName)
+			},
+			OnUnuse: func(ctx *common.EngineContext, app *common.App) error {
+				if err := evmsync.EventSyncer.UnregisterListener(uniqueName); err != nil {
+					return err
+				}
+				if err := orderedsync.Synchronizer.UnregisterTopic(ctx.TxContext.Ctx, app.DB, app.Engine, uniqueName); err != nil {
+					return err
+				}
+
+				instances.mu.Lock()
+				delete(instances.m, uniqueName)
+				instances.mu.Unlock()
+				return nil
+			},
+			Methods: []precompiles.Method{
+				{
+					Name: "info",
+					Returns: &precompiles.MethodReturn{
+						Fields: []precompiles.PrecompileValue{
+							{Name: "chain", Type: types.TextType},
+							{Name: "contract", Type: types.TextType},
+							{Name: "event", Type: types.TextType},
+							{Name: "namespace", Type: types.TextType},
+							{Name: "action", Type: types.TextType},
+						},
+					},
+					Handler: func(ctx *common.EngineContext, app *common.App, inputs []any, resultFn func([]any) error) error {
+						return resultFn([]any{inst.chain.Name.String(), inst.contract.Hex(), inst.event.event.Sig, inst.namespace, inst.action})
+					},
+					AccessModifiers: []precompiles.Modifier{precompiles.PUBLIC, precompiles.VIEW},
+				},
+			},
+		}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	// the action is called in the namespace that USE was executed in, unless
+	// the namespace is given.
+	err = precompiles.RegisterNamespaceMetadata(ExtensionName, "namespace")
+	if err != nil {
+		panic(err)
+	}
+
+	evmsync.RegisterEventResolution(resolutionName, func(ctx context.Context, app *common.App, block *common.BlockContext, uniqueName string, logs []*evmsync.EthLog) error {
+		instances.mu.RLock()
+		inst, ok := instances.m[uniqueName]
+		instances.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("evm_events instance %s not found", uniqueName)
+		}
+
+		logger := app.Service.Logger.New(uniqueName)
+		for _, l := range logs {
+			if err := inst.apply(ctx, app, block, l, logger); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// newInstance reads the configuration of an instance from the metadata of a
+// USE statement.
+func newInstance(metadata map[string]any) (*instance, error) {
+	getString := func(key string, required bool) (string, error) {
+		v, ok := metadata[key]
+		if !ok {
+			if required {
+				return "", fmt.Errorf("missing required metadata field '%s'", key)
+			}
+			return "", nil
+		}
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("metadata field '%s' must be a string", key)
+		}
+		return s, nil
+	}
+
+	chainName, err := getString("chain", true)
+	if err != nil {
+		return nil, err
+	}
+	chainInfo, ok := chains.GetChainInfo(chains.Chain(strings.ToLower(chainName)))
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain %s", chainName)
+	}
+
+	contract, err := getString("contract", true)
+	if err != nil {
+		return nil, err
+	}
+	if !ethcommon.IsHexAddress(contract) {
+		return nil, fmt.Errorf("invalid contract address %s", contract)
+	}
+
+	sig, err := getString("abi_event", true)
+	if err != nil {
+		return nil, err
+	}
+	event, err := parseEventSig(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := getString("action", true)
+	if err != nil {
+		return nil, err
+	}
+	// the namespace is set to that of the USE statement if it is not given
+	namespace, err := getString("namespace", true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instance{
+		chain:     chainInfo,
+		contract:  ethcommon.HexToAddress(contract),
+		event:     event,
+		namespace: strings.ToLower(namespace),
+		action:    strings.ToLower(action),
+	}, nil
+}
+
+// listenerUniqueName returns the unique name of the listener and ordered-sync
+// topic of the instance with the given alias.
+func listenerUniqueName(alias string) string {
+	return ExtensionName + "_" + alias
+}
+
+// startListener starts syncing the event logs of the instance.
+func (i *instance) startListener(uniqueName string) error {
+	return evmsync.EventSyncer.RegisterNewListener(evmsync.EVMEventListenerConfig{
+		UniqueName: uniqueName,
+		Chain:      i.chain.Name,
+		GetLogs: func(ctx context.Context, client *ethclient.Client, startBlock, endBlock uint64, logger log.Logger) ([]*evmsync.EthLog, error) {
+			var logs []*evmsync.EthLog
+			err := utils.Retry(ctx, evmMaxRetries, func() error {
+				ethLogs, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+					FromBlock: new(big.Int).SetUint64(startBlock),
+					ToBlock:   new(big.Int).SetUint64(endBlock),
+					Addresses: []ethcommon.Address{i.contract},
+					Topics:    [][]ethcommon.Hash{{i.event.ID()}},
+				})
+				if err != nil {
+					return fmt.Errorf("failed to get %s logs: %w", i.event.event.Name, err)
+				}
+
+				logs = make([]*evmsync.EthLog, len(ethLogs))
+				for j := range ethLogs {
+					logs[j] = &evmsync.EthLog{Log: &ethLogs[j]}
+				}
+				return nil
+			})
+			return logs, err
+		},
+	})
+}
+
+// apply calls the instance's action for a log. A log that cannot be decoded
+// or an action that fails is logged and skipped, since it is caused by the
+// user's configuration and must not halt the network. Only database errors
+// are returned.
+func (i *instance) apply(ctx context.Context, app *common.App, block *common.BlockContext, l *evmsync.EthLog, logger log.Logger) error {
+	args, err := i.event.decode(l.Log)
+	if err != nil {
+		logger.Warn("skipping log that cannot be decoded", "tx", l.Log.TxHash, "index", l.Log.Index, "err", err)
+		return nil
+	}
+	args = append(args, int64(l.Log.BlockNumber), l.Log.TxHash.Bytes(), int64(l.Log.Index))
+
+	tx, err := app.DB.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := app.Engine.Call(&common.EngineContext{
+		TxContext: &common.TxContext{
+			Ctx:          ctx,
+			BlockContext: block,
+		},
+		OverrideAuthz: true,
+	}, tx, i.namespace, i.action, args, nil)
+	if err == nil && res.Error != nil {
+		err = res.Error
+	}
+	if err != nil {
+		logger.Warn("action failed, skipping log", "action", i.namespace+"."+i.action, "tx", l.Log.TxHash,
+			"index", l.Log.Index, "err", err)
+		return tx.Rollback(ctx)
+	}
+
+	return tx.Commit(ctx)
+}
@@ -1,21 +1,60 @@
 // package chains tracks the EVM chains that are supported by the node.
+//
+// Ethereum mainnet, Sepolia, and Base Sepolia are always supported. Other
+// chains are declared in the evm_chains section of the genesis config, and
+// registered with Register when the node starts. Since the declared chains are
+// part of the genesis app hash, they are fixed for the life of a network; a
+// chain cannot be added to a running network by a vote or a config change.
 package chains
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // ChainInfo is the information about a chain.
 type ChainInfo struct {
 	// Name is the name of the chain.
 	// It is case-insensitive and unique.
-	Name Chain
+	Name Chain `json:"name"`
 	// ID is the unique identifier of the chain.
 	// e.g. Ethereum mainnet is 1.
-	ID string
+	ID string `json:"id"`
 	// RequiredConfirmations is the number of confirmations required before an event is considered final.
 	// For example, Ethereum mainnet requires 12 confirmations.
-	RequiredConfirmations int64
+	RequiredConfirmations int64 `json:"required_confirmations"`
+}
+
+// Validate checks that the chain info is well formed. It does not check
+// whether the chain conflicts with a registered chain.
+func (c ChainInfo) Validate() error {
+	if c.Name == "" {
+		return errors.New("chain name is required")
+	}
+	if string(c.Name) != strings.ToLower(string(c.Name)) || strings.ContainsFunc(string(c.Name), isSpaceOrControl) {
+		return fmt.Errorf("chain name must be lowercase without spaces: %s", c.Name)
+	}
+
+	id, err := strconv.ParseUint(c.ID, 10, 64)
+	if err != nil || id == 0 {
+		return fmt.Errorf("chain ID must be a positive integer: %s", c.Name)
+	}
+	if strconv.FormatUint(id, 10) != c.ID {
+		return fmt.Errorf("chain ID must not have leading zeros: %s", c.Name)
+	}
+
+	if c.RequiredConfirmations < 1 {
+		return fmt.Errorf("required confirmations must be >= 1: %s", c.Name)
+	}
+
+	return nil
+}
+
+func isSpaceOrControl(r rune) bool {
+	return r <= ' ' || r == 0x7f
 }
 
 func init() {
@@ -53,33 +92,64 @@ func (c Chain) String() string {
 	return string(c)
 }
 
+// Valid returns an error if the chain is not registered.
 func (c Chain) Valid() error {
-	switch c {
-	case Ethereum, Sepolia, BaseSepolia:
-		return nil
-	default:
+	if _, ok := GetChainInfo(c); !ok {
 		return fmt.Errorf("invalid chain: %s", c)
 	}
+	return nil
 }
 
-var registeredChains = map[Chain]ChainInfo{}
-var chainIDs = map[string]Chain{}
+var (
+	// mu protects registeredChains and chainIDs. Chains are only registered
+	// at startup, but the registry is read concurrently by listeners.
+	mu               sync.RWMutex
+	registeredChains = map[Chain]ChainInfo{}
+	chainIDs         = map[string]Chain{}
+)
+
+// Register registers chains in addition to the built-in chains. It should be
+// called when the node starts, before the engine is built, with the chains
+// declared in the genesis config, so that all nodes on a network support the
+// same chains. Registering a chain that is already registered with identical
+// info is a no-op. Either all chains are registered, or none are.
+func Register(chains ...ChainInfo) error {
+	return registerChain(chains...)
+}
 
 func registerChain(chains ...ChainInfo) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	// validate all chains before registering any of them
+	names := make(map[Chain]ChainInfo, len(chains))
+	ids := make(map[string]Chain, len(chains))
 	for _, chain := range chains {
-		if err := chain.Name.Valid(); err != nil {
+		if err := chain.Validate(); err != nil {
 			return err
 		}
 
-		_, ok := registeredChains[chain.Name]
-		if ok {
+		existing, ok := registeredChains[chain.Name]
+		if !ok {
+			existing, ok = names[chain.Name]
+		}
+		if ok && existing != chain {
 			return fmt.Errorf("chain already registered: %s", chain.Name)
 		}
 
-		if chain.RequiredConfirmations < 1 {
-			return fmt.Errorf("required confirmations must be >= 1: %s", chain.Name)
+		name, ok := chainIDs[chain.ID]
+		if !ok {
+			name, ok = ids[chain.ID]
 		}
+		if ok && name != chain.Name {
+			return fmt.Errorf("chain ID %s of %s is already registered for %s", chain.ID, chain.Name, name)
+		}
+
+		names[chain.Name] = chain
+		ids[chain.ID] = chain.Name
+	}
 
+	for _, chain := range chains {
 		registeredChains[chain.Name] = chain
 		chainIDs[chain.ID] = chain.Name
 	}
@@ -89,12 +159,18 @@ func registerChain(chains ...ChainInfo) error {
 
 // GetChainInfo returns the chain information for the given chain.
 func GetChainInfo(name Chain) (ChainInfo, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	chain, ok := registeredChains[name]
 	return chain, ok
 }
 
 // GetChainInfoByID returns the chain information for the given chain ID.
 func GetChainInfoByID(id string) (ChainInfo, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	name, ok := chainIDs[id]
 	if !ok {
 		return ChainInfo{}, false
@@ -0,0 +1,64 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	anvil := ChainInfo{Name: "anvil-test", ID: "31337", RequiredConfirmations: 1}
+
+	require.Error(t, Chain("anvil-test").Valid())
+
+	require.NoError(t, Register(anvil))
+	require.NoError(t, Chain("anvil-test").Valid())
+
+	info, ok := GetChainInfoByID("31337")
+	require.True(t, ok)
+	require.Equal(t, anvil, info)
+
+	// registering the same chain again is a no-op
+	require.NoError(t, Register(anvil))
+
+	// conflicting names and IDs are rejected
+	require.Error(t, Register(ChainInfo{Name: "anvil-test", ID: "31338", RequiredConfirmations: 1}))
+	require.Error(t, Register(ChainInfo{Name: "ethereum", ID: "1", RequiredConfirmations: 1}))
+	require.Error(t, Register(ChainInfo{Name: "not-ethereum", ID: "1", RequiredConfirmations: 12}))
+
+	// a failed registration registers nothing
+	require.Error(t, Register(
+		ChainInfo{Name: "arbitrum-test", ID: "42161", RequiredConfirmations: 1},
+		ChainInfo{Name: "arbitrum-test-2", ID: "42161", RequiredConfirmations: 1},
+	))
+	require.Error(t, Chain("arbitrum-test").Valid())
+}
+
+func TestChainInfoValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    ChainInfo
+		wantErr bool
+	}{
+		{"valid", ChainInfo{Name: "polygon", ID: "137", RequiredConfirmations: 128}, false},
+		{"no name", ChainInfo{ID: "137", RequiredConfirmations: 1}, true},
+		{"uppercase name", ChainInfo{Name: "Polygon", ID: "137", RequiredConfirmations: 1}, true},
+		{"space in name", ChainInfo{Name: "my chain", ID: "137", RequiredConfirmations: 1}, true},
+		{"no id", ChainInfo{Name: "polygon", RequiredConfirmations: 1}, true},
+		{"zero id", ChainInfo{Name: "polygon", ID: "0", RequiredConfirmations: 1}, true},
+		{"non-numeric id", ChainInfo{Name: "polygon", ID: "0x89", RequiredConfirmations: 1}, true},
+		{"leading zero id", ChainInfo{Name: "polygon", ID: "0137", RequiredConfirmations: 1}, true},
+		{"no confirmations", ChainInfo{Name: "polygon", ID: "137"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.info.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
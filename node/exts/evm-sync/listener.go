@@ -121,7 +121,7 @@ func getChainConf(cfg config.ERC20BridgeConfig, chain chains.Chain) (*chainConfi
 		return nil, fmt.Errorf("local configuration does not have an '%s' config", chain.String())
 	}
 
-	syncChunk, ok := cfg.BlockSyncChuckSize[chains.Ethereum.String()]
+	syncChunk, ok := cfg.BlockSyncChuckSize[chain.String()]
 	if !ok {
 		syncChunk = "1000000"
 	}
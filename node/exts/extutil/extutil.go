@@ -0,0 +1,71 @@
+// Package extutil contains helpers that are shared by the precompiles that
+// store their state in a namespace of their own, such as schedule and
+// sequence.
+package extutil
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/node/engine"
+)
+
+// NamespaceExists reports whether a namespace exists, by reading from a table
+// that is created with the namespace.
+func NamespaceExists(ctx context.Context, app *common.App, namespace, table string) (bool, error) {
+	err := app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `{`+namespace+`}SELECT 1 FROM `+table+` LIMIT 1`, nil, nil)
+	switch {
+	case errors.Is(err, engine.ErrNamespaceNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+// EnsureNamespace creates a namespace and executes its schema if the
+// namespace does not exist. The table is one that the schema creates, and is
+// used to check whether the namespace exists.
+func EnsureNamespace(ctx context.Context, app *common.App, namespace, table, schema string) error {
+	exists, err := NamespaceExists(ctx, app, namespace, table)
+	if err != nil || exists {
+		return err
+	}
+
+	err = app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `CREATE NAMESPACE `+namespace, nil, nil)
+	if err != nil {
+		return err
+	}
+	return app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, schema, nil, nil)
+}
+
+// Int converts a metadata value of a USE statement to an integer. Integers
+// may be given as integer literals or as strings.
+func Int(v any) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, errors.New("must be an integer")
+		}
+		return n, nil
+	default:
+		return 0, errors.New("must be an integer")
+	}
+}
+
+// PositiveInt is like Int, but also requires the integer to be positive.
+func PositiveInt(v any) (int64, error) {
+	n, err := Int(v)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, errors.New("must be positive")
+	}
+	return n, nil
+}
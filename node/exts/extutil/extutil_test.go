@@ -0,0 +1,31 @@
+package extutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInt(t *testing.T) {
+	for _, v := range []any{int64(-10), "-10"} {
+		n, err := Int(v)
+		require.NoError(t, err)
+		require.Equal(t, int64(-10), n)
+	}
+
+	for _, v := range []any{"ten", "1.5", true, nil} {
+		_, err := Int(v)
+		require.Error(t, err, v)
+	}
+}
+
+func TestPositiveInt(t *testing.T) {
+	n, err := PositiveInt("86400")
+	require.NoError(t, err)
+	require.Equal(t, int64(86400), n)
+
+	for _, v := range []any{int64(0), "-1", "daily"} {
+		_, err := PositiveInt(v)
+		require.Error(t, err, v)
+	}
+}
@@ -0,0 +1,140 @@
+package httporacle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a parsed JSONPath expression. Only the subset of JSONPath that
+// selects a single value is supported: the root ($), child members (.name,
+// ['name'] or ["name"]) and array indexes ([0], or [-1] for the last element).
+type jsonPath []pathStep
+
+// pathStep is a single step of a path. It is either an object member or an
+// array index.
+type pathStep struct {
+	member  string
+	index   int
+	isIndex bool
+}
+
+func (s pathStep) String() string {
+	if s.isIndex {
+		return "[" + strconv.Itoa(s.index) + "]"
+	}
+	return "." + s.member
+}
+
+// parseJSONPath parses a JSONPath expression.
+func parseJSONPath(path string) (jsonPath, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", path)
+	}
+
+	var steps jsonPath
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			if name == "" || name == "*" {
+				return nil, fmt.Errorf("invalid JSONPath %q: expected member name after '.'", path)
+			}
+			steps = append(steps, pathStep{member: name})
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unclosed '['", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, pathStep{member: inner[1 : len(inner)-1]})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSONPath %q: unsupported selector [%s]", path, inner)
+			}
+			steps = append(steps, pathStep{index: idx, isIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", path, rest[0])
+		}
+	}
+
+	return steps, nil
+}
+
+// get returns the value at the path in a document decoded by encoding/json.
+func (p jsonPath) get(doc any) (any, error) {
+	v := doc
+	for i, step := range p {
+		switch node := v.(type) {
+		case map[string]any:
+			if step.isIndex {
+				return nil, fmt.Errorf("%s: cannot index an object", p[:i+1])
+			}
+			child, ok := node[step.member]
+			if !ok {
+				return nil, fmt.Errorf("%s: member not found", p[:i+1])
+			}
+			v = child
+		case []any:
+			if !step.isIndex {
+				return nil, fmt.Errorf("%s: cannot select a member of an array", p[:i+1])
+			}
+			idx := step.index
+			if idx < 0 {
+				idx += len(node)
+			}
+			if idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("%s: index out of range", p[:i+1])
+			}
+			v = node[idx]
+		default:
+			return nil, fmt.Errorf("%s: not an object or array", p[:i+1])
+		}
+	}
+	return v, nil
+}
+
+func (p jsonPath) String() string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, step := range p {
+		sb.WriteString(step.String())
+	}
+	return sb.String()
+}
+
+// stringValue returns the text of a value selected from a JSON document, as
+// it is submitted for voting. Numbers keep the text they have in the
+// document, and objects and arrays are encoded as compact JSON.
+func stringValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", errors.New("value is null")
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
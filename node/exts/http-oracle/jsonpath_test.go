@@ -0,0 +1,67 @@
+package httporacle
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPath(t *testing.T) {
+	const doc = `{
+		"bitcoin": {"usd": 64123.55},
+		"data": [{"price": "1.01"}, {"price": "1.02", "ok": true}],
+		"odd key": {"x.y": null},
+		"big": 123456789012345678901234567890
+	}`
+
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	var v any
+	require.NoError(t, dec.Decode(&v))
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "$.bitcoin.usd", want: "64123.55"},
+		{path: "$['bitcoin'][\"usd\"]", want: "64123.55"},
+		{path: "$.data[0].price", want: "1.01"},
+		{path: "$.data[-1].ok", want: "true"},
+		{path: "$.data[1]", want: `{"ok":true,"price":"1.02"}`},
+		{path: "$.big", want: "123456789012345678901234567890"},
+		{path: " $.bitcoin ", want: `{"usd":64123.55}`},
+		{path: "$['odd key']['x.y']", wantErr: true}, // null
+		{path: "$.missing", wantErr: true},
+		{path: "$.data[2]", wantErr: true},
+		{path: "$.data.price", wantErr: true},
+		{path: "$.bitcoin[0]", wantErr: true},
+		{path: "$.bitcoin.usd.x", wantErr: true},
+		{path: "bitcoin.usd", wantErr: true},
+		{path: "$..usd", wantErr: true},
+		{path: "$.data[*]", wantErr: true},
+		{path: "$.data[0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := parseJSONPath(tt.path)
+			if err == nil {
+				var got any
+				got, err = path.get(v)
+				if err == nil {
+					var s string
+					s, err = stringValue(got)
+					if err == nil {
+						require.False(t, tt.wantErr, "expected an error, got %q", s)
+						require.Equal(t, tt.want, s)
+						return
+					}
+				}
+			}
+			require.True(t, tt.wantErr, "unexpected error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,417 @@
+// package httporacle implements the http_oracle listener, which polls HTTP
+// endpoints that return JSON, and calls an action with the value that the
+// network agrees on.
+//
+// Each feed is configured in its own extension section of the node config,
+// named http_oracle_ followed by the name of the feed:
+//
+//	[extensions.http_oracle_btc_usd]
+//	url = "https://api.example.com/price?ids=bitcoin"
+//	path = "$.bitcoin.usd"
+//	action = "set_btc_price"
+//	interval = "1m"
+//	aggregate = "median"
+//
+// The keys are:
+//   - url: the URL that is requested with GET. Required.
+//   - path: a JSONPath expression that selects the value from the response.
+//     Only members and array indexes are supported, e.g. $.data[0].price.
+//     Required.
+//   - action: the action that is called with the agreed value. Required.
+//   - namespace: the namespace of the action. Defaults to "main".
+//   - interval: how often the URL is polled. Defaults to 1m.
+//   - timeout: the timeout of each request. Defaults to 10s.
+//   - aggregate: how the values of the validators are combined, either
+//     "mode" (the value submitted by the most power) or "median" (the
+//     weighted median of numeric values). Defaults to "mode".
+//
+// Time is divided into rounds of the feed's interval, and each validator
+// submits the value it polled in a round as an aggregated resolution (see
+// extensions/resolutions). All validators must configure a feed identically
+// for their votes to be counted together. Once validators with enough power
+// have voted in a round, the action is called by the network with the feed
+// name ($text), the aggregated value ($text) and the round's start as a UNIX
+// timestamp ($int8). The action is called bypassing access modifiers, so it
+// should be SYSTEM to prevent users from calling it directly.
+package httporacle
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/listeners"
+	"github.com/kwilteam/kwil-db/extensions/resolutions"
+	"github.com/kwilteam/kwil-db/node/exts/poll"
+)
+
+const (
+	// ListenerName is the name of the listener.
+	ListenerName = "http_oracle"
+	// feedPrefix is the prefix of the extension config sections of feeds.
+	feedPrefix = ListenerName + "_"
+
+	// ModeResolution and MedianResolution are the resolution types of
+	// feeds that aggregate their values by mode and median.
+	ModeResolution   = "http_oracle_mode"
+	MedianResolution = "http_oracle_median"
+
+	defaultInterval = time.Minute
+	defaultTimeout  = 10 * time.Second
+	// maxResponseSize is the maximum size of a response body that is read.
+	maxResponseSize = 1 << 20
+	// maxValueSize is the maximum size of a value that is submitted, since
+	// validators pay for the size of their vote bodies.
+	maxValueSize = 1024
+)
+
+func init() {
+	err := listeners.RegisterListener(ListenerName, Start)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, res := range []struct {
+		name      string
+		aggregate resolutions.AggregateFunc
+	}{
+		{ModeResolution, resolutions.Mode},
+		{MedianResolution, resolutions.Median},
+	} {
+		err = resolutions.RegisterResolution(res.name, resolutions.ModAdd, resolutions.ResolutionConfig{
+			ConfirmationThreshold: big.NewRat(2, 3),
+			RefundThreshold:       big.NewRat(1, 3),
+			ExpirationPeriod:      time.Hour,
+			ResolveFunc:           resolve,
+			Aggregate:             res.aggregate,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Start starts polling all configured feeds. It returns once the context is
+// canceled, or if a feed fails to store its values.
+func Start(ctx context.Context, service *common.Service, eventStore listeners.EventStore) error {
+	feeds, err := parseFeeds(service.LocalConfig.Extensions)
+	if err != nil {
+		return fmt.Errorf("invalid http_oracle configuration: %w", err)
+	}
+	if len(feeds) == 0 {
+		service.Logger.Debug("no http_oracle feeds configured")
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(feeds))
+	for i, f := range feeds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger := service.Logger.New(feedPrefix + f.name)
+			errs[i] = poll.NewPoller(f.interval, func(ctx context.Context, service *common.Service, eventstore listeners.EventStore) (poll.PollFunc, error) {
+				return func(ctx context.Context, service *common.Service, eventstore listeners.EventStore) (bool, error) {
+					value, round, err := f.poll(ctx, time.Now())
+					if err != nil {
+						logger.Warn("failed to poll feed", "url", f.url, "error", err)
+						return false, nil
+					}
+					if value == nil {
+						return false, nil // already polled this round
+					}
+					return false, f.broadcast(ctx, eventstore, value, round)
+				}, nil
+			})(ctx, service, eventStore)
+			if errs[i] != nil {
+				cancel() // stop the other feeds
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("feed %s: %w", feeds[i].name, err)
+		}
+	}
+	return nil
+}
+
+// feed is a configured feed.
+type feed struct {
+	name      string
+	url       string
+	path      jsonPath
+	namespace string
+	action    string
+	interval  time.Duration
+	timeout   time.Duration
+	// resolutionType is the resolution type that determines how the values
+	// of the validators are aggregated.
+	resolutionType string
+	client         *http.Client
+
+	// lastRound is the last round in which a value was submitted. A
+	// validator must submit a single value per round, otherwise its votes
+	// are ignored.
+	lastRound int64
+}
+
+// parseFeeds reads the feeds from the extension sections of the node config.
+// Feeds are returned ordered by name.
+func parseFeeds(exts map[string]map[string]string) ([]*feed, error) {
+	var feeds []*feed
+	for section, cfg := range exts {
+		name, ok := strings.CutPrefix(section, feedPrefix)
+		if !ok {
+			continue
+		}
+		f, err := newFeed(name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("feed %s: %w", name, err)
+		}
+		feeds = append(feeds, f)
+	}
+
+	slices.SortFunc(feeds, func(a, b *feed) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return feeds, nil
+}
+
+func newFeed(name string, cfg map[string]string) (*feed, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing feed name in section %q", feedPrefix)
+	}
+
+	f := &feed{
+		name:           name,
+		url:            cfg["url"],
+		namespace:      "main",
+		action:         strings.ToLower(cfg["action"]),
+		interval:       defaultInterval,
+		timeout:        defaultTimeout,
+		resolutionType: ModeResolution,
+		client:         http.DefaultClient,
+	}
+
+	if f.url == "" {
+		return nil, errors.New("url is required")
+	}
+	if !strings.HasPrefix(f.url, "http://") && !strings.HasPrefix(f.url, "https://") {
+		return nil, fmt.Errorf("url must be http or https: %s", f.url)
+	}
+
+	pathStr, ok := cfg["path"]
+	if !ok {
+		return nil, errors.New("path is required")
+	}
+	path, err := parseJSONPath(pathStr)
+	if err != nil {
+		return nil, err
+	}
+	f.path = path
+
+	if f.action == "" {
+		return nil, errors.New("action is required")
+	}
+	if ns, ok := cfg["namespace"]; ok && ns != "" {
+		f.namespace = strings.ToLower(ns)
+	}
+
+	for key, dst := range map[string]*time.Duration{"interval": &f.interval, "timeout": &f.timeout} {
+		s, ok := cfg[key]
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("%s must be at least 1s", key)
+		}
+		*dst = d
+	}
+
+	switch agg := strings.ToLower(cfg["aggregate"]); agg {
+	case "", "mode":
+	case "median":
+		f.resolutionType = MedianResolution
+	default:
+		return nil, fmt.Errorf("unknown aggregate %q, expected mode or median", agg)
+	}
+
+	return f, nil
+}
+
+// poll fetches the value of the feed for the round that now is in. It
+// returns a nil value if a value was already fetched in the round.
+func (f *feed) poll(ctx context.Context, now time.Time) (value []byte, round int64, err error) {
+	secs := int64(f.interval / time.Second)
+	round = now.Unix() - now.Unix()%secs
+	if round == f.lastRound {
+		return nil, round, nil
+	}
+
+	v, err := f.fetch(ctx)
+	if err != nil {
+		return nil, round, err
+	}
+	f.lastRound = round
+	return []byte(v), round, nil
+}
+
+// fetch requests the feed's URL, and returns the value selected by its path.
+func (f *feed) fetch(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	dec := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize))
+	dec.UseNumber()
+	var doc any
+	if err = dec.Decode(&doc); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	v, err := f.path.get(doc)
+	if err != nil {
+		return "", err
+	}
+	s, err := stringValue(v)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", f.path, err)
+	}
+	if len(s) > maxValueSize {
+		return "", fmt.Errorf("%s: value is larger than %d bytes", f.path, maxValueSize)
+	}
+	return s, nil
+}
+
+// broadcast stores the value polled in a round for broadcast to the network.
+func (f *feed) broadcast(ctx context.Context, eventStore listeners.EventStore, value []byte, round int64) error {
+	key, err := (&feedKey{
+		Feed:      f.name,
+		Namespace: f.namespace,
+		Action:    f.action,
+		Round:     round,
+	}).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	body, err := (&resolutions.Outcome{Key: key, Value: value}).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return eventStore.Broadcast(ctx, f.resolutionType, body)
+}
+
+// feedKey is the key of the aggregated resolution of a feed's round. It
+// identifies the action to call, so that it is agreed on by the network.
+type feedKey struct {
+	Feed      string
+	Namespace string
+	Action    string
+	Round     int64
+}
+
+const feedKeyVersion = 0
+
+func (k *feedKey) MarshalBinary() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := binary.Write(buf, binary.BigEndian, uint16(feedKeyVersion)); err != nil {
+		return nil, err
+	}
+	for _, s := range []string{k.Feed, k.Namespace, k.Action} {
+		if err := types.WriteString(buf, s); err != nil {
+			return nil, err
+		}
+	}
+	if err := binary.Write(buf, binary.BigEndian, k.Round); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (k *feedKey) UnmarshalBinary(b []byte) error {
+	buf := bytes.NewBuffer(b)
+	var version uint16
+	if err := binary.Read(buf, binary.BigEndian, &version); err != nil {
+		return err
+	}
+	if version != feedKeyVersion {
+		return fmt.Errorf("invalid feed key version: %d", version)
+	}
+
+	var err error
+	for _, s := range []*string{&k.Feed, &k.Namespace, &k.Action} {
+		if *s, err = types.ReadString(buf); err != nil {
+			return err
+		}
+	}
+	if err = binary.Read(buf, binary.BigEndian, &k.Round); err != nil {
+		return err
+	}
+	if buf.Len() != 0 {
+		return errors.New("unexpected trailing data in feed key")
+	}
+	return nil
+}
+
+// resolve calls the action of a feed with the aggregated value of a round.
+func resolve(ctx context.Context, app *common.App, resolution *resolutions.Resolution, block *common.BlockContext) error {
+	var outcome resolutions.Outcome
+	if err := outcome.UnmarshalBinary(resolution.Body); err != nil {
+		return err
+	}
+	var key feedKey
+	if err := key.UnmarshalBinary(outcome.Key); err != nil {
+		return err
+	}
+
+	res, err := app.Engine.Call(&common.EngineContext{
+		TxContext: &common.TxContext{
+			Ctx:          ctx,
+			BlockContext: block,
+		},
+		OverrideAuthz: true,
+	}, app.DB, key.Namespace, key.Action, []any{key.Feed, string(outcome.Value), key.Round}, nil)
+	if err != nil {
+		return err
+	}
+	return res.Error
+}
@@ -0,0 +1,147 @@
+package httporacle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/resolutions"
+)
+
+type mockEventStore struct {
+	events []*types.VotableEvent
+}
+
+func (m *mockEventStore) Broadcast(ctx context.Context, eventType string, data []byte) error {
+	m.events = append(m.events, &types.VotableEvent{Type: eventType, Body: data})
+	return nil
+}
+
+func (m *mockEventStore) Set(ctx context.Context, key []byte, value []byte) error { return nil }
+
+func (m *mockEventStore) Get(ctx context.Context, key []byte) ([]byte, error) { return nil, nil }
+
+func (m *mockEventStore) Delete(ctx context.Context, key []byte) error { return nil }
+
+func TestParseFeeds(t *testing.T) {
+	feeds, err := parseFeeds(map[string]map[string]string{
+		"erc20_bridge": {"rpc": "ignored"},
+		"http_oracle_eth_usd": {
+			"url":       "https://example.com/eth",
+			"path":      "$.eth",
+			"action":    "Set_Price",
+			"namespace": "Prices",
+			"interval":  "30s",
+			"aggregate": "median",
+		},
+		"http_oracle_btc_usd": {
+			"url":    "http://example.com/btc",
+			"path":   "$.btc",
+			"action": "set_price",
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, feeds, 2)
+
+	btc, eth := feeds[0], feeds[1]
+	require.Equal(t, "btc_usd", btc.name)
+	require.Equal(t, "main", btc.namespace)
+	require.Equal(t, defaultInterval, btc.interval)
+	require.Equal(t, ModeResolution, btc.resolutionType)
+
+	require.Equal(t, "eth_usd", eth.name)
+	require.Equal(t, "prices", eth.namespace)
+	require.Equal(t, "set_price", eth.action)
+	require.Equal(t, 30*time.Second, eth.interval)
+	require.Equal(t, MedianResolution, eth.resolutionType)
+
+	valid := map[string]string{"url": "https://example.com", "path": "$.x", "action": "a"}
+	for key, value := range map[string]string{
+		"url":       "ftp://example.com",
+		"path":      "x",
+		"action":    "",
+		"interval":  "10ms",
+		"timeout":   "soon",
+		"aggregate": "mean",
+	} {
+		cfg := make(map[string]string)
+		for k, v := range valid {
+			cfg[k] = v
+		}
+		cfg[key] = value
+		_, err := parseFeeds(map[string]map[string]string{"http_oracle_x": cfg})
+		require.Error(t, err, key)
+	}
+}
+
+func TestFeedPoll(t *testing.T) {
+	price := `{"data": {"price": 101.5}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Accept"))
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(price))
+	}))
+	defer srv.Close()
+
+	f, err := newFeed("price", map[string]string{
+		"url":    srv.URL + "/price",
+		"path":   "$.data.price",
+		"action": "set_price",
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Unix(1_699_999_980+30, 0)
+
+	value, round, err := f.poll(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, "101.5", string(value))
+	require.Equal(t, int64(1_699_999_980), round)
+
+	// a single value is submitted per round
+	value, _, err = f.poll(ctx, now.Add(10*time.Second))
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	price = `{"data": {"price": 102}}`
+	value, round, err = f.poll(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "102", string(value))
+	require.Equal(t, int64(1_700_000_040), round)
+
+	store := &mockEventStore{}
+	require.NoError(t, f.broadcast(ctx, store, value, round))
+	require.Len(t, store.events, 1)
+	require.Equal(t, ModeResolution, store.events[0].Type)
+
+	var outcome resolutions.Outcome
+	require.NoError(t, outcome.UnmarshalBinary(store.events[0].Body))
+	require.Equal(t, "102", string(outcome.Value))
+
+	var key feedKey
+	require.NoError(t, key.UnmarshalBinary(outcome.Key))
+	require.Equal(t, feedKey{Feed: "price", Namespace: "main", Action: "set_price", Round: round}, key)
+
+	// errors are returned, and do not use up the round
+	f.url = srv.URL + "/missing"
+	_, _, err = f.poll(ctx, now.Add(2*time.Minute))
+	require.Error(t, err)
+	f.url = srv.URL + "/price"
+	value, _, err = f.poll(ctx, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "102", string(value))
+
+	f.path, err = parseJSONPath("$.data.volume")
+	require.NoError(t, err)
+	_, _, err = f.poll(ctx, now.Add(3*time.Minute))
+	require.Error(t, err)
+}
@@ -0,0 +1,407 @@
+// package schedule implements the schedule precompile, which calls an action
+// periodically at the end of blocks.
+//
+// It is used as:
+//
+//	USE schedule {
+//		action: 'payout',
+//		every_blocks: 7200
+//	} AS daily_payout;
+//
+// or, based on the block timestamp:
+//
+//	USE schedule {
+//		namespace: 'rewards',
+//		action: 'payout',
+//		every_seconds: 86400
+//	} AS daily_payout;
+//
+// Exactly one of every_blocks and every_seconds must be set. The namespace
+// defaults to "main". The first run is one period after the schedule is
+// created, and each run is at the end of the first block that is at least one
+// period after the previous run. Schedules are run in order of their names,
+// after the transactions of the block and before resolutions are processed.
+//
+// The action is called with no arguments by the network, bypassing access
+// modifiers, so it should be SYSTEM to prevent users from calling it directly.
+// It is called with an empty @caller. If the action fails, its changes are
+// rolled back, the error is recorded, and it is run again in the next period.
+// Schedules are removed with UNUSE.
+//
+// Each run is recorded with its height, block timestamp, error, and the logs of
+// the action, like the result of a transaction. The last 100 runs of a
+// schedule can be read with the runs method, e.g. daily_payout.runs(10), and
+// its status with the status method, e.g. daily_payout.status().
+//
+// Schedules are configured with USE rather than dedicated DDL such as CREATE
+// SCHEDULE, so that no grammar changes are needed. They are stored in the
+// kwil_schedule namespace, since the info namespace is a read-only view of the
+// engine's catalog. Scheduled actions have no gas budget, since the engine
+// does not meter action execution; transactions do not either.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/hooks"
+	"github.com/kwilteam/kwil-db/extensions/precompiles"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/exts/extutil"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+const (
+	// ExtensionName is the name used to USE the precompile.
+	ExtensionName = "schedule"
+	// schemaName is the namespace that stores the schedules.
+	schemaName = "kwil_schedule"
+	// maxRunHistory is the number of runs of each schedule that are kept.
+	maxRunHistory = 100
+)
+
+// schema creates the tables of schedules and their recent runs. A schedule's
+// last run is initialized to when it was created.
+var schema = `SET CURRENT NAMESPACE TO ` + schemaName + `;
+
+CREATE TABLE jobs (
+	name TEXT PRIMARY KEY,
+	namespace TEXT NOT NULL,
+	action TEXT NOT NULL,
+	every_blocks INT8,
+	every_seconds INT8,
+	last_height INT8 NOT NULL,
+	last_time INT8 NOT NULL,
+	runs INT8 NOT NULL DEFAULT 0,
+	last_error TEXT
+);
+
+CREATE TABLE runs (
+	name TEXT NOT NULL REFERENCES jobs(name) ON DELETE CASCADE,
+	run INT8 NOT NULL,
+	height INT8 NOT NULL,
+	time INT8 NOT NULL,
+	error TEXT,
+	logs TEXT NOT NULL,
+	PRIMARY KEY (name, run)
+);`
+
+func init() {
+	err := precompiles.RegisterInitializer(ExtensionName, func(ctx context.Context, service *common.Service,
+		db sql.DB, alias string, metadata map[string]any) (precompiles.Precompile, error) {
+		j, err := newJob(alias, metadata)
+		if err != nil {
+			return precompiles.Precompile{}, err
+		}
+
+		return precompiles.Precompile{
+			OnUse: func(ctx *common.EngineContext, app *common.App) error {
+				if err := extutil.EnsureNamespace(ctx.TxContext.Ctx, app, schemaName, "jobs", schema); err != nil {
+					return err
+				}
+				return j.create(ctx.TxContext.Ctx, app, ctx.TxContext.BlockContext)
+			},
+			OnUnuse: func(ctx *common.EngineContext, app *common.App) error {
+				return app.Engine.ExecuteWithoutEngineCtx(ctx.TxContext.Ctx, app.DB,
+					`{`+schemaName+`}DELETE FROM jobs WHERE name = $name`, map[string]any{"name": j.name}, nil)
+			},
+			Methods: []precompiles.Method{
+				{
+					Name: "status",
+					Returns: &precompiles.MethodReturn{
+						Fields: []precompiles.PrecompileValue{
+							{Name: "namespace", Type: types.TextType},
+							{Name: "action", Type: types.TextType},
+							{Name: "every_blocks", Type: types.IntType, Nullable: true},
+							{Name: "every_seconds", Type: types.IntType, Nullable: true},
+							{Name: "last_height", Type: types.IntType},
+							{Name: "last_time", Type: types.IntType},
+							{Name: "runs", Type: types.IntType},
+							{Name: "last_error", Type: types.TextType, Nullable: true},
+						},
+					},
+					Handler: func(ctx *common.EngineContext, app *common.App, inputs []any, resultFn func([]any) error) error {
+						return app.Engine.ExecuteWithoutEngineCtx(ctx.TxContext.Ctx, app.DB,
+							`{`+schemaName+`}SELECT namespace, action, every_blocks, every_seconds, last_height, last_time, runs, last_error
+							FROM jobs WHERE name = $name`, map[string]any{"name": j.name}, func(r *common.Row) error {
+								return resultFn(r.Values)
+							})
+					},
+					AccessModifiers: []precompiles.Modifier{precompiles.PUBLIC, precompiles.VIEW},
+				},
+				{
+					Name: "runs",
+					Parameters: []precompiles.PrecompileValue{
+						{Name: "limit", Type: types.IntType},
+					},
+					Returns: &precompiles.MethodReturn{
+						IsTable: true,
+						Fields: []precompiles.PrecompileValue{
+							{Name: "run", Type: types.IntType},
+							{Name: "height", Type: types.IntType},
+							{Name: "time", Type: types.IntType},
+							{Name: "error", Type: types.TextType, Nullable: true},
+							{Name: "logs", Type: types.TextType},
+						},
+					},
+					Handler: func(ctx *common.EngineContext, app *common.App, inputs []any, resultFn func([]any) error) error {
+						return app.Engine.ExecuteWithoutEngineCtx(ctx.TxContext.Ctx, app.DB,
+							`{`+schemaName+`}SELECT run, height, time, error, logs FROM runs
+							WHERE name = $name ORDER BY run DESC LIMIT $limit`, map[string]any{
+								"name":  j.name,
+								"limit": inputs[0],
+							}, func(r *common.Row) error {
+								return resultFn(r.Values)
+							})
+					},
+					AccessModifiers: []precompiles.Modifier{precompiles.PUBLIC, precompiles.VIEW},
+				},
+			},
+		}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = hooks.RegisterEndBlockHook(ExtensionName+"_end_block_hook", runDue)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// job is a scheduled action call.
+type job struct {
+	name      string
+	namespace string
+	action    string
+	// exactly one of everyBlocks and everySeconds is set.
+	everyBlocks  *int64
+	everySeconds *int64
+
+	lastHeight int64
+	lastTime   int64
+	runs       int64
+}
+
+// newJob reads the configuration of a schedule from the metadata of a USE
+// statement.
+func newJob(alias string, metadata map[string]any) (*job, error) {
+	j := &job{name: alias, namespace: "main"}
+
+	for key, v := range metadata {
+		switch key {
+		case "namespace", "action":
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("metadata field '%s' must be a string", key)
+			}
+			if key == "namespace" {
+				j.namespace = strings.ToLower(s)
+			} else {
+				j.action = strings.ToLower(s)
+			}
+		case "every_blocks", "every_seconds":
+			n, err := extutil.PositiveInt(v)
+			if err != nil {
+				return nil, fmt.Errorf("metadata field '%s': %w", key, err)
+			}
+			if key == "every_blocks" {
+				j.everyBlocks = &n
+			} else {
+				j.everySeconds = &n
+			}
+		default:
+			return nil, fmt.Errorf("unknown metadata field '%s'", key)
+		}
+	}
+
+	if j.action == "" {
+		return nil, errors.New("missing required metadata field 'action'")
+	}
+	if (j.everyBlocks == nil) == (j.everySeconds == nil) {
+		return nil, errors.New("exactly one of 'every_blocks' and 'every_seconds' must be set")
+	}
+
+	return j, nil
+}
+
+// due reports whether the job should run in a block.
+func (j *job) due(block *common.BlockContext) bool {
+	if j.everyBlocks != nil {
+		return block.Height-j.lastHeight >= *j.everyBlocks
+	}
+	return block.Timestamp-j.lastTime >= *j.everySeconds
+}
+
+// create stores a new schedule, with its last run set to the current block.
+func (j *job) create(ctx context.Context, app *common.App, block *common.BlockContext) error {
+	var height, timestamp int64
+	if block != nil {
+		height, timestamp = block.Height, block.Timestamp
+	}
+
+	return app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `{`+schemaName+`}INSERT INTO jobs
+		(name, namespace, action, every_blocks, every_seconds, last_height, last_time)
+		VALUES ($name, $namespace, $action, $every_blocks, $every_seconds, $height, $time)`, map[string]any{
+		"name":          j.name,
+		"namespace":     j.namespace,
+		"action":        j.action,
+		"every_blocks":  j.everyBlocks,
+		"every_seconds": j.everySeconds,
+		"height":        height,
+		"time":          timestamp,
+	}, nil)
+}
+
+// runDue is an EndBlockHook that runs the schedules that are due.
+func runDue(ctx context.Context, app *common.App, block *common.BlockContext) error {
+	var jobs []*job
+	err := app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `{`+schemaName+`}SELECT name, namespace, action,
+		every_blocks, every_seconds, last_height, last_time, runs FROM jobs ORDER BY name`, nil, func(r *common.Row) error {
+		j, err := jobFromRow(r.Values)
+		if err != nil {
+			return err
+		}
+		if j.due(block) {
+			jobs = append(jobs, j)
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, engine.ErrNamespaceNotFound):
+		return nil // no schedule has been created
+	case err != nil:
+		return err
+	}
+
+	for _, j := range jobs {
+		if err = j.run(ctx, app, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func jobFromRow(vals []any) (*job, error) {
+	if len(vals) != 8 {
+		return nil, fmt.Errorf("expected 8 values, got %d", len(vals))
+	}
+
+	j := &job{}
+	var ok bool
+	for i, dst := range []*string{&j.name, &j.namespace, &j.action} {
+		if *dst, ok = vals[i].(string); !ok {
+			return nil, fmt.Errorf("unexpected type %T for column %d", vals[i], i)
+		}
+	}
+	for i, dst := range []**int64{&j.everyBlocks, &j.everySeconds} {
+		if vals[3+i] == nil {
+			continue
+		}
+		n, ok := vals[3+i].(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type %T for column %d", vals[3+i], 3+i)
+		}
+		*dst = &n
+	}
+	for i, dst := range []*int64{&j.lastHeight, &j.lastTime, &j.runs} {
+		if *dst, ok = vals[5+i].(int64); !ok {
+			return nil, fmt.Errorf("unexpected type %T for column %d", vals[5+i], 5+i)
+		}
+	}
+	if (j.everyBlocks == nil) == (j.everySeconds == nil) {
+		return nil, fmt.Errorf("schedule %s has an invalid period", j.name)
+	}
+	return j, nil
+}
+
+// run calls the job's action in a nested transaction, and records the run.
+// An action that fails is rolled back and its error is recorded. Only
+// database errors are returned.
+func (j *job) run(ctx context.Context, app *common.App, block *common.BlockContext) error {
+	tx, err := app.DB.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := app.Engine.Call(&common.EngineContext{
+		TxContext: &common.TxContext{
+			Ctx:          ctx,
+			BlockContext: block,
+		},
+		OverrideAuthz: true,
+	}, tx, j.namespace, j.action, nil, nil)
+	if err == nil && res.Error != nil {
+		err = res.Error
+	}
+
+	var logs string
+	if res != nil {
+		logs = res.FormatLogs()
+	}
+
+	var runError *string
+	if err != nil {
+		app.Service.Logger.Warn("scheduled action failed", "schedule", j.name, "action", j.namespace+"."+j.action,
+			"height", block.Height, "err", err)
+		msg := err.Error()
+		runError = &msg
+		if err = tx.Rollback(ctx); err != nil {
+			return err
+		}
+	} else {
+		app.Service.Logger.Debug("ran scheduled action", "schedule", j.name, "action", j.namespace+"."+j.action,
+			"height", block.Height)
+		if err = tx.Commit(ctx); err != nil {
+			return err
+		}
+	}
+
+	return j.record(ctx, app, block, runError, logs)
+}
+
+// record stores the result of a run, and removes the runs that are no longer
+// kept.
+func (j *job) record(ctx context.Context, app *common.App, block *common.BlockContext, runError *string, logs string) error {
+	j.runs++
+	j.lastHeight, j.lastTime = block.Height, block.Timestamp
+
+	err := app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `{`+schemaName+`}UPDATE jobs
+		SET last_height = $height, last_time = $time, runs = $run, last_error = $error
+		WHERE name = $name`, map[string]any{
+		"name":   j.name,
+		"height": block.Height,
+		"time":   block.Timestamp,
+		"run":    j.runs,
+		"error":  runError,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	err = app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `{`+schemaName+`}INSERT INTO runs
+		(name, run, height, time, error, logs) VALUES ($name, $run, $height, $time, $error, $logs)`, map[string]any{
+		"name":   j.name,
+		"run":    j.runs,
+		"height": block.Height,
+		"time":   block.Timestamp,
+		"error":  runError,
+		"logs":   logs,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	if j.runs <= maxRunHistory {
+		return nil
+	}
+	return app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `{`+schemaName+`}DELETE FROM runs
+		WHERE name = $name AND run <= $oldest`, map[string]any{
+		"name":   j.name,
+		"oldest": j.runs - maxRunHistory,
+	}, nil)
+}
@@ -0,0 +1,233 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/log"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+func TestNewJob(t *testing.T) {
+	j, err := newJob("daily", map[string]any{"action": "Payout", "every_blocks": int64(7200)})
+	require.NoError(t, err)
+	require.Equal(t, "daily", j.name)
+	require.Equal(t, "main", j.namespace)
+	require.Equal(t, "payout", j.action)
+	require.Equal(t, int64(7200), *j.everyBlocks)
+	require.Nil(t, j.everySeconds)
+
+	j, err = newJob("daily", map[string]any{"namespace": "Rewards", "action": "payout", "every_seconds": "86400"})
+	require.NoError(t, err)
+	require.Equal(t, "rewards", j.namespace)
+	require.Equal(t, int64(86400), *j.everySeconds)
+
+	for name, metadata := range map[string]map[string]any{
+		"no action":    {"every_blocks": int64(1)},
+		"no period":    {"action": "a"},
+		"both periods": {"action": "a", "every_blocks": int64(1), "every_seconds": int64(1)},
+		"zero period":  {"action": "a", "every_blocks": int64(0)},
+		"bad period":   {"action": "a", "every_seconds": "daily"},
+		"bad action":   {"action": int64(1), "every_blocks": int64(1)},
+		"unknown":      {"action": "a", "every_blocks": int64(1), "args": "x"},
+	} {
+		_, err := newJob("x", metadata)
+		require.Error(t, err, name)
+	}
+}
+
+func TestJobDue(t *testing.T) {
+	blocks, seconds := int64(10), int64(60)
+	byBlocks := &job{everyBlocks: &blocks, lastHeight: 100, lastTime: 1000}
+	byTime := &job{everySeconds: &seconds, lastHeight: 100, lastTime: 1000}
+
+	require.False(t, byBlocks.due(&common.BlockContext{Height: 109, Timestamp: 5000}))
+	require.True(t, byBlocks.due(&common.BlockContext{Height: 110, Timestamp: 1000}))
+
+	require.False(t, byTime.due(&common.BlockContext{Height: 500, Timestamp: 1059}))
+	require.True(t, byTime.due(&common.BlockContext{Height: 101, Timestamp: 1060}))
+}
+
+func TestJobFromRow(t *testing.T) {
+	j, err := jobFromRow([]any{"daily", "main", "payout", nil, int64(60), int64(5), int64(1000), int64(3)})
+	require.NoError(t, err)
+	require.Nil(t, j.everyBlocks)
+	require.Equal(t, int64(60), *j.everySeconds)
+	require.Equal(t, int64(5), j.lastHeight)
+	require.Equal(t, int64(1000), j.lastTime)
+	require.Equal(t, int64(3), j.runs)
+
+	_, err = jobFromRow([]any{"daily", "main", "payout", nil, nil, int64(5), int64(1000), int64(0)})
+	require.Error(t, err)
+	_, err = jobFromRow([]any{"daily", "main", "payout", int64(1), nil, "5", int64(1000), int64(0)})
+	require.Error(t, err)
+}
+
+// fakeEngine returns the configured schedules, and records the actions that
+// are called and the statements that are executed.
+type fakeEngine struct {
+	jobs     [][]any
+	jobsErr  error
+	failing  map[string]error // actions that fail
+	calls    []string
+	executed []executed
+}
+
+type executed struct {
+	stmt   string
+	params map[string]any
+}
+
+func (e *fakeEngine) Call(ctx *common.EngineContext, db sql.DB, namespace, action string, args []any, resultFn func(*common.Row) error) (*common.CallResult, error) {
+	if !ctx.OverrideAuthz {
+		return nil, errors.New("scheduled actions must override authorization")
+	}
+	e.calls = append(e.calls, namespace+"."+action)
+	if err := e.failing[action]; err != nil {
+		return nil, err
+	}
+	return &common.CallResult{Logs: []string{"paid"}}, nil
+}
+
+func (e *fakeEngine) CallWithoutEngineCtx(ctx context.Context, db sql.DB, namespace, action string, args []any, resultFn func(*common.Row) error) (*common.CallResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *fakeEngine) Execute(ctx *common.EngineContext, db sql.DB, statement string, params map[string]any, fn func(*common.Row) error) error {
+	return errors.New("not implemented")
+}
+
+func (e *fakeEngine) ExecuteWithoutEngineCtx(ctx context.Context, db sql.DB, statement string, params map[string]any, fn func(*common.Row) error) error {
+	if strings.Contains(statement, "FROM jobs ORDER BY name") {
+		if e.jobsErr != nil {
+			return e.jobsErr
+		}
+		for _, vals := range e.jobs {
+			if err := fn(&common.Row{Values: vals}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	e.executed = append(e.executed, executed{statement, params})
+	return nil
+}
+
+// fakeDB counts the nested transactions that are committed and rolled back.
+type fakeDB struct {
+	sql.Executor
+	commits, rollbacks int
+}
+
+func (db *fakeDB) BeginTx(ctx context.Context) (sql.Tx, error) {
+	return &fakeTx{db: db}, nil
+}
+
+type fakeTx struct {
+	sql.Executor
+	db *fakeDB
+}
+
+func (tx *fakeTx) BeginTx(ctx context.Context) (sql.Tx, error) {
+	return tx.db.BeginTx(ctx)
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.db.commits++
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	tx.db.rollbacks++
+	return nil
+}
+
+func TestRunDue(t *testing.T) {
+	ctx := context.Background()
+	eng := &fakeEngine{
+		jobs: [][]any{
+			{"a_daily", "main", "payout", int64(10), nil, int64(90), int64(0), int64(4)},
+			{"b_hourly", "rewards", "accrue", nil, int64(3600), int64(95), int64(1000), int64(0)},
+			{"c_later", "main", "later", int64(50), nil, int64(90), int64(0), int64(0)},
+		},
+		failing: map[string]error{"accrue": errors.New("out of funds")},
+	}
+	db := &fakeDB{}
+	app := &common.App{
+		Service: &common.Service{Logger: log.DiscardLogger},
+		DB:      db,
+		Engine:  eng,
+	}
+
+	block := &common.BlockContext{Height: 100, Timestamp: 4600}
+	require.NoError(t, runDue(ctx, app, block))
+
+	// Due schedules are run in order of their names.
+	require.Equal(t, []string{"main.payout", "rewards.accrue"}, eng.calls)
+	require.Equal(t, 1, db.commits)
+	require.Equal(t, 1, db.rollbacks)
+
+	// Each run updates the schedule and records its result.
+	require.Len(t, eng.executed, 4)
+	for i, want := range []struct {
+		name  string
+		run   int64
+		error any
+		logs  string
+	}{
+		{"a_daily", 5, (*string)(nil), "1. paid"},
+		{"b_hourly", 1, "out of funds", ""},
+	} {
+		update, insert := eng.executed[2*i], eng.executed[2*i+1]
+		require.Contains(t, update.stmt, "UPDATE jobs")
+		require.Equal(t, want.name, update.params["name"])
+		require.Equal(t, want.run, update.params["run"])
+		require.Equal(t, block.Height, update.params["height"])
+		require.Equal(t, block.Timestamp, update.params["time"])
+
+		require.Contains(t, insert.stmt, "INSERT INTO runs")
+		require.Equal(t, want.name, insert.params["name"])
+		require.Equal(t, want.run, insert.params["run"])
+		require.Equal(t, want.logs, insert.params["logs"])
+		if msg, ok := want.error.(string); ok {
+			require.Equal(t, msg, *insert.params["error"].(*string))
+		} else {
+			require.Nil(t, insert.params["error"])
+		}
+	}
+
+	// Nothing is run before the first schedule is created.
+	eng = &fakeEngine{jobsErr: engine.ErrNamespaceNotFound}
+	app.Engine = eng
+	require.NoError(t, runDue(ctx, app, block))
+	require.Empty(t, eng.calls)
+}
+
+func TestRunPrunesHistory(t *testing.T) {
+	eng := &fakeEngine{}
+	app := &common.App{
+		Service: &common.Service{Logger: log.DiscardLogger},
+		DB:      &fakeDB{},
+		Engine:  eng,
+	}
+	blocks := int64(1)
+	j := &job{name: "often", namespace: "main", action: "tick", everyBlocks: &blocks, runs: maxRunHistory - 1}
+
+	block := &common.BlockContext{Height: 100}
+	require.NoError(t, j.run(context.Background(), app, block))
+	require.Len(t, eng.executed, 2) // the history is not full yet
+
+	block = &common.BlockContext{Height: 101}
+	require.NoError(t, j.run(context.Background(), app, block))
+	require.Len(t, eng.executed, 5)
+	prune := eng.executed[4]
+	require.Contains(t, prune.stmt, "DELETE FROM runs")
+	require.Equal(t, int64(1), prune.params["oldest"])
+	require.Equal(t, int64(maxRunHistory+1), j.runs)
+}
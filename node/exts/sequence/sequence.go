@@ -0,0 +1,277 @@
+// package sequence implements the sequence precompile, which allocates
+// increasing integers deterministically, e.g. for primary keys.
+//
+// It is used as:
+//
+//	USE sequence AS order_ids;
+//
+// or, with an explicit start and increment:
+//
+//	USE sequence {
+//		start: 1000,
+//		increment: 10
+//	} AS order_ids;
+//
+// Both default to 1, and the increment may be negative. Values are allocated
+// by calling the next method from an action:
+//
+//	CREATE ACTION create_order($item TEXT) PUBLIC {
+//		$id := order_ids.next();
+//		INSERT INTO orders (id, item) VALUES ($id, $item);
+//	};
+//
+// The next method is SYSTEM, so users cannot allocate values by calling it
+// directly. In SQL, values are allocated with the sequence_next function,
+// which can be used in actions and in ad-hoc INSERT, UPDATE, and DELETE
+// statements, including as a column default to make an identity column:
+//
+//	CREATE TABLE orders (
+//		id INT8 PRIMARY KEY DEFAULT sequence_next('order_ids'),
+//		item TEXT NOT NULL
+//	);
+//
+//	INSERT INTO orders (item) VALUES ('apples'), ('pears');
+//
+// Unlike Postgres sequences, the state of a sequence is stored in a regular
+// table, so it is transactional: a value allocated by a transaction that fails
+// is allocated again by the next transaction. Values are therefore allocated
+// without gaps, in the order that transactions are executed in blocks, and
+// every node allocates the same values. The last allocated value can be read
+// with the current method, which returns NULL before the first allocation.
+// A sequence is removed with UNUSE.
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/precompiles"
+	"github.com/kwilteam/kwil-db/node/exts/extutil"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+const (
+	// ExtensionName is the name used to USE the precompile.
+	ExtensionName = "sequence"
+	// schemaName is the namespace that stores the sequences.
+	schemaName = "kwil_sequence"
+)
+
+// schema creates the table of sequences. The value is the last allocated
+// value, and is NULL if no value has been allocated.
+var schema = `SET CURRENT NAMESPACE TO ` + schemaName + `;
+
+CREATE TABLE sequences (
+	name TEXT PRIMARY KEY,
+	start INT8 NOT NULL,
+	increment INT8 NOT NULL,
+	value INT8
+);`
+
+// sqlCreateNextValue creates the Postgres function that the sequence_next SQL
+// function calls. It allocates values like the next method.
+var sqlCreateNextValue = `CREATE OR REPLACE FUNCTION ` + schemaName + `.next_value(seq_name TEXT)
+RETURNS INT8 AS $$
+DECLARE
+	v INT8;
+BEGIN
+	UPDATE ` + schemaName + `.sequences
+	SET value = CASE WHEN value IS NULL THEN start ELSE value + increment END
+	WHERE name = seq_name
+	RETURNING value INTO v;
+	IF NOT FOUND THEN
+		RAISE EXCEPTION 'sequence % not found', seq_name;
+	END IF;
+	RETURN v;
+EXCEPTION
+	WHEN numeric_value_out_of_range THEN
+		RAISE EXCEPTION 'sequence % is exhausted', seq_name;
+END;
+$$ LANGUAGE plpgsql;`
+
+func init() {
+	err := precompiles.RegisterInitializer(ExtensionName, func(ctx context.Context, service *common.Service,
+		db sql.DB, alias string, metadata map[string]any) (precompiles.Precompile, error) {
+		s, err := newSequence(alias, metadata)
+		if err != nil {
+			return precompiles.Precompile{}, err
+		}
+
+		return precompiles.Precompile{
+			OnStart: func(ctx context.Context, app *common.App) error {
+				// The function is created when the node starts since it is
+				// not part of the engine's state. When the first sequence
+				// is used, OnStart is called before the namespace exists, and
+				// OnUse creates it.
+				exists, err := extutil.NamespaceExists(ctx, app, schemaName, "sequences")
+				if err != nil || !exists {
+					return err
+				}
+				_, err = app.DB.Execute(ctx, sqlCreateNextValue)
+				return err
+			},
+			OnUse: func(ctx *common.EngineContext, app *common.App) error {
+				if err := extutil.EnsureNamespace(ctx.TxContext.Ctx, app, schemaName, "sequences", schema); err != nil {
+					return err
+				}
+				if _, err := app.DB.Execute(ctx.TxContext.Ctx, sqlCreateNextValue); err != nil {
+					return err
+				}
+				return app.Engine.ExecuteWithoutEngineCtx(ctx.TxContext.Ctx, app.DB,
+					`{`+schemaName+`}INSERT INTO sequences (name, start, increment) VALUES ($name, $start, $increment)`,
+					map[string]any{"name": s.name, "start": s.start, "increment": s.increment}, nil)
+			},
+			OnUnuse: func(ctx *common.EngineContext, app *common.App) error {
+				return app.Engine.ExecuteWithoutEngineCtx(ctx.TxContext.Ctx, app.DB,
+					`{`+schemaName+`}DELETE FROM sequences WHERE name = $name`, map[string]any{"name": s.name}, nil)
+			},
+			Methods: []precompiles.Method{
+				{
+					Name: "next",
+					Returns: &precompiles.MethodReturn{
+						Fields: []precompiles.PrecompileValue{{Name: "value", Type: types.IntType}},
+					},
+					Handler: func(ctx *common.EngineContext, app *common.App, inputs []any, resultFn func([]any) error) error {
+						v, err := s.next(ctx.TxContext.Ctx, app)
+						if err != nil {
+							return err
+						}
+						return resultFn([]any{v})
+					},
+					AccessModifiers: []precompiles.Modifier{precompiles.SYSTEM},
+				},
+				{
+					Name: "current",
+					Returns: &precompiles.MethodReturn{
+						Fields: []precompiles.PrecompileValue{{Name: "value", Type: types.IntType, Nullable: true}},
+					},
+					Handler: func(ctx *common.EngineContext, app *common.App, inputs []any, resultFn func([]any) error) error {
+						st, err := s.load(ctx.TxContext.Ctx, app)
+						if err != nil {
+							return err
+						}
+						return resultFn([]any{st.value})
+					},
+					AccessModifiers: []precompiles.Modifier{precompiles.PUBLIC, precompiles.VIEW},
+				},
+			},
+		}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// sequence is the configuration and state of a sequence.
+type sequence struct {
+	name      string
+	start     int64
+	increment int64
+	// value is the last allocated value. It is nil if no value has been
+	// allocated.
+	value *int64
+}
+
+// newSequence reads the configuration of a sequence from the metadata of a USE
+// statement.
+func newSequence(alias string, metadata map[string]any) (*sequence, error) {
+	s := &sequence{name: alias, start: 1, increment: 1}
+
+	for key, v := range metadata {
+		n, err := extutil.Int(v)
+		if err != nil {
+			return nil, fmt.Errorf("metadata field '%s': %w", key, err)
+		}
+		switch key {
+		case "start":
+			s.start = n
+		case "increment":
+			if n == 0 {
+				return nil, errors.New("metadata field 'increment' must not be zero")
+			}
+			s.increment = n
+		default:
+			return nil, fmt.Errorf("unknown metadata field '%s'", key)
+		}
+	}
+
+	return s, nil
+}
+
+// following returns the value that is allocated after the last allocated
+// value. It returns an error if the sequence is exhausted.
+func (s *sequence) following() (int64, error) {
+	if s.value == nil {
+		return s.start, nil
+	}
+
+	v := *s.value + s.increment
+	if (s.increment > 0 && v < *s.value) || (s.increment < 0 && v > *s.value) {
+		return 0, fmt.Errorf("sequence %s is exhausted", s.name)
+	}
+	return v, nil
+}
+
+// load reads the stored state of the sequence.
+func (s *sequence) load(ctx context.Context, app *common.App) (*sequence, error) {
+	var st *sequence
+	err := app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `{`+schemaName+`}SELECT start, increment, value
+		FROM sequences WHERE name = $name`, map[string]any{"name": s.name}, func(r *common.Row) error {
+		var err error
+		st, err = sequenceFromRow(s.name, r.Values)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, fmt.Errorf("sequence %s not found", s.name)
+	}
+	return st, nil
+}
+
+// next allocates and returns the next value of the sequence.
+func (s *sequence) next(ctx context.Context, app *common.App) (int64, error) {
+	st, err := s.load(ctx, app)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := st.following()
+	if err != nil {
+		return 0, err
+	}
+
+	err = app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `{`+schemaName+`}UPDATE sequences SET value = $value
+		WHERE name = $name`, map[string]any{"name": s.name, "value": v}, nil)
+	if err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+func sequenceFromRow(name string, vals []any) (*sequence, error) {
+	if len(vals) != 3 {
+		return nil, fmt.Errorf("expected 3 values, got %d", len(vals))
+	}
+
+	s := &sequence{name: name}
+	var ok bool
+	if s.start, ok = vals[0].(int64); !ok {
+		return nil, fmt.Errorf("unexpected type %T for start", vals[0])
+	}
+	if s.increment, ok = vals[1].(int64); !ok {
+		return nil, fmt.Errorf("unexpected type %T for increment", vals[1])
+	}
+	if vals[2] != nil {
+		v, ok := vals[2].(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type %T for value", vals[2])
+		}
+		s.value = &v
+	}
+	return s, nil
+}
@@ -0,0 +1,68 @@
+package sequence
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSequence(t *testing.T) {
+	s, err := newSequence("ids", nil)
+	require.NoError(t, err)
+	require.Equal(t, "ids", s.name)
+	require.Equal(t, int64(1), s.start)
+	require.Equal(t, int64(1), s.increment)
+
+	s, err = newSequence("ids", map[string]any{"start": int64(1000), "increment": "-10"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), s.start)
+	require.Equal(t, int64(-10), s.increment)
+
+	for name, metadata := range map[string]map[string]any{
+		"zero increment": {"increment": int64(0)},
+		"bad start":      {"start": "one"},
+		"bad type":       {"start": true},
+		"unknown":        {"cycle": int64(1)},
+	} {
+		_, err := newSequence("x", metadata)
+		require.Error(t, err, name)
+	}
+}
+
+func TestSequenceFollowing(t *testing.T) {
+	s := &sequence{name: "ids", start: 5, increment: 3}
+	v, err := s.following()
+	require.NoError(t, err)
+	require.Equal(t, int64(5), v)
+
+	s.value = &v
+	v, err = s.following()
+	require.NoError(t, err)
+	require.Equal(t, int64(8), v)
+
+	last := int64(math.MaxInt64 - 1)
+	s.value = &last
+	_, err = s.following()
+	require.Error(t, err)
+
+	first := int64(math.MinInt64 + 2)
+	s = &sequence{name: "down", increment: -3, value: &first}
+	_, err = s.following()
+	require.Error(t, err)
+}
+
+func TestSequenceFromRow(t *testing.T) {
+	s, err := sequenceFromRow("ids", []any{int64(1), int64(2), nil})
+	require.NoError(t, err)
+	require.Nil(t, s.value)
+
+	s, err = sequenceFromRow("ids", []any{int64(1), int64(2), int64(7)})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), *s.value)
+
+	_, err = sequenceFromRow("ids", []any{int64(1), "2", nil})
+	require.Error(t, err)
+	_, err = sequenceFromRow("ids", []any{int64(1), int64(2)})
+	require.Error(t, err)
+}
@@ -0,0 +1,428 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+const (
+	// hostModule is the module that host functions are imported from.
+	hostModule = "kwil"
+	// allocExport and methodsExport are the functions that every module must
+	// export, in addition to its memory.
+	allocExport   = "alloc"
+	methodsExport = "methods"
+	memoryExport  = "memory"
+
+	// maxMemoryPages limits the memory of a module to 16 MiB.
+	maxMemoryPages = 256
+	// hostCallGas is the gas charged for each call of a host function, in
+	// addition to one unit of gas for each byte passed to or from it.
+	hostCallGas = 1000
+	// startGas is the gas that the start function of a module may use when
+	// it is instantiated.
+	startGas = 1_000_000
+)
+
+// ErrOutOfGas is returned when a module uses more gas than its limit.
+var ErrOutOfGas = errors.New("wasm module ran out of gas")
+
+var (
+	runtimeOnce sync.Once
+	wasmRuntime wazero.Runtime
+	runtimeErr  error
+)
+
+// getRuntime returns the runtime that all modules are compiled and run with.
+// It uses the interpreter, so that execution does not depend on the platform,
+// and does not provide WASI, so modules have no access to clocks, randomness,
+// or the file system.
+func getRuntime() (wazero.Runtime, error) {
+	runtimeOnce.Do(func() {
+		ctx := context.Background()
+		cfg := wazero.NewRuntimeConfigInterpreter().
+			WithCoreFeatures(api.CoreFeaturesV2.SetEnabled(api.CoreFeatureSIMD, false)).
+			WithMemoryLimitPages(maxMemoryPages)
+		wasmRuntime = wazero.NewRuntimeWithConfig(ctx, cfg)
+
+		i32, i64 := api.ValueTypeI32, api.ValueTypeI64
+		_, runtimeErr = wasmRuntime.NewHostModuleBuilder(hostModule).
+			NewFunctionBuilder().
+			WithGoModuleFunction(api.GoModuleFunc(hostExecute), []api.ValueType{i32, i32, i32, i32}, []api.ValueType{i64}).
+			Export("execute").
+			NewFunctionBuilder().
+			WithGoModuleFunction(api.GoModuleFunc(hostCall), []api.ValueType{i32, i32, i32, i32, i32, i32}, []api.ValueType{i64}).
+			Export("call").
+			Instantiate(ctx)
+	})
+	return wasmRuntime, runtimeErr
+}
+
+var (
+	compiledMu sync.Mutex
+	compiled   = make(map[[32]byte]wazero.CompiledModule)
+)
+
+// compile instruments and compiles a module, and checks that it has the
+// exports that every module needs. Compiled modules are cached by hash.
+func compile(ctx context.Context, hash [32]byte, code []byte) (wazero.CompiledModule, error) {
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+	if cm, ok := compiled[hash]; ok {
+		return cm, nil
+	}
+
+	rt, err := getRuntime()
+	if err != nil {
+		return nil, err
+	}
+	metered, err := instrument(code, startGas)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := rt.CompileModule(ctx, metered)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkExports(cm); err != nil {
+		return nil, errors.Join(err, cm.Close(ctx))
+	}
+
+	compiled[hash] = cm
+	return cm, nil
+}
+
+func checkExports(cm wazero.CompiledModule) error {
+	for _, imp := range cm.ImportedFunctions() {
+		if mod, name, _ := imp.Import(); mod != hostModule {
+			return fmt.Errorf("module imports %s.%s, but may only import from %s", mod, name, hostModule)
+		}
+	}
+	if _, ok := cm.ExportedMemories()[memoryExport]; !ok {
+		return fmt.Errorf("module does not export %s", memoryExport)
+	}
+
+	funcs := cm.ExportedFunctions()
+	if err := checkSignature(funcs, allocExport, []api.ValueType{api.ValueTypeI32}, api.ValueTypeI32); err != nil {
+		return err
+	}
+	return checkSignature(funcs, methodsExport, nil, api.ValueTypeI64)
+}
+
+func checkSignature(funcs map[string]api.FunctionDefinition, name string, params []api.ValueType, result api.ValueType) error {
+	fn, ok := funcs[name]
+	if !ok {
+		return fmt.Errorf("module does not export function %s", name)
+	}
+	if !bytes.Equal(fn.ParamTypes(), params) || !bytes.Equal(fn.ResultTypes(), []api.ValueType{result}) {
+		return fmt.Errorf("function %s has signature %v -> %v, expected %v -> %v",
+			name, fn.ParamTypes(), fn.ResultTypes(), params, []api.ValueType{result})
+	}
+	return nil
+}
+
+// session is the state of a single call into a module. Host functions find it
+// in their context.
+type session struct {
+	// engineCtx and app are nil when the module is called outside of a method,
+	// in which case it cannot access the database.
+	engineCtx *common.EngineContext
+	app       *common.App
+	// err is the error that a host function failed with.
+	err error
+}
+
+type sessionKey struct{}
+
+// invoke instantiates a module and calls one of its exports with the given
+// gas limit. If input is not nil, it is written to the module's memory and
+// passed as a pointer and length. The export returns a pointer and length of
+// its output packed into an i64, which is read from the module's memory.
+//
+// Each call gets a new instance, so no state is kept in memory between calls.
+func invoke(ctx context.Context, cm wazero.CompiledModule, s *session, gasLimit int64, export string, input []byte) ([]byte, error) {
+	rt, err := getRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, sessionKey{}, s)
+	mod, err := rt.InstantiateModule(ctx, cm, wazero.NewModuleConfig().WithName("").WithStartFunctions())
+	if err != nil {
+		return nil, err
+	}
+	defer mod.Close(ctx)
+
+	gas := mod.ExportedGlobal(gasExport).(api.MutableGlobal)
+	gas.Set(uint64(gasLimit))
+
+	var params []uint64
+	if input != nil {
+		ptr, err := writeBytes(ctx, mod, input)
+		if err != nil {
+			return nil, callError(s, gas, err)
+		}
+		params = []uint64{uint64(ptr), uint64(len(input))}
+	}
+
+	fn := mod.ExportedFunction(export)
+	if fn == nil {
+		return nil, fmt.Errorf("module does not export function %s", export)
+	}
+	res, err := fn.Call(ctx, params...)
+	if err != nil {
+		return nil, callError(s, gas, err)
+	}
+	return readBytes(mod, res[0])
+}
+
+func callError(s *session, gas api.Global, err error) error {
+	if int64(gas.Get()) < 0 {
+		return ErrOutOfGas
+	}
+	if s.err != nil {
+		return s.err
+	}
+	return fmt.Errorf("wasm module failed: %w", err)
+}
+
+// writeBytes copies b into memory that is allocated by the module.
+func writeBytes(ctx context.Context, mod api.Module, b []byte) (uint32, error) {
+	res, err := mod.ExportedFunction(allocExport).Call(ctx, uint64(len(b)))
+	if err != nil {
+		return 0, err
+	}
+	ptr := uint32(res[0])
+	if !mod.Memory().Write(ptr, b) {
+		return 0, fmt.Errorf("%s returned out of range memory", allocExport)
+	}
+	return ptr, nil
+}
+
+// readBytes copies the memory at a packed pointer and length. Zero is read as
+// no bytes.
+func readBytes(mod api.Module, packed uint64) ([]byte, error) {
+	return readMemory(mod, uint32(packed>>32), uint32(packed))
+}
+
+func readMemory(mod api.Module, ptr, length uint32) ([]byte, error) {
+	if length == 0 {
+		return nil, nil
+	}
+	b, ok := mod.Memory().Read(ptr, length)
+	if !ok {
+		return nil, errors.New("module returned out of range memory")
+	}
+	return bytes.Clone(b), nil
+}
+
+// chargeGas subtracts gas from the counter of a module.
+func chargeGas(mod api.Module, cost int64) error {
+	gas := mod.ExportedGlobal(gasExport).(api.MutableGlobal)
+	left := int64(gas.Get()) - cost
+	gas.Set(uint64(left))
+	if left < 0 {
+		return ErrOutOfGas
+	}
+	return nil
+}
+
+// hostFunc adapts a host function that returns bytes or an error. The bytes
+// are written to the module's memory and returned as a packed pointer and
+// length. An error is recorded in the session and traps the module.
+func hostFunc(ctx context.Context, mod api.Module, stack []uint64, fn func(s *session) ([]byte, error)) {
+	s := ctx.Value(sessionKey{}).(*session)
+	packed, err := hostResult(ctx, mod, s, fn)
+	if err != nil {
+		s.err = err
+		panic(err)
+	}
+	stack[0] = packed
+}
+
+func hostResult(ctx context.Context, mod api.Module, s *session, fn func(s *session) ([]byte, error)) (uint64, error) {
+	if s.app == nil {
+		return 0, errors.New("the database can only be accessed from methods")
+	}
+	out, err := fn(s)
+	if err != nil {
+		return 0, err
+	}
+	if err = chargeGas(mod, int64(len(out))); err != nil {
+		return 0, err
+	}
+	ptr, err := writeBytes(ctx, mod, out)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(ptr)<<32 | uint64(len(out)), nil
+}
+
+// hostExecute implements execute(stmt_ptr, stmt_len, params_ptr, params_len),
+// which executes a statement like common.Engine.Execute. The parameters are a
+// JSON object, and the result is a JSON array of rows.
+func hostExecute(ctx context.Context, mod api.Module, stack []uint64) {
+	hostFunc(ctx, mod, stack, func(s *session) ([]byte, error) {
+		stmt, err := readMemory(mod, uint32(stack[0]), uint32(stack[1]))
+		if err != nil {
+			return nil, err
+		}
+		rawParams, err := readMemory(mod, uint32(stack[2]), uint32(stack[3]))
+		if err != nil {
+			return nil, err
+		}
+		if err = chargeGas(mod, hostCallGas+int64(len(stmt)+len(rawParams))); err != nil {
+			return nil, err
+		}
+
+		var params map[string]any
+		if len(rawParams) > 0 {
+			var raw map[string]any
+			if err = decodeJSON(rawParams, &raw); err != nil {
+				return nil, fmt.Errorf("invalid parameters: %w", err)
+			}
+			params = make(map[string]any, len(raw))
+			for k, v := range raw {
+				if params[k], err = paramValue(v); err != nil {
+					return nil, fmt.Errorf("parameter %s: %w", k, err)
+				}
+			}
+		}
+
+		rows := [][]any{}
+		err = s.app.Engine.Execute(s.engineCtx, s.app.DB, string(stmt), params, func(r *common.Row) error {
+			rows = append(rows, r.Values)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(rows)
+	})
+}
+
+// hostCall implements call(namespace_ptr, namespace_len, action_ptr,
+// action_len, args_ptr, args_len), which calls an action like
+// common.Engine.Call. The arguments are a JSON array, and the result is a JSON
+// array of rows.
+func hostCall(ctx context.Context, mod api.Module, stack []uint64) {
+	hostFunc(ctx, mod, stack, func(s *session) ([]byte, error) {
+		var strs [3][]byte
+		for i := range strs {
+			b, err := readMemory(mod, uint32(stack[2*i]), uint32(stack[2*i+1]))
+			if err != nil {
+				return nil, err
+			}
+			strs[i] = b
+		}
+		if err := chargeGas(mod, hostCallGas+int64(len(strs[0])+len(strs[1])+len(strs[2]))); err != nil {
+			return nil, err
+		}
+
+		var args []any
+		if len(strs[2]) > 0 {
+			if err := decodeJSON(strs[2], &args); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+			for i, v := range args {
+				var err error
+				if args[i], err = paramValue(v); err != nil {
+					return nil, fmt.Errorf("argument %d: %w", i+1, err)
+				}
+			}
+		}
+
+		rows := [][]any{}
+		_, err := s.app.Engine.Call(s.engineCtx, s.app.DB, string(strs[0]), string(strs[1]), args, func(r *common.Row) error {
+			rows = append(rows, r.Values)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(rows)
+	})
+}
+
+func decodeJSON(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// paramValue converts a JSON value passed to a host function to a value that
+// the engine accepts. Numbers are integers, or numerics if they have a
+// fraction. There is no JSON type for bytea, so modules pass base64 strings
+// and decode them in SQL.
+func paramValue(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return types.ParseDecimal(v.String())
+	default:
+		return nil, fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// resultValue converts a JSON value returned by a method to a value of the
+// declared type. Values of the types that JSON does not have are strings, and
+// bytea values are base64 encoded.
+func resultValue(v any, dt *types.DataType) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var ok bool
+	var err error
+	switch dt.Name {
+	case types.IntType.Name:
+		var n json.Number
+		if n, ok = v.(json.Number); ok {
+			v, err = n.Int64()
+		}
+	case types.TextType.Name:
+		_, ok = v.(string)
+	case types.BoolType.Name:
+		_, ok = v.(bool)
+	case types.ByteaType.Name:
+		var s string
+		if s, ok = v.(string); ok {
+			v, err = base64.StdEncoding.DecodeString(s)
+		}
+	case types.NumericStr:
+		var s string
+		switch n := v.(type) {
+		case json.Number:
+			s, ok = n.String(), true
+		case string:
+			s, ok = n, true
+		}
+		if ok {
+			v, err = types.ParseDecimalExplicit(s, dt.Metadata[0], dt.Metadata[1])
+		}
+	case types.UUIDType.Name:
+		var s string
+		if s, ok = v.(string); ok {
+			v, err = types.ParseUUID(s)
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("expected %s, got %v", dt, v)
+	}
+	return v, err
+}
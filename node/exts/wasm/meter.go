@@ -0,0 +1,543 @@
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// gasExport is the name that an instrumented module exports its gas counter
+// as. The counter is a mutable i64 global holding the remaining gas.
+const gasExport = "kwil_gas"
+
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+const (
+	sectionCustom  = 0
+	sectionImport  = 2
+	sectionGlobal  = 6
+	sectionExport  = 7
+	sectionCode    = 10
+	importFunc     = 0x00
+	importTable    = 0x01
+	importMemory   = 0x02
+	importGlobal   = 0x03
+	exportGlobal   = 0x03
+	valueTypeI64   = 0x7e
+	blockTypeEmpty = 0x40
+
+	opUnreachable = 0x00
+	opBlock       = 0x02
+	opLoop        = 0x03
+	opIf          = 0x04
+	opEnd         = 0x0b
+	opGlobalGet   = 0x23
+	opGlobalSet   = 0x24
+	opI64Const    = 0x42
+	opI64LtS      = 0x53
+	opI64Sub      = 0x7d
+	opPrefixFC    = 0xfc
+)
+
+// sectionOrder is the order that the known sections must appear in. Custom
+// sections may appear anywhere.
+var sectionOrder = map[byte]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 13: 6, 6: 7, 7: 8, 8: 9, 9: 10, 12: 11, 10: 12, 11: 13}
+
+type section struct {
+	id   byte
+	data []byte
+}
+
+// instrument adds gas metering to a WebAssembly module. It adds a mutable i64
+// global that holds the remaining gas, initialized to initialGas and exported
+// as gasExport, and code that charges gas at the start of each function and
+// each loop iteration. The charge is the number of instructions in the
+// function or loop body, not counting nested loops, which charge for
+// themselves. The module traps when the counter becomes negative. Every
+// backward branch targets a loop, so a module cannot run for longer than its
+// gas allows.
+//
+// Modules that use floating point arithmetic, comparison, or conversion
+// instructions are rejected, since the bits of NaN results differ between
+// platforms. Floating point loads, stores, and constants only copy bits and
+// are allowed. SIMD instructions are not supported.
+func instrument(code []byte, initialGas int64) ([]byte, error) {
+	if !bytes.HasPrefix(code, wasmHeader) {
+		return nil, errors.New("not a WebAssembly module")
+	}
+
+	var sections []section
+	r := &reader{b: code[len(wasmHeader):]}
+	for !r.done() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		if id != sectionCustom {
+			if _, ok := sectionOrder[id]; !ok {
+				return nil, fmt.Errorf("unknown section %d", id)
+			}
+		}
+		sections = append(sections, section{id: id, data: data})
+	}
+
+	// The gas global is added after the globals that the module defines, so
+	// the indexes of existing globals do not change.
+	importedGlobals, err := countImportedGlobals(findSection(sections, sectionImport))
+	if err != nil {
+		return nil, err
+	}
+	definedGlobals, globals, err := vecEntries(findSection(sections, sectionGlobal))
+	if err != nil {
+		return nil, err
+	}
+	gasGlobal := importedGlobals + definedGlobals
+
+	newGlobals := appendU32(nil, definedGlobals+1)
+	newGlobals = append(newGlobals, globals...)
+	newGlobals = append(newGlobals, valueTypeI64, 0x01, opI64Const)
+	newGlobals = appendS64(newGlobals, initialGas)
+	newGlobals = append(newGlobals, opEnd)
+	sections = setSection(sections, sectionGlobal, newGlobals)
+
+	exports, exportEntries, err := vecEntries(findSection(sections, sectionExport))
+	if err != nil {
+		return nil, err
+	}
+	newExports := appendU32(nil, exports+1)
+	newExports = append(newExports, exportEntries...)
+	newExports = appendU32(newExports, uint32(len(gasExport)))
+	newExports = append(newExports, gasExport...)
+	newExports = append(newExports, exportGlobal)
+	newExports = appendU32(newExports, gasGlobal)
+	sections = setSection(sections, sectionExport, newExports)
+
+	if codeSection := findSection(sections, sectionCode); codeSection != nil {
+		newCode, err := meterCode(codeSection, gasGlobal)
+		if err != nil {
+			return nil, err
+		}
+		sections = setSection(sections, sectionCode, newCode)
+	}
+
+	out := bytes.Clone(wasmHeader)
+	for _, s := range sections {
+		out = append(out, s.id)
+		out = appendU32(out, uint32(len(s.data)))
+		out = append(out, s.data...)
+	}
+	return out, nil
+}
+
+func findSection(sections []section, id byte) []byte {
+	for _, s := range sections {
+		if s.id == id {
+			return s.data
+		}
+	}
+	return nil
+}
+
+// setSection replaces the data of a section, or inserts the section in order
+// if the module does not have it.
+func setSection(sections []section, id byte, data []byte) []section {
+	for i := range sections {
+		if sections[i].id == id {
+			sections[i].data = data
+			return sections
+		}
+	}
+	for i, s := range sections {
+		if s.id != sectionCustom && sectionOrder[s.id] > sectionOrder[id] {
+			return append(sections[:i], append([]section{{id: id, data: data}}, sections[i:]...)...)
+		}
+	}
+	return append(sections, section{id: id, data: data})
+}
+
+// vecEntries returns the number of entries in a section that is a vector, and
+// the encoded entries. It returns zero entries for a missing section.
+func vecEntries(data []byte) (uint32, []byte, error) {
+	if data == nil {
+		return 0, nil, nil
+	}
+	r := &reader{b: data}
+	n, err := r.u32()
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, data[r.pos:], nil
+}
+
+func countImportedGlobals(data []byte) (uint32, error) {
+	n, entries, err := vecEntries(data)
+	if err != nil {
+		return 0, err
+	}
+
+	r := &reader{b: entries}
+	var globals uint32
+	for range n {
+		if err = r.skipName(); err != nil { // module
+			return 0, err
+		}
+		if err = r.skipName(); err != nil { // field
+			return 0, err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case importFunc:
+			_, err = r.u32()
+		case importTable:
+			if _, err = r.byte(); err == nil {
+				err = r.skipLimits()
+			}
+		case importMemory:
+			err = r.skipLimits()
+		case importGlobal:
+			globals++
+			_, err = r.bytes(2) // value type and mutability
+		default:
+			err = fmt.Errorf("unknown import kind %d", kind)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return globals, nil
+}
+
+// meterCode adds gas charges to each function body in the code section.
+func meterCode(data []byte, gasGlobal uint32) ([]byte, error) {
+	n, entries, err := vecEntries(data)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reader{b: entries}
+	out := appendU32(nil, n)
+	for i := range n {
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		body, err = meterBody(body, gasGlobal)
+		if err != nil {
+			return nil, fmt.Errorf("function %d: %w", i, err)
+		}
+		out = appendU32(out, uint32(len(body)))
+		out = append(out, body...)
+	}
+	return out, nil
+}
+
+// meter is a point in a function body where gas is charged.
+type meter struct {
+	pos  int // offset that the charge is inserted at
+	cost int64
+}
+
+func meterBody(body []byte, gasGlobal uint32) ([]byte, error) {
+	r := &reader{b: body}
+	localGroups, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	for range localGroups {
+		if _, err = r.u32(); err != nil {
+			return nil, err
+		}
+		if _, err = r.byte(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Each open block records the meter of its innermost loop, or of the
+	// function, which every instruction in the block is charged to.
+	meters := []meter{{pos: r.pos}}
+	blocks := []int{0}
+	for len(blocks) > 0 {
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		meters[blocks[len(blocks)-1]].cost++
+
+		switch op {
+		case opBlock, opIf:
+			if err = r.skipBlockType(); err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, blocks[len(blocks)-1])
+		case opLoop:
+			if err = r.skipBlockType(); err != nil {
+				return nil, err
+			}
+			meters = append(meters, meter{pos: r.pos})
+			blocks = append(blocks, len(meters)-1)
+		case opEnd:
+			blocks = blocks[:len(blocks)-1]
+		default:
+			if err = r.skipImmediates(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !r.done() {
+		return nil, errors.New("unexpected bytes after end of function")
+	}
+
+	out := make([]byte, 0, len(body)+len(meters)*24)
+	last := 0
+	for _, m := range meters {
+		out = append(out, body[last:m.pos]...)
+		out = appendCharge(out, gasGlobal, m.cost)
+		last = m.pos
+	}
+	return append(out, body[last:]...), nil
+}
+
+// appendCharge appends code that subtracts cost from the gas counter and
+// traps if the counter is negative.
+func appendCharge(b []byte, gasGlobal uint32, cost int64) []byte {
+	b = append(b, opGlobalGet)
+	b = appendU32(b, gasGlobal)
+	b = append(b, opI64Const)
+	b = appendS64(b, cost)
+	b = append(b, opI64Sub, opGlobalSet)
+	b = appendU32(b, gasGlobal)
+	b = append(b, opGlobalGet)
+	b = appendU32(b, gasGlobal)
+	b = append(b, opI64Const, 0x00, opI64LtS, opIf, blockTypeEmpty, opUnreachable, opEnd)
+	return b
+}
+
+// isFloatOp reports whether an instruction performs floating point
+// arithmetic, comparison, or conversion.
+func isFloatOp(op byte) bool {
+	switch {
+	case op >= 0x5b && op <= 0x66: // comparisons
+	case op >= 0x8b && op <= 0xa6: // arithmetic
+	case op >= 0xa8 && op <= 0xab: // i32.trunc
+	case op >= 0xae && op <= 0xbf: // i64.trunc, conversions, reinterpretations
+	default:
+		return false
+	}
+	return true
+}
+
+// skipImmediates skips the immediates of an instruction other than block,
+// loop, if, and end.
+func (r *reader) skipImmediates(op byte) error {
+	if isFloatOp(op) {
+		return fmt.Errorf("floating point instruction 0x%02x is not allowed", op)
+	}
+
+	switch {
+	case op <= 0x01, op == 0x05, op == 0x0f, op == 0x1a, op == 0x1b,
+		op >= 0x45 && op <= 0xc4, op == 0xd1:
+		// no immediates
+		return nil
+	case op == 0x0c, op == 0x0d, op == 0x10, op >= 0x20 && op <= 0x26, op == 0x3f, op == 0x40, op == 0xd2:
+		_, err := r.u32()
+		return err
+	case op == 0x0e: // br_table
+		n, err := r.u32()
+		if err != nil {
+			return err
+		}
+		for range n + 1 {
+			if _, err = r.u32(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case op == 0x11, op >= 0x28 && op <= 0x3e: // call_indirect, memory access
+		if _, err := r.u32(); err != nil {
+			return err
+		}
+		_, err := r.u32()
+		return err
+	case op == 0x1c: // typed select
+		n, err := r.u32()
+		if err != nil {
+			return err
+		}
+		_, err = r.bytes(int(n))
+		return err
+	case op == 0x41, op == opI64Const:
+		return r.skipLEB()
+	case op == 0x43:
+		_, err := r.bytes(4)
+		return err
+	case op == 0x44:
+		_, err := r.bytes(8)
+		return err
+	case op == 0xd0: // ref.null
+		_, err := r.byte()
+		return err
+	case op == opPrefixFC:
+		return r.skipPrefixFC()
+	default:
+		return fmt.Errorf("unsupported instruction 0x%02x", op)
+	}
+}
+
+func (r *reader) skipPrefixFC() error {
+	op, err := r.u32()
+	if err != nil {
+		return err
+	}
+
+	var immediates int
+	switch op {
+	case 0, 1, 2, 3, 4, 5, 6, 7:
+		return fmt.Errorf("floating point instruction 0xfc %d is not allowed", op)
+	case 11: // memory.fill
+		immediates = 1
+	case 9, 13, 15, 16, 17: // data.drop, elem.drop, table.grow, table.size, table.fill
+		immediates = 1
+	case 8, 10, 12, 14: // memory.init, memory.copy, table.init, table.copy
+		immediates = 2
+	default:
+		return fmt.Errorf("unsupported instruction 0xfc %d", op)
+	}
+	for range immediates {
+		if _, err = r.u32(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *reader) skipBlockType() error {
+	if r.pos >= len(r.b) {
+		return errors.New("unexpected end of function")
+	}
+	switch b := r.b[r.pos]; {
+	case b == blockTypeEmpty, b >= 0x6f && b <= 0x7f:
+		r.pos++ // empty or a value type
+		return nil
+	default:
+		return r.skipLEB() // type index
+	}
+}
+
+// reader decodes the parts of a WebAssembly binary that instrument needs.
+type reader struct {
+	b   []byte
+	pos int
+}
+
+func (r *reader) done() bool {
+	return r.pos >= len(r.b)
+}
+
+var errUnexpectedEnd = errors.New("unexpected end of WebAssembly module")
+
+func (r *reader) byte() (byte, error) {
+	if r.done() {
+		return 0, errUnexpectedEnd
+	}
+	b := r.b[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || len(r.b)-r.pos < n {
+		return nil, errUnexpectedEnd
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) u32() (uint32, error) {
+	var v uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, errors.New("integer too long")
+}
+
+// skipLEB skips a signed or unsigned LEB128 integer of up to 64 bits.
+func (r *reader) skipLEB() error {
+	for range 10 {
+		b, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if b&0x80 == 0 {
+			return nil
+		}
+	}
+	return errors.New("integer too long")
+}
+
+func (r *reader) skipName() error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	_, err = r.bytes(int(n))
+	return err
+}
+
+func (r *reader) skipLimits() error {
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if _, err = r.u32(); err != nil {
+		return err
+	}
+	if flags&1 != 0 {
+		_, err = r.u32()
+	}
+	return err
+}
+
+func appendU32(b []byte, v uint32) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func appendS64(b []byte, v int64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
@@ -0,0 +1,389 @@
+// Package wasm implements precompiles that are WebAssembly modules stored on
+// chain, so that new precompiles can be added without building a custom
+// kwild binary.
+//
+// The database owner stores a module with the store method of the
+// wasm_modules precompile, which returns the SHA-256 hash of the module:
+//
+//	USE wasm_modules AS wasm_modules;
+//
+// The module is then used by its hash, optionally with a gas limit for each
+// call that defaults to 10 million:
+//
+//	USE wasm_ext {
+//		module_hash: '<hex encoded hash>',
+//		gas_limit: 1000000
+//	} AS counter;
+//
+// Modules are stored in the kwil_wasm namespace, so every node loads the same
+// code. They are run by the wazero interpreter without WASI, and without
+// floating point arithmetic, so that they execute identically on every node.
+// Execution is metered by instrumenting the module: every instruction costs
+// one unit of gas, and host functions cost extra. A call that runs out of gas
+// fails.
+//
+// Gas is counted by code that is added to the module, which charges for the
+// instructions of each function and loop body as it is entered. It does not
+// depend on time, or on the context's deadline, so a call uses the same gas
+// and fails at the same instruction on every validator, however fast each
+// node runs it.
+//
+// A module must export its memory as "memory", and the functions:
+//
+//   - alloc(size i32) i32, which allocates memory for the host to write to.
+//   - methods() i64, which returns the JSON declaration of the methods.
+//
+// Strings are passed as a pointer and length, and returned as an i64 with the
+// pointer in the high 32 bits and the length in the low 32 bits. The method
+// declaration is an array of:
+//
+//	{
+//		"name": "increment",
+//		"modifiers": ["PUBLIC"],
+//		"parameters": [{"name": "key", "type": "text"}],
+//		"returns": {"table": false, "fields": [{"name": "value", "type": "int8", "nullable": true}]}
+//	}
+//
+// Each method is an export of the same name that takes the JSON array of
+// arguments and returns a JSON array of result rows, each itself an array.
+// The supported types are int8, text, bool, bytea, numeric, and uuid. Values
+// of types that JSON does not have are strings, and bytea values are base64
+// encoded.
+//
+// Modules reach the database through host functions imported from the "kwil"
+// module, which mirror common.Engine:
+//
+//   - execute(stmt_ptr, stmt_len, params_ptr, params_len i32) i64 executes a
+//     statement with a JSON object of parameters.
+//   - call(namespace_ptr, namespace_len, action_ptr, action_len, args_ptr,
+//     args_len i32) i64 calls an action with a JSON array of arguments.
+//
+// Both return a JSON array of result rows, and run with the privileges of the
+// caller of the method. A module is instantiated for each call, so it cannot
+// keep state in memory between calls.
+package wasm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/precompiles"
+	"github.com/kwilteam/kwil-db/node/exts/extutil"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+const (
+	// ExtensionName is the name used to USE a module.
+	ExtensionName = "wasm_ext"
+	// RegistryName is the name used to USE the precompile that stores
+	// modules.
+	RegistryName = "wasm_modules"
+	// schemaName is the namespace that stores the modules.
+	schemaName = "kwil_wasm"
+
+	defaultGasLimit = 10_000_000
+	// maxModuleSize is the largest module that can be stored.
+	maxModuleSize = 4 << 20
+)
+
+var schema = `SET CURRENT NAMESPACE TO ` + schemaName + `;
+
+CREATE TABLE modules (
+	hash BYTEA PRIMARY KEY,
+	code BYTEA NOT NULL,
+	size INT8 NOT NULL
+);`
+
+func init() {
+	err := precompiles.RegisterInitializer(ExtensionName, func(ctx context.Context, service *common.Service,
+		db sql.DB, alias string, metadata map[string]any) (precompiles.Precompile, error) {
+		hash, gasLimit, err := parseMetadata(metadata)
+		if err != nil {
+			return precompiles.Precompile{}, err
+		}
+		code, err := loadModule(ctx, db, hash)
+		if err != nil {
+			return precompiles.Precompile{}, err
+		}
+		m, err := newModule(ctx, code, gasLimit)
+		if err != nil {
+			return precompiles.Precompile{}, fmt.Errorf("wasm module %x: %w", hash, err)
+		}
+		return precompiles.Precompile{Methods: m.methods}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = precompiles.RegisterPrecompile(RegistryName, precompiles.Precompile{
+		OnUse: func(ctx *common.EngineContext, app *common.App) error {
+			return extutil.EnsureNamespace(ctx.TxContext.Ctx, app, schemaName, "modules", schema)
+		},
+		Methods: []precompiles.Method{
+			{
+				Name:       "store",
+				Parameters: []precompiles.PrecompileValue{{Name: "code", Type: types.ByteaType}},
+				Returns: &precompiles.MethodReturn{
+					Fields: []precompiles.PrecompileValue{{Name: "hash", Type: types.ByteaType}},
+				},
+				Handler: func(ctx *common.EngineContext, app *common.App, inputs []any, resultFn func([]any) error) error {
+					code := inputs[0].([]byte)
+					hash, err := storeModule(ctx.TxContext.Ctx, app, code)
+					if err != nil {
+						return err
+					}
+					return resultFn([]any{hash})
+				},
+				AccessModifiers: []precompiles.Modifier{precompiles.PUBLIC, precompiles.OWNER},
+			},
+			{
+				Name: "list",
+				Returns: &precompiles.MethodReturn{
+					IsTable: true,
+					Fields: []precompiles.PrecompileValue{
+						{Name: "hash", Type: types.ByteaType},
+						{Name: "size", Type: types.IntType},
+					},
+				},
+				Handler: func(ctx *common.EngineContext, app *common.App, inputs []any, resultFn func([]any) error) error {
+					return app.Engine.ExecuteWithoutEngineCtx(ctx.TxContext.Ctx, app.DB,
+						`{`+schemaName+`}SELECT hash, size FROM modules ORDER BY hash`, nil, func(r *common.Row) error {
+							return resultFn(r.Values)
+						})
+				},
+				AccessModifiers: []precompiles.Modifier{precompiles.PUBLIC, precompiles.VIEW},
+			},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func parseMetadata(metadata map[string]any) (hash []byte, gasLimit int64, err error) {
+	hexHash, ok := metadata["module_hash"].(string)
+	if !ok {
+		return nil, 0, errors.New("module_hash is required")
+	}
+	hash, err = hex.DecodeString(strings.TrimPrefix(hexHash, "0x"))
+	if err != nil || len(hash) != sha256.Size {
+		return nil, 0, errors.New("module_hash must be a hex encoded SHA-256 hash")
+	}
+
+	gasLimit = defaultGasLimit
+	if v, ok := metadata["gas_limit"]; ok {
+		if gasLimit, err = extutil.PositiveInt(v); err != nil {
+			return nil, 0, fmt.Errorf("gas_limit %w", err)
+		}
+	}
+	return hash, gasLimit, nil
+}
+
+// storeModule validates and stores a module, and returns its hash. Storing a
+// module that is already stored does nothing.
+func storeModule(ctx context.Context, app *common.App, code []byte) ([]byte, error) {
+	if len(code) > maxModuleSize {
+		return nil, fmt.Errorf("module is larger than %d bytes", maxModuleSize)
+	}
+	if _, err := newModule(ctx, code, defaultGasLimit); err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(code)
+	err := app.Engine.ExecuteWithoutEngineCtx(ctx, app.DB, `{`+schemaName+`}INSERT INTO modules (hash, code, size)
+		VALUES ($hash, $code, $size) ON CONFLICT (hash) DO NOTHING`,
+		map[string]any{"hash": hash[:], "code": code, "size": int64(len(code))}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return hash[:], nil
+}
+
+// loadModule reads a stored module. It is read directly from Postgres, since
+// the engine is not available to initializers.
+func loadModule(ctx context.Context, db sql.DB, hash []byte) ([]byte, error) {
+	res, err := db.Execute(ctx, `SELECT to_regclass('`+schemaName+`.modules') IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) == 1 && res.Rows[0][0] == true {
+		res, err = db.Execute(ctx, `SELECT code FROM `+schemaName+`.modules WHERE hash = $1`, hash)
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Rows) == 1 {
+			code, ok := res.Rows[0][0].([]byte)
+			if !ok {
+				return nil, fmt.Errorf("unexpected type %T for module code", res.Rows[0][0])
+			}
+			if sum := sha256.Sum256(code); string(sum[:]) != string(hash) {
+				return nil, fmt.Errorf("stored wasm module %x does not match its hash", hash)
+			}
+			return code, nil
+		}
+	}
+	return nil, fmt.Errorf("wasm module %x is not stored, use %s.store to store it", hash, RegistryName)
+}
+
+// module is a compiled module and the methods that it declares.
+type module struct {
+	compiled wazero.CompiledModule
+	gasLimit int64
+	methods  []precompiles.Method
+}
+
+// newModule compiles a module and reads its method declarations.
+func newModule(ctx context.Context, code []byte, gasLimit int64) (*module, error) {
+	cm, err := compile(ctx, sha256.Sum256(code), code)
+	if err != nil {
+		return nil, err
+	}
+	m := &module{compiled: cm, gasLimit: gasLimit}
+
+	out, err := invoke(ctx, cm, &session{}, gasLimit, methodsExport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading methods: %w", err)
+	}
+	var decls []methodDecl
+	if err = json.Unmarshal(out, &decls); err != nil {
+		return nil, fmt.Errorf("invalid method declarations: %w", err)
+	}
+	for _, decl := range decls {
+		method, err := m.method(decl)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", decl.Name, err)
+		}
+		m.methods = append(m.methods, method)
+	}
+	return m, nil
+}
+
+type methodDecl struct {
+	Name       string      `json:"name"`
+	Modifiers  []string    `json:"modifiers"`
+	Parameters []valueDecl `json:"parameters"`
+	Returns    *struct {
+		Table  bool        `json:"table"`
+		Fields []valueDecl `json:"fields"`
+	} `json:"returns"`
+}
+
+type valueDecl struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// supportedTypes are the types that can be converted to and from JSON.
+var supportedTypes = map[string]bool{
+	types.IntType.Name:   true,
+	types.TextType.Name:  true,
+	types.BoolType.Name:  true,
+	types.ByteaType.Name: true,
+	types.NumericStr:     true,
+	types.UUIDType.Name:  true,
+}
+
+func (v *valueDecl) value() (precompiles.PrecompileValue, error) {
+	dt, err := types.ParseDataType(v.Type)
+	if err != nil {
+		return precompiles.PrecompileValue{}, err
+	}
+	if dt.IsArray || !supportedTypes[dt.Name] {
+		return precompiles.PrecompileValue{}, fmt.Errorf("unsupported type %s", dt)
+	}
+	return precompiles.NewPrecompileValue(v.Name, dt, v.Nullable), nil
+}
+
+func (m *module) method(decl methodDecl) (precompiles.Method, error) {
+	switch decl.Name {
+	case allocExport, methodsExport, memoryExport, gasExport:
+		return precompiles.Method{}, errors.New("method name is reserved")
+	}
+	if err := checkSignature(m.compiled.ExportedFunctions(), decl.Name,
+		[]api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, api.ValueTypeI64); err != nil {
+		return precompiles.Method{}, err
+	}
+
+	method := precompiles.Method{Name: decl.Name}
+	for _, mod := range decl.Modifiers {
+		modifier := precompiles.Modifier(strings.ToUpper(mod))
+		switch modifier {
+		case precompiles.PUBLIC, precompiles.PRIVATE, precompiles.SYSTEM, precompiles.VIEW, precompiles.OWNER:
+		default:
+			return precompiles.Method{}, fmt.Errorf("unknown modifier %s", mod)
+		}
+		method.AccessModifiers = append(method.AccessModifiers, modifier)
+	}
+	for _, param := range decl.Parameters {
+		v, err := param.value()
+		if err != nil {
+			return precompiles.Method{}, fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		method.Parameters = append(method.Parameters, v)
+	}
+	if decl.Returns != nil {
+		method.Returns = &precompiles.MethodReturn{IsTable: decl.Returns.Table}
+		for _, field := range decl.Returns.Fields {
+			v, err := field.value()
+			if err != nil {
+				return precompiles.Method{}, fmt.Errorf("return field %s: %w", field.Name, err)
+			}
+			method.Returns.Fields = append(method.Returns.Fields, v)
+		}
+	}
+
+	method.Handler = func(ctx *common.EngineContext, app *common.App, inputs []any, resultFn func([]any) error) error {
+		return m.call(ctx, app, &method, inputs, resultFn)
+	}
+	return method, nil
+}
+
+// call calls a method, and converts the rows that it returns to the declared
+// types.
+func (m *module) call(ctx *common.EngineContext, app *common.App, method *precompiles.Method, inputs []any, resultFn func([]any) error) error {
+	args, err := json.Marshal(inputs)
+	if err != nil {
+		return err
+	}
+	out, err := invoke(ctx.TxContext.Ctx, m.compiled, &session{engineCtx: ctx, app: app}, m.gasLimit, method.Name, args)
+	if err != nil {
+		return err
+	}
+	if method.Returns == nil {
+		return nil
+	}
+
+	var rows [][]any
+	if err = decodeJSON(out, &rows); err != nil {
+		return fmt.Errorf("invalid result of method %s: %w", method.Name, err)
+	}
+	fields := method.Returns.Fields
+	for _, row := range rows {
+		if len(row) != len(fields) {
+			return fmt.Errorf("method %s returned %d values, expected %d", method.Name, len(row), len(fields))
+		}
+		for i, v := range row {
+			if v == nil && !fields[i].Nullable {
+				return fmt.Errorf("method %s returned null for %s", method.Name, fields[i].Name)
+			}
+			if row[i], err = resultValue(v, fields[i].Type); err != nil {
+				return fmt.Errorf("method %s returned invalid %s: %w", method.Name, fields[i].Name, err)
+			}
+		}
+		if err = resultFn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package wasm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/extensions/precompiles"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+// The functions below assemble small WebAssembly modules for the tests.
+
+func vec(items ...[]byte) []byte {
+	b := appendU32(nil, uint32(len(items)))
+	for _, item := range items {
+		b = append(b, item...)
+	}
+	return b
+}
+
+func name(s string) []byte {
+	return append(appendU32(nil, uint32(len(s))), s...)
+}
+
+func sect(id byte, items ...[]byte) []byte {
+	content := vec(items...)
+	return append(append([]byte{id}, appendU32(nil, uint32(len(content)))...), content...)
+}
+
+func funcType(params []byte, results []byte) []byte {
+	b := append([]byte{0x60}, name(string(params))...)
+	return append(b, name(string(results))...)
+}
+
+func funcBody(code ...byte) []byte {
+	body := append([]byte{0x00}, code...) // no locals
+	return append(appendU32(nil, uint32(len(body))), body...)
+}
+
+func dataSegment(offset int64, data string) []byte {
+	b := append([]byte{0x00, 0x41}, appendS64(nil, offset)...)
+	return append(append(b, opEnd), name(data)...)
+}
+
+func i32Const(v int64) []byte {
+	return append([]byte{0x41}, appendS64(nil, v)...)
+}
+
+func assemble(sections ...[]byte) []byte {
+	b := append([]byte{}, wasmHeader...)
+	for _, s := range sections {
+		b = append(b, s...)
+	}
+	return b
+}
+
+const (
+	testMethods = `[
+		{"name": "query", "modifiers": ["PUBLIC", "VIEW"], "returns": {"table": true, "fields": [
+			{"name": "n", "type": "int8"},
+			{"name": "s", "type": "text"},
+			{"name": "b", "type": "bytea", "nullable": true}
+		]}},
+		{"name": "spin", "modifiers": ["public"]}
+	]`
+	testStmt   = `SELECT n, s, b FROM t WHERE n >= $n`
+	testParams = `{"n": 5}`
+)
+
+// testModule exports:
+//   - alloc, a bump allocator.
+//   - methods, which returns testMethods.
+//   - query, which executes testStmt with testParams and returns the result.
+//   - spin, which loops forever.
+//   - count, which loops countIterations times and uses exactly countGas.
+func testModule() []byte {
+	const i32, i64 = 0x7f, 0x7e
+	var (
+		stmtOffset   = int64(2048)
+		paramsOffset = int64(3072)
+		heapStart    = int64(4096)
+	)
+
+	var query []byte
+	query = append(query, i32Const(stmtOffset)...)
+	query = append(query, i32Const(int64(len(testStmt)))...)
+	query = append(query, i32Const(paramsOffset)...)
+	query = append(query, i32Const(int64(len(testParams)))...)
+	query = append(query, 0x10, 0x00, opEnd) // call execute
+
+	methods := append([]byte{opI64Const}, appendS64(nil, int64(len(testMethods)))...) // pointer 0
+	methods = append(methods, opEnd)
+
+	return assemble(
+		sect(1, // types
+			funcType([]byte{i32}, []byte{i32}),
+			funcType(nil, []byte{i64}),
+			funcType([]byte{i32, i32}, []byte{i64}),
+			funcType([]byte{i32, i32, i32, i32}, []byte{i64}),
+		),
+		sect(2, append(append(name("kwil"), name("execute")...), importFunc, 3)),
+		sect(3, []byte{0}, []byte{1}, []byte{2}, []byte{2}, []byte{1}),
+		sect(5, []byte{0x00, 1}), // one page of memory
+		sect(6, append(append([]byte{i32, 0x01}, i32Const(heapStart)...), opEnd)),
+		sect(7,
+			append(name("memory"), 0x02, 0),
+			append(name("alloc"), 0x00, 1),
+			append(name("methods"), 0x00, 2),
+			append(name("query"), 0x00, 3),
+			append(name("spin"), 0x00, 4),
+			append(name("count"), 0x00, 5),
+		),
+		sect(10,
+			// global.get 0, global.get 0, local.get 0, i32.add, global.set 0
+			funcBody(opGlobalGet, 0, opGlobalGet, 0, 0x20, 0, 0x6a, opGlobalSet, 0, opEnd),
+			funcBody(methods...),
+			funcBody(query...),
+			// loop, br 0, end, i64.const 0
+			funcBody(opLoop, blockTypeEmpty, 0x0c, 0, opEnd, opI64Const, 0, opEnd),
+			countBody(),
+		),
+		sect(11,
+			dataSegment(0, testMethods),
+			dataSegment(stmtOffset, testStmt),
+			dataSegment(paramsOffset, testParams),
+		),
+	)
+}
+
+const (
+	countIterations = 1000
+	// countGas is the gas used by count: 3 instructions outside of its loop,
+	// and 8 in each iteration.
+	countGas = 3 + 8*countIterations
+)
+
+// countBody is the body of a function with an i32 local that loops until the
+// local is countIterations.
+func countBody() []byte {
+	const i32 = 0x7f
+	body := []byte{0x01, 0x01, i32} // one i32 local
+	body = append(body, opLoop, blockTypeEmpty)
+	// local.get 0, i32.const 1, i32.add, local.tee 0
+	body = append(body, 0x20, 0)
+	body = append(body, i32Const(1)...)
+	body = append(body, 0x6a, 0x22, 0)
+	// i32.const countIterations, i32.lt_s, br_if 0
+	body = append(body, i32Const(countIterations)...)
+	body = append(body, 0x48, 0x0d, 0, opEnd)
+	body = append(body, opI64Const, 0, opEnd)
+	return append(appendU32(nil, uint32(len(body))), body...)
+}
+
+type fakeEngine struct {
+	common.Engine
+	stmt   string
+	params map[string]any
+}
+
+func (e *fakeEngine) Execute(ctx *common.EngineContext, db sql.DB, statement string, params map[string]any, fn func(*common.Row) error) error {
+	e.stmt, e.params = statement, params
+	for _, values := range [][]any{{int64(5), "five", []byte{1, 2}}, {int64(6), "six", nil}} {
+		if err := fn(&common.Row{Values: values}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func callMethod(t *testing.T, m *module, methodName string, app *common.App) ([][]any, error) {
+	ctx := &common.EngineContext{TxContext: &common.TxContext{Ctx: context.Background()}}
+	for _, method := range m.methods {
+		if method.Name == methodName {
+			var rows [][]any
+			err := method.Handler(ctx, app, nil, func(row []any) error {
+				rows = append(rows, row)
+				return nil
+			})
+			return rows, err
+		}
+	}
+	t.Fatalf("method %s not found", methodName)
+	return nil, nil
+}
+
+func TestModule(t *testing.T) {
+	m, err := newModule(context.Background(), testModule(), 100_000)
+	require.NoError(t, err)
+
+	require.Len(t, m.methods, 2)
+	require.Equal(t, "query", m.methods[0].Name)
+	require.Equal(t, []precompiles.Modifier{precompiles.PUBLIC, precompiles.VIEW}, m.methods[0].AccessModifiers)
+	require.True(t, m.methods[0].Returns.IsTable)
+	require.Len(t, m.methods[0].Returns.Fields, 3)
+	require.Equal(t, "spin", m.methods[1].Name)
+	require.Equal(t, []precompiles.Modifier{precompiles.PUBLIC}, m.methods[1].AccessModifiers)
+	require.Nil(t, m.methods[1].Returns)
+
+	engine := &fakeEngine{}
+	rows, err := callMethod(t, m, "query", &common.App{Engine: engine})
+	require.NoError(t, err)
+	require.Equal(t, testStmt, engine.stmt)
+	require.Equal(t, map[string]any{"n": int64(5)}, engine.params)
+	require.Equal(t, [][]any{{int64(5), "five", []byte{1, 2}}, {int64(6), "six", nil}}, rows)
+
+	// An infinite loop runs out of gas.
+	_, err = callMethod(t, m, "spin", &common.App{Engine: engine})
+	require.ErrorIs(t, err, ErrOutOfGas)
+
+	// Host functions are charged for.
+	m.gasLimit = hostCallGas
+	_, err = callMethod(t, m, "query", &common.App{Engine: engine})
+	require.ErrorIs(t, err, ErrOutOfGas)
+}
+
+func TestInstrumentRejectsFloats(t *testing.T) {
+	code := assemble(
+		sect(1, funcType(nil, nil)),
+		sect(3, []byte{0}),
+		// f32.const 0, f32.const 0, f32.add, drop
+		sect(10, funcBody(0x43, 0, 0, 0, 0, 0x43, 0, 0, 0, 0, 0x92, 0x1a, opEnd)),
+	)
+	_, err := instrument(code, 0)
+	require.ErrorContains(t, err, "floating point")
+
+	// Copying floats is allowed.
+	code = assemble(
+		sect(1, funcType(nil, nil)),
+		sect(3, []byte{0}),
+		sect(10, funcBody(0x43, 0, 0, 0, 0, 0x1a, opEnd)),
+	)
+	_, err = instrument(code, 0)
+	require.NoError(t, err)
+}
+
+func TestParseMetadata(t *testing.T) {
+	hash := "0x" + strings.Repeat("ab", 32)
+	_, gasLimit, err := parseMetadata(map[string]any{"module_hash": hash})
+	require.NoError(t, err)
+	require.Equal(t, int64(defaultGasLimit), gasLimit)
+
+	_, gasLimit, err = parseMetadata(map[string]any{"module_hash": hash, "gas_limit": "500"})
+	require.NoError(t, err)
+	require.Equal(t, int64(500), gasLimit)
+
+	for _, metadata := range []map[string]any{
+		{},
+		{"module_hash": "abcd"},
+		{"module_hash": hash, "gas_limit": int64(0)},
+	} {
+		_, _, err = parseMetadata(metadata)
+		require.Error(t, err, metadata)
+	}
+}
+
+// TestGasDeterministic checks that gas is the number of instructions executed,
+// so a call uses the same gas, and runs out of gas at the same point, on every
+// node and every run, however long it takes.
+func TestGasDeterministic(t *testing.T) {
+	ctx := context.Background()
+	code := testModule()
+
+	first, err := instrument(code, 0)
+	require.NoError(t, err)
+	second, err := instrument(code, 0)
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	for range 2 {
+		m, err := newModule(ctx, code, defaultGasLimit)
+		require.NoError(t, err)
+
+		_, err = invoke(ctx, m.compiled, &session{}, countGas, "count", nil)
+		require.NoError(t, err)
+
+		_, err = invoke(ctx, m.compiled, &session{}, countGas-1, "count", nil)
+		require.ErrorIs(t, err, ErrOutOfGas)
+	}
+}
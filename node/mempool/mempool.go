@@ -4,11 +4,15 @@ import (
 	"context"
 	"slices"
 	"sync"
+	"time"
 
 	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/node/types"
 )
 
+var mets metrics.MempoolMetrics = metrics.Mempool
+
 // Mempool maintains a thread-safe pool of unconfirmed transactions with size limits.
 type Mempool struct {
 	mtx         sync.RWMutex
@@ -26,18 +30,34 @@ type Mempool struct {
 
 type sizedTx struct {
 	*types.Tx
-	size int64
+	size  int64
+	added time.Time
 }
 
 // New creates a new Mempool instance with a default max size of 200MB.
 // See also SetMaxSize.
 func New(sz, txSz int64) *Mempool {
-	return &Mempool{
+	mp := &Mempool{
 		txns:      make(map[types.Hash]*sizedTx),
 		fetching:  make(map[types.Hash]bool),
 		maxSize:   sz,
 		maxTxSize: txSz,
 	}
+	mets.ObserveMempool(mp.stats)
+	return mp
+}
+
+// stats returns the number of transactions, their total size in bytes, and
+// the time since the oldest transaction was added.
+func (mp *Mempool) stats() (numTxns, totalBytes int64, oldest time.Duration) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+	if len(mp.txQ) > 0 {
+		if tx := mp.txns[mp.txQ[0].Hash()]; tx != nil {
+			oldest = time.Since(tx.added)
+		}
+	}
+	return int64(len(mp.txQ)), mp.currentSize, oldest
 }
 
 // SetMaxSize updates the maximum allowed size in bytes for the mempool.
@@ -123,8 +143,9 @@ func (mp *Mempool) Store(tx *types.Tx) error {
 	mp.currentSize += sz
 
 	mp.txns[txid] = &sizedTx{
-		Tx:   tx,
-		size: sz,
+		Tx:    tx,
+		size:  sz,
+		added: time.Now(),
 	}
 	mp.txQ = append(mp.txQ, tx)
 	return nil
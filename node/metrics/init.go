@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/sdk/metric"
@@ -18,8 +19,10 @@ import (
 type OTELOption func(*otelOptions)
 
 type otelOptions struct {
-	endpoint string
-	interval time.Duration
+	endpoint   string
+	interval   time.Duration
+	noOTLP     bool
+	prometheus *PrometheusExporter
 }
 
 func WithOTELEndpoint(endpoint string) OTELOption {
@@ -34,11 +37,29 @@ func WithOTELInterval(interval time.Duration) OTELOption {
 	}
 }
 
+// WithoutOTLP disables the export of metrics and traces to an OTLP collector,
+// e.g. when only the Prometheus exporter is used.
+func WithoutOTLP() OTELOption {
+	return func(o *otelOptions) {
+		o.noOTLP = true
+	}
+}
+
+// WithPrometheus adds a Prometheus exporter that serves the metrics when
+// scraped. Attributes with unbounded values, such as block heights, are dropped
+// from all metrics so that the number of series remains bounded.
+func WithPrometheus(exp *PrometheusExporter) OTELOption {
+	return func(o *otelOptions) {
+		o.prometheus = exp
+	}
+}
+
 // StartOTEL bootstraps the OpenTelemetry pipeline. The collected metrics are
 // exported to the specified OTLP (opentelemetry protocol) collector HTTP
-// endpoint. The endpoint is in host port format, with no schema, as it uses
-// unencrypted HTTP currently. If it does not return an error, make sure to call
-// shutdown for proper cleanup.
+// endpoint, unless WithoutOTLP is used, and to a Prometheus exporter if one is
+// provided with WithPrometheus. The endpoint is in host port format, with no
+// schema, as it uses unencrypted HTTP currently. If it does not return an
+// error, make sure to call shutdown for proper cleanup.
 func StartOTEL(ctx context.Context, options ...OTELOption) (func(context.Context) error, error) {
 	opts := &otelOptions{
 		endpoint: "127.0.0.1:4318",
@@ -76,42 +97,53 @@ func StartOTEL(ctx context.Context, options ...OTELOption) (func(context.Context
 		return errors.Join(inErr, shutdown(ctx))
 	}
 
-	// Set up propagator.
-	// prop := newPropagator()
-	// otel.SetTextMapPropagator(prop)
+	// Set up propagator, for instrumentation that uses the global one.
+	otel.SetTextMapPropagator(propagator)
 
-	// Set up trace provider.
-	traceExporter, err := otlptracehttp.New(context.Background(),
-		otlptracehttp.WithEndpoint(opts.endpoint),
-		otlptracehttp.WithInsecure(),
-	)
-	// traceExporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
-	if err != nil {
-		return nil, handleErr(err)
+	var meterOpts []metric.Option
+
+	if !opts.noOTLP {
+		// Set up trace provider.
+		traceExporter, err := otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpoint(opts.endpoint),
+			otlptracehttp.WithInsecure(),
+		)
+		// traceExporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, handleErr(err)
+		}
+		tracerProvider := trace.NewTracerProvider(
+			trace.WithResource(res),
+			trace.WithBatcher(traceExporter, trace.WithBatchTimeout(opts.interval)),
+		)
+		shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
+
+		otel.SetTracerProvider(tracerProvider) // for use with otel.Tracer()
+
+		// Set up meter exporter.
+		metricExporter, err := otlpmetrichttp.New(context.Background(),
+			otlpmetrichttp.WithEndpoint(opts.endpoint),
+			otlpmetrichttp.WithInsecure(),
+		)
+		if err != nil {
+			return nil, handleErr(err)
+		}
+		meterOpts = append(meterOpts, metric.WithReader(metric.NewPeriodicReader(metricExporter,
+			metric.WithInterval(opts.interval)))) // Default is 1m.
 	}
-	tracerProvider := trace.NewTracerProvider(
-		trace.WithResource(res),
-		trace.WithBatcher(traceExporter, trace.WithBatchTimeout(opts.interval)),
-	)
-	shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
 
-	otel.SetTracerProvider(tracerProvider) // for use with otel.Tracer()
+	if opts.prometheus != nil {
+		meterOpts = append(meterOpts, metric.WithReader(opts.prometheus.reader),
+			metric.WithView(metric.NewView(metric.Instrument{Name: "*"},
+				metric.Stream{AttributeFilter: attribute.NewDenyKeysFilter(highCardinalityKeys...)})))
+	}
 
-	// Set up meter exporter.
-	metricExporter, err := otlpmetrichttp.New(context.Background(),
-		otlpmetrichttp.WithEndpoint(opts.endpoint),
-		otlpmetrichttp.WithInsecure(),
-	)
-	if err != nil {
-		return nil, handleErr(err)
+	if len(meterOpts) == 0 {
+		return shutdown, nil // nothing to export
 	}
 
 	// Set up meter provider.
-	meterProvider := metric.NewMeterProvider(
-		metric.WithResource(res),
-		metric.WithReader(metric.NewPeriodicReader(metricExporter,
-			metric.WithInterval(opts.interval))), // Default is 1m.
-	)
+	meterProvider := metric.NewMeterProvider(append(meterOpts, metric.WithResource(res))...)
 
 	shutdownFuncs = append(shutdownFuncs, meterProvider.Shutdown)
 	otel.SetMeterProvider(meterProvider)
@@ -128,13 +160,6 @@ func StartOTEL(ctx context.Context, options ...OTELOption) (func(context.Context
 	return shutdown, nil
 }
 
-// func newPropagator() propagation.TextMapPropagator {
-// 	return propagation.NewCompositeTextMapPropagator(
-// 		propagation.TraceContext{},
-// 		propagation.Baggage{},
-// 	)
-// }
-
 // func newLoggerProvider() (*log.LoggerProvider, error) {
 // 	logExporter, err := stdoutlog.New()
 // 	if err != nil {
@@ -17,6 +17,10 @@ var (
 	Consensus ConsensusMetrics = consensusMetrics{}
 	Node      NodeMetrics      = nodeMetrics{}
 	Store     StoreMetrics     = storeMetrics{}
+	Engine    EngineMetrics    = engineMetrics{}
+	Mempool   MempoolMetrics   = mempoolMetrics{}
+	Block     BlockMetrics     = blockMetrics{}
+	Votes     VotesMetrics     = votesMetrics{}
 )
 
 // If we do not want to use the otel global meter provider, we can create our
@@ -50,6 +54,23 @@ var (
 	// Engine metrics
 	// engineNumNamespaces metric.Int64Gauge // TODO
 	// engineStatementParseCount metric.Int64Counter
+	actionLatencyHist metric.Float64Histogram
+	actionErrCounter  metric.Int64Counter
+
+	// Mempool metrics
+	mempoolMeter       metric.Meter
+	mempoolTxnsGauge   metric.Int64ObservableGauge
+	mempoolBytesGauge  metric.Int64ObservableGauge
+	mempoolOldestTxAge metric.Float64ObservableGauge
+
+	// Block processor metrics
+	blockPhaseLatencyHist metric.Float64Histogram
+
+	// Resolution (voting) metrics
+	resolutionsResolvedCounter metric.Int64Counter
+	resolutionsExpiredCounter  metric.Int64Counter
+	resolutionsPendingGauge    metric.Int64Gauge
+	resolutionsApprovalGauge   metric.Float64Gauge
 
 	// Accounts metrics
 	// accountsNum metric.Int64ObservableGauge // callback should get account count?
@@ -63,7 +84,9 @@ var (
 	execCounter       metric.Int64Counter
 
 	// Node / p2p metrics
+	nodeMeter                metric.Meter
 	numPeersGauge            metric.Int64Gauge
+	peersByRoleGauge         metric.Int64ObservableGauge
 	downloadedBlocksCounter  metric.Int64Counter
 	servedBlocksCounter      metric.Int64Counter
 	servedBlockBytesCounter  metric.Int64Counter
@@ -103,6 +126,8 @@ const (
 	BlockStoreMeterName = "github.com/kwilteam/kwil-db/node/store"
 
 	AccountsMeterName = "github.com/kwilteam/kwil-db/node/accounts"
+
+	VotesMeterName = "github.com/kwilteam/kwil-db/node/txapp" // resolution voting
 )
 
 // init sets up all meters and instruments. Initially, the no-op meter
@@ -122,8 +147,9 @@ func init() {
 	latencyHist, _ = rpcMeter.Float64Histogram("requests.duration")
 
 	// Node metrics
-	nodeMeter := otel.Meter(NodeMeterName)
+	nodeMeter = otel.Meter(NodeMeterName)
 	numPeersGauge, _ = nodeMeter.Int64Gauge("node.peers.total")
+	peersByRoleGauge, _ = nodeMeter.Int64ObservableGauge("node.peers.connected")
 	downloadedBlocksCounter, _ = nodeMeter.Int64Counter("node.blocks_downloaded.count")
 	servedBlocksCounter, _ = nodeMeter.Int64Counter("node.blocks_served.count")
 	servedBlockBytesCounter, _ = nodeMeter.Int64Counter("node.blocks_served.bytes")
@@ -148,6 +174,28 @@ func init() {
 	bsBlocksRetrievedCounter, _ = storeMeter.Int64Counter("blocks.retrieved.count")
 	bsBlockBytesRetrievedCounter, _ = storeMeter.Int64Counter("blocks.retrieved.bytes")
 	bsTransactionsRetrievedCounter, _ = storeMeter.Int64Counter("transactions.retrieved.count")
+
+	// Engine metrics
+	engineMeter := otel.Meter(EngineMeterName)
+	actionLatencyHist, _ = engineMeter.Float64Histogram("engine.action.latency")
+	actionErrCounter, _ = engineMeter.Int64Counter("engine.action.errors")
+
+	// Mempool metrics
+	mempoolMeter = otel.Meter(MempoolMeterName)
+	mempoolTxnsGauge, _ = mempoolMeter.Int64ObservableGauge("mempool.txns")
+	mempoolBytesGauge, _ = mempoolMeter.Int64ObservableGauge("mempool.bytes")
+	mempoolOldestTxAge, _ = mempoolMeter.Float64ObservableGauge("mempool.oldest_tx.age") // seconds
+
+	// Block processor metrics
+	bpMeter := otel.Meter(BlockProcessorMeterName)
+	blockPhaseLatencyHist, _ = bpMeter.Float64Histogram("block.exec.phase.latency")
+
+	// Resolution voting metrics
+	votesMeter := otel.Meter(VotesMeterName)
+	resolutionsResolvedCounter, _ = votesMeter.Int64Counter("resolutions.resolved")
+	resolutionsExpiredCounter, _ = votesMeter.Int64Counter("resolutions.expired")
+	resolutionsPendingGauge, _ = votesMeter.Int64Gauge("resolutions.pending")
+	resolutionsApprovalGauge, _ = votesMeter.Float64Gauge("resolutions.pending.approval") // fraction of the required power
 }
 
 type storeMetrics struct{}
@@ -182,6 +230,7 @@ func (storeMetrics) TransactionRetrieved(ctx context.Context) {
 
 type NodeMetrics interface {
 	PeerCount(ctx context.Context, numPeers int)
+	ObservePeers(fn func() map[string]int64)
 	DownloadedBlock(ctx context.Context, blockHeight, size int64)
 	ServedBlock(ctx context.Context, blockHeight, size int64)
 	Advertised(ctx context.Context, protocol string)
@@ -197,6 +246,17 @@ func (nodeMetrics) PeerCount(ctx context.Context, numPeers int) {
 	numPeersGauge.Record(ctx, int64(numPeers))
 }
 
+// ObservePeers registers a function that returns the number of connected peers
+// by role (e.g. "validator"), which is called when metrics are collected.
+func (nodeMetrics) ObservePeers(fn func() map[string]int64) {
+	nodeMeter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for role, n := range fn() {
+			o.ObserveInt64(peersByRoleGauge, n, metric.WithAttributes(attribute.String("role", role)))
+		}
+		return nil
+	}, peersByRoleGauge)
+}
+
 func (nodeMetrics) DownloadedBlock(ctx context.Context, blockHeight, size int64) {
 	downloadedBlocksCounter.Add(ctx, 1,
 		metric.WithAttributes(attribute.Int64("height", blockHeight), attribute.Int64("size", size)),
@@ -313,3 +373,80 @@ func (rpcMetrics) RecordRequest(ctx context.Context, method string, status int,
 		metric.WithAttributes(attribute.String("method", method)),
 	)
 }
+
+type EngineMetrics interface {
+	ActionExecuted(ctx context.Context, namespace, action string, latency time.Duration, failed bool)
+}
+
+type engineMetrics struct{}
+
+// ActionExecuted records the execution of a top level action call.
+func (engineMetrics) ActionExecuted(ctx context.Context, namespace, action string, latency time.Duration, failed bool) {
+	attrs := metric.WithAttributes(attribute.String("namespace", namespace), attribute.String("action", action))
+	actionLatencyHist.Record(ctx, 1000*latency.Seconds(), attrs)
+	if failed {
+		actionErrCounter.Add(ctx, 1, attrs)
+	}
+}
+
+type MempoolMetrics interface {
+	ObserveMempool(fn func() (numTxns, totalBytes int64, oldest time.Duration))
+}
+
+type mempoolMetrics struct{}
+
+// ObserveMempool registers a function that returns the number of transactions
+// in the mempool, their total size, and the time since the oldest was added,
+// which is called when metrics are collected.
+func (mempoolMetrics) ObserveMempool(fn func() (numTxns, totalBytes int64, oldest time.Duration)) {
+	mempoolMeter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		numTxns, totalBytes, oldest := fn()
+		o.ObserveInt64(mempoolTxnsGauge, numTxns)
+		o.ObserveInt64(mempoolBytesGauge, totalBytes)
+		o.ObserveFloat64(mempoolOldestTxAge, oldest.Seconds())
+		return nil
+	}, mempoolTxnsGauge, mempoolBytesGauge, mempoolOldestTxAge)
+}
+
+type BlockMetrics interface {
+	RecordPhase(ctx context.Context, phase string, latency time.Duration)
+}
+
+type blockMetrics struct{}
+
+// RecordPhase records the duration of a phase of block execution, such as the
+// execution of the block's transactions.
+func (blockMetrics) RecordPhase(ctx context.Context, phase string, latency time.Duration) {
+	blockPhaseLatencyHist.Record(ctx, 1000*latency.Seconds(), metric.WithAttributes(attribute.String("phase", phase)))
+}
+
+type VotesMetrics interface {
+	ResolutionResolved(ctx context.Context, resolutionType string, failed bool)
+	ResolutionExpired(ctx context.Context, resolutionType string, refunded bool)
+	ResolutionsPending(ctx context.Context, resolutionType string, pending int64, maxApproval float64)
+}
+
+type votesMetrics struct{}
+
+// ResolutionResolved records a resolution that reached its confirmation
+// threshold. It is failed if its resolve function returned an error.
+func (votesMetrics) ResolutionResolved(ctx context.Context, resolutionType string, failed bool) {
+	resolutionsResolvedCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("type", resolutionType),
+		attribute.Bool("failed", failed)))
+}
+
+// ResolutionExpired records a resolution that expired before it was confirmed.
+func (votesMetrics) ResolutionExpired(ctx context.Context, resolutionType string, refunded bool) {
+	resolutionsExpiredCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("type", resolutionType),
+		attribute.Bool("refunded", refunded)))
+}
+
+// ResolutionsPending records the number of unresolved resolutions of a type,
+// and the highest approved power among them as a fraction of the power
+// required to confirm them. A value of 1 or more means that it will be
+// confirmed in the next block.
+func (votesMetrics) ResolutionsPending(ctx context.Context, resolutionType string, pending int64, maxApproval float64) {
+	attrs := metric.WithAttributes(attribute.String("type", resolutionType))
+	resolutionsPendingGauge.Record(ctx, pending, attrs)
+	resolutionsApprovalGauge.Record(ctx, maxApproval, attrs)
+}
@@ -0,0 +1,212 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// prometheusNamespace prefixes the names of the metrics served to Prometheus.
+const prometheusNamespace = "kwild"
+
+// highCardinalityKeys are attributes that are dropped from all metrics when
+// the Prometheus exporter is used, since each distinct value would create a
+// new time series that is kept for the life of the process.
+var highCardinalityKeys = []attribute.Key{"height", "size", "latency", "num_txns", "error"}
+
+// PrometheusExporter serves the node's metrics in the Prometheus exposition
+// format, for scraping by a Prometheus server. It must be provided to
+// StartOTEL with WithPrometheus, and collects nothing until then. Metrics from
+// the default Prometheus registry, such as the Go runtime metrics, are also
+// served.
+type PrometheusExporter struct {
+	reader  *metric.ManualReader
+	handler http.Handler
+}
+
+var _ http.Handler = (*PrometheusExporter)(nil)
+
+// NewPrometheusExporter creates a new PrometheusExporter.
+func NewPrometheusExporter() *PrometheusExporter {
+	reader := metric.NewManualReader()
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(&otelCollector{reader: reader})
+
+	return &PrometheusExporter{
+		reader: reader,
+		handler: promhttp.HandlerFor(prometheus.Gatherers{reg, prometheus.DefaultGatherer},
+			promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}),
+	}
+}
+
+// ServeHTTP serves the current metrics.
+func (p *PrometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.handler.ServeHTTP(w, r)
+}
+
+// otelCollector is a prometheus.Collector that converts the metrics collected
+// by an OpenTelemetry reader. It is an "unchecked" collector since the metrics
+// are not known in advance.
+type otelCollector struct {
+	reader *metric.ManualReader
+}
+
+func (c *otelCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *otelCollector) Collect(ch chan<- prometheus.Metric) {
+	var rm metricdata.ResourceMetrics
+	err := c.reader.Collect(context.Background(), &rm)
+	if err != nil {
+		if !errors.Is(err, metric.ErrReaderNotRegistered) {
+			ch <- prometheus.NewInvalidMetric(prometheus.NewDesc(prometheusNamespace+"_otel_collect_error",
+				"error collecting metrics", nil, nil), err)
+		}
+		return
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			collectMetric(ch, m)
+		}
+	}
+}
+
+func collectMetric(ch chan<- prometheus.Metric, m metricdata.Metrics) {
+	name, help := promName(m.Name), m.Description
+	if help == "" {
+		help = m.Name
+	}
+
+	switch data := m.Data.(type) {
+	case metricdata.Sum[int64]:
+		collectSum(ch, name, help, data.IsMonotonic, data.DataPoints)
+	case metricdata.Sum[float64]:
+		collectSum(ch, name, help, data.IsMonotonic, data.DataPoints)
+	case metricdata.Gauge[int64]:
+		collectPoints(ch, name, help, prometheus.GaugeValue, data.DataPoints)
+	case metricdata.Gauge[float64]:
+		collectPoints(ch, name, help, prometheus.GaugeValue, data.DataPoints)
+	case metricdata.Histogram[int64]:
+		collectHistogram(ch, name, help, data.DataPoints)
+	case metricdata.Histogram[float64]:
+		collectHistogram(ch, name, help, data.DataPoints)
+	} // exponential histograms and summaries are not used
+}
+
+func collectSum[N int64 | float64](ch chan<- prometheus.Metric, name, help string, monotonic bool, points []metricdata.DataPoint[N]) {
+	if !monotonic { // e.g. an UpDownCounter
+		collectPoints(ch, name, help, prometheus.GaugeValue, points)
+		return
+	}
+	if !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	collectPoints(ch, name, help, prometheus.CounterValue, points)
+}
+
+func collectPoints[N int64 | float64](ch chan<- prometheus.Metric, name, help string, valueType prometheus.ValueType, points []metricdata.DataPoint[N]) {
+	sets := make([]attribute.Set, len(points))
+	for i, p := range points {
+		sets[i] = p.Attributes
+	}
+	keys := labelKeys(sets)
+	desc := prometheus.NewDesc(name, help, promLabels(keys), nil)
+
+	for _, p := range points {
+		m, err := prometheus.NewConstMetric(desc, valueType, float64(p.Value), labelValues(keys, p.Attributes)...)
+		if err != nil {
+			m = prometheus.NewInvalidMetric(desc, err)
+		}
+		ch <- m
+	}
+}
+
+func collectHistogram[N int64 | float64](ch chan<- prometheus.Metric, name, help string, points []metricdata.HistogramDataPoint[N]) {
+	sets := make([]attribute.Set, len(points))
+	for i, p := range points {
+		sets[i] = p.Attributes
+	}
+	keys := labelKeys(sets)
+	desc := prometheus.NewDesc(name, help, promLabels(keys), nil)
+
+	for _, p := range points {
+		// OpenTelemetry bucket counts are per bucket, while Prometheus buckets
+		// are cumulative. The last OpenTelemetry bucket is the +Inf bucket,
+		// which is implied by the count.
+		buckets := make(map[float64]uint64, len(p.Bounds))
+		var cumulative uint64
+		for i, bound := range p.Bounds {
+			cumulative += p.BucketCounts[i]
+			buckets[bound] = cumulative
+		}
+
+		m, err := prometheus.NewConstHistogram(desc, p.Count, float64(p.Sum), buckets, labelValues(keys, p.Attributes)...)
+		if err != nil {
+			m = prometheus.NewInvalidMetric(desc, err)
+		}
+		ch <- m
+	}
+}
+
+// labelKeys returns the sorted union of the keys of the attribute sets.
+// Prometheus requires all series of a metric to have the same labels, so
+// attributes that are missing from a series are given an empty value.
+func labelKeys(sets []attribute.Set) []attribute.Key {
+	var keys []attribute.Key
+	for _, set := range sets {
+		iter := set.Iter()
+		for iter.Next() {
+			if key := iter.Attribute().Key; !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func labelValues(keys []attribute.Key, set attribute.Set) []string {
+	vals := make([]string, len(keys))
+	for i, key := range keys {
+		if v, ok := set.Value(key); ok {
+			vals[i] = v.Emit()
+		}
+	}
+	return vals
+}
+
+func promLabels(keys []attribute.Key) []string {
+	labels := make([]string, len(keys))
+	for i, key := range keys {
+		labels[i] = sanitize(string(key))
+	}
+	return labels
+}
+
+// promName converts an instrument name like "consensus.commit.latency" to a
+// Prometheus metric name like "kwild_consensus_commit_latency".
+func promName(name string) string {
+	return prometheusNamespace + "_" + sanitize(name)
+}
+
+// sanitize replaces the characters that are not valid in Prometheus metric
+// and label names with underscores.
+func sanitize(name string) string {
+	s := []byte(name)
+	for i, c := range s {
+		valid := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')
+		if !valid {
+			s[i] = '_'
+		}
+	}
+	return string(s)
+}
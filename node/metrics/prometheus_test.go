@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestSanitize(t *testing.T) {
+	require.Equal(t, "kwild_consensus_commit_latency", promName("consensus.commit.latency"))
+	require.Equal(t, "_lives_x_y", sanitize("9lives-x/y"))
+	require.Equal(t, "role", sanitize("role"))
+}
+
+func TestPrometheusExporter(t *testing.T) {
+	exp := NewPrometheusExporter()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(exp.reader))
+	defer provider.Shutdown(context.Background())
+
+	meter := provider.Meter("test")
+	counter, err := meter.Int64Counter("block.txns", metric.WithDescription("number of transactions"))
+	require.NoError(t, err)
+	hist, err := meter.Float64Histogram("action.latency", metric.WithExplicitBucketBoundaries(0.1, 1))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	counter.Add(ctx, 3, metric.WithAttributes(attribute.String("role", "leader")))
+	counter.Add(ctx, 2)
+	hist.Record(ctx, 0.05)
+	hist.Record(ctx, 0.5)
+	hist.Record(ctx, 5)
+
+	rec := httptest.NewRecorder()
+	exp.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	out := string(body)
+
+	require.Contains(t, out, "# HELP kwild_block_txns_total number of transactions")
+	require.Contains(t, out, `kwild_block_txns_total{role="leader"} 3`)
+	require.Contains(t, out, `kwild_block_txns_total{role=""} 2`)
+	require.Contains(t, out, `kwild_action_latency_bucket{le="0.1"} 1`)
+	require.Contains(t, out, `kwild_action_latency_bucket{le="1"} 2`)
+	require.Contains(t, out, `kwild_action_latency_bucket{le="+Inf"} 3`)
+	require.Contains(t, out, "kwild_action_latency_count 3")
+	require.Contains(t, out, "go_goroutines") // default registry
+}
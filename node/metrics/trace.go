@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracers for the instrumented packages. Like the meters, they use the no-op
+// tracer provider until StartOTEL configures an exporter, so spans cost little
+// when tracing is disabled. The tracer names match the meter names.
+var (
+	RPCTracer            = otel.Tracer(RPCMeterName)
+	ConsensusTracer      = otel.Tracer(ConsensusMeterName)
+	BlockProcessorTracer = otel.Tracer(BlockProcessorMeterName)
+	TxAppTracer          = otel.Tracer(VotesMeterName) // node/txapp
+	EngineTracer         = otel.Tracer(EngineMeterName)
+	DBTracer             = otel.Tracer(DBMeterName)
+)
+
+// propagator carries the trace context in the W3C traceparent and tracestate
+// headers, and any baggage in the baggage header.
+var propagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// ExtractTraceContext returns a context with the remote span context, if any,
+// from the headers of an incoming request, so that spans created by the server
+// are part of the client's trace.
+func ExtractTraceContext(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectTraceContext sets the headers of an outgoing request that propagate
+// the span context of ctx, if it has one. It may be used as the InjectHeaders
+// option of a core/client.Client.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// StartSpan starts a span with the tracer. The span must be ended, e.g. with
+// EndSpan. A nil ctx is treated as context.Background().
+func StartSpan(ctx context.Context, tracer trace.Tracer, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends the span, recording the error if it is not nil.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextPropagation(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	defer provider.Shutdown(context.Background())
+	tracer := provider.Tracer("test")
+
+	// client side
+	ctx, clientSpan := StartSpan(context.Background(), tracer, "client")
+	header := make(http.Header)
+	InjectTraceContext(ctx, header)
+	require.NotEmpty(t, header.Get("traceparent"))
+	clientSpan.End()
+
+	// server side
+	ctx = ExtractTraceContext(context.Background(), header)
+	_, serverSpan := StartSpan(ctx, tracer, "server")
+	EndSpan(serverSpan, errors.New("boom"))
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	client, server := ended[0], ended[1]
+	require.Equal(t, client.SpanContext().TraceID(), server.SpanContext().TraceID())
+	require.Equal(t, client.SpanContext().SpanID(), server.Parent().SpanID())
+	require.True(t, server.Parent().IsRemote())
+	require.Equal(t, codes.Error, server.Status().Code)
+	require.Equal(t, "boom", server.Status().Description)
+
+	// no trace context
+	ctx = ExtractTraceContext(context.Background(), http.Header{})
+	require.False(t, trace.SpanContextFromContext(ctx).IsValid())
+}
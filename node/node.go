@@ -12,6 +12,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -104,6 +105,49 @@ func (wl *WhitelistMgr) RemovePeer(nodeID string) error {
 	return nil
 }
 
+// UpdateConfigured applies a change to the whitelist in the node's config,
+// which is not persisted, from the previous to the current list of node IDs.
+// A node removed from the config stays whitelisted if it is in the persistent
+// whitelist, such as a validator or a peer added with AddPeer. This only has
+// an effect in private mode.
+func (wl *WhitelistMgr) UpdateConfigured(prev, cur []string) error {
+	prevIDs, err := nodeIDsToPeerIDs(prev)
+	if err != nil {
+		return err
+	}
+	curIDs, err := nodeIDsToPeerIDs(cur)
+	if err != nil {
+		return err
+	}
+	persistent := wl.pm.AllowedPersistent()
+
+	for _, peerID := range curIDs {
+		if !slices.Contains(prevIDs, peerID) {
+			wl.logger.Infof("Adding configured peer to whitelist: %v", peerID)
+			wl.pm.Allow(peerID)
+		}
+	}
+	for _, peerID := range prevIDs {
+		if !slices.Contains(curIDs, peerID) && !slices.Contains(persistent, peerID) {
+			wl.logger.Infof("Removing configured peer from whitelist: %v", peerID)
+			wl.pm.Disallow(peerID)
+		}
+	}
+	return nil
+}
+
+func nodeIDsToPeerIDs(nodeIDs []string) ([]peer.ID, error) {
+	peerIDs := make([]peer.ID, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		peerID, err := nodeIDToPeerID(nodeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid whitelist node ID %q: %w", nodeID, err)
+		}
+		peerIDs = append(peerIDs, peerID)
+	}
+	return peerIDs, nil
+}
+
 func (wl *WhitelistMgr) List() []string {
 	var list []string
 	for _, peerID := range wl.pm.AllowedPersistent() {
@@ -286,6 +330,8 @@ func (n *Node) Start(ctx context.Context) error {
 		n.pm.Allow(peerID)
 	}
 
+	mets.ObservePeers(n.peerRoles)
+
 	valSub := n.bp.SubscribeValidators()
 	n.wg.Add(1)
 	go func() {
@@ -630,6 +676,27 @@ func (randSrc) Uint64() uint64 {
 
 var rng = mrand2.New(randSrc{})
 
+// peerRoles returns the number of connected peers that are validators, and
+// the number that are not.
+func (n *Node) peerRoles() map[string]int64 {
+	validators := make(map[peer.ID]bool)
+	for _, val := range n.bp.GetValidators() {
+		if peerID, err := peerIDForValidator(val.Identifier); err == nil {
+			validators[peerID] = true
+		}
+	}
+
+	roles := map[string]int64{"validator": 0, "other": 0}
+	for _, peerID := range n.host.Network().Peers() {
+		if validators[peerID] {
+			roles["validator"]++
+		} else {
+			roles["other"]++
+		}
+	}
+	return roles
+}
+
 func (n *Node) peers() []peer.ID {
 	return peerHosts(n.host)
 }
@@ -590,6 +590,10 @@ func (m *mockEventStore) GetUnbroadcastedEvents(ctx context.Context) ([]*ktypes.
 	return ids, nil
 }
 
+func (m *mockEventStore) GetUnbroadcastedNewEvents(ctx context.Context) ([]*ktypes.VotableEvent, error) {
+	return nil, nil
+}
+
 func (m *mockEventStore) HasEvents() bool {
 	return true
 }
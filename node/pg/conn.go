@@ -110,12 +110,61 @@ type PoolConfig struct {
 
 // NewPool creates a connection pool to a PostgreSQL database.
 func NewPool(ctx context.Context, cfg *PoolConfig) (*Pool, error) {
-	if cfg.User == "" {
-		return nil, errors.New("db user must not be empty")
-	}
 	if cfg.MaxConns < 2 {
 		return nil, errors.New("at least two total connections are required")
 	}
+
+	subscribers := syncmap.New[int64, chan<- string]()
+	pCfg, err := poolConfig(cfg, subscribers)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := pgxpool.NewWithConfig(ctx, pCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	writerCfg := pCfg.Copy()
+	writerCfg.MaxConns = 2 // just one should be fine, but keep a pair for faster reconnect if it needs reconnect
+	writer, err := pgxpool.NewWithConfig(ctx, writerCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	reservedCfg := pCfg.Copy()
+	reservedCfg.MaxConns = 2 // just one should be fine, but keep a pair for faster reconnect if it needs reconnect
+	reserved, err := pgxpool.NewWithConfig(ctx, reservedCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// acquire a writer to determine the OID of the custom types
+	writerConn, err := writer.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer writerConn.Release()
+	oidTypes := oidTypesMap(writerConn.Conn().TypeMap())
+
+	pool := &Pool{
+		readers:     db,
+		writer:      writer,
+		reserved:    reserved,
+		idTypes:     oidTypes,
+		subscribers: subscribers,
+	}
+
+	return pool, db.Ping(ctx)
+}
+
+// poolConfig creates the pgxpool config for the connection settings, with
+// handlers that log postgres notices and errors, and deliver the notices from
+// the special NOTICE() function to the subscribers.
+func poolConfig(cfg *PoolConfig, subscribers *syncmap.Map[int64, chan<- string]) (*pgxpool.Config, error) {
+	if cfg.User == "" {
+		return nil, errors.New("db user must not be empty")
+	}
 	const repl = false
 	connStr := connString(cfg.Host, cfg.Port, cfg.User, cfg.Pass, cfg.DBName, repl)
 	connStr += fmt.Sprintf(" pool_max_conns=%d", cfg.MaxConns)
@@ -124,8 +173,6 @@ func NewPool(ctx context.Context, cfg *PoolConfig) (*Pool, error) {
 		return nil, err
 	}
 
-	subscribers := syncmap.New[int64, chan<- string]()
-
 	// NOTE: we can consider changing the default exec mode at construction e.g.:
 	// pCfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
 	pCfg.ConnConfig.OnNotice = func(_ *pgconn.PgConn, n *pgconn.Notice) {
@@ -187,42 +234,7 @@ func NewPool(ctx context.Context, cfg *PoolConfig) (*Pool, error) {
 		return defaultOnPgError(c, n) // automatically close any fatal errors (default we are overridding)
 	}
 
-	db, err := pgxpool.NewWithConfig(ctx, pCfg)
-	if err != nil {
-		return nil, err
-	}
-
-	writerCfg := pCfg.Copy()
-	writerCfg.MaxConns = 2 // just one should be fine, but keep a pair for faster reconnect if it needs reconnect
-	writer, err := pgxpool.NewWithConfig(ctx, writerCfg)
-	if err != nil {
-		return nil, err
-	}
-
-	reservedCfg := pCfg.Copy()
-	reservedCfg.MaxConns = 2 // just one should be fine, but keep a pair for faster reconnect if it needs reconnect
-	reserved, err := pgxpool.NewWithConfig(ctx, reservedCfg)
-	if err != nil {
-		return nil, err
-	}
-
-	// acquire a writer to determine the OID of the custom types
-	writerConn, err := writer.Acquire(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer writerConn.Release()
-	oidTypes := oidTypesMap(writerConn.Conn().TypeMap())
-
-	pool := &Pool{
-		readers:     db,
-		writer:      writer,
-		reserved:    reserved,
-		idTypes:     oidTypes,
-		subscribers: subscribers,
-	}
-
-	return pool, db.Ping(ctx)
+	return pCfg, nil
 }
 
 // Query performs a read-only query using the read connection pool. It is
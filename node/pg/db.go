@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/kwilteam/kwil-db/core/utils/random"
 	"github.com/kwilteam/kwil-db/node/metrics"
@@ -389,7 +390,11 @@ func (db *DB) BeginTx(ctx context.Context) (sql.Tx, error) {
 // It obtains a read connection from the pool, which will be returned
 // to the pool when the transaction is closed.
 func (db *DB) BeginReadTx(ctx context.Context) (sql.OuterReadTx, error) {
-	return db.beginReadTx(ctx, pgx.RepeatableRead)
+	tx, err := db.beginReadTx(ctx, pgx.RepeatableRead)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
 }
 
 // BeginSnapshotTx creates a read-only transaction with serializable isolation
@@ -415,7 +420,7 @@ func (db *DB) BeginSnapshotTx(ctx context.Context) (sql.Tx, string, error) {
 	return tx, snapshotID, err
 }
 
-func (db *DB) beginReadTx(ctx context.Context, iso pgx.TxIsoLevel) (sql.OuterReadTx, error) {
+func (db *DB) beginReadTx(ctx context.Context, iso pgx.TxIsoLevel) (*readTx, error) {
 	// stat := db.pool.readers.Stat()
 	// fmt.Printf("total / max cons: %d / %d\n", stat.TotalConns(), stat.MaxConns())
 	conn, err := db.pool.readers.Acquire(ctx) // ensure we have a connection
@@ -465,12 +470,65 @@ func (db *DB) BeginReservedReadTx(ctx context.Context) (sql.Tx, error) {
 	}, nil
 }
 
+// scratchStatementTimeout and scratchLockTimeout limit the statements of a
+// scratch transaction, so that the row locks it takes do not block the writer
+// for long. A statement that waits longer than scratchLockTimeout for a lock,
+// such as one held by the writer, fails instead.
+const (
+	scratchStatementTimeout = time.Second
+	scratchLockTimeout      = 200 * time.Millisecond
+)
+
+// BeginScratchTx starts a read-write transaction on a reader connection that
+// can only be rolled back. It allows changes to be made and inspected outside
+// of consensus, such as when tracing an action for debugging, without using
+// the writer connection. Rows it modifies are locked until it is rolled back,
+// so each statement is limited to scratchStatementTimeout, and waits at most
+// scratchLockTimeout for a lock. Commit rolls back and returns ErrScratchTx.
+func (db *DB) BeginScratchTx(ctx context.Context) (sql.Tx, error) {
+	conn, err := db.pool.readers.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadWrite,
+		IsoLevel:   pgx.ReadCommitted,
+	})
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+	for _, stmt := range []string{
+		fmt.Sprintf("SET LOCAL statement_timeout = %d", scratchStatementTimeout.Milliseconds()),
+		fmt.Sprintf("SET LOCAL lock_timeout = %d", scratchLockTimeout.Milliseconds()),
+	} {
+		if _, err = tx.Exec(ctx, stmt); err != nil {
+			err = errors.Join(err, tx.Rollback(ctx))
+			conn.Release()
+			return nil, err
+		}
+	}
+
+	return &scratchTx{
+		nestedTx: &nestedTx{
+			Tx:         tx,
+			accessMode: sql.ReadWrite,
+			oidTypes:   db.pool.idTypes,
+		},
+		release: sync.OnceFunc(conn.Release),
+	}, nil
+}
+
 // BeginDelayedReadTx returns a valid SQL transaction, but will only
 // start the transaction once the first query is executed. This is useful
 // for when a calling module is expected to control the lifetime of a read
 // transaction, but the implementation might not need to use the transaction.
 func (db *DB) BeginDelayedReadTx() sql.OuterReadTx {
-	return &delayedReadTx{db: db}
+	return &delayedReadTx{
+		begin: func(ctx context.Context) (*readTx, error) {
+			return db.beginReadTx(ctx, pgx.RepeatableRead)
+		},
+	}
 }
 
 type writeTxWrapper struct {
@@ -0,0 +1,270 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// embeddedSettings are the server settings that an embedded PostgreSQL server
+// is started with, which satisfy the checks in verifySettings. These are the
+// same as in the kwil-postgres docker image.
+var embeddedSettings = []string{
+	"wal_level=logical",
+	"max_wal_senders=10",
+	"max_replication_slots=10",
+	"track_commit_timestamp=true",
+	"wal_sender_timeout=0",
+	"max_prepared_transactions=2",
+	"max_locks_per_transaction=4096",
+	"max_connections=128",
+}
+
+const (
+	embeddedStartTimeout = 30 * time.Second
+	embeddedStopTimeout  = 30 * time.Second
+)
+
+// EmbeddedConfig is the configuration for an embedded PostgreSQL server.
+type EmbeddedConfig struct {
+	// DataDir is the data directory of the database cluster. If it does not
+	// contain a cluster, one is created with initdb.
+	DataDir string
+	// BinDir is the directory containing the initdb and postgres binaries. If
+	// it is empty, they are found on the PATH.
+	BinDir string
+	// Port is the TCP port that the server listens on. The server only
+	// listens on the loopback interface, and not on a UNIX socket.
+	Port string
+	// User is the superuser created by initdb, and Pass is its password. If
+	// Pass is empty, connections are trusted without a password.
+	User, Pass string
+	// DBName is the database that is created if it does not exist.
+	DBName string
+	// LogFile is the file that the server's output is appended to.
+	LogFile string
+}
+
+// EmbeddedServer is a PostgreSQL server process that is managed by kwild,
+// using the locally installed PostgreSQL binaries. It is intended for
+// development and single-node setups, where provisioning a separate server
+// is inconvenient.
+type EmbeddedServer struct {
+	cmd     *exec.Cmd
+	logFile string
+
+	done chan struct{}
+	err  error // set before done is closed
+
+	stopOnce sync.Once
+}
+
+// StartEmbedded starts a PostgreSQL server with the data directory in the
+// config, first initializing the database cluster if needed, and waits for it
+// to accept connections. The configured database is created if it does not
+// exist. The server must be stopped with Stop.
+func StartEmbedded(ctx context.Context, cfg *EmbeddedConfig) (*EmbeddedServer, error) {
+	if os.Geteuid() == 0 {
+		return nil, errors.New("the embedded postgres server cannot be run as root")
+	}
+	if cfg.User == "" {
+		return nil, errors.New("db user must not be empty")
+	}
+
+	initialized, err := clusterExists(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	if !initialized {
+		logger.Infof("Initializing embedded postgres database cluster in %s", cfg.DataDir)
+		if err = initCluster(ctx, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	postgres, err := embeddedBinary(cfg.BinDir, "postgres")
+	if err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(cfg.LogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer logFile.Close() // the child process has its own descriptor
+
+	args := []string{"-D", cfg.DataDir, "-p", cfg.Port,
+		"-c", "listen_addresses=127.0.0.1", "-c", "unix_socket_directories="}
+	for _, setting := range embeddedSettings {
+		args = append(args, "-c", setting)
+	}
+	cmd := exec.Command(postgres, args...)
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	setEmbeddedProcAttr(cmd)
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start postgres: %w", err)
+	}
+
+	s := &EmbeddedServer{
+		cmd:     cmd,
+		logFile: cfg.LogFile,
+		done:    make(chan struct{}),
+	}
+	go func() {
+		err := cmd.Wait()
+		if err == nil {
+			err = errors.New("exit status 0")
+		}
+		s.err = fmt.Errorf("postgres exited: %w (see %s)", err, s.logFile)
+		close(s.done)
+	}()
+
+	if err = s.waitReady(ctx, cfg); err != nil {
+		return nil, errors.Join(err, s.Stop())
+	}
+	logger.Infof("Embedded postgres server started on port %s (pid %d)", cfg.Port, cmd.Process.Pid)
+
+	return s, nil
+}
+
+// clusterExists reports if the data directory contains a database cluster.
+func clusterExists(dataDir string) (bool, error) {
+	_, err := os.Stat(filepath.Join(dataDir, "PG_VERSION"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func embeddedBinary(binDir, name string) (string, error) {
+	if binDir != "" {
+		return filepath.Join(binDir, name), nil
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("postgres binary %s not found: %w", name, err)
+	}
+	return path, nil
+}
+
+// initCluster creates a database cluster with initdb. The cluster uses UTF8
+// encoding with the C locale, and the superuser in the config.
+func initCluster(ctx context.Context, cfg *EmbeddedConfig) error {
+	initdb, err := embeddedBinary(cfg.BinDir, "initdb")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(cfg.DataDir), 0755); err != nil {
+		return err
+	}
+
+	args := []string{"-D", cfg.DataDir, "-U", cfg.User, "--encoding=UTF8", "--no-locale"}
+	if cfg.Pass == "" {
+		args = append(args, "--auth=trust")
+	} else {
+		pwFile, err := os.CreateTemp("", "kwild-pwfile-")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(pwFile.Name())
+		_, err = pwFile.WriteString(cfg.Pass)
+		if err = errors.Join(err, pwFile.Close()); err != nil {
+			return err
+		}
+		args = append(args, "--auth-local=trust", "--auth-host=scram-sha-256", "--pwfile="+pwFile.Name())
+	}
+
+	out, err := exec.CommandContext(ctx, initdb, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("initdb failed: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// waitReady waits for the server to accept connections, and then creates the
+// configured database if it does not exist.
+func (s *EmbeddedServer) waitReady(ctx context.Context, cfg *EmbeddedConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, embeddedStartTimeout)
+	defer cancel()
+
+	const repl = false
+	connStr := connString("127.0.0.1", cfg.Port, cfg.User, cfg.Pass, "postgres", repl)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return s.err
+		case <-ctx.Done():
+			return fmt.Errorf("postgres did not start (see %s): %w", s.logFile, ctx.Err())
+		case <-ticker.C:
+		}
+
+		conn, err := pgx.Connect(ctx, connStr)
+		if err != nil {
+			continue // not ready
+		}
+		defer conn.Close(context.Background())
+
+		var exists bool
+		err = conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)",
+			cfg.DBName).Scan(&exists)
+		if err != nil || exists {
+			return err
+		}
+		logger.Infof("Creating database %s", cfg.DBName)
+		_, err = conn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{cfg.DBName}.Sanitize()+
+			" OWNER "+pgx.Identifier{cfg.User}.Sanitize())
+		return err
+	}
+}
+
+// Done returns a channel that is closed when the server process exits, either
+// when it is stopped or if it crashes.
+func (s *EmbeddedServer) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason that the server process exited, or nil if it is
+// still running.
+func (s *EmbeddedServer) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Stop shuts down the server with a fast shutdown, which rolls back active
+// transactions and disconnects clients. If it does not exit in time, it is
+// killed.
+func (s *EmbeddedServer) Stop() error {
+	var err error
+	s.stopOnce.Do(func() {
+		select {
+		case <-s.done:
+			return // already exited
+		default:
+		}
+
+		if err = interruptEmbedded(s.cmd); err != nil {
+			err = errors.Join(err, s.cmd.Process.Kill())
+		}
+		select {
+		case <-s.done:
+		case <-time.After(embeddedStopTimeout):
+			logger.Warnf("Embedded postgres server did not shut down, killing it")
+			err = errors.Join(err, s.cmd.Process.Kill())
+			<-s.done
+		}
+	})
+	return err
+}
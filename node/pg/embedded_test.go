@@ -0,0 +1,70 @@
+package pg
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func freePort(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+}
+
+func TestStartEmbedded(t *testing.T) {
+	if _, err := exec.LookPath("initdb"); err != nil {
+		t.Skip("postgres binaries not found on the PATH")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("the embedded postgres server cannot be run as root")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := &EmbeddedConfig{
+		DataDir: filepath.Join(dir, "pgdata"),
+		Port:    freePort(t),
+		User:    "kwild",
+		Pass:    "kwild",
+		DBName:  "kwild",
+		LogFile: filepath.Join(dir, "postgres.log"),
+	}
+
+	s, err := StartEmbedded(ctx, cfg)
+	require.NoError(t, err)
+
+	const repl = false
+	conn, err := pgx.Connect(ctx, connString("127.0.0.1", cfg.Port, cfg.User, cfg.Pass, cfg.DBName, repl))
+	require.NoError(t, err)
+	var walLevel string
+	require.NoError(t, conn.QueryRow(ctx, "SHOW wal_level").Scan(&walLevel))
+	require.Equal(t, "logical", walLevel)
+	require.NoError(t, conn.Close(ctx))
+
+	require.NoError(t, s.Err())
+	require.NoError(t, s.Stop())
+	<-s.Done()
+	require.NoError(t, s.Stop()) // idempotent
+
+	// The existing cluster is reused, and a crash is reported by Done and Err.
+	s, err = StartEmbedded(ctx, cfg)
+	require.NoError(t, err)
+	require.NoError(t, s.cmd.Process.Kill())
+	select {
+	case <-s.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("embedded server did not exit")
+	}
+	require.ErrorContains(t, s.Err(), cfg.LogFile)
+	require.NoError(t, s.Stop())
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package pg
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setEmbeddedProcAttr starts the server in its own process group, so that a
+// terminal's interrupt is not delivered to it directly, and kwild can close
+// its connections before stopping the server.
+func setEmbeddedProcAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interruptEmbedded requests a fast shutdown of the server.
+func interruptEmbedded(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(syscall.SIGINT)
+}
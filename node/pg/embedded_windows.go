@@ -0,0 +1,13 @@
+//go:build windows
+
+package pg
+
+import "os/exec"
+
+func setEmbeddedProcAttr(*exec.Cmd) {}
+
+// interruptEmbedded kills the server, since a signal for a fast shutdown
+// cannot be sent on Windows.
+func interruptEmbedded(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
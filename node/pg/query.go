@@ -7,7 +7,10 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 )
 
@@ -145,6 +148,14 @@ type connQueryer interface {
 
 var _ connQueryer = (*cqWrapper)(nil)
 
+// startQuerySpan starts a span for a statement. The statement is recorded since
+// it is usually generated, e.g. by the engine for an action, and is needed to
+// understand where a slow transaction spent its time.
+func startQuerySpan(ctx context.Context, stmt string) (context.Context, trace.Span) {
+	return metrics.StartSpan(ctx, metrics.DBTracer, "query",
+		attribute.String("db.system", "postgresql"), attribute.String("db.statement", stmt))
+}
+
 // cqWrapper implements connQueryer from a *pgx.Conn (as a pgx.Tx does).
 // This looks
 type cqWrapper struct {
@@ -159,7 +170,10 @@ func (cq *cqWrapper) Query(ctx context.Context, sql string, args ...any) (pgx.Ro
 	return cq.c.Query(ctx, sql, args...)
 }
 
-func query(ctx context.Context, oidToDataType map[uint32]*datatype, cq connQueryer, stmt string, args ...any) (*sql.ResultSet, error) {
+func query(ctx context.Context, oidToDataType map[uint32]*datatype, cq connQueryer, stmt string, args ...any) (_ *sql.ResultSet, err error) {
+	ctx, span := startQuerySpan(ctx, stmt)
+	defer func() { metrics.EndSpan(span, err) }()
+
 	q := cq.Query
 	if mustInferArgs(args) {
 		// return nil, errors.New("cannot use QueryModeInferredArgTypes with query")
@@ -253,15 +267,34 @@ func queryTx(ctx context.Context, oidToDataType map[uint32]*datatype, dbTx txBeg
 }
 
 func queryRowFunc(ctx context.Context, conn *pgx.Conn, stmt string,
-	scans []any, fn func() error, args ...any) error {
+	scans []any, fn func() error, args ...any) (err error) {
+	ctx, span := startQuerySpan(ctx, stmt)
+	defer func() { metrics.EndSpan(span, err) }()
+
 	rows, _ := conn.Query(ctx, stmt, args...)
-	_, err := pgx.ForEachRow(rows, scans, fn)
+	ctag, err := pgx.ForEachRow(rows, scans, fn)
+	if rec, ok := ctx.Value(commandTagRecorderKey{}).(func(sql.CommandTag)); ok && err == nil {
+		rec(sql.CommandTag{
+			Text:         ctag.String(),
+			RowsAffected: ctag.RowsAffected(),
+		})
+	}
 	if sql.IsFatalDBError(err) {
 		err = errors.Join(err, sql.ErrDBFailure)
 	}
 	return err
 }
 
+type commandTagRecorderKey struct{}
+
+// WithCommandTagRecorder returns a context that, when used with QueryRowFunc,
+// causes fn to be called with the command tag of each successful query. This
+// allows a caller that only handles the returned rows, such as the engine when
+// tracing, to learn the number of rows affected by a statement.
+func WithCommandTagRecorder(ctx context.Context, fn func(sql.CommandTag)) context.Context {
+	return context.WithValue(ctx, commandTagRecorderKey{}, fn)
+}
+
 // QueryRowFunc will attempt to execute an SQL statement, handling the rows and
 // returned values as described by the sql.QueryScanner interface. If the
 // provided Executor is also a sql.QueryScanner, that method will be used,
@@ -310,7 +343,10 @@ func QueryRowFuncAny(ctx context.Context, tx sql.Executor, stmt string,
 }
 
 func queryRowFuncAny(ctx context.Context, conn *pgx.Conn, stmt string,
-	fn func(vals []any) error, args ...any) error {
+	fn func(vals []any) error, args ...any) (err error) {
+	ctx, span := startQuerySpan(ctx, stmt)
+	defer func() { metrics.EndSpan(span, err) }()
+
 	oidTypes := oidTypesMap(conn.TypeMap())
 
 	rows, _ := conn.Query(ctx, stmt, args...)
@@ -0,0 +1,256 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/kwilteam/kwil-db/node/types/sql"
+	"github.com/kwilteam/kwil-db/node/utils/syncmap"
+)
+
+// replicaLagCheckInterval is how often the replay lag of a Replica is checked.
+// Between checks, read transactions are routed by the last result. A check
+// that takes longer than replicaLagCheckTimeout fails, and the primary is used
+// until a later check succeeds.
+const (
+	replicaLagCheckInterval = time.Second
+	replicaLagCheckTimeout  = 500 * time.Millisecond
+)
+
+// ReplicaConfig is the configuration for a Replica.
+type ReplicaConfig struct {
+	// PoolConfig is the connection settings and size of the pool of read
+	// connections to the standby.
+	PoolConfig
+
+	// MaxLag is the maximum number of blocks that the standby may be behind the
+	// primary's committed height for read transactions to use it.
+	MaxLag int64
+
+	// Height returns the committed height recorded in the database, such as
+	// meta.GetChainState. It is used on both the primary and the standby to
+	// determine the replay lag.
+	Height func(ctx context.Context, tx sql.Executor) (int64, error)
+}
+
+// Replica routes read-only transactions to a streaming standby of the node's
+// own database, so that expensive user queries do not contend with block
+// execution on the primary. The standby's replay lag is checked against the
+// committed height on the primary, and read transactions fall back to the
+// primary while the lag exceeds the configured maximum, or if the standby is
+// unreachable. The lag is checked in the background, so beginning a
+// transaction does not wait for the standby.
+//
+// Notices from a transaction on the standby cannot be subscribed to since a
+// standby cannot assign transaction IDs.
+type Replica struct {
+	primary     *DB
+	readers     *pgxpool.Pool
+	idTypes     map[uint32]*datatype
+	subscribers *syncmap.Map[int64, chan<- string]
+
+	maxLag int64
+	lag    func(ctx context.Context) (int64, error)
+
+	caughtUp atomic.Bool
+	checked  bool // only used by the monitor goroutine
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ sql.ReadTxMaker = (*Replica)(nil)
+var _ sql.DelayedReadTxMaker = (*Replica)(nil)
+
+// NewReplica creates a pool of read connections to a standby of the primary
+// DB. The standby is not contacted until its lag is first checked, so it need
+// not be reachable yet. Until then, read transactions use the primary. The
+// primary is not closed by the Replica's Close method.
+func NewReplica(ctx context.Context, primary *DB, cfg *ReplicaConfig) (*Replica, error) {
+	if cfg.Height == nil {
+		return nil, errors.New("no height function")
+	}
+	if cfg.MaxConns < 1 {
+		return nil, errors.New("at least one connection is required")
+	}
+	if cfg.MaxLag < 0 {
+		return nil, errors.New("negative max lag")
+	}
+
+	subscribers := syncmap.New[int64, chan<- string]()
+	pCfg, err := poolConfig(&cfg.PoolConfig, subscribers)
+	if err != nil {
+		return nil, err
+	}
+	readers, err := pgxpool.NewWithConfig(ctx, pCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replica{
+		primary:     primary,
+		readers:     readers,
+		idTypes:     primary.pool.idTypes, // the standby has the same types
+		subscribers: subscribers,
+		maxLag:      cfg.MaxLag,
+		done:        make(chan struct{}),
+	}
+	var warnedNotStandby bool
+	r.lag = func(ctx context.Context) (int64, error) {
+		lag, inRecovery, err := r.replayLag(ctx, cfg.Height)
+		if err == nil && !inRecovery && !warnedNotStandby {
+			logger.Warnf("read replica at %s is not a standby server", cfg.Host)
+			warnedNotStandby = true
+		}
+		return lag, err
+	}
+
+	var monitorCtx context.Context
+	monitorCtx, r.cancel = context.WithCancel(context.Background())
+	go r.monitor(monitorCtx, replicaLagCheckInterval)
+	return r, nil
+}
+
+// monitor checks the replay lag every interval until ctx is canceled.
+func (r *Replica) monitor(ctx context.Context, interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, replicaLagCheckTimeout)
+		r.check(checkCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replayLag returns the number of blocks the standby is behind the primary,
+// and if it is in recovery, as a standby server is.
+func (r *Replica) replayLag(ctx context.Context, height func(context.Context, sql.Executor) (int64, error)) (lag int64, inRecovery bool, err error) {
+	primaryTx, err := r.primary.beginReadTx(ctx, pgx.RepeatableRead)
+	if err != nil {
+		return 0, false, err
+	}
+	defer primaryTx.Rollback(ctx)
+	primaryHeight, err := height(ctx, primaryTx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	standbyTx, err := r.beginStandbyReadTx(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer standbyTx.Rollback(ctx)
+	standbyHeight, err := height(ctx, standbyTx)
+	if err != nil {
+		return 0, false, err
+	}
+	if err = standbyTx.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return 0, false, err
+	}
+	return primaryHeight - standbyHeight, inRecovery, nil
+}
+
+// check checks the replay lag, and updates whether read transactions use the
+// standby.
+func (r *Replica) check(ctx context.Context) {
+	first := !r.checked
+	r.checked = true
+
+	lag, err := r.lag(ctx)
+	if err != nil {
+		if r.caughtUp.Swap(false) || first {
+			logger.Warnf("failed to check read replica lag, using primary: %v", err)
+		}
+		return
+	}
+
+	caughtUp := lag <= r.maxLag
+	if r.caughtUp.Swap(caughtUp) != caughtUp {
+		if caughtUp {
+			logger.Infof("read replica caught up (lag %d blocks), routing queries to it", lag)
+		} else {
+			logger.Warnf("read replica is %d blocks behind, routing queries to primary", lag)
+		}
+	}
+}
+
+// useStandby reports if read transactions should use the standby, according
+// to the last check of the replay lag.
+func (r *Replica) useStandby() bool {
+	return r.caughtUp.Load()
+}
+
+func (r *Replica) beginStandbyReadTx(ctx context.Context) (*readTx, error) {
+	conn, err := r.readers.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Serializable is not permitted on a standby.
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadOnly,
+		IsoLevel:   pgx.RepeatableRead,
+	})
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return &readTx{
+		nestedTx: &nestedTx{
+			Tx:         tx,
+			accessMode: sql.ReadOnly,
+			oidTypes:   r.idTypes,
+		},
+		release:     sync.OnceFunc(conn.Release),
+		subscribers: r.subscribers,
+	}, nil
+}
+
+func (r *Replica) beginReadTx(ctx context.Context) (*readTx, error) {
+	if r.useStandby() {
+		tx, err := r.beginStandbyReadTx(ctx)
+		if err == nil {
+			return tx, nil
+		}
+		logger.Warnf("failed to begin read replica transaction, using primary: %v", err)
+	}
+	return r.primary.beginReadTx(ctx, pgx.RepeatableRead)
+}
+
+// BeginReadTx starts a read-only transaction on the standby, or on the primary
+// if the standby is lagging or unavailable.
+func (r *Replica) BeginReadTx(ctx context.Context) (sql.OuterReadTx, error) {
+	tx, err := r.beginReadTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// BeginDelayedReadTx returns a read-only transaction that is started on the
+// first query, on either the standby or the primary like BeginReadTx.
+func (r *Replica) BeginDelayedReadTx() sql.OuterReadTx {
+	return &delayedReadTx{begin: r.beginReadTx}
+}
+
+// Close stops checking the replay lag and closes the standby's connection
+// pool.
+func (r *Replica) Close() error {
+	r.cancel()
+	<-r.done
+	r.readers.Close()
+	return nil
+}
@@ -0,0 +1,72 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaUseStandby(t *testing.T) {
+	ctx := context.Background()
+
+	var lag int64
+	var lagErr error
+	r := &Replica{
+		maxLag: 2,
+		lag: func(context.Context) (int64, error) {
+			return lag, lagErr
+		},
+	}
+
+	// The primary is used until the lag is first checked.
+	require.False(t, r.useStandby())
+
+	r.check(ctx)
+	require.True(t, r.useStandby())
+
+	lag = 3
+	r.check(ctx)
+	require.False(t, r.useStandby())
+
+	lag = 2
+	r.check(ctx)
+	require.True(t, r.useStandby())
+
+	// The primary is used if the lag cannot be checked.
+	lagErr = errors.New("connection refused")
+	r.check(ctx)
+	require.False(t, r.useStandby())
+
+	lagErr = nil
+	r.check(ctx)
+	require.True(t, r.useStandby())
+}
+
+func TestReplicaMonitor(t *testing.T) {
+	var checks atomic.Int64
+	r := &Replica{
+		maxLag: 0,
+		lag: func(ctx context.Context) (int64, error) {
+			if checks.Add(1) == 1 {
+				// A check that does not return in time fails.
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return 0, nil
+		},
+		done: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go r.monitor(ctx, time.Millisecond)
+
+	require.Eventually(t, r.useStandby, 5*time.Second, time.Millisecond)
+	require.GreaterOrEqual(t, checks.Load(), int64(2))
+
+	cancel()
+	<-r.done
+}
@@ -4,6 +4,7 @@ package pg
 
 import (
 	"context"
+	"errors"
 
 	"github.com/jackc/pgx/v5"
 
@@ -152,25 +153,50 @@ func (tx *readTx) Subscribe(ctx context.Context) (ch <-chan string, done func(co
 	return subscribe(ctx, tx, tx.subscribers)
 }
 
+// ErrScratchTx is returned when committing a transaction from BeginScratchTx.
+var ErrScratchTx = errors.New("scratch transaction cannot be committed")
+
+// scratchTx is a read-write tx on a reader connection that is never
+// committed. It will release the connection back to the reader pool when it
+// is rolled back.
+type scratchTx struct {
+	*nestedTx
+	release func()
+}
+
+var _ conner = (*scratchTx)(nil)
+
+// Commit rolls back the transaction and returns ErrScratchTx.
+func (tx *scratchTx) Commit(ctx context.Context) error {
+	return errors.Join(ErrScratchTx, tx.Rollback(ctx))
+}
+
+// Rollback will unconditionally return the connection to the pool.
+func (tx *scratchTx) Rollback(ctx context.Context) error {
+	defer tx.release()
+
+	return tx.nestedTx.Rollback(ctx)
+}
+
 // delayedReadTx is a tx that handles a read-only transaction.
 // It is delayed, meaning that the tx will only be actually started
 // when the first query is executed. This is useful for when a calling
 // module is expected to control the lifetime of a read transaction, but
 // the implementation might not need to use the transaction.
 type delayedReadTx struct {
-	db *DB
+	begin func(context.Context) (*readTx, error)
 
 	tx *readTx
 }
 
 func (d *delayedReadTx) ensureTx(ctx context.Context) error {
 	if d.tx == nil {
-		tx, err := d.db.BeginReadTx(ctx)
+		tx, err := d.begin(ctx)
 		if err != nil {
 			return err
 		}
 
-		d.tx = tx.(*readTx)
+		d.tx = tx
 	}
 
 	return nil
@@ -222,5 +248,5 @@ func (d *delayedReadTx) Subscribe(ctx context.Context) (ch <-chan string, done f
 		return nil, nil, err
 	}
 
-	return subscribe(ctx, d.tx, d.db.pool.subscribers)
+	return subscribe(ctx, d.tx, d.tx.subscribers)
 }
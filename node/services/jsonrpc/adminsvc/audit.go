@@ -0,0 +1,85 @@
+package adminsvc
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kwilteam/kwil-db/core/log"
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
+)
+
+// auditRecord is one line of the audit log.
+type auditRecord struct {
+	Time   time.Time         `json:"time"`
+	Caller string            `json:"caller"`
+	Remote string            `json:"remote,omitempty"`
+	Method string            `json:"method"`
+	Params json.RawMessage   `json:"params,omitempty"`
+	Code   jsonrpc.ErrorCode `json:"code,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// AuditLog is an append-only log of the method calls to the admin service, in
+// JSON lines format. Each line records the time, the caller's identity, the
+// method and its parameters, and the error returned if the call failed.
+type AuditLog struct {
+	log log.Logger
+
+	mtx sync.Mutex
+	f   *os.File
+}
+
+var _ rpcserver.Auditor = (*AuditLog)(nil)
+
+// OpenAuditLog opens the audit log file for appending, creating it if needed.
+func OpenAuditLog(file string, logger log.Logger) (*AuditLog, error) {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{log: logger, f: f}, nil
+}
+
+// Audit appends a record of the call to the log. A failure to write the record
+// is logged, but does not fail the call.
+func (al *AuditLog) Audit(ctx context.Context, caller *rpcserver.Caller, method string, params json.RawMessage, rpcErr *jsonrpc.Error) {
+	rec := &auditRecord{
+		Time:   time.Now().UTC(),
+		Method: method,
+		Params: params,
+	}
+	if caller != nil {
+		rec.Caller = caller.Name
+	}
+	rec.Remote, _ = ctx.Value(rpcserver.RequestIPCtx).(string)
+	if rpcErr != nil {
+		rec.Code, rec.Error = rpcErr.Code, rpcErr.Message
+	}
+	if !json.Valid(rec.Params) {
+		rec.Params = nil
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		al.log.Errorf("failed to encode audit record: %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	al.mtx.Lock()
+	defer al.mtx.Unlock()
+	if _, err = al.f.Write(line); err != nil {
+		al.log.Errorf("failed to write audit record: %v", err)
+	}
+}
+
+// Close closes the log file.
+func (al *AuditLog) Close() error {
+	al.mtx.Lock()
+	defer al.mtx.Unlock()
+	return al.f.Close()
+}
@@ -3,9 +3,12 @@ package adminsvc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
+	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/config"
 	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/crypto/auth"
@@ -15,6 +18,7 @@ import (
 	userjson "github.com/kwilteam/kwil-db/core/rpc/json/user"
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	types "github.com/kwilteam/kwil-db/core/types/admin"
+	authExt "github.com/kwilteam/kwil-db/extensions/auth"
 	"github.com/kwilteam/kwil-db/extensions/resolutions"
 	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
 	ntypes "github.com/kwilteam/kwil-db/node/types"
@@ -32,6 +36,7 @@ type Node interface {
 	Role() ntypes.Role
 	AbortBlockExecution(height int64, txIDs []ktypes.Hash) error
 	PromoteLeader(leader crypto.PublicKey, height int64) error
+	TxQuery(ctx context.Context, hash ktypes.Hash, prove bool) (*ktypes.TxQueryResponse, error)
 }
 
 type Whitelister interface { // maybe merge with Node since it's same job
@@ -54,6 +59,43 @@ type App interface {
 	BlockExecutionStatus() *ktypes.BlockExecutionStatus
 }
 
+// Tracer traces the execution of actions and statements in a transaction that
+// is rolled back.
+type Tracer interface {
+	TraceCall(ctx *common.EngineContext, db sql.DB, namespace, action string, args []any) (*types.TraceCall, *common.CallResult, error)
+	TraceExecute(ctx *common.EngineContext, db sql.DB, statement string, params map[string]any) (*types.TraceCall, error)
+}
+
+// ConfigReloader provides the node's config, and applies changes to the config
+// file to the running node.
+type ConfigReloader interface {
+	// Config returns the config in effect.
+	Config() *config.Config
+	// ReloadConfig re-reads the config file and applies the settings that may
+	// be changed at runtime.
+	ReloadConfig(ctx context.Context) (*types.ConfigReload, error)
+}
+
+// Tokens manages the named credentials for the admin service and their scopes.
+type Tokens interface {
+	// Mint creates an API token and returns its secret, or grants the scopes
+	// to a PEM encoded TLS client certificate if one is given.
+	Mint(name string, scopes []string, certPEM []byte) (string, error)
+	// Revoke deletes the named token.
+	Revoke(name string) error
+	// List returns all tokens.
+	List() []*types.Token
+}
+
+// DB is the database used by the admin service.
+type DB interface {
+	sql.DelayedReadTxMaker
+	// BeginScratchTx starts a read-write transaction that can only be rolled
+	// back, for tracing. Its statements must have short lock and statement
+	// timeouts so that it cannot block the writer for long.
+	BeginScratchTx(ctx context.Context) (sql.Tx, error)
+}
+
 type Validators interface {
 	SetValidatorPower(ctx context.Context, tx sql.Executor, pubKey []byte, pubKeyType crypto.KeyType, power int64) error
 	GetValidatorPower(ctx context.Context, pubKey []byte, pubKeyType crypto.KeyType) (int64, error)
@@ -66,17 +108,19 @@ type Service struct {
 	blockchain Node // node is the local node that can accept transactions.
 	app        App
 	voting     Validators
-	db         sql.DelayedReadTxMaker
+	tracer     Tracer
+	db         DB
 	whitelist  Whitelister
+	tokens     Tokens
 
-	cfg     *config.Config
+	cfg     ConfigReloader
 	chainID string
 	signer  auth.Signer // ed25519 signer derived from the node's private key
 }
 
 const (
 	apiVerMajor = 0
-	apiVerMinor = 2
+	apiVerMinor = 4
 	apiVerPatch = 0
 
 	serviceName = "admin"
@@ -86,6 +130,10 @@ const (
 //
 // apiVerMinor = 2 indicates the presence of the peer whitelist, resolution, and
 // health methods added in Kwil v0.9
+//
+// apiVerMinor = 3 indicates the presence of the reload_config method
+//
+// apiVerMinor = 4 indicates the presence of the token methods
 
 var (
 	apiSemver = fmt.Sprintf("%d.%d.%d", apiVerMajor, apiVerMinor, apiVerPatch)
@@ -165,6 +213,9 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 		adminjson.MethodConfig: rpcserver.MakeMethodDef(svc.GetConfig,
 			"retrieve the current effective node config",
 			"the raw bytes of the effective config TOML document"),
+		adminjson.MethodReloadConfig: rpcserver.MakeMethodDef(svc.ReloadConfig,
+			"re-read the config file and apply the settings that may be changed without a restart",
+			"the changed settings that were applied, and those that require a restart"),
 		adminjson.MethodValApprove: rpcserver.MakeMethodDef(svc.Approve,
 			"approve a validator join request",
 			"the hash of the broadcasted validator approve transaction"),
@@ -196,6 +247,15 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 		adminjson.MethodListPeers: rpcserver.MakeMethodDef(svc.ListPeers,
 			"list the peers from the node's whitelist",
 			"the list of peers from which the node can accept connections from."),
+		adminjson.MethodMintToken: rpcserver.MakeMethodDef(svc.MintToken,
+			"create a named admin API token with scopes, or grant scopes to a TLS client certificate",
+			"the secret of the new token, which cannot be retrieved again"),
+		adminjson.MethodRevokeToken: rpcserver.MakeMethodDef(svc.RevokeToken,
+			"revoke a named admin API token or client certificate",
+			"an empty result"),
+		adminjson.MethodListTokens: rpcserver.MakeMethodDef(svc.ListTokens,
+			"list the admin API tokens and client certificates with their scopes",
+			"the tokens, without their secrets"),
 		adminjson.MethodCreateResolution: rpcserver.MakeMethodDef(svc.CreateResolution,
 			"create a resolution",
 			"the hash of the broadcasted create resolution transaction",
@@ -223,6 +283,10 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 			"cancel the block execution at the given height and discard the specified transactions from the mempool",
 			"",
 		),
+		adminjson.MethodTraceTx: rpcserver.MakeMethodDef(svc.TraceTx,
+			"trace the execution of a transaction in the latest block, an action call, or a SQL statement against the current state, without committing it",
+			"the statements, variables, queries, notices, and nested calls of each action call",
+		),
 	}
 }
 
@@ -235,13 +299,15 @@ func (svc *Service) Handlers() map[jsonrpc.Method]rpcserver.MethodHandler {
 }
 
 // NewService constructs a new Service.
-func NewService(db sql.DelayedReadTxMaker, blockchain Node, app App,
-	vs Validators, wl Whitelister, txSigner auth.Signer, cfg *config.Config,
+func NewService(db DB, blockchain Node, app App, tracer Tracer,
+	vs Validators, wl Whitelister, tokens Tokens, txSigner auth.Signer, cfg ConfigReloader,
 	chainID string, logger log.Logger) *Service {
 	return &Service{
 		blockchain: blockchain,
 		whitelist:  wl,
+		tokens:     tokens,
 		app:        app,
+		tracer:     tracer,
 		voting:     vs,
 		signer:     txSigner,
 		chainID:    chainID,
@@ -534,7 +600,7 @@ func (svc *Service) approvalsInfo(resolution *resolutions.Resolution, allVoters
 }
 
 func (svc *Service) GetConfig(ctx context.Context, req *adminjson.GetConfigRequest) (*adminjson.GetConfigResponse, *jsonrpc.Error) {
-	bts, err := svc.cfg.ToTOML()
+	bts, err := svc.cfg.Config().ToTOML()
 	if err != nil {
 		return nil, jsonrpc.NewError(jsonrpc.ErrorResultEncoding, "failed to encode node config", nil)
 	}
@@ -544,6 +610,35 @@ func (svc *Service) GetConfig(ctx context.Context, req *adminjson.GetConfigReque
 	}, nil
 }
 
+func (svc *Service) ReloadConfig(ctx context.Context, req *adminjson.ReloadConfigRequest) (*adminjson.ReloadConfigResponse, *jsonrpc.Error) {
+	res, err := svc.cfg.ReloadConfig(ctx)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInternal, "failed to reload config: "+err.Error(), nil)
+	}
+	return res, nil
+}
+
+func (svc *Service) MintToken(ctx context.Context, req *adminjson.MintTokenRequest) (*adminjson.MintTokenResponse, *jsonrpc.Error) {
+	token, err := svc.tokens.Mint(req.Name, req.Scopes, req.Cert)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "failed to mint token: "+err.Error(), nil)
+	}
+	svc.log.Info("minted admin token", "name", req.Name, "scopes", req.Scopes, "cert", len(req.Cert) > 0)
+	return &adminjson.MintTokenResponse{Token: token}, nil
+}
+
+func (svc *Service) RevokeToken(ctx context.Context, req *adminjson.RevokeTokenRequest) (*adminjson.RevokeTokenResponse, *jsonrpc.Error) {
+	if err := svc.tokens.Revoke(req.Name); err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "failed to revoke token: "+err.Error(), nil)
+	}
+	svc.log.Info("revoked admin token", "name", req.Name)
+	return &adminjson.RevokeTokenResponse{}, nil
+}
+
+func (svc *Service) ListTokens(ctx context.Context, req *adminjson.ListTokensRequest) (*adminjson.ListTokensResponse, *jsonrpc.Error) {
+	return &adminjson.ListTokensResponse{Tokens: svc.tokens.List()}, nil
+}
+
 func (svc *Service) AddPeer(ctx context.Context, req *adminjson.PeerRequest) (*adminjson.PeerResponse, *jsonrpc.Error) {
 	err := svc.whitelist.AddPeer(req.PeerID)
 	if err != nil {
@@ -668,3 +763,166 @@ func (svc *Service) AbortBlockExecution(ctx context.Context, req *adminjson.Abor
 
 	return &adminjson.AbortBlockExecResponse{}, nil
 }
+
+// TraceTx traces the execution of a committed transaction, an action call, or a
+// SQL statement. Since the node does not keep historical state, only a
+// transaction in the latest block can be traced, and it is executed again
+// against the state after that block, not the state it was executed against.
+// All changes are rolled back.
+func (svc *Service) TraceTx(ctx context.Context, req *adminjson.TraceTxRequest) (*adminjson.TraceTxResponse, *jsonrpc.Error) {
+	var (
+		sender, authType  = []byte(req.Sender), req.AuthType
+		namespace, action = req.Namespace, req.Action
+		argSets           [][]any
+		statement         = req.Statement
+		params            map[string]any
+		err               error
+	)
+
+	status, err := svc.blockchain.Status(ctx)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "node status unavailable", nil)
+	}
+
+	switch {
+	case req.TxHash != nil:
+		res, err := svc.blockchain.TxQuery(ctx, *req.TxHash, false)
+		if err != nil {
+			if errors.Is(err, ktypes.ErrTxNotFound) {
+				return nil, jsonrpc.NewError(jsonrpc.ErrorTxNotFound, "transaction not found", nil)
+			}
+			return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "failed to query transaction: "+err.Error(), nil)
+		}
+		// The state before older blocks is not kept, and the changes of
+		// later blocks would make the trace wrong, e.g. with a different
+		// balance or a conflicting insert.
+		if res.Height != status.Sync.BestBlockHeight {
+			return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams,
+				fmt.Sprintf("only transactions in the latest block (%d) can be traced, the transaction is in block %d",
+					status.Sync.BestBlockHeight, res.Height), nil)
+		}
+		tx := res.Tx
+		sender = tx.Sender
+		if tx.Signature != nil {
+			authType = tx.Signature.Type
+		}
+
+		switch tx.Body.PayloadType {
+		case ktypes.PayloadTypeExecute:
+			exec := &ktypes.ActionExecution{}
+			if err = exec.UnmarshalBinary(tx.Body.Payload); err != nil {
+				return nil, jsonrpc.NewError(jsonrpc.ErrorTxPayloadInvalid, "failed to decode payload: "+err.Error(), nil)
+			}
+			namespace, action = exec.Namespace, exec.Action
+			argSets = make([][]any, len(exec.Arguments))
+			for i, args := range exec.Arguments {
+				if argSets[i], err = decodeArgs(args); err != nil {
+					return nil, jsonrpc.NewError(jsonrpc.ErrorTxPayloadInvalid, "failed to decode argument: "+err.Error(), nil)
+				}
+			}
+			if len(argSets) == 0 {
+				argSets = make([][]any, 1)
+			}
+		case ktypes.PayloadTypeRawStatement:
+			raw := &ktypes.RawStatement{}
+			if err = raw.UnmarshalBinary(tx.Body.Payload); err != nil {
+				return nil, jsonrpc.NewError(jsonrpc.ErrorTxPayloadInvalid, "failed to decode payload: "+err.Error(), nil)
+			}
+			statement = raw.Statement
+			params = make(map[string]any, len(raw.Parameters))
+			for _, p := range raw.Parameters {
+				if params[p.Name], err = p.Value.Decode(); err != nil {
+					return nil, jsonrpc.NewError(jsonrpc.ErrorTxPayloadInvalid, "failed to decode parameter: "+err.Error(), nil)
+				}
+			}
+		default:
+			return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams,
+				fmt.Sprintf("cannot trace a %s transaction", tx.Body.PayloadType), nil)
+		}
+	case action != "":
+		args, err := decodeArgs(req.Args)
+		if err != nil {
+			return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "failed to decode argument: "+err.Error(), nil)
+		}
+		argSets = [][]any{args}
+	case statement != "":
+		params = make(map[string]any, len(req.Params))
+		for name, v := range req.Params {
+			if params[name], err = v.Decode(); err != nil {
+				return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "failed to decode parameter: "+err.Error(), nil)
+			}
+		}
+	default:
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "a transaction hash, action, or statement is required", nil)
+	}
+
+	var caller string
+	if len(sender) > 0 && authType != "" {
+		caller, err = authExt.GetIdentifier(authType, sender)
+		if err != nil {
+			return nil, jsonrpc.NewError(jsonrpc.ErrorIdentInvalid, "failed to get caller: "+err.Error(), nil)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(svc.cfg.Config().DB.ReadTxTimeout))
+	defer cancel()
+
+	engCtx := &common.EngineContext{
+		TxContext: &common.TxContext{
+			Ctx:           ctx,
+			Signer:        sender,
+			Caller:        caller,
+			Authenticator: authType,
+			BlockContext: &common.BlockContext{
+				Height:    status.Sync.BestBlockHeight,
+				Timestamp: status.Sync.BestBlockTime.Unix(),
+				Hash:      status.Sync.BestBlockHash,
+			},
+		},
+	}
+	if req.TxHash != nil {
+		engCtx.TxContext.TxID = req.TxHash.String()
+	}
+
+	tx, err := svc.db.BeginScratchTx(ctx)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorDBInternal, "failed to start transaction: "+err.Error(), nil)
+	}
+	defer tx.Rollback(ctx)
+
+	// Errors from execution are part of the trace. As in a block, execution
+	// stops at the first failed call.
+	resp := &adminjson.TraceTxResponse{}
+	if req.TxHash != nil {
+		resp.StateDiffers = true
+		resp.Height = status.Sync.BestBlockHeight
+	}
+	if argSets == nil {
+		trace, err := svc.tracer.TraceExecute(engCtx, tx, statement, params)
+		if trace == nil { // the statement could not be executed
+			trace = &types.TraceCall{Statement: statement, Error: err.Error()}
+		}
+		resp.Traces = append(resp.Traces, trace)
+		return resp, nil
+	}
+
+	for _, args := range argSets {
+		trace, res, err := svc.tracer.TraceCall(engCtx, tx, namespace, action, args)
+		resp.Traces = append(resp.Traces, trace)
+		if err != nil || res.Error != nil {
+			break
+		}
+	}
+	return resp, nil
+}
+
+func decodeArgs(vals []*ktypes.EncodedValue) ([]any, error) {
+	args := make([]any, len(vals))
+	for i, v := range vals {
+		var err error
+		if args[i], err = v.Decode(); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
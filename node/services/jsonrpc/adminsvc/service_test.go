@@ -0,0 +1,100 @@
+package adminsvc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/config"
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	adminjson "github.com/kwilteam/kwil-db/core/rpc/json/admin"
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+	types "github.com/kwilteam/kwil-db/core/types/admin"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+type traceNode struct {
+	Node
+	height int64
+	tx     *ktypes.TxQueryResponse
+}
+
+func (n *traceNode) Status(context.Context) (*types.Status, error) {
+	return &types.Status{Sync: &types.SyncInfo{BestBlockHeight: n.height}}, nil
+}
+
+func (n *traceNode) TxQuery(context.Context, ktypes.Hash, bool) (*ktypes.TxQueryResponse, error) {
+	return n.tx, nil
+}
+
+type scratchTx struct {
+	sql.Tx
+}
+
+func (scratchTx) Rollback(context.Context) error { return nil }
+
+type scratchDB struct {
+	DB
+}
+
+func (scratchDB) BeginScratchTx(context.Context) (sql.Tx, error) {
+	return scratchTx{}, nil
+}
+
+type countingTracer struct {
+	Tracer
+	calls int
+}
+
+func (tr *countingTracer) TraceCall(_ *common.EngineContext, _ sql.DB, namespace, action string, _ []any) (*types.TraceCall, *common.CallResult, error) {
+	tr.calls++
+	return &types.TraceCall{Namespace: namespace, Action: action}, &common.CallResult{}, nil
+}
+
+type staticConfig struct {
+	ConfigReloader
+}
+
+func (staticConfig) Config() *config.Config {
+	return config.DefaultConfig()
+}
+
+func TestTraceTxLatestBlock(t *testing.T) {
+	tx, err := ktypes.CreateTransaction(&ktypes.ActionExecution{Namespace: "ns", Action: "act"}, "chainid", 1)
+	require.NoError(t, err)
+	txHash := tx.Hash()
+
+	for _, tt := range []struct {
+		name     string
+		txHeight int64
+		wantCode jsonrpc.ErrorCode
+	}{
+		{name: "latest block", txHeight: 10},
+		{name: "older block", txHeight: 9, wantCode: jsonrpc.ErrorInvalidParams},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &countingTracer{}
+			svc := &Service{
+				blockchain: &traceNode{height: 10, tx: &ktypes.TxQueryResponse{Height: tt.txHeight, Tx: tx}},
+				db:         scratchDB{},
+				tracer:     tracer,
+				cfg:        staticConfig{},
+			}
+
+			resp, jsonErr := svc.TraceTx(context.Background(), &adminjson.TraceTxRequest{TxHash: &txHash})
+			if tt.wantCode != 0 {
+				require.NotNil(t, jsonErr)
+				require.Equal(t, tt.wantCode, jsonErr.Code)
+				require.Zero(t, tracer.calls)
+				return
+			}
+			require.Nil(t, jsonErr)
+			require.Equal(t, 1, tracer.calls)
+			require.True(t, resp.StateDiffers)
+			require.Equal(t, int64(10), resp.Height)
+			require.Len(t, resp.Traces, 1)
+		})
+	}
+}
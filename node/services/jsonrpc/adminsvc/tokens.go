@@ -0,0 +1,349 @@
+package adminsvc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	adminjson "github.com/kwilteam/kwil-db/core/rpc/json/admin"
+	types "github.com/kwilteam/kwil-db/core/types/admin"
+	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
+)
+
+// tokenPrefix begins the secret of each API token, making it recognizable.
+const tokenPrefix = "kwt_"
+
+// Callers that are not identified by a token or mapped client certificate.
+const (
+	callerPassword  = "password"
+	callerAnonymous = "anonymous"
+	callerCertPfx   = "cert:" // followed by the certificate's common name
+)
+
+var tokenNameRE = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,64}$`)
+
+// methodScopes is the scope required by each admin service method. Unlisted
+// methods require types.ScopeAdmin, unless they are from one of the services
+// that are also on the user RPC server (see requiredScope).
+var methodScopes = map[jsonrpc.Method]string{
+	adminjson.MethodHealth:           types.ScopeStatus,
+	adminjson.MethodVersion:          types.ScopeStatus,
+	adminjson.MethodStatus:           types.ScopeStatus,
+	adminjson.MethodPeers:            types.ScopeStatus,
+	adminjson.MethodConfig:           types.ScopeStatus,
+	adminjson.MethodValJoinStatus:    types.ScopeStatus,
+	adminjson.MethodValList:          types.ScopeStatus,
+	adminjson.MethodValListJoins:     types.ScopeStatus,
+	adminjson.MethodListPeers:        types.ScopeStatus,
+	adminjson.MethodResolutionStatus: types.ScopeStatus,
+	adminjson.MethodBlockExecStatus:  types.ScopeStatus,
+
+	adminjson.MethodAddPeer:    types.ScopePeers,
+	adminjson.MethodRemovePeer: types.ScopePeers,
+
+	adminjson.MethodValApprove:        types.ScopeValidators,
+	adminjson.MethodValJoin:           types.ScopeValidators,
+	adminjson.MethodValRemove:         types.ScopeValidators,
+	adminjson.MethodValLeave:          types.ScopeValidators,
+	adminjson.MethodValPromote:        types.ScopeValidators,
+	adminjson.MethodCreateResolution:  types.ScopeValidators,
+	adminjson.MethodApproveResolution: types.ScopeValidators,
+
+	adminjson.MethodAbortBlockExecution: types.ScopeBlocks,
+}
+
+// requiredScope returns the scope required to call a method. The methods of
+// the user, chain, and function services, which are public on the user RPC
+// server, only require types.ScopeStatus on the admin server, except for
+// broadcasting transactions.
+func requiredScope(method string) string {
+	if scope, ok := methodScopes[jsonrpc.Method(method)]; ok {
+		return scope
+	}
+	if method == "user.broadcast" {
+		return types.ScopeAdmin
+	}
+	switch ns, _, _ := strings.Cut(method, "."); ns {
+	case "user", "chain", "function", "rpc":
+		return types.ScopeStatus
+	}
+	return types.ScopeAdmin
+}
+
+// storedToken is a token as persisted in the tokens file. Only the SHA-256
+// hash of an API token's secret is stored.
+type storedToken struct {
+	types.Token
+	SecretHash string `json:"secret_hash,omitempty"`
+}
+
+// TokenStore holds the named credentials for the admin service and their
+// scopes, which are persisted in a JSON file. It is the Authenticator for the
+// admin RPC server.
+//
+// A request is authenticated by either a bearer token in the Authorization
+// header, the configured password with basic authentication, or a verified TLS
+// client certificate. A verified client certificate that was not granted
+// scopes with Mint has all scopes. When no password is configured, requests
+// without credentials also have all scopes, but once any token exists, this is
+// only so on a UNIX socket. Otherwise a caller could avoid the scopes of its
+// token by leaving it out.
+type TokenStore struct {
+	file    string
+	passSHA []byte
+
+	mtx    sync.RWMutex
+	tokens map[string]*storedToken // by name
+}
+
+var _ rpcserver.Authenticator = (*TokenStore)(nil)
+
+// NewTokenStore loads the tokens file, if it exists. If pass is not empty,
+// requests must have credentials, and basic authentication with the password
+// grants all scopes.
+func NewTokenStore(file, pass string) (*TokenStore, error) {
+	ts := &TokenStore{
+		file:   file,
+		tokens: make(map[string]*storedToken),
+	}
+	if pass != "" {
+		passSHA := sha256.Sum256([]byte(pass))
+		ts.passSHA = passSHA[:]
+	}
+
+	bts, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return ts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tokens []*storedToken
+	if err = json.Unmarshal(bts, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid tokens file %s: %w", file, err)
+	}
+	for _, tok := range tokens {
+		ts.tokens[tok.Name] = tok
+	}
+	return ts, nil
+}
+
+func checkScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.New("no scopes")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(types.Scopes, scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+func certFingerprint(cert *x509.Certificate) string {
+	fp := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(fp[:])
+}
+
+// Mint creates a named API token with the scopes, and returns its secret. If a
+// PEM encoded certificate is given, the scopes are granted to the TLS client
+// certificate instead, and the secret is empty. The certificate must also be
+// trusted by the admin server for a client to use it.
+func (ts *TokenStore) Mint(name string, scopes []string, certPEM []byte) (string, error) {
+	if !tokenNameRE.MatchString(name) {
+		return "", fmt.Errorf("invalid token name %q", name)
+	}
+	if err := checkScopes(scopes); err != nil {
+		return "", err
+	}
+
+	tok := &storedToken{
+		Token: types.Token{
+			Name:    name,
+			Scopes:  slices.Clone(scopes),
+			Created: time.Now().UTC().Truncate(time.Second),
+		},
+	}
+	var secret string
+	if len(certPEM) > 0 {
+		block, _ := pem.Decode(certPEM)
+		if block == nil || block.Type != "CERTIFICATE" {
+			return "", errors.New("invalid PEM certificate")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("invalid certificate: %w", err)
+		}
+		tok.CertFingerprint = certFingerprint(cert)
+	} else {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return "", err
+		}
+		secret = tokenPrefix + hex.EncodeToString(b[:])
+		secretHash := sha256.Sum256([]byte(secret))
+		tok.SecretHash = hex.EncodeToString(secretHash[:])
+	}
+
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	if _, have := ts.tokens[name]; have {
+		return "", fmt.Errorf("token %q already exists", name)
+	}
+	if tok.CertFingerprint != "" {
+		for _, other := range ts.tokens {
+			if other.CertFingerprint == tok.CertFingerprint {
+				return "", fmt.Errorf("certificate already has token %q", other.Name)
+			}
+		}
+	}
+	ts.tokens[name] = tok
+	if err := ts.save(); err != nil {
+		delete(ts.tokens, name)
+		return "", err
+	}
+	return secret, nil
+}
+
+// Revoke deletes the named API token or client certificate scopes.
+func (ts *TokenStore) Revoke(name string) error {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	tok, have := ts.tokens[name]
+	if !have {
+		return fmt.Errorf("token %q not found", name)
+	}
+	delete(ts.tokens, name)
+	if err := ts.save(); err != nil {
+		ts.tokens[name] = tok
+		return err
+	}
+	return nil
+}
+
+// List returns the tokens sorted by name.
+func (ts *TokenStore) List() []*types.Token {
+	ts.mtx.RLock()
+	defer ts.mtx.RUnlock()
+
+	tokens := make([]*types.Token, 0, len(ts.tokens))
+	for _, tok := range ts.tokens {
+		t := tok.Token
+		tokens = append(tokens, &t)
+	}
+	slices.SortFunc(tokens, func(a, b *types.Token) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return tokens
+}
+
+// save writes the tokens file. The caller must hold the write lock.
+func (ts *TokenStore) save() error {
+	tokens := make([]*storedToken, 0, len(ts.tokens))
+	for _, tok := range ts.tokens {
+		tokens = append(tokens, tok)
+	}
+	slices.SortFunc(tokens, func(a, b *storedToken) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	bts, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write and rename so that a failure does not lose the existing tokens.
+	tmp := ts.file + ".tmp"
+	if err = os.WriteFile(tmp, bts, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ts.file)
+}
+
+// Authenticate identifies the caller from the request's credentials.
+func (ts *TokenStore) Authenticate(r *http.Request) (*rpcserver.Caller, error) {
+	authHdr := r.Header.Get("Authorization")
+	if secret, isBearer := strings.CutPrefix(authHdr, "Bearer "); isBearer {
+		secretHash := sha256.Sum256([]byte(secret))
+		ts.mtx.RLock()
+		defer ts.mtx.RUnlock()
+		for _, tok := range ts.tokens {
+			if tok.SecretHash == "" {
+				continue
+			}
+			if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(secretHash[:])), []byte(tok.SecretHash)) == 1 {
+				return &rpcserver.Caller{Name: tok.Name, Scopes: tok.Scopes}, nil
+			}
+		}
+		return nil, errors.New("unknown token")
+	}
+	if authHdr != "" {
+		_, pass, ok := r.BasicAuth()
+		if !ok || ts.passSHA == nil {
+			return nil, errors.New("unsupported authorization")
+		}
+		passSHA := sha256.Sum256([]byte(pass))
+		if subtle.ConstantTimeCompare(ts.passSHA, passSHA[:]) != 1 {
+			return nil, errors.New("invalid password")
+		}
+		return &rpcserver.Caller{Name: callerPassword, Scopes: []string{types.ScopeAdmin}}, nil
+	}
+
+	// Only a client certificate that the TLS server verified is trusted.
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		cert := r.TLS.PeerCertificates[0]
+		fp := certFingerprint(cert)
+		ts.mtx.RLock()
+		defer ts.mtx.RUnlock()
+		for _, tok := range ts.tokens {
+			if tok.CertFingerprint == fp {
+				return &rpcserver.Caller{Name: tok.Name, Scopes: tok.Scopes}, nil
+			}
+		}
+		return &rpcserver.Caller{Name: callerCertPfx + cert.Subject.CommonName, Scopes: []string{types.ScopeAdmin}}, nil
+	}
+
+	if ts.passSHA != nil {
+		return nil, errors.New("credentials required")
+	}
+	if !isUnixSocket(r) {
+		ts.mtx.RLock()
+		haveTokens := len(ts.tokens) > 0
+		ts.mtx.RUnlock()
+		if haveTokens {
+			return nil, errors.New("credentials required when API tokens exist")
+		}
+	}
+	return &rpcserver.Caller{Name: callerAnonymous, Scopes: []string{types.ScopeAdmin}}, nil
+}
+
+// isUnixSocket reports if the request was received on a UNIX socket, where
+// access is controlled by the socket file's permissions.
+func isUnixSocket(r *http.Request) bool {
+	_, isUnix := r.Context().Value(http.LocalAddrContextKey).(*net.UnixAddr)
+	return isUnix
+}
+
+// Authorize reports if the caller has the scope required by the method.
+func (ts *TokenStore) Authorize(caller *rpcserver.Caller, method string) bool {
+	if caller == nil {
+		return false
+	}
+	return slices.Contains(caller.Scopes, types.ScopeAdmin) ||
+		slices.Contains(caller.Scopes, requiredScope(method))
+}
@@ -0,0 +1,207 @@
+package adminsvc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/log"
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	adminjson "github.com/kwilteam/kwil-db/core/rpc/json/admin"
+	"github.com/kwilteam/kwil-db/core/rpc/transport"
+	types "github.com/kwilteam/kwil-db/core/types/admin"
+	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
+)
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/rpc/v1", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestTokenStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tokens.json")
+	ts, err := NewTokenStore(file, "")
+	require.NoError(t, err)
+
+	// Without a password, a request without credentials has all scopes.
+	caller, err := ts.Authenticate(bearerRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, callerAnonymous, caller.Name)
+	assert.True(t, ts.Authorize(caller, string(adminjson.MethodAbortBlockExecution)))
+
+	_, err = ts.Mint("monitor", []string{"bogus"}, nil)
+	require.Error(t, err)
+	_, err = ts.Mint("bad name", []string{types.ScopeStatus}, nil)
+	require.Error(t, err)
+
+	secret, err := ts.Mint("monitor", []string{types.ScopeStatus}, nil)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, tokenPrefix))
+	_, err = ts.Mint("monitor", []string{types.ScopeStatus}, nil)
+	require.Error(t, err) // exists
+
+	caller, err = ts.Authenticate(bearerRequest(secret))
+	require.NoError(t, err)
+	assert.Equal(t, "monitor", caller.Name)
+
+	// Once a token exists, anonymous requests are only allowed on a UNIX
+	// socket, so the token's scopes cannot be avoided by leaving it out.
+	_, err = ts.Authenticate(bearerRequest(""))
+	require.Error(t, err)
+	unixReq := bearerRequest("")
+	unixReq = unixReq.WithContext(context.WithValue(unixReq.Context(), http.LocalAddrContextKey,
+		&net.UnixAddr{Name: "/tmp/kwild.socket", Net: "unix"}))
+	caller, err = ts.Authenticate(unixReq)
+	require.NoError(t, err)
+	assert.Equal(t, callerAnonymous, caller.Name)
+
+	caller, err = ts.Authenticate(bearerRequest(secret))
+	require.NoError(t, err)
+	assert.True(t, ts.Authorize(caller, string(adminjson.MethodStatus)))
+	assert.True(t, ts.Authorize(caller, "chain.block"))
+	assert.False(t, ts.Authorize(caller, "user.broadcast"))
+	assert.False(t, ts.Authorize(caller, string(adminjson.MethodAddPeer)))
+	assert.False(t, ts.Authorize(caller, string(adminjson.MethodValRemove)))
+	assert.False(t, ts.Authorize(caller, string(adminjson.MethodAbortBlockExecution)))
+	assert.False(t, ts.Authorize(caller, string(adminjson.MethodMintToken)))
+	assert.False(t, ts.Authorize(nil, string(adminjson.MethodStatus)))
+
+	_, err = ts.Authenticate(bearerRequest(secret + "0"))
+	require.Error(t, err)
+
+	// The tokens are persisted, but not the secrets.
+	bts, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotContains(t, string(bts), secret)
+
+	ts2, err := NewTokenStore(file, "")
+	require.NoError(t, err)
+	require.Len(t, ts2.List(), 1)
+	caller, err = ts2.Authenticate(bearerRequest(secret))
+	require.NoError(t, err)
+	assert.Equal(t, "monitor", caller.Name)
+
+	require.NoError(t, ts2.Revoke("monitor"))
+	require.Error(t, ts2.Revoke("monitor"))
+	_, err = ts2.Authenticate(bearerRequest(secret))
+	require.Error(t, err)
+
+	ts3, err := NewTokenStore(file, "")
+	require.NoError(t, err)
+	require.Empty(t, ts3.List())
+}
+
+func TestTokenStorePassword(t *testing.T) {
+	ts, err := NewTokenStore(filepath.Join(t.TempDir(), "tokens.json"), "secret")
+	require.NoError(t, err)
+
+	_, err = ts.Authenticate(bearerRequest(""))
+	require.Error(t, err) // credentials required
+
+	r := bearerRequest("")
+	r.SetBasicAuth("user", "wrong")
+	_, err = ts.Authenticate(r)
+	require.Error(t, err)
+
+	r.SetBasicAuth("user", "secret")
+	caller, err := ts.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, callerPassword, caller.Name)
+	assert.True(t, ts.Authorize(caller, string(adminjson.MethodMintToken)))
+}
+
+func TestTokenStoreClientCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.cert"), filepath.Join(dir, "client.key")
+	require.NoError(t, transport.GenTLSKeyPair(certFile, keyFile, "test", nil))
+	certPEM, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	ts, err := NewTokenStore(filepath.Join(dir, "tokens.json"), "")
+	require.NoError(t, err)
+
+	certRequest := func(verified bool) *http.Request {
+		r := bearerRequest("")
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		if verified {
+			r.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return r
+	}
+
+	// A trusted certificate without scopes has all scopes.
+	caller, err := ts.Authenticate(certRequest(true))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(caller.Name, callerCertPfx))
+	assert.True(t, ts.Authorize(caller, string(adminjson.MethodValRemove)))
+
+	secret, err := ts.Mint("peer-manager", []string{types.ScopePeers}, certPEM)
+	require.NoError(t, err)
+	require.Empty(t, secret)
+	_, err = ts.Mint("again", []string{types.ScopePeers}, certPEM)
+	require.Error(t, err)
+
+	caller, err = ts.Authenticate(certRequest(true))
+	require.NoError(t, err)
+	assert.Equal(t, "peer-manager", caller.Name)
+	assert.True(t, ts.Authorize(caller, string(adminjson.MethodAddPeer)))
+	assert.False(t, ts.Authorize(caller, string(adminjson.MethodValRemove)))
+
+	// An unverified certificate is not an identity.
+	_, err = ts.Authenticate(certRequest(false))
+	require.ErrorContains(t, err, "credentials required")
+
+	tokens := ts.List()
+	require.Len(t, tokens, 1)
+	assert.Len(t, tokens[0].CertFingerprint, 64)
+}
+
+func TestAuditLog(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	al, err := OpenAuditLog(file, log.DiscardLogger)
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), rpcserver.RequestIPCtx, "10.0.0.1")
+	caller := &rpcserver.Caller{Name: "monitor"}
+	al.Audit(ctx, caller, "admin.status", nil, nil)
+	al.Audit(ctx, caller, "admin.add_peer", json.RawMessage(`{"peer_id":"abc"}`),
+		jsonrpc.NewError(jsonrpc.ErrorUnauthorized, "not authorized", nil))
+	require.NoError(t, al.Close())
+
+	// Reopening appends.
+	al, err = OpenAuditLog(file, log.DiscardLogger)
+	require.NoError(t, err)
+	al.Audit(context.Background(), nil, "admin.version", json.RawMessage(`null`), nil)
+	require.NoError(t, al.Close())
+
+	bts, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(bts)), "\n")
+	require.Len(t, lines, 3)
+
+	var rec auditRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &rec))
+	assert.Equal(t, "monitor", rec.Caller)
+	assert.Equal(t, "10.0.0.1", rec.Remote)
+	assert.Equal(t, "admin.add_peer", rec.Method)
+	assert.JSONEq(t, `{"peer_id":"abc"}`, string(rec.Params))
+	assert.Equal(t, jsonrpc.ErrorUnauthorized, rec.Code)
+}
@@ -0,0 +1,36 @@
+package chainsvc
+
+import (
+	"net/http"
+
+	chainjson "github.com/kwilteam/kwil-db/core/rpc/json/chain"
+	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/openapi"
+)
+
+// The chain Service provides REST routes when the server's REST facade is
+// enabled.
+var _ rpcserver.RESTSvc = (*Service)(nil)
+
+// RESTRoutes returns the REST routes for the chain service.
+func (svc *Service) RESTRoutes() []*rpcserver.RESTRoute {
+	get := func(path string, method string, summary string) *rpcserver.RESTRoute {
+		return &rpcserver.RESTRoute{Route: openapi.Route{
+			HTTPMethod: http.MethodGet,
+			Path:       path,
+			Method:     method,
+			Summary:    summary,
+		}}
+	}
+	return []*rpcserver.RESTRoute{
+		get("/v1/chain/version", string(chainjson.MethodVersion), "retrieve the API version of the chain service"),
+		get("/v1/chain/block", string(chainjson.MethodBlock), "retrieve the block by height or hash, or the latest block"),
+		get("/v1/chain/block/{height}", string(chainjson.MethodBlock), "retrieve the block at a height"),
+		get("/v1/chain/block_result/{height}", string(chainjson.MethodBlockResult), "retrieve the block result at a height"),
+		get("/v1/chain/tx/{hash}", string(chainjson.MethodTx), "retrieve a transaction in a block by hash"),
+		get("/v1/chain/genesis", string(chainjson.MethodGenesis), "retrieve the genesis info"),
+		get("/v1/chain/consensus_params", string(chainjson.MethodConsensusParams), "retrieve the consensus parameters"),
+		get("/v1/chain/validators", string(chainjson.MethodValidators), "retrieve the current validators"),
+		get("/v1/chain/unconfirmed_txs", string(chainjson.MethodUnconfirmedTxs), "retrieve unconfirmed transactions"),
+	}
+}
@@ -0,0 +1,289 @@
+// Package openapi generates an OpenAPI 3 document describing REST routes that
+// are mapped onto JSON-RPC methods. The schemas are reflected from the same
+// method definitions used to generate the OpenRPC specification.
+package openapi
+
+import (
+	"cmp"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/openrpc"
+)
+
+// Version is the OpenAPI specification version of the generated document.
+const Version = "3.0.3"
+
+// Spec is the structure of an OpenAPI document.
+type Spec struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       openrpc.Info        `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// PathItem describes the operations available on a single path, keyed by the
+// lower case HTTP method.
+type PathItem map[string]*Operation
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter of an operation.
+type Parameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"` // "path" or "query"
+	Required    bool           `json:"required"`
+	Description string         `json:"description,omitempty"`
+	Schema      openrpc.Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response from an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType provides the schema for a content type.
+type MediaType struct {
+	Schema any `json:"schema"`
+}
+
+// ObjectSchema is an "object" schema with a list of required properties, which
+// openrpc.Schema does not represent.
+type ObjectSchema struct {
+	Type       string                    `json:"type"`
+	Properties map[string]openrpc.Schema `json:"properties,omitempty"`
+	Required   []string                  `json:"required,omitempty"`
+}
+
+// Components holds the reusable schemas referenced in the document.
+type Components struct {
+	Schemas map[string]any `json:"schemas"`
+}
+
+// Route describes a REST endpoint for which an Operation is generated.
+type Route struct {
+	// HTTPMethod is the HTTP request method, e.g. GET or POST.
+	HTTPMethod string
+	// Path is the URL path, with wildcards in braces, e.g. /v1/tx/{hash}.
+	Path string
+	// Method is the name of the JSON-RPC method that handles the request.
+	Method string
+	// Summary is a short description of the route. If empty, the method
+	// description is used.
+	Summary string
+	// Fields maps path wildcard and query parameter names to the JSON field
+	// names of the request type when they differ.
+	Fields map[string]string
+	// Body is the schema of the request body when it is not the method's
+	// request type, for routes with a custom mapping onto the method.
+	Body any
+}
+
+const contentTypeJSON = "application/json"
+
+var wildcardRE = regexp.MustCompile(`\{([^}.]+)(?:\.\.\.)?\}`)
+
+// PathParams returns the names of the wildcards in the path.
+func PathParams(path string) []string {
+	var names []string
+	for _, m := range wildcardRE.FindAllStringSubmatch(path, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// field returns the request field name for a route parameter name.
+func (r *Route) field(name string) string {
+	if f, ok := r.Fields[name]; ok {
+		return f
+	}
+	return name
+}
+
+// MakeSpec creates an OpenAPI document for the routes, using the method
+// definitions to describe the parameters, bodies, and responses.
+func MakeSpec(info openrpc.Info, routes []*Route, methodDefs map[string]*openrpc.MethodDefinition) *Spec {
+	knownSchemas := make(map[reflect.Type]openrpc.Schema)
+	methods := openrpc.InventoryAPI(methodDefs, knownSchemas)
+	methodsByName := make(map[string]*openrpc.Method, len(methods))
+	for i := range methods {
+		methodsByName[methods[i].Name] = &methods[i]
+	}
+
+	spec := &Spec{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]any, len(knownSchemas)),
+		},
+	}
+	for _, schema := range knownSchemas {
+		spec.Components.Schemas[schema.Name()] = schema
+	}
+
+	errSchemaName := "error"
+	spec.Components.Schemas[errSchemaName] = ObjectSchema{
+		Type: openrpc.TypeObject,
+		Properties: map[string]openrpc.Schema{
+			"code":    {Type: openrpc.TypeInteger},
+			"message": {Type: openrpc.TypeString},
+			"data":    {Type: openrpc.TypeObject, AdditionalProperties: true},
+		},
+		Required: []string{"code", "message"},
+	}
+	errResp := Response{
+		Description: "error",
+		Content: map[string]MediaType{
+			contentTypeJSON: {Schema: openrpc.Schema{Ref: "#/components/schemas/" + errSchemaName}},
+		},
+	}
+
+	for _, route := range routes {
+		method, ok := methodsByName[route.Method]
+		if !ok {
+			continue // method not registered on this server
+		}
+
+		op := &Operation{
+			OperationID: operationID(route),
+			Summary:     cmp.Or(route.Summary, method.Description),
+			Description: "Handled by the " + method.Name + " JSON-RPC method.",
+			Tags:        []string{strings.SplitN(method.Name, ".", 2)[0]},
+			Responses: map[string]Response{
+				"200": {
+					Description: cmp.Or(method.Result.Description, "success"),
+					Content: map[string]MediaType{
+						contentTypeJSON: {Schema: method.Result.Schema},
+					},
+				},
+				"default": errResp,
+			},
+		}
+
+		params := make(map[string]*openrpc.Param, len(method.Params))
+		for i := range method.Params {
+			params[method.Params[i].Name] = &method.Params[i]
+		}
+
+		inPath := make(map[string]bool)
+		for _, name := range PathParams(route.Path) {
+			inPath[route.field(name)] = true
+			param := Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   openrpc.Schema{Type: openrpc.TypeString},
+			}
+			if p, ok := params[route.field(name)]; ok {
+				param.Schema = p.Schema
+				param.Description = p.Description
+			}
+			op.Parameters = append(op.Parameters, param)
+		}
+
+		switch {
+		case route.Body != nil:
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{contentTypeJSON: {Schema: route.Body}},
+			}
+		case route.HTTPMethod == http.MethodGet:
+			// Remaining params are in the query string, but only simple types
+			// can be represented there.
+			for _, p := range method.Params {
+				if inPath[p.Name] || p.Schema.Type == openrpc.TypeObject || p.Schema.Type == openrpc.TypeArray {
+					continue
+				}
+				op.Parameters = append(op.Parameters, Parameter{
+					Name:        queryName(route, p.Name),
+					In:          "query",
+					Required:    false, // zero values are used when omitted
+					Description: p.Description,
+					Schema:      p.Schema,
+				})
+			}
+		default:
+			body := ObjectSchema{
+				Type:       openrpc.TypeObject,
+				Properties: make(map[string]openrpc.Schema),
+			}
+			for _, p := range method.Params {
+				if inPath[p.Name] {
+					continue
+				}
+				body.Properties[p.Name] = p.Schema
+				if p.Required != nil && *p.Required {
+					body.Required = append(body.Required, p.Name)
+				}
+			}
+			if len(body.Properties) > 0 {
+				op.RequestBody = &RequestBody{
+					Required: len(body.Required) > 0,
+					Content:  map[string]MediaType{contentTypeJSON: {Schema: body}},
+				}
+			}
+		}
+
+		item, ok := spec.Paths[route.Path]
+		if !ok {
+			item = make(PathItem)
+			spec.Paths[route.Path] = item
+		}
+		item[strings.ToLower(route.HTTPMethod)] = op
+	}
+
+	return spec
+}
+
+// queryName returns the query parameter name for a request field, which is
+// the field name unless the route renames it.
+func queryName(route *Route, field string) string {
+	for name, f := range route.Fields {
+		if f == field {
+			return name
+		}
+	}
+	return field
+}
+
+// operationID creates a unique operation ID from the HTTP method and path.
+func operationID(route *Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.HTTPMethod))
+	for _, seg := range strings.FieldsFunc(route.Path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == ':' || r == '.' || r == '_'
+	}) {
+		if seg == "v1" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(seg[:1]) + seg[1:])
+	}
+	return b.String()
+}
+
+// SortRoutes sorts routes by path and then HTTP method.
+func SortRoutes(routes []*Route) {
+	slices.SortFunc(routes, func(a, b *Route) int {
+		return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.HTTPMethod, b.HTTPMethod))
+	})
+}
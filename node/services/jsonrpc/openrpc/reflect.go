@@ -107,6 +107,12 @@ func lowerFirstChar(s string) string {
 	return strings.ToLower(string(r)) + s[sz:]
 }
 
+// SchemaType returns the OpenRPC schema type name (e.g. "string" or "object")
+// for the JSON encoding of a Go type.
+func SchemaType(t reflect.Type) string {
+	return typeToSchemaType(t)
+}
+
 func typeToSchemaType(t reflect.Type) string {
 	// Some special cases first. These are types that our JSON-RPC service
 	// should marshal as a JSON string. In some cases this is by virtue of the
@@ -125,6 +131,8 @@ func typeToSchemaType(t reflect.Type) string {
 		return "string"
 	case reflect.TypeFor[types.UUID](): // MarshalJSON also makes JSON string
 		return "string"
+	case reflect.TypeFor[types.Hash](): // MarshalJSON makes a hexadecimal JSON string
+		return "string"
 	case reflect.TypeFor[types.Decimal](): // MarshalJSON also makes JSON string
 		return "string"
 	case reflect.TypeFor[[]byte]():
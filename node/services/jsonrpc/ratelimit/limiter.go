@@ -1,9 +1,14 @@
 package ratelimit
 
 import (
+	"encoding/json"
+	"math"
 	"sync"
+	"time"
 
 	"golang.org/x/time/rate"
+
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
 )
 
 // IPRateLimiter is a map of IP address to rate limiter.
@@ -39,3 +44,124 @@ func (i *IPRateLimiter) IP(ip string) *rate.Limiter {
 	i.ips[ip] = limiter
 	return limiter
 }
+
+// KeyedLimiter is a collection of token bucket rate limiters keyed by an
+// arbitrary string, such as a client IP address or an authenticated identity.
+// Unlike IPRateLimiter, requests may consume more than one token, and a denied
+// request reports how long the client should wait before retrying.
+type KeyedLimiter struct {
+	mtx  sync.Mutex
+	lims map[string]*keyedEntry
+
+	r     rate.Limit
+	burst int
+}
+
+type keyedEntry struct {
+	lim      *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewKeyedLimiter creates a KeyedLimiter that refills each key's bucket at rps
+// tokens per second, up to burst tokens.
+func NewKeyedLimiter(rps float64, burst int) *KeyedLimiter {
+	return &KeyedLimiter{
+		lims:  make(map[string]*keyedEntry),
+		r:     rate.Limit(rps),
+		burst: burst,
+	}
+}
+
+// Allow attempts to take cost tokens from the bucket for key. If there are not
+// enough tokens, no tokens are consumed, and the returned duration is how long
+// until the request would be permitted. A cost larger than the burst can never
+// be satisfied, in which case the retry duration is zero.
+func (kl *KeyedLimiter) Allow(key string, cost int) (bool, time.Duration) {
+	return kl.allowAt(key, cost, time.Now())
+}
+
+func (kl *KeyedLimiter) allowAt(key string, cost int, now time.Time) (bool, time.Duration) {
+	kl.mtx.Lock()
+	defer kl.mtx.Unlock()
+
+	ent, have := kl.lims[key]
+	if !have {
+		ent = &keyedEntry{lim: rate.NewLimiter(kl.r, kl.burst)}
+		kl.lims[key] = ent
+	}
+	ent.lastSeen = now
+
+	res := ent.lim.ReserveN(now, cost)
+	if !res.OK() { // cost exceeds burst
+		return false, 0
+	}
+	delay := res.DelayFrom(now)
+	if delay == 0 {
+		return true, 0
+	}
+	res.CancelAt(now) // return the tokens, we are not going to wait
+	return false, delay
+}
+
+// Prune removes limiters for keys that have not been used within the idle
+// duration. A limiter that has been idle long enough to completely refill is
+// indistinguishable from a new one, so this does not relax any limits.
+func (kl *KeyedLimiter) Prune(idle time.Duration) {
+	kl.mtx.Lock()
+	defer kl.mtx.Unlock()
+	cutoff := time.Now().Add(-idle)
+	for key, ent := range kl.lims {
+		if ent.lastSeen.Before(cutoff) {
+			delete(kl.lims, key)
+		}
+	}
+}
+
+// Len returns the number of keys presently tracked.
+func (kl *KeyedLimiter) Len() int {
+	kl.mtx.Lock()
+	defer kl.mtx.Unlock()
+	return len(kl.lims)
+}
+
+// refillTime is the time required for an empty bucket to become full.
+func (kl *KeyedLimiter) refillTime() time.Duration {
+	if kl.r <= 0 || kl.r == rate.Inf {
+		return 0
+	}
+	return time.Duration(float64(kl.burst) / float64(kl.r) * float64(time.Second))
+}
+
+// ErrorCode is the JSON-RPC error code used when a request is rejected by a
+// rate limiter. This is the same code used by the gateway for this purpose.
+const ErrorCode = jsonrpc.ErrorKGWTooManyRequests
+
+// ErrorData is the data included in a rate limit jsonrpc.Error.
+type ErrorData struct {
+	// RetryAfter is the number of seconds after which the request may succeed.
+	// It is zero if the request can never be satisfied with the current limits.
+	RetryAfter int64 `json:"retry_after"`
+}
+
+// NewError creates a jsonrpc.Error for a rate limited request. The retry
+// duration is rounded up to the nearest second, as in the HTTP Retry-After
+// header.
+func NewError(msg string, retryAfter time.Duration) *jsonrpc.Error {
+	data, _ := json.Marshal(&ErrorData{
+		RetryAfter: int64(math.Ceil(retryAfter.Seconds())),
+	})
+	return jsonrpc.NewError(ErrorCode, msg, data)
+}
+
+// RetryAfter extracts the retry duration in seconds from a rate limit
+// jsonrpc.Error. The bool is false if the error is not a rate limit error.
+func RetryAfter(err *jsonrpc.Error) (int64, bool) {
+	if err == nil || err.Code != ErrorCode {
+		return 0, false
+	}
+	var data ErrorData
+	if err.Data == nil || json.Unmarshal(err.Data, &data) != nil {
+		return 0, true
+	}
+	return data.RetryAfter, true
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedLimiter(t *testing.T) {
+	kl := NewKeyedLimiter(2, 5) // 2 tokens/sec, burst 5
+	now := time.Now()
+
+	ok, _ := kl.allowAt("a", 3, now)
+	assert.True(t, ok)
+	ok, _ = kl.allowAt("a", 2, now)
+	assert.True(t, ok)
+
+	// empty bucket, need 1.5 sec for 3 tokens
+	ok, retry := kl.allowAt("a", 3, now)
+	assert.False(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, retry)
+
+	// the denied request did not consume tokens
+	ok, _ = kl.allowAt("a", 3, now.Add(1500*time.Millisecond))
+	assert.True(t, ok)
+
+	// other keys are independent
+	ok, _ = kl.allowAt("b", 5, now)
+	assert.True(t, ok)
+
+	// cost larger than the burst can never succeed
+	ok, retry = kl.allowAt("c", 6, now)
+	assert.False(t, ok)
+	assert.Zero(t, retry)
+}
+
+func TestMethodLimiter(t *testing.T) {
+	ml := NewMethodLimiter(&MethodLimiterConfig{
+		RPS:   1,
+		Burst: 10,
+		Costs: map[string]int{
+			"user.call":     5,
+			"user.ping":     0,
+			"user.disabled": -1,
+		},
+	})
+
+	assert.Equal(t, 5, ml.Cost("user.call"))
+	assert.Equal(t, 1, ml.Cost("user.account"))
+
+	for range 2 {
+		ok, _ := ml.Allow("1.2.3.4", "user.call")
+		assert.True(t, ok)
+	}
+	ok, retry := ml.Allow("1.2.3.4", "user.call")
+	assert.False(t, ok)
+	assert.Greater(t, retry, 4*time.Second)
+
+	// free methods are always allowed
+	ok, _ = ml.Allow("1.2.3.4", "user.ping")
+	assert.True(t, ok)
+
+	ok, _ = ml.Allow("5.6.7.8", "user.disabled")
+	assert.False(t, ok)
+}
+
+func TestRateLimitError(t *testing.T) {
+	jsonErr := NewError("slow down", 1200*time.Millisecond)
+	retryAfter, ok := RetryAfter(jsonErr)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), retryAfter)
+
+	_, ok = RetryAfter(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"time"
+)
+
+// MethodLimiterConfig configures a MethodLimiter.
+type MethodLimiterConfig struct {
+	// RPS is the sustained rate of request cost units per second permitted for
+	// each client.
+	RPS float64
+	// Burst is the maximum number of cost units a client may use at once.
+	Burst int
+	// Costs are the cost weights of specific methods (e.g. "user.call"). A
+	// method that is not listed costs DefaultCost. A negative cost disables
+	// the method entirely.
+	Costs map[string]int
+	// DefaultCost is the cost of any method not in Costs. If zero, it is 1.
+	DefaultCost int
+}
+
+// MethodLimiter limits clients by the weighted cost of the methods they call,
+// so that expensive methods such as "user.call" consume more of a client's
+// allowance than cheap ones such as "user.ping".
+type MethodLimiter struct {
+	lim         *KeyedLimiter
+	costs       map[string]int
+	defaultCost int
+}
+
+// NewMethodLimiter creates a new MethodLimiter with the provided config.
+func NewMethodLimiter(cfg *MethodLimiterConfig) *MethodLimiter {
+	defaultCost := cfg.DefaultCost
+	if defaultCost == 0 {
+		defaultCost = 1
+	}
+	costs := make(map[string]int, len(cfg.Costs))
+	for method, cost := range cfg.Costs {
+		costs[method] = cost
+	}
+	return &MethodLimiter{
+		lim:         NewKeyedLimiter(cfg.RPS, cfg.Burst),
+		costs:       costs,
+		defaultCost: defaultCost,
+	}
+}
+
+// Cost returns the cost weight of the method.
+func (ml *MethodLimiter) Cost(method string) int {
+	if cost, ok := ml.costs[method]; ok {
+		return cost
+	}
+	return ml.defaultCost
+}
+
+// Allow charges the cost of the method to the client. If the client has
+// exceeded its allowance, the returned duration indicates when it may retry.
+func (ml *MethodLimiter) Allow(client, method string) (bool, time.Duration) {
+	cost := ml.Cost(method)
+	if cost == 0 {
+		return true, 0
+	}
+	if cost < 0 {
+		return false, 0 // disabled
+	}
+	return ml.lim.Allow(client, cost)
+}
+
+// Prune removes state for clients that have been idle long enough for their
+// allowance to be fully replenished.
+func (ml *MethodLimiter) Prune() {
+	ml.lim.Prune(max(ml.lim.refillTime(), time.Minute))
+}
@@ -0,0 +1,384 @@
+package rpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	"github.com/kwilteam/kwil-db/node/metrics"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/openapi"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/openrpc"
+)
+
+const (
+	pathRESTV1  = "/v1"
+	pathOpenAPI = "/openapi.json"
+)
+
+// RESTRoute maps an HTTP endpoint onto a JSON-RPC method. Unless Params is
+// set, the method's parameters object is assembled from the JSON request body,
+// the query string, and the path wildcards, in increasing order of precedence.
+// Query and path values are converted according to the type of the
+// corresponding field of the method's request type.
+//
+// The final path segment may be a wildcard followed by a ":verb" suffix, as in
+// "/v1/namespaces/{ns}/actions/{action}:call".
+type RESTRoute struct {
+	openapi.Route
+
+	// Params, if set, creates the JSON-RPC method parameters from the path
+	// wildcard values and the raw request body.
+	Params func(pathVals map[string]string, body []byte) (json.RawMessage, error)
+}
+
+// RESTSvc is a Svc that exposes some of its methods with REST routes in
+// addition to the generic POST /v1/{svc}/{method} routes.
+type RESTSvc interface {
+	Svc
+	RESTRoutes() []*RESTRoute
+}
+
+// WithREST enables a REST facade over the registered JSON-RPC methods. Every
+// method is available with a POST to /v1/{service}/{method} with the method's
+// parameters in the request body, and services implementing RESTSvc may
+// provide more conventional routes. An OpenAPI document describing the routes
+// is served at /openapi.json.
+func WithREST() Opt {
+	return func(c *serverConfig) {
+		c.rest = true
+	}
+}
+
+// restRoutes returns all of the REST routes for the registered services.
+func (s *Server) restRoutes() []*RESTRoute {
+	var routes []*RESTRoute
+	have := make(map[string]bool)
+	for _, svc := range s.services {
+		restSvc, ok := svc.(RESTSvc)
+		if !ok {
+			continue
+		}
+		for _, route := range restSvc.RESTRoutes() {
+			if _, registered := s.methodDefs[route.Method]; !registered {
+				continue
+			}
+			key := route.HTTPMethod + " " + route.Path
+			if have[key] {
+				continue
+			}
+			have[key] = true
+			routes = append(routes, route)
+		}
+	}
+
+	for method := range s.methodDefs {
+		svcName, name, ok := strings.Cut(method, ".")
+		if !ok || svcName == "rpc" {
+			continue
+		}
+		path := pathRESTV1 + "/" + svcName + "/" + name
+		if have[http.MethodPost+" "+path] {
+			continue
+		}
+		routes = append(routes, &RESTRoute{
+			Route: openapi.Route{
+				HTTPMethod: http.MethodPost,
+				Path:       path,
+				Method:     method,
+			},
+		})
+	}
+
+	openapi.SortRoutes(routesOf(routes))
+	return routes
+}
+
+func routesOf(routes []*RESTRoute) []*openapi.Route {
+	rs := make([]*openapi.Route, len(routes))
+	for i, r := range routes {
+		rs[i] = &r.Route
+	}
+	return rs
+}
+
+// mountREST registers the REST route handlers and the OpenAPI document handler
+// on the server's mux. This must be called after all services are registered.
+func (s *Server) mountREST() error {
+	routes := s.restRoutes()
+
+	specInfo := *s.specInfo
+	specInfo.Title += " (REST)"
+	spec := openapi.MakeSpec(specInfo, routesOf(routes), s.methodDefs)
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range routes {
+		pattern, verb := muxPattern(route.Path)
+		h := s.restHandler(route, verb)
+		s.mux.Handle(route.HTTPMethod+" "+pattern, s.restMW(h))
+	}
+
+	s.mux.Handle(http.MethodGet+" "+pathOpenAPI, s.restMW(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.ServeContent(w, r, "openapi.json", time.Time{}, strings.NewReader(string(specJSON)))
+	})))
+
+	return nil
+}
+
+// muxPattern converts a route path with an optional ":verb" suffix on the last
+// wildcard into a http.ServeMux pattern and the verb.
+func muxPattern(path string) (pattern, verb string) {
+	lastSlash := strings.LastIndexByte(path, '/')
+	last := path[lastSlash+1:]
+	if strings.HasPrefix(last, "{") {
+		if wc, v, ok := strings.Cut(last, "}:"); ok {
+			return path[:lastSlash+1] + wc + "}", v
+		}
+	}
+	return path, ""
+}
+
+// restHandler creates the http.Handler for a REST route.
+func (s *Server) restHandler(route *RESTRoute, verb string) http.Handler {
+	wildcards := openapi.PathParams(route.Path)
+	var reqType reflect.Type
+	if def, ok := s.methodDefs[route.Method]; ok {
+		reqType = def.RequestType
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, ok := s.authenticate(r)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		pathVals := make(map[string]string, len(wildcards))
+		for i, name := range wildcards {
+			val := r.PathValue(name)
+			if verb != "" && i == len(wildcards)-1 {
+				var ok bool
+				val, ok = strings.CutSuffix(val, ":"+verb)
+				if !ok {
+					http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+					return
+				}
+			}
+			pathVals[name] = val
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = io.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				http.Error(w, "error reading request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var params json.RawMessage
+		var err error
+		if route.Params != nil {
+			params, err = route.Params(pathVals, body)
+		} else {
+			params, err = restParams(&route.Route, reqType, pathVals, r.URL.Query(), body)
+		}
+		if err != nil {
+			s.writeRESTError(w, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, err.Error(), nil))
+			return
+		}
+
+		ctx := metrics.ExtractTraceContext(r.Context(), r.Header) // continue the client's trace, if any
+		if caller != nil {
+			ctx = context.WithValue(ctx, CallerCtx, caller)
+		}
+		result, rpcErr := s.handleRESTRequest(ctx, route.Method, params)
+		if rpcErr != nil {
+			s.writeRESTError(w, rpcErr)
+			return
+		}
+		s.writeJSON(w, result, http.StatusOK)
+	})
+}
+
+// handleRESTRequest calls the JSON-RPC method with the parameters, applying
+// the same rate limits, authorization, and auditing as a JSON-RPC request.
+func (s *Server) handleRESTRequest(ctx context.Context, method string, params json.RawMessage) (any, *jsonrpc.Error) {
+	if rpcErr := s.checkRateLimit(ctx, method); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	req := &jsonrpc.Request{Method: method, Params: params}
+	caller, _ := ctx.Value(CallerCtx).(*Caller)
+	if s.auth != nil && !s.auth.Authorize(caller, method) {
+		rpcErr := jsonrpc.NewError(jsonrpc.ErrorUnauthorized, "caller is not authorized for this method", nil)
+		s.audit(ctx, caller, req, rpcErr)
+		return nil, rpcErr
+	}
+
+	t0 := time.Now()
+	result, rpcErr := s.handleMethod(ctx, jsonrpc.Method(method), params)
+	s.audit(ctx, caller, req, rpcErr)
+	if rpcErr != nil {
+		s.log.Debug("REST request failure", "method", method, "elapsed", time.Since(t0),
+			"code", rpcErr.Code, "message", rpcErr.Message)
+		return nil, rpcErr
+	}
+	s.log.Debug("REST request success", "method", method, "elapsed", time.Since(t0))
+	return result, nil
+}
+
+// writeRESTError writes the jsonrpc.Error as the response body, with a status
+// code consistent with the JSON-RPC handler, or 500 for other server errors.
+func (s *Server) writeRESTError(w http.ResponseWriter, rpcErr *jsonrpc.Error) {
+	statusCode := errorStatusCode(w, rpcErr)
+	if statusCode == http.StatusOK {
+		statusCode = http.StatusInternalServerError
+		switch rpcErr.Code {
+		case jsonrpc.ErrorTxNotFound, jsonrpc.ErrorBlkNotFound, jsonrpc.ErrorEngineDatasetNotFound,
+			jsonrpc.ErrorValidatorNotFound:
+			statusCode = http.StatusNotFound
+		case jsonrpc.ErrorTimeout:
+			statusCode = http.StatusGatewayTimeout
+		case jsonrpc.ErrorBroadcastRejected, jsonrpc.ErrorTxPayloadInvalid, jsonrpc.ErrorEngineDatasetExists,
+			jsonrpc.ErrorIdentInvalid, jsonrpc.ErrorNoQueryWithPrivateRPC:
+			statusCode = http.StatusUnprocessableEntity
+		case jsonrpc.ErrorCallChallengeNotFound, jsonrpc.ErrorInvalidCallChallenge,
+			jsonrpc.ErrorCallChallengeExpired, jsonrpc.ErrorInvalidCallSignature:
+			statusCode = http.StatusUnauthorized
+		}
+	}
+	s.writeJSON(w, rpcErr, statusCode)
+}
+
+var errNotObject = errors.New("request body must be a JSON object")
+
+// restParams assembles the JSON-RPC parameters object for a request.
+func restParams(route *openapi.Route, reqType reflect.Type, pathVals map[string]string,
+	query map[string][]string, body []byte) (json.RawMessage, error) {
+	params := make(map[string]json.RawMessage)
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &params); err != nil {
+			return nil, errNotObject
+		}
+	}
+
+	fieldTypes := jsonFieldTypes(reqType)
+	set := func(name, val string) error {
+		field := name
+		if f, ok := route.Fields[name]; ok {
+			field = f
+		}
+		ft, ok := fieldTypes[field]
+		if !ok {
+			return errors.New("unknown parameter " + name)
+		}
+		raw, err := convertParam(ft, val)
+		if err != nil {
+			return errors.New("invalid parameter " + name + ": " + err.Error())
+		}
+		params[field] = raw
+		return nil
+	}
+
+	for name, vals := range query {
+		if len(vals) == 0 {
+			continue
+		}
+		if err := set(name, vals[len(vals)-1]); err != nil {
+			return nil, err
+		}
+	}
+	for name, val := range pathVals {
+		if err := set(name, val); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(params)
+}
+
+// jsonFieldTypes maps the JSON field names of a struct type to their types.
+func jsonFieldTypes(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	if t == nil {
+		return fields
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous {
+			for name, ft := range jsonFieldTypes(field.Type) {
+				fields[name] = ft
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			name, _, _ = strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// convertParam converts a path or query string value into the JSON encoding
+// of the field type. Strings are quoted, while numbers and booleans are
+// validated and used as is. Other types must be provided as JSON.
+func convertParam(t reflect.Type, val string) (json.RawMessage, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch openrpc.SchemaType(t) {
+	case openrpc.TypeString:
+		return json.Marshal(val)
+	case openrpc.TypeInteger:
+		if _, err := strconv.ParseInt(val, 10, 64); err != nil {
+			if _, err = strconv.ParseUint(val, 10, 64); err != nil {
+				return nil, errors.New("not an integer")
+			}
+		}
+		return json.RawMessage(val), nil
+	case openrpc.TypeNumber:
+		if _, err := strconv.ParseFloat(val, 64); err != nil {
+			return nil, errors.New("not a number")
+		}
+		return json.RawMessage(val), nil
+	case openrpc.TypeBoolean:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, errors.New("not a boolean")
+		}
+		return json.Marshal(b)
+	}
+	if !json.Valid([]byte(val)) {
+		return nil, errors.New("not valid JSON")
+	}
+	return json.RawMessage(val), nil
+}
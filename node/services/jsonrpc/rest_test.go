@@ -0,0 +1,199 @@
+package rpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/log"
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/openapi"
+)
+
+type echoRequest struct {
+	Height int64  `json:"height"`
+	Name   string `json:"name"`
+	Raw    bool   `json:"raw,omitempty"`
+}
+
+type echoResponse struct {
+	Height int64  `json:"height"`
+	Name   string `json:"name"`
+	Raw    bool   `json:"raw"`
+}
+
+type restTestSvc struct{}
+
+func (restTestSvc) Name() string { return "test" }
+
+func (restTestSvc) Health(context.Context) (json.RawMessage, bool) { return nil, true }
+
+func (restTestSvc) Methods() map[jsonrpc.Method]MethodDef {
+	return map[jsonrpc.Method]MethodDef{
+		"test.echo": MakeMethodDef(func(_ context.Context, req *echoRequest) (*echoResponse, *jsonrpc.Error) {
+			if req.Height < 0 {
+				return nil, jsonrpc.NewError(jsonrpc.ErrorBlkNotFound, "no such block", nil)
+			}
+			return &echoResponse{Height: req.Height, Name: req.Name, Raw: req.Raw}, nil
+		}, "echo the request", "the request"),
+	}
+}
+
+func (restTestSvc) RESTRoutes() []*RESTRoute {
+	return []*RESTRoute{
+		{Route: openapi.Route{
+			HTTPMethod: http.MethodGet,
+			Path:       "/v1/test/echo/{h}",
+			Method:     "test.echo",
+			Fields:     map[string]string{"h": "height"},
+		}},
+		{
+			Route: openapi.Route{
+				HTTPMethod: http.MethodPost,
+				Path:       "/v1/test/things/{name}:echo",
+				Method:     "test.echo",
+			},
+			Params: func(pathVals map[string]string, _ []byte) (json.RawMessage, error) {
+				return json.Marshal(&echoRequest{Name: pathVals["name"], Height: 7})
+			},
+		},
+	}
+}
+
+func TestREST(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:", log.DiscardLogger, WithREST())
+	require.NoError(t, err)
+	srv.RegisterSvc(restTestSvc{})
+	require.NoError(t, srv.mountREST())
+
+	do := func(method, path, body string) (int, map[string]any) {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(w, r)
+		var out map[string]any
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&out))
+		return w.Code, out
+	}
+
+	// path wildcard and query parameters
+	code, out := do(http.MethodGet, "/v1/test/echo/12?name=bob&raw=true", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]any{"height": 12.0, "name": "bob", "raw": true}, out)
+
+	// invalid integer
+	code, out = do(http.MethodGet, "/v1/test/echo/twelve", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.EqualValues(t, jsonrpc.ErrorInvalidParams, out["code"])
+
+	// method error
+	code, _ = do(http.MethodGet, "/v1/test/echo/-1", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// generic route with params in the body
+	code, out = do(http.MethodPost, "/v1/test/echo", `{"height":3,"name":"alice"}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]any{"height": 3.0, "name": "alice", "raw": false}, out)
+
+	// custom params with a verb
+	code, out = do(http.MethodPost, "/v1/test/things/widget:echo", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "widget", out["name"])
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/test/things/widget:nope", nil)
+	w := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// OpenAPI document
+	code, out = do(http.MethodGet, pathOpenAPI, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, openapi.Version, out["openapi"])
+	paths := out["paths"].(map[string]any)
+	assert.Contains(t, paths, "/v1/test/echo/{h}")
+	assert.Contains(t, paths, "/v1/test/things/{name}:echo")
+	assert.Contains(t, paths, "/v1/test/echo")
+}
+
+// restWriteSvc has a method that testAuth only authorizes for writers.
+type restWriteSvc struct{}
+
+func (restWriteSvc) Name() string { return "write" }
+
+func (restWriteSvc) Health(context.Context) (json.RawMessage, bool) { return nil, true }
+
+func (restWriteSvc) Methods() map[jsonrpc.Method]MethodDef {
+	return map[jsonrpc.Method]MethodDef{
+		"rpc.write": MakeMethodDef(func(ctx context.Context, _ *echoRequest) (*string, *jsonrpc.Error) {
+			caller := ctx.Value(CallerCtx).(*Caller)
+			return &caller.Name, nil
+		}, "return the caller's name", "the caller's name"),
+	}
+}
+
+func (restWriteSvc) RESTRoutes() []*RESTRoute {
+	return []*RESTRoute{{Route: openapi.Route{
+		HTTPMethod: http.MethodPost,
+		Path:       "/v1/test/write",
+		Method:     "rpc.write",
+	}}}
+}
+
+func TestRESTAuthenticator(t *testing.T) {
+	auditor := &testAuditor{}
+	srv, err := NewServer("127.0.0.1:", log.DiscardLogger, WithREST(),
+		WithAuthenticator(testAuth{}), WithAuditor(auditor))
+	require.NoError(t, err)
+	srv.RegisterSvc(restTestSvc{})
+	srv.RegisterSvc(restWriteSvc{})
+	require.NoError(t, srv.mountREST())
+
+	call := func(path, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call("/v1/test/echo", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, call("/v1/test/write", "nobody").Code)
+	assert.Equal(t, http.StatusOK, call("/v1/test/echo", "reader").Code)
+
+	w := call("/v1/test/write", "reader")
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "not authorized")
+
+	w = call("/v1/test/write", "writer")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"writer"`, strings.TrimSpace(w.Body.String()))
+
+	assert.Equal(t, []string{"reader test.echo false", "reader rpc.write true", "writer rpc.write false"}, auditor.calls)
+}
+
+func TestRESTPass(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:", log.DiscardLogger, WithREST(), WithPass("secret"))
+	require.NoError(t, err)
+	srv.RegisterSvc(restTestSvc{})
+	require.NoError(t, srv.mountREST())
+
+	call := func(pass string) int {
+		r := httptest.NewRequest(http.MethodGet, "/v1/test/echo/1", nil)
+		if pass != "" {
+			r.SetBasicAuth("", pass)
+		}
+		w := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, call(""))
+	assert.Equal(t, http.StatusUnauthorized, call("wrong"))
+	assert.Equal(t, http.StatusOK, call("secret"))
+}
@@ -18,17 +18,22 @@ import (
 	"reflect"
 	"runtime/debug"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 
 	"github.com/kwilteam/kwil-db/core/log"
 	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
 	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/openrpc"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/ratelimit"
 )
 
 // The endpoint path is constant for now.
@@ -39,6 +44,8 @@ const (
 
 	pathRPCV1  = "/rpc/v1"
 	pathSpecV1 = "/spec/v1"
+
+	pathMetrics = "/metrics"
 )
 
 type contextRPCKey string
@@ -46,8 +53,33 @@ type contextRPCKey string
 const (
 	RequestIPCtx contextRPCKey = "clientIP"
 	ServerCtx    contextRPCKey = "server"
+	CallerCtx    contextRPCKey = "caller"
 )
 
+// Caller is the authenticated client of a request, as determined by the
+// server's Authenticator. It is set in the request context with CallerCtx.
+type Caller struct {
+	Name   string   // identifies the client e.g. in an audit log
+	Scopes []string // the access granted to the client
+}
+
+// Authenticator identifies the client of a request from its credentials, such
+// as an Authorization header or a TLS client certificate, and decides which
+// methods the client may call.
+type Authenticator interface {
+	// Authenticate returns the caller, or an error if the request's
+	// credentials are missing or invalid.
+	Authenticate(r *http.Request) (*Caller, error)
+	// Authorize reports if the caller may call the method.
+	Authorize(caller *Caller, method string) bool
+}
+
+// Auditor records the method calls made by authenticated clients.
+type Auditor interface {
+	// Audit records a call, and the error returned to the caller if any.
+	Audit(ctx context.Context, caller *Caller, method string, params json.RawMessage, rpcErr *jsonrpc.Error)
+}
+
 // Server is a JSON-RPC server.
 type Server struct {
 	srv            *http.Server
@@ -60,11 +92,25 @@ type Server struct {
 	specInfo       *openrpc.Info
 	spec           json.RawMessage
 	authSHA        []byte
+	auth           Authenticator
+	auditor        Auditor
 	tlsCfg         *tls.Config
+
+	// The request limits may be changed while the server is running.
+	timeout    atomic.Int64 // time.Duration
+	maxTimeout time.Duration
+	reqSzLimit atomic.Int64
+	limiter    atomic.Pointer[ratelimit.MethodLimiter]
+
+	mux    *http.ServeMux
+	rest   bool                            // mount REST routes in ServeOn
+	restMW func(http.Handler) http.Handler // middleware for REST handlers
 }
 
 type serverConfig struct {
 	pass       string
+	auth       Authenticator
+	auditor    Auditor
 	tlsConfig  *tls.Config
 	timeout    time.Duration
 	enableCORS bool
@@ -72,6 +118,9 @@ type serverConfig struct {
 	specInfo   *openrpc.Info
 	reqSzLimit int
 	proxyCount int
+	limiter    *ratelimit.MethodLimiter
+	rest       bool
+	metrics    http.Handler
 }
 
 type Opt func(*serverConfig)
@@ -85,6 +134,23 @@ func WithPass(pass string) Opt {
 	}
 }
 
+// WithAuthenticator requires each request to be authenticated and authorized
+// for the requested method by the given Authenticator. This supersedes
+// WithPass, but the Authenticator may also accept a password.
+func WithAuthenticator(auth Authenticator) Opt {
+	return func(c *serverConfig) {
+		c.auth = auth
+	}
+}
+
+// WithAuditor records each method call by an authenticated client with the
+// given Auditor. This is only used with WithAuthenticator.
+func WithAuditor(auditor Auditor) Opt {
+	return func(c *serverConfig) {
+		c.auditor = auditor
+	}
+}
+
 // WithTLS provides a tls.Config to use with tls.NewListener around the standard
 // net.Listener.
 func WithTLS(cfg *tls.Config) Opt {
@@ -93,12 +159,24 @@ func WithTLS(cfg *tls.Config) Opt {
 	}
 }
 
+// WithTrustedProxyCount sets the number of reverse proxies in front of the
+// server that may be trusted to set the X-Forwarded-For header. This is used to
+// determine the real client IP address for rate limiting.
 func WithTrustedProxyCount(trustedProxyCount int) Opt {
 	return func(c *serverConfig) {
 		c.proxyCount = trustedProxyCount
 	}
 }
 
+// WithRateLimiter limits the rate of requests from each client IP address
+// according to the cost of the requested method. To identify clients behind a
+// reverse proxy, use WithTrustedProxyCount.
+func WithRateLimiter(limiter *ratelimit.MethodLimiter) Opt {
+	return func(c *serverConfig) {
+		c.limiter = limiter
+	}
+}
+
 // WithMetricsNamespace enables metrics with the provided namespace.
 // func WithMetricsNamespace(namespace string) Opt {
 // 	return func(c *serverConfig) {
@@ -147,6 +225,15 @@ func WithCompression() Opt {
 	}
 }
 
+// WithMetricsHandler serves the provided handler, such as a Prometheus
+// exporter, at the /metrics path. It requires the same basic authentication as
+// the JSON-RPC handler if WithPass or WithAuthenticator is used.
+func WithMetricsHandler(h http.Handler) Opt {
+	return func(c *serverConfig) {
+		c.metrics = h
+	}
+}
+
 // checkAddr cleans the address, and indicates if it is a unix socket (local
 // filesystem path). The addr for NewServer should be a host:port style string,
 // but if it is a URL, this will attempt to get the host and port from it.
@@ -177,6 +264,10 @@ func checkAddr(addr string) (string, bool, error) {
 const (
 	// defaultWriteTimeout is the default WriteTimeout for the http.Server.
 	defaultWriteTimeout = 45 * time.Second
+	// disconnectDelay is added to the request timeout for the http.Server's
+	// WriteTimeout, so that jsonRPCTimeoutHandler may respond before the
+	// connection is closed.
+	disconnectDelay = 5 * time.Second
 	// 4 MiB + overhead request size limit
 	defaultSzLimit = 1<<22 + 1<<14
 )
@@ -227,7 +318,7 @@ func NewServer(addr string, log log.Logger, opts ...Opt) (*Server, error) {
 
 	mux := http.NewServeMux() // http.DefaultServeMux has the pprof endpoints mounted
 
-	disconnectTimeout := cfg.timeout + 5*time.Second // for jsonRPCTimeoutHandler to respond, don't disconnect immediately
+	disconnectTimeout := cfg.timeout + disconnectDelay // for jsonRPCTimeoutHandler to respond, don't disconnect immediately
 	srv := &http.Server{
 		Addr:              addr, // only used with srv.ListenAndServe, not Serve
 		Handler:           mux,
@@ -251,34 +342,45 @@ func NewServer(addr string, log log.Logger, opts ...Opt) (*Server, error) {
 		methodDefs:     make(map[string]*openrpc.MethodDefinition),
 		services:       make(map[string]Svc),
 		specInfo:       cfg.specInfo,
+		auth:           cfg.auth,
+		auditor:        cfg.auditor,
 		tlsCfg:         cfg.tlsConfig,
+		maxTimeout:     cfg.timeout,
+		mux:            mux,
+		rest:           cfg.rest,
 	}
+	s.timeout.Store(int64(cfg.timeout))
+	s.reqSzLimit.Store(int64(cfg.reqSzLimit))
+	s.limiter.Store(cfg.limiter)
 
 	if cfg.pass != "" {
 		authSHA := sha256.Sum256([]byte(cfg.pass))
 		s.authSHA = slices.Clone(authSHA[:])
 	} // otherwise no basic auth check
 
-	// JSON-RPC handler (POST+OPTIONS)
-	var h http.Handler
-	h = http.HandlerFunc(s.handlerJSONRPCV1) // last, after middleware below
-	h = http.MaxBytesHandler(h, int64(cfg.reqSzLimit))
-	// h = middleware.Logger(h)
-	h = middleware.Recoverer(h)
-	// amazingly, exceeding the server's write timeout does not cancel request
-	// contexts: https://github.com/golang/go/issues/59602
-	// So, we add a timeout to the Request's context.
-	h = jsonRPCTimeoutHandler(h, cfg.timeout, log)
-	if cfg.enableCORS {
-		h = corsHandler(h)
-	}
-
 	compMW := func(h http.Handler) http.Handler { return h }
 	if cfg.compress {
 		compMW = middleware.Compress(5)
 	}
-	h = compMW(h)
-	h = realIPHandler(h, cfg.proxyCount) // for effective rate limiting
+
+	// Middleware for the JSON-RPC handler and any REST handlers.
+	s.restMW = func(h http.Handler) http.Handler {
+		h = maxBytesHandler(h, s.reqSzLimit.Load)
+		// h = middleware.Logger(h)
+		h = middleware.Recoverer(h)
+		// amazingly, exceeding the server's write timeout does not cancel request
+		// contexts: https://github.com/golang/go/issues/59602
+		// So, we add a timeout to the Request's context.
+		h = jsonRPCTimeoutHandler(h, s.Timeout, log)
+		if cfg.enableCORS {
+			h = corsHandler(h)
+		}
+		h = compMW(h)
+		return realIPHandler(h, cfg.proxyCount) // for effective rate limiting
+	}
+
+	// JSON-RPC handler (POST+OPTIONS)
+	h := s.restMW(http.HandlerFunc(s.handlerJSONRPCV1))
 
 	// h = recoverer(h, log) // first, wrap with defer and call next ^
 
@@ -324,9 +426,57 @@ func NewServer(addr string, log log.Logger, opts ...Opt) (*Server, error) {
 	userHealthHandler = recoverer(userHealthHandler, log)
 	mux.Handle(pathSvcHealthV1, userHealthHandler)
 
+	// metrics handler (GET), e.g. for Prometheus to scrape
+	if cfg.metrics != nil {
+		var metricsHandler http.Handler
+		metricsHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+				return
+			}
+			if _, ok := s.authenticate(r); !ok {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			cfg.metrics.ServeHTTP(w, r)
+		})
+		metricsHandler = recoverer(metricsHandler, log)
+		mux.Handle(pathMetrics, metricsHandler)
+	}
+
 	return s, nil
 }
 
+// authorized checks the request's basic authentication, if the server requires
+// it. The comparison reveals nothing about the configured pass in verification
+// time.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.authSHA == nil {
+		return true
+	}
+	_, pass, haveAuth := r.BasicAuth() // r.Header.Get("Authorization")
+	if !haveAuth {
+		return false
+	}
+	authSHA := sha256.Sum256([]byte(pass))
+	return subtle.ConstantTimeCompare(s.authSHA, authSHA[:]) == 1
+}
+
+// authenticate identifies the request's caller with the server's
+// Authenticator, if it has one, otherwise it checks the request's basic
+// authentication. The caller is nil without an Authenticator.
+func (s *Server) authenticate(r *http.Request) (*Caller, bool) {
+	if s.auth == nil {
+		return nil, s.authorized(r)
+	}
+	caller, err := s.auth.Authenticate(r)
+	if err != nil {
+		s.log.Warn("request authentication failed", "remote", r.RemoteAddr, "error", err)
+		return nil, false
+	}
+	return caller, true
+}
+
 // handleSvcHealth handles the /health/{svc} endpoint. This sets the HTTP status
 // code in the response to 200 if the service indicates it is healthy, otherwise
 // 503 (service unavailable). This is required to support common health checks
@@ -367,12 +517,14 @@ func (s *Server) handleSvcHealth(w http.ResponseWriter, r *http.Request) {
 // in processRequest, which pertains only to the handling of the request and
 // thus reflects the server's computational burden, while this duration provides
 // insight into the latencies introduced by bandwidth and marshalling.
-func jsonRPCTimeoutHandler(h http.Handler, timeout time.Duration, logger log.Logger) http.Handler {
+//
+// The timeout function is called for each request, so that the timeout may be
+// changed while the server is running.
+func jsonRPCTimeoutHandler(h http.Handler, timeout func() time.Duration, logger log.Logger) http.Handler {
 	// We'll respond with a jsonrpc.Response type, but the request handler is
 	// downstream and we don't have the request ID.
 	resp := jsonrpc.NewErrorResponse(-1, jsonrpc.NewError(jsonrpc.ErrorTimeout, "RPC timeout", nil))
 	respMsg, _ := json.Marshal(resp)
-	h = http.TimeoutHandler(h, timeout, string(respMsg)) // https://github.com/golang/go/issues/27375
 
 	// Log total request handling time (including transfer).
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -382,10 +534,53 @@ func jsonRPCTimeoutHandler(h http.Handler, timeout time.Duration, logger log.Log
 		}()
 		// NOTE, to give downstream handlers access to t0 instead of a defer here:
 		// ctx := context.WithValue(r.Context(), CtxStartTime, t0); r = r.WithContext(ctx)
-		h.ServeHTTP(w, r)
+		th := http.TimeoutHandler(h, timeout(), string(respMsg)) // https://github.com/golang/go/issues/27375
+		th.ServeHTTP(w, r)
+	})
+}
+
+// maxBytesHandler is like http.MaxBytesHandler, but the limit function is
+// called for each request, so that the limit may be changed while the server
+// is running.
+func maxBytesHandler(h http.Handler, limit func() int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := *r
+		r2.Body = http.MaxBytesReader(w, r.Body, limit())
+		h.ServeHTTP(w, &r2)
 	})
 }
 
+// Timeout returns the time limit on requests.
+func (s *Server) Timeout() time.Duration {
+	return time.Duration(s.timeout.Load())
+}
+
+// SetTimeout changes the time limit on requests. The timeout may not exceed
+// the one the server was created with, since that also determines the
+// connection write deadline, which cannot be changed while running.
+func (s *Server) SetTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+	if timeout > s.maxTimeout {
+		return fmt.Errorf("timeout may not be increased above %v without a restart", s.maxTimeout)
+	}
+	s.timeout.Store(int64(timeout))
+	return nil
+}
+
+// SetReqSizeLimit changes the request size limit in bytes.
+func (s *Server) SetReqSizeLimit(sz int) {
+	s.reqSzLimit.Store(int64(sz))
+}
+
+// SetRateLimiter replaces the server's rate limiter. A nil limiter disables
+// rate limiting. The state of the previous limiter, i.e. the allowance used by
+// each client, is discarded.
+func (s *Server) SetRateLimiter(limiter *ratelimit.MethodLimiter) {
+	s.limiter.Store(limiter)
+}
+
 // corsHandler adds CORS headers to the response. We don't need sophisticated
 // cors handling here (not really kwild's concern, there should be other services
 // like LBs or KGW do that), so we just allow them.
@@ -541,6 +736,12 @@ func (s *Server) ServeOn(ctx context.Context, ln net.Listener) error {
 		return err
 	}
 
+	if s.rest {
+		if err = s.mountREST(); err != nil {
+			return err
+		}
+	}
+
 	s.RegisterMethodHandler(
 		"rpc.discover",
 		MakeMethodHandler(func(context.Context, *any) (*json.RawMessage, *jsonrpc.Error) {
@@ -559,6 +760,23 @@ func (s *Server) ServeOn(ctx context.Context, ln net.Listener) error {
 	)
 
 	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() { // the rate limiter may be set after starting
+		defer wg.Done()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if limiter := s.limiter.Load(); limiter != nil {
+					limiter.Prune()
+				}
+			}
+		}
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -604,18 +822,10 @@ func (s *Server) handlerJSONRPCV1(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	r.Close = true
 
-	if s.authSHA != nil {
-		_, pass, haveAuth := r.BasicAuth() // r.Header.Get("Authorization")
-		if !haveAuth {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
-		// Reveal nothing about the configured pass in verification time.
-		authSHA := sha256.Sum256([]byte(pass))
-		if subtle.ConstantTimeCompare(s.authSHA, authSHA[:]) != 1 {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
+	caller, ok := s.authenticate(r)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
 	}
 
 	/* stricter and inline decoding
@@ -648,7 +858,11 @@ func (s *Server) handlerJSONRPCV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.processJSONRPCRequest(r.Context(), w, req)
+	ctx := metrics.ExtractTraceContext(r.Context(), r.Header) // continue the client's trace, if any
+	if caller != nil {
+		ctx = context.WithValue(ctx, CallerCtx, caller)
+	}
+	s.processJSONRPCRequest(ctx, w, req)
 }
 
 // processRequest handles the jsonrpc.Request with handleRequest to call the
@@ -661,22 +875,36 @@ func (s *Server) processJSONRPCRequest(ctx context.Context, w http.ResponseWrite
 	// Some conventions dictate 200 for everything, with the Response.Error
 	// being the only sign of issue. However, a certain set of errors warrant an
 	// http status code.
-	statusCode := http.StatusOK
-	if resp.Error != nil {
-		switch resp.Error.Code {
-		case jsonrpc.ErrorUnknownMethod: // other "not found" is not a 404 since the method at least existed
-			statusCode = http.StatusNotFound // 404
-		case jsonrpc.ErrorInvalidParams, jsonrpc.ErrorInvalidRequest, jsonrpc.ErrorParse:
-			statusCode = http.StatusBadRequest // 400
-		case jsonrpc.ErrorInternal:
-			statusCode = http.StatusInternalServerError // 500
-		}
-	}
+	statusCode := errorStatusCode(w, resp.Error)
 
 	// Write the response
 	s.writeJSON(w, resp, statusCode)
 }
 
+// errorStatusCode returns the http status code for a response with the given
+// error, which may be nil. The Retry-After header is set for rate limit errors.
+func errorStatusCode(w http.ResponseWriter, rpcErr *jsonrpc.Error) int {
+	if rpcErr == nil {
+		return http.StatusOK
+	}
+	switch rpcErr.Code {
+	case jsonrpc.ErrorUnknownMethod: // other "not found" is not a 404 since the method at least existed
+		return http.StatusNotFound // 404
+	case jsonrpc.ErrorInvalidParams, jsonrpc.ErrorInvalidRequest, jsonrpc.ErrorParse:
+		return http.StatusBadRequest // 400
+	case jsonrpc.ErrorInternal:
+		return http.StatusInternalServerError // 500
+	case jsonrpc.ErrorUnauthorized:
+		return http.StatusForbidden // 403
+	case ratelimit.ErrorCode:
+		if retryAfter, _ := ratelimit.RetryAfter(rpcErr); retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+		}
+		return http.StatusTooManyRequests // 429
+	}
+	return http.StatusOK
+}
+
 // writeJSONWithStatus marshals the provided interface and writes the bytes to
 // the ResponseWriter with the specified response code.
 func (s *Server) writeJSON(w http.ResponseWriter, thing any, code int) {
@@ -709,6 +937,32 @@ func zeroID(id any) bool {
 	return false // already did rv.IsZero
 }
 
+// checkRateLimit charges the cost of the method to the client identified by
+// the request's IP address, if the server has a rate limiter. Requests without
+// a known client IP (e.g. UNIX socket connections) are not limited.
+func (s *Server) checkRateLimit(ctx context.Context, method string) *jsonrpc.Error {
+	limiter := s.limiter.Load()
+	if limiter == nil {
+		return nil
+	}
+	clientIP, _ := ctx.Value(RequestIPCtx).(string)
+	if clientIP == "" {
+		return nil
+	}
+	if ok, retryAfter := limiter.Allow(clientIP, method); !ok {
+		return ratelimit.NewError("rate limit exceeded", retryAfter)
+	}
+	return nil
+}
+
+// audit records the method call with the server's Auditor, if it has one.
+func (s *Server) audit(ctx context.Context, caller *Caller, req *jsonrpc.Request, rpcErr *jsonrpc.Error) {
+	if s.auditor == nil || s.auth == nil {
+		return
+	}
+	s.auditor.Audit(ctx, caller, req.Method, req.Params, rpcErr)
+}
+
 // handleJSONRPCRequest sends the request to the correct handler function if able.
 func (s *Server) handleJSONRPCRequest(ctx context.Context, req *jsonrpc.Request) *jsonrpc.Response {
 	if req.JSONRPC != "2.0" || zeroID(req.ID) {
@@ -720,18 +974,40 @@ func (s *Server) handleJSONRPCRequest(ctx context.Context, req *jsonrpc.Request)
 		return jsonrpc.NewErrorResponse(req.ID, rpcErr)
 	}
 
+	if rpcErr := s.checkRateLimit(ctx, req.Method); rpcErr != nil {
+		s.log.Debug("request rate limited", "method", req.Method)
+		return jsonrpc.NewErrorResponse(req.ID, rpcErr)
+	}
+
+	caller, _ := ctx.Value(CallerCtx).(*Caller)
+	if s.auth != nil {
+		if !s.auth.Authorize(caller, req.Method) {
+			rpcErr := jsonrpc.NewError(jsonrpc.ErrorUnauthorized, "caller is not authorized for this method", nil)
+			s.audit(ctx, caller, req, rpcErr)
+			return jsonrpc.NewErrorResponse(req.ID, rpcErr)
+		}
+	}
+
 	s.log.Debug("handling request", "method", req.Method)
 	t0 := time.Now().UTC() // time only the handling (pertains to server utilization)
 
+	ctx, span := metrics.StartSpan(ctx, metrics.RPCTracer, "jsonrpc "+req.Method,
+		attribute.String("rpc.system", "jsonrpc"), attribute.String("rpc.method", req.Method))
+	defer span.End()
+
 	// call the method with the params
 	result, rpcErr := s.handleMethod(ctx, jsonrpc.Method(req.Method), req.Params)
+	s.audit(ctx, caller, req, rpcErr)
 	if rpcErr != nil {
+		span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", int(rpcErr.Code)))
+		span.SetStatus(codes.Error, rpcErr.Message)
+
 		level := log.LevelInfo
 		switch rpcErr.Code {
 		case jsonrpc.ErrorInvalidParams, jsonrpc.ErrorInvalidRequest,
 			jsonrpc.ErrorParse, jsonrpc.ErrorUnknownMethod,
 			jsonrpc.ErrorTxNotFound, jsonrpc.ErrorBlkNotFound,
-			jsonrpc.ErrorEngineDatasetNotFound:
+			jsonrpc.ErrorEngineDatasetNotFound, ratelimit.ErrorCode:
 			level = log.LevelDebug
 		case jsonrpc.ErrorInternal, jsonrpc.ErrorTimeout, jsonrpc.ErrorResultEncoding:
 			level = log.LevelWarn
@@ -3,6 +3,8 @@ package rpcserver
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -17,6 +19,7 @@ import (
 
 	"github.com/kwilteam/kwil-db/core/log"
 	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/ratelimit"
 )
 
 func ptrTo[T any](x T) *T {
@@ -60,7 +63,7 @@ func Test_timeout(t *testing.T) {
 	})
 
 	// Wrap that handler with a 500ms timeout.
-	h = jsonRPCTimeoutHandler(h, 500*time.Millisecond, log.New(log.WithWriter(os.Stdout), log.WithLevel(log.LevelDebug)))
+	h = jsonRPCTimeoutHandler(h, func() time.Duration { return 500 * time.Millisecond }, log.New(log.WithWriter(os.Stdout), log.WithLevel(log.LevelDebug)))
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodGet, "/", nil)
 	h.ServeHTTP(w, r)
@@ -219,3 +222,117 @@ func Test_options(t *testing.T) {
 		})
 	}
 }
+
+func Test_rateLimit(t *testing.T) {
+	logger := log.DiscardLogger
+	limiter := ratelimit.NewMethodLimiter(&ratelimit.MethodLimiterConfig{
+		RPS:   1,
+		Burst: 4,
+		Costs: map[string]int{"rpc.expensive": 3},
+	})
+	srv, err := NewServer("127.0.0.1:", logger, WithRateLimiter(limiter), WithTrustedProxyCount(1))
+	require.NoError(t, err)
+
+	srv.RegisterMethodHandler(
+		"rpc.expensive",
+		MakeMethodHandler(func(context.Context, *any) (*json.RawMessage, *jsonrpc.Error) {
+			respjson := []byte(`"hi"`)
+			return (*json.RawMessage)(&respjson), nil
+		}),
+	)
+
+	call := func(clientIP string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, pathRPCV1,
+			strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc.expensive"}`))
+		r.Header.Set("X-Forwarded-For", clientIP)
+		w := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := call("10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The second expensive call exceeds the burst of 4.
+	w = call("10.0.0.1")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Result().Header.Get("Retry-After"))
+
+	var resp jsonrpc.Response
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.NotNil(t, resp.Error)
+	retryAfter, isRateLimit := ratelimit.RetryAfter(resp.Error)
+	assert.True(t, isRateLimit)
+	assert.Equal(t, int64(2), retryAfter)
+
+	// A different client forwarded by the trusted proxy has its own allowance.
+	w = call("10.0.0.2")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+type testAuth struct{}
+
+func (testAuth) Authenticate(r *http.Request) (*Caller, error) {
+	switch r.Header.Get("Authorization") {
+	case "Bearer reader":
+		return &Caller{Name: "reader", Scopes: []string{"read"}}, nil
+	case "Bearer writer":
+		return &Caller{Name: "writer", Scopes: []string{"read", "write"}}, nil
+	}
+	return nil, errors.New("unknown token")
+}
+
+func (testAuth) Authorize(caller *Caller, method string) bool {
+	return method != "rpc.write" || slices.Contains(caller.Scopes, "write")
+}
+
+type testAuditor struct {
+	calls []string
+}
+
+func (ta *testAuditor) Audit(_ context.Context, caller *Caller, method string, _ json.RawMessage, rpcErr *jsonrpc.Error) {
+	ta.calls = append(ta.calls, fmt.Sprintf("%s %s %v", caller.Name, method, rpcErr != nil))
+}
+
+func Test_authenticator(t *testing.T) {
+	auditor := &testAuditor{}
+	srv, err := NewServer("127.0.0.1:", log.DiscardLogger,
+		WithAuthenticator(testAuth{}), WithAuditor(auditor))
+	require.NoError(t, err)
+
+	srv.RegisterMethodHandler(
+		"rpc.write",
+		MakeMethodHandler(func(ctx context.Context, _ *any) (*string, *jsonrpc.Error) {
+			caller := ctx.Value(CallerCtx).(*Caller)
+			return &caller.Name, nil
+		}),
+	)
+
+	call := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, pathRPCV1,
+			strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc.write"}`))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call("").Code)
+	assert.Equal(t, http.StatusUnauthorized, call("nobody").Code)
+
+	w := call("reader")
+	require.Equal(t, http.StatusForbidden, w.Code)
+	var resp jsonrpc.Response
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, jsonrpc.ErrorUnauthorized, resp.Error.Code)
+
+	w = call("writer")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"result":"writer"`)
+
+	// Only the calls by authenticated callers are audited.
+	assert.Equal(t, []string{"reader rpc.write true", "writer rpc.write false"}, auditor.calls)
+}
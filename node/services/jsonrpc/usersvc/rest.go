@@ -0,0 +1,140 @@
+package usersvc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	userjson "github.com/kwilteam/kwil-db/core/rpc/json/user"
+	"github.com/kwilteam/kwil-db/core/types"
+	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
+	"github.com/kwilteam/kwil-db/node/services/jsonrpc/openapi"
+)
+
+// The user Service provides REST routes when the server's REST facade is
+// enabled.
+var _ rpcserver.RESTSvc = (*Service)(nil)
+
+// RESTRoutes returns the REST routes for the user service.
+func (svc *Service) RESTRoutes() []*rpcserver.RESTRoute {
+	route := func(httpMethod, path string, method string, summary string) *rpcserver.RESTRoute {
+		return &rpcserver.RESTRoute{Route: openapi.Route{
+			HTTPMethod: httpMethod,
+			Path:       path,
+			Method:     method,
+			Summary:    summary,
+		}}
+	}
+
+	txRoute := route(http.MethodGet, "/v1/tx/{hash}", string(userjson.MethodTxQuery), "query for the status of a transaction")
+	txRoute.Fields = map[string]string{"hash": "tx_hash"}
+
+	return []*rpcserver.RESTRoute{
+		route(http.MethodGet, "/v1/version", string(userjson.MethodUserVersion), "retrieve the API version of the user service"),
+		route(http.MethodGet, "/v1/ping", string(userjson.MethodPing), "ping the server"),
+		route(http.MethodGet, "/v1/chain_info", string(userjson.MethodChainInfo), "get current blockchain info"),
+		route(http.MethodGet, "/v1/num_accounts", string(userjson.MethodNumAccounts), "get the current number of accounts"),
+		txRoute,
+		route(http.MethodPost, "/v1/tx", string(userjson.MethodBroadcast), "broadcast a transaction"),
+		route(http.MethodPost, "/v1/query", string(userjson.MethodQuery), "perform an ad-hoc SQL query"),
+		{
+			Route: openapi.Route{
+				HTTPMethod: http.MethodPost,
+				Path:       "/v1/namespaces/{ns}/actions/{action}:call",
+				Method:     string(userjson.MethodCall),
+				Summary:    "call a view action with positional arguments",
+				Body:       restCallBodySchema,
+			},
+			Params: restCallParams,
+		},
+	}
+}
+
+// RESTCallRequest is the request body of the REST action call route. The
+// arguments are plain JSON values. Integers are passed as int8, non-integer
+// numbers as numeric, and homogeneous arrays as arrays of those types.
+type RESTCallRequest struct {
+	Args []any `json:"args"`
+}
+
+var restCallBodySchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"args": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{},
+			"description": "positional action arguments as plain JSON values",
+		},
+	},
+}
+
+// restCallParams creates the user.call parameters for an unauthenticated call
+// of the action in the path with the arguments in the body.
+func restCallParams(pathVals map[string]string, body []byte) (json.RawMessage, error) {
+	var req RESTCallRequest
+	if len(bytes.TrimSpace(body)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+	}
+
+	args := make([]*types.EncodedValue, len(req.Args))
+	for i, arg := range req.Args {
+		val, err := restArg(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+		args[i], err = types.EncodeValue(val)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+	}
+
+	payload, err := (&types.ActionCall{
+		Namespace: pathVals["ns"],
+		Action:    pathVals["action"],
+		Arguments: args,
+	}).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&userjson.CallRequest{
+		Body: &types.CallMessageBody{
+			Payload: payload,
+		},
+	})
+}
+
+// restArg converts a decoded JSON value into a value that can be encoded with
+// types.EncodeValue.
+func restArg(arg any) (any, error) {
+	switch v := arg.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return types.ParseDecimal(v.String())
+	case []any:
+		arr := make([]any, len(v))
+		for i, elem := range v {
+			if _, isArr := elem.([]any); isArr {
+				return nil, errors.New("nested arrays are not supported")
+			}
+			var err error
+			if arr[i], err = restArg(elem); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("unsupported argument type %T", arg)
+	}
+}
@@ -5,12 +5,14 @@ import (
 
 	"context"
 	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kwilteam/kwil-db/common"
@@ -51,6 +53,7 @@ type NodeApp interface {
 	NumAccounts(ctx context.Context, db sql.Executor) (count, height int64, err error)
 	Price(ctx context.Context, dbTx sql.DB, tx *types.Transaction) (*big.Int, error)
 	GetMigrationMetadata(ctx context.Context) (*types.MigrationMetadata, error)
+	SimulateTx(ctx context.Context, db sql.DB, tx *types.Transaction) (*types.TxResult, error)
 }
 
 type Validators interface {
@@ -75,7 +78,8 @@ type Service struct {
 	challengeExpiry time.Duration
 
 	engine      EngineReader
-	db          DB // this should only ever make a read-only tx
+	db          DB      // this should only ever make a read-only tx
+	queryDB     QueryDB // for user queries and calls, possibly a read replica
 	nodeApp     NodeApp
 	chainClient BlockchainTransactor
 	validators  Validators
@@ -85,11 +89,25 @@ type Service struct {
 	challengeMtx     sync.Mutex
 	challenges       map[[32]byte]time.Time
 	challengeLimiter *ratelimit.IPRateLimiter
+
+	// identityLimiter, if set, limits authenticated requests by identity.
+	identityLimiter atomic.Pointer[ratelimit.MethodLimiter]
 }
 
 type DB interface {
 	sql.ReadTxMaker
 	sql.DelayedReadTxMaker
+	// BeginScratchTx starts a read-write transaction that can only be rolled
+	// back, for simulating transactions. Its statements must have short lock
+	// and statement timeouts so that it cannot block the writer for long.
+	BeginScratchTx(ctx context.Context) (sql.Tx, error)
+}
+
+// QueryDB makes the read-only transactions for user queries and calls, which
+// may be on a read replica instead of the node's primary DB.
+type QueryDB interface {
+	sql.ReadTxMaker
+	sql.DelayedReadTxMaker
 }
 
 type serviceCfg struct {
@@ -98,6 +116,8 @@ type serviceCfg struct {
 	challengeExpiry    time.Duration
 	challengeRateLimit float64 // challenge requests/sec, sustained
 	blockAgeThresh     time.Duration
+	identityLimits     *ratelimit.MethodLimiterConfig
+	queryDB            QueryDB
 }
 
 // Opt is a Service option.
@@ -129,6 +149,25 @@ func WithChallengeRateLimit(limit float64) Opt {
 	}
 }
 
+// WithIdentityRateLimit enables per-identity quotas on authenticated requests,
+// which are only possible in private mode where callers sign a server-issued
+// challenge. Each authenticated user.call and user.authenticated_query is
+// charged according to the method cost in the provided config.
+func WithIdentityRateLimit(limits *ratelimit.MethodLimiterConfig) Opt {
+	return func(cfg *serviceCfg) {
+		cfg.identityLimits = limits
+	}
+}
+
+// WithQueryDB sets the DB used for the read transactions of the query,
+// authenticated_query, and call methods, such as a read replica. By default,
+// these use the same DB as the other methods.
+func WithQueryDB(db QueryDB) Opt {
+	return func(cfg *serviceCfg) {
+		cfg.queryDB = db
+	}
+}
+
 func WithBlockAgeHealth(ageThresh time.Duration) Opt {
 	return func(cfg *serviceCfg) {
 		cfg.blockAgeThresh = ageThresh
@@ -164,6 +203,7 @@ func NewService(db DB, engine EngineReader, chainClient BlockchainTransactor,
 		chainClient:      chainClient,
 		validators:       vals,
 		db:               db,
+		queryDB:          db,
 		migrator:         migrator,
 		privateMode:      cfg.privateMode,
 		challengeExpiry:  cfg.challengeExpiry,
@@ -171,14 +211,26 @@ func NewService(db DB, engine EngineReader, chainClient BlockchainTransactor,
 		challengeLimiter: ratelimit.NewIPRateLimiter(cfg.challengeRateLimit, int(6*defaultChallengeRateLimit)), // allow many calls at start of block
 	}
 
+	if cfg.queryDB != nil {
+		svc.queryDB = cfg.queryDB
+	}
+
+	svc.SetIdentityRateLimit(cfg.identityLimits)
+
 	// Start the expiry goroutine, unsupervised for now since services don't
 	// "start" or "stop", but their lifetime is roughly that of the process.
 	if cfg.privateMode {
 		go func() {
 			ticker := time.NewTicker(5 * time.Second)
 			defer ticker.Stop()
+			var n int
 			for range ticker.C {
 				svc.expireChallenges()
+				if n++; n%12 == 0 {
+					if limiter := svc.identityLimiter.Load(); limiter != nil {
+						limiter.Prune()
+					}
+				}
 			}
 		}()
 	}
@@ -186,6 +238,17 @@ func NewService(db DB, engine EngineReader, chainClient BlockchainTransactor,
 	return svc
 }
 
+// SetIdentityRateLimit replaces the per-identity quotas on authenticated
+// requests. A nil config disables them, as they are when not in private mode.
+// The quota used by each identity under the previous limits is discarded.
+func (svc *Service) SetIdentityRateLimit(cfg *ratelimit.MethodLimiterConfig) {
+	if !svc.privateMode || cfg == nil {
+		svc.identityLimiter.Store(nil)
+		return
+	}
+	svc.identityLimiter.Store(ratelimit.NewMethodLimiter(cfg))
+}
+
 // The "user" service is versioned by these values. However, despite this API
 // level versioning, methods can be versioned. For example "user.account.v2".
 // The APIs minor version can indicate which new methods (or method versions)
@@ -193,7 +256,7 @@ func NewService(db DB, engine EngineReader, chainClient BlockchainTransactor,
 // or any other breaking changes.
 const (
 	apiVerMajor = 0
-	apiVerMinor = 2
+	apiVerMinor = 3
 	apiVerPatch = 0
 
 	serviceName = "user"
@@ -203,6 +266,8 @@ const (
 //
 // apiVerMinor = 2 indicates the presence of the migration, challenge, and
 // health methods added in Kwil v0.9
+//
+// apiVerMinor = 3 indicates the presence of the simulate method
 
 var (
 	apiVerSemver = fmt.Sprintf("%d.%d.%d", apiVerMajor, apiVerMinor, apiVerPatch)
@@ -326,6 +391,11 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 			"estimate the price of a transaction",
 			"balance and nonce of an accounts",
 		),
+		userjson.MethodSimulate: rpcserver.MakeMethodDef(
+			svc.Simulate,
+			"simulate the execution of a transaction without broadcasting it",
+			"the result the transaction would have and its estimated price",
+		),
 		userjson.MethodQuery: rpcserver.MakeMethodDef(
 			svc.Query,
 			"perform an ad-hoc SQL query",
@@ -516,6 +586,52 @@ func (svc *Service) EstimatePrice(ctx context.Context, req *userjson.EstimatePri
 	}, nil
 }
 
+// Simulate executes a transaction as if it were included in the next block, in
+// a database transaction that is always rolled back.
+func (svc *Service) Simulate(ctx context.Context, req *userjson.SimulateRequest) (*userjson.SimulateResponse, *jsonrpc.Error) {
+	if req.Tx == nil || req.Tx.Body == nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "missing transaction", nil)
+	}
+
+	// An unsigned transaction may claim any sender. A signature, which
+	// SimulateTx verifies, is required in private mode, where calls must be
+	// authenticated, and for raw statements, which may include DDL.
+	if req.Tx.Signature == nil || len(req.Tx.Signature.Data) == 0 {
+		if svc.privateMode {
+			return nil, jsonrpc.NewError(jsonrpc.ErrorUnauthorized,
+				"a signed transaction is required to simulate when authenticated calls are enforced (private mode)", nil)
+		}
+		if req.Tx.Body.PayloadType == types.PayloadTypeRawStatement {
+			return nil, jsonrpc.NewError(jsonrpc.ErrorUnauthorized, "a signed transaction is required to simulate a raw statement", nil)
+		}
+	}
+
+	ctxExec, cancel := context.WithTimeout(ctx, svc.readTxTimeout)
+	defer cancel()
+
+	tx, err := svc.db.BeginScratchTx(ctxExec)
+	if err != nil {
+		svc.log.Error("failed to start scratch tx", "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "failed to start transaction", nil)
+	}
+	defer tx.Rollback(ctx)
+
+	price, err := svc.nodeApp.Price(ctxExec, tx, req.Tx)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorTxInternal, "failed to estimate price: "+err.Error(), nil)
+	}
+
+	res, err := svc.nodeApp.SimulateTx(ctxExec, tx, req.Tx)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "failed to simulate transaction: "+err.Error(), nil)
+	}
+
+	return &userjson.SimulateResponse{
+		Result: res,
+		Price:  price.String(),
+	}, nil
+}
+
 func (svc *Service) Query(ctx context.Context, req *userjson.QueryRequest) (*userjson.QueryResponse, *jsonrpc.Error) {
 	ctxExec, cancel := context.WithTimeout(ctx, svc.readTxTimeout)
 	defer cancel()
@@ -525,7 +641,7 @@ func (svc *Service) Query(ctx context.Context, req *userjson.QueryRequest) (*use
 			"query is prohibited when authenticated calls are enforced (private mode)", nil)
 	}
 
-	readTx := svc.db.BeginDelayedReadTx()
+	readTx := svc.queryDB.BeginDelayedReadTx()
 	defer readTx.Rollback(ctx)
 
 	params := make(map[string]any)
@@ -569,6 +685,9 @@ func (svc *Service) AuthenticatedQuery(ctx context.Context, req *userjson.Authen
 	if jsonRPCErr := svc.authenticate(req.SignatureData, req.Challenge, req.Sender, req.AuthType, sigText); jsonRPCErr != nil {
 		return nil, jsonRPCErr
 	}
+	if jsonRPCErr := svc.limitIdentity(string(userjson.MethodAuthenticatedQuery), req.Sender, req.AuthType); jsonRPCErr != nil {
+		return nil, jsonRPCErr
+	}
 
 	params := make(map[string]any)
 	for _, v := range req.Body.Parameters {
@@ -584,7 +703,7 @@ func (svc *Service) AuthenticatedQuery(ctx context.Context, req *userjson.Authen
 		return nil, jsonrpc.NewError(jsonrpc.ErrorInternal, "failed to create tx context: "+jsonRPCErr.Error(), nil)
 	}
 
-	readTx := svc.db.BeginDelayedReadTx()
+	readTx := svc.queryDB.BeginDelayedReadTx()
 	defer readTx.Rollback(ctx)
 
 	r := &rowReader{}
@@ -723,6 +842,9 @@ func (svc *Service) Call(ctx context.Context, req *userjson.CallRequest) (*userj
 		msg.Body.Payload, msg.Body.Challenge)); jsonRPCErr != nil {
 		return nil, jsonRPCErr
 	}
+	if jsonRPCErr := svc.limitIdentity(string(userjson.MethodCall), msg.Sender, msg.AuthType); jsonRPCErr != nil {
+		return nil, jsonRPCErr
+	}
 
 	args := make([]any, len(body.Arguments))
 	for i, arg := range body.Arguments {
@@ -743,7 +865,7 @@ func (svc *Service) Call(ctx context.Context, req *userjson.CallRequest) (*userj
 
 	// we use a basic read tx since we are subscribing to notices,
 	// and it is therefore pointless to use a delayed tx
-	readTx, err := svc.db.BeginReadTx(ctx)
+	readTx, err := svc.queryDB.BeginReadTx(ctx)
 	if err != nil {
 		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "failed to start read tx", nil)
 	}
@@ -875,6 +997,21 @@ func (svc *Service) authenticate(signature, challenge, sender []byte, authtype,
 	return nil
 }
 
+// limitIdentity charges the cost of an authenticated request to the caller's
+// identity quota. This must only be used after authenticate has verified the
+// caller's signature, which is only done in private mode.
+func (svc *Service) limitIdentity(method string, sender []byte, authType string) *jsonrpc.Error {
+	limiter := svc.identityLimiter.Load()
+	if limiter == nil {
+		return nil
+	}
+	ident := authType + ":" + hex.EncodeToString(sender)
+	if ok, retryAfter := limiter.Allow(ident, method); !ok {
+		return ratelimit.NewError("identity rate limit exceeded", retryAfter)
+	}
+	return nil
+}
+
 func (svc *Service) TxQuery(ctx context.Context, req *userjson.TxQueryRequest) (*userjson.TxQueryResponse, *jsonrpc.Error) {
 	// logger := svc.log.With(log.String("rpc", "TxQuery"),
 	// 	log.String("TxHash", hex.EncodeToString(req.TxHash)))
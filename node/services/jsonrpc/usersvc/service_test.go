@@ -0,0 +1,117 @@
+package usersvc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/log"
+	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
+	userjson "github.com/kwilteam/kwil-db/core/rpc/json/user"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+type scratchTx struct {
+	sql.Tx
+	rolledBack bool
+}
+
+func (tx *scratchTx) Rollback(ctx context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+type scratchDB struct {
+	DB
+	tx *scratchTx
+}
+
+func (db *scratchDB) BeginScratchTx(ctx context.Context) (sql.Tx, error) {
+	db.tx = &scratchTx{}
+	return db.tx, nil
+}
+
+type simulatingApp struct {
+	NodeApp
+	simulated int
+}
+
+func (app *simulatingApp) Price(ctx context.Context, db sql.DB, tx *types.Transaction) (*big.Int, error) {
+	return big.NewInt(10), nil
+}
+
+func (app *simulatingApp) SimulateTx(ctx context.Context, db sql.DB, tx *types.Transaction) (*types.TxResult, error) {
+	app.simulated++
+	return &types.TxResult{Code: uint32(types.CodeOk), Gas: 1}, nil
+}
+
+func TestSimulate(t *testing.T) {
+	newTx := func(payloadType types.PayloadType, signed bool) *types.Transaction {
+		tx := &types.Transaction{
+			Body:      &types.TransactionBody{PayloadType: payloadType},
+			Signature: &auth.Signature{Type: auth.Secp256k1Auth},
+		}
+		if signed {
+			tx.Signature.Data = []byte{1}
+		}
+		return tx
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Opt
+		tx       *types.Transaction
+		wantCode jsonrpc.ErrorCode
+	}{
+		{
+			name: "unsigned action",
+			tx:   newTx(types.PayloadTypeExecute, false),
+		},
+		{
+			name:     "unsigned raw statement",
+			tx:       newTx(types.PayloadTypeRawStatement, false),
+			wantCode: jsonrpc.ErrorUnauthorized,
+		},
+		{
+			name: "signed raw statement",
+			tx:   newTx(types.PayloadTypeRawStatement, true),
+		},
+		{
+			name:     "unsigned action in private mode",
+			opts:     []Opt{WithPrivateMode(true)},
+			tx:       newTx(types.PayloadTypeExecute, false),
+			wantCode: jsonrpc.ErrorUnauthorized,
+		},
+		{
+			name: "signed action in private mode",
+			opts: []Opt{WithPrivateMode(true)},
+			tx:   newTx(types.PayloadTypeExecute, true),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, app := &scratchDB{}, &simulatingApp{}
+			svc := NewService(db, nil, nil, app, nil, nil, log.DiscardLogger, tt.opts...)
+
+			resp, jsonErr := svc.Simulate(context.Background(), &userjson.SimulateRequest{Tx: tt.tx})
+			if tt.wantCode != 0 {
+				require.NotNil(t, jsonErr)
+				require.Equal(t, tt.wantCode, jsonErr.Code)
+				require.Zero(t, app.simulated)
+				require.Nil(t, db.tx)
+				return
+			}
+
+			require.Nil(t, jsonErr)
+			require.Equal(t, uint32(types.CodeOk), resp.Result.Code)
+			require.Equal(t, "10", resp.Price)
+			require.Equal(t, 1, app.simulated)
+			require.True(t, db.tx.rolledBack)
+		})
+	}
+}
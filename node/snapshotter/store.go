@@ -69,7 +69,7 @@ type SnapshotStore struct {
 	// Snapshot Store
 	snapshots       map[uint64]*Snapshot // Map of snapshot height to snapshot header
 	snapshotHeights []uint64             // List of snapshot heights
-	snapshotsMtx    sync.RWMutex         // Protects access to snapshots and snapshotHeights
+	snapshotsMtx    sync.RWMutex         // Protects access to snapshots and snapshotHeights, and the limits in cfg
 
 	// Snapshotter
 	snapshotter DBSnapshotter
@@ -109,6 +109,9 @@ func (s *SnapshotStore) Enabled() bool {
 
 // IsSnapshotDue checks if a snapshot is due at the given height.
 func (s *SnapshotStore) IsSnapshotDue(height uint64) bool {
+	s.snapshotsMtx.RLock()
+	defer s.snapshotsMtx.RUnlock()
+
 	if s.cfg.RecurringHeight == 0 || !s.cfg.Enable {
 		return false
 	}
@@ -116,6 +119,24 @@ func (s *SnapshotStore) IsSnapshotDue(height uint64) bool {
 	return (height % s.cfg.RecurringHeight) == 0
 }
 
+// SetLimits changes the snapshot creation period in blocks and the number of
+// snapshots to keep. If there are more snapshots than the new maximum, the
+// oldest are deleted.
+func (s *SnapshotStore) SetLimits(recurringHeight uint64, maxSnapshots int) error {
+	s.snapshotsMtx.Lock()
+	defer s.snapshotsMtx.Unlock()
+
+	s.cfg.RecurringHeight = recurringHeight
+	s.cfg.MaxSnapshots = maxSnapshots
+
+	for len(s.snapshotHeights) > s.cfg.MaxSnapshots {
+		if err := s.deleteOldestSnapshot(); err != nil {
+			return fmt.Errorf("failed to delete oldest snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
 // List snapshots lists all the registered snapshots in the snapshot store.
 func (s *SnapshotStore) ListSnapshots() []*Snapshot {
 	s.snapshotsMtx.RLock()
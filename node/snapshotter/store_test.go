@@ -201,6 +201,38 @@ func TestRegisterSnapshot(t *testing.T) {
 	}
 }
 
+func TestSetLimits(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg := &SnapshotConfig{
+		Enable:          true,
+		RecurringHeight: 1,
+		SnapshotDir:     dir,
+		MaxSnapshots:    3,
+	}
+	store, err := NewMockSnapshotStore(dir, cfg, log.DiscardLogger)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for height := uint64(1); height <= 3; height++ {
+		err = store.CreateSnapshot(ctx, height, "snapshot", nil, nil, nil)
+		require.NoError(t, err)
+	}
+	require.Len(t, store.ListSnapshots(), 3)
+	require.True(t, store.IsSnapshotDue(5))
+
+	// reducing the max deletes the oldest snapshots
+	err = store.SetLimits(10, 1)
+	require.NoError(t, err)
+
+	snaps := store.ListSnapshots()
+	require.Len(t, snaps, 1)
+	require.Equal(t, uint64(3), snaps[0].Height)
+
+	require.False(t, store.IsSnapshotDue(5))
+	require.True(t, store.IsSnapshotDue(20))
+}
+
 func TestLoadSnapshotChunk(t *testing.T) {
 	dir := t.TempDir()
 	snapshotter := NewMockSnapshotter(dir)
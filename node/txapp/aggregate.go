@@ -0,0 +1,118 @@
+package txapp
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/resolutions"
+)
+
+// aggregatedOutcome is the set of resolutions that vote on the same key of an
+// aggregated resolution type, each with a different value.
+type aggregatedOutcome struct {
+	key []byte
+	// resolutions are all of the resolutions for the key.
+	resolutions []*resolutions.Resolution
+	// votes are the votes of the validators that voted for a single value.
+	votes []*resolutions.WeightedVote
+	// voters are the validators that voted for a single value, and power is
+	// their total power.
+	voters []*types.Validator
+	power  int64
+}
+
+// groupOutcomes groups the resolutions of an aggregated resolution type by
+// key, and returns the groups whose voters hold at least the required power,
+// ordered by key. A validator that voted for more than one value of a key is
+// not counted for that key. Resolutions whose bodies are not Outcomes are
+// ignored, and left to expire.
+func groupOutcomes(pending []*resolutions.Resolution, required int64) []*aggregatedOutcome {
+	groups := make(map[string]*aggregatedOutcome)
+	for _, res := range pending {
+		var outcome resolutions.Outcome
+		if err := outcome.UnmarshalBinary(res.Body); err != nil {
+			continue
+		}
+
+		g, ok := groups[string(outcome.Key)]
+		if !ok {
+			g = &aggregatedOutcome{key: outcome.Key}
+			groups[string(outcome.Key)] = g
+		}
+		g.resolutions = append(g.resolutions, res)
+	}
+
+	var confirmed []*aggregatedOutcome
+	for _, g := range groups {
+		// resolutions are ordered by ID, so that the proposer and the order
+		// of the votes do not depend on the order of the query results
+		slices.SortFunc(g.resolutions, func(a, b *resolutions.Resolution) int {
+			return bytes.Compare(a.ID[:], b.ID[:])
+		})
+
+		votesByVoter := make(map[string]int)
+		for _, res := range g.resolutions {
+			for _, voter := range res.Voters {
+				votesByVoter[voterKey(voter)]++
+			}
+		}
+
+		for _, res := range g.resolutions {
+			var outcome resolutions.Outcome
+			_ = outcome.UnmarshalBinary(res.Body) // already decoded above
+
+			for _, voter := range res.Voters {
+				if votesByVoter[voterKey(voter)] != 1 {
+					continue // equivocating voter
+				}
+				g.votes = append(g.votes, &resolutions.WeightedVote{
+					Value: outcome.Value,
+					Power: voter.Power,
+				})
+				g.voters = append(g.voters, voter)
+				g.power += voter.Power
+			}
+		}
+
+		if g.power >= required && len(g.votes) > 0 {
+			confirmed = append(confirmed, g)
+		}
+	}
+
+	slices.SortFunc(confirmed, func(a, b *aggregatedOutcome) int {
+		return bytes.Compare(a.key, b.key)
+	})
+	return confirmed
+}
+
+// resolution returns the resolution that is passed to the resolve function of
+// the resolution type, with the aggregated value.
+func (g *aggregatedOutcome) resolution(resType string, value []byte) (*resolutions.Resolution, error) {
+	body, err := (&resolutions.Outcome{Key: g.key, Value: value}).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	expiration := g.resolutions[0].Expiration
+	for _, res := range g.resolutions[1:] {
+		if res.Expiration.Before(expiration) {
+			expiration = res.Expiration
+		}
+	}
+
+	return &resolutions.Resolution{
+		ID:            resolutions.OutcomeID(resType, g.key),
+		Body:          body,
+		Type:          resType,
+		Expiration:    expiration,
+		ApprovedPower: g.power,
+		Voters:        g.voters,
+		Proposer:      g.resolutions[0].Proposer,
+	}, nil
+}
+
+func voterKey(v *types.Validator) string {
+	return fmt.Sprintf("%x#%s", v.Identifier, v.KeyType)
+}
@@ -0,0 +1,74 @@
+package txapp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/resolutions"
+)
+
+func TestGroupOutcomes(t *testing.T) {
+	val := func(id byte, power int64) *types.Validator {
+		return &types.Validator{
+			AccountID: types.AccountID{Identifier: []byte{id}, KeyType: crypto.KeyTypeSecp256k1},
+			Power:     power,
+		}
+	}
+	a, b, c, d := val(1, 1), val(2, 1), val(3, 1), val(4, 1)
+
+	res := func(key, value string, voters ...*types.Validator) *resolutions.Resolution {
+		body, err := (&resolutions.Outcome{Key: []byte(key), Value: []byte(value)}).MarshalBinary()
+		require.NoError(t, err)
+		ev := &types.VotableEvent{Type: "price", Body: body}
+		return &resolutions.Resolution{
+			ID:         ev.ID(),
+			Body:       body,
+			Type:       "price",
+			Expiration: time.Unix(int64(len(voters)), 0),
+			Voters:     voters,
+			Proposer:   voters[0],
+		}
+	}
+
+	pending := []*resolutions.Resolution{
+		// key "x": a and b vote 10, c votes 11, d equivocates
+		res("x", "10", a, b, d),
+		res("x", "11", c, d),
+		// key "y": only a has voted
+		res("y", "5", a),
+		// not an outcome, ignored
+		{ID: types.NewUUIDV5([]byte("junk")), Body: []byte("junk"), Voters: []*types.Validator{a, b, c, d}},
+	}
+
+	groups := groupOutcomes(pending, 3)
+	require.Len(t, groups, 1)
+	g := groups[0]
+	require.Equal(t, []byte("x"), g.key)
+	require.Len(t, g.resolutions, 2)
+	require.Equal(t, int64(3), g.power)
+	require.Len(t, g.votes, 3)
+	require.NotContains(t, g.voters, d)
+
+	value, err := resolutions.Mode(g.votes)
+	require.NoError(t, err)
+	require.Equal(t, "10", string(value))
+
+	resolution, err := g.resolution("price", value)
+	require.NoError(t, err)
+	require.Equal(t, resolutions.OutcomeID("price", []byte("x")), resolution.ID)
+	require.Equal(t, time.Unix(2, 0), resolution.Expiration)
+
+	var outcome resolutions.Outcome
+	require.NoError(t, outcome.UnmarshalBinary(resolution.Body))
+	require.Equal(t, "10", string(outcome.Value))
+
+	// with less power required, both keys are confirmed, ordered by key
+	groups = groupOutcomes(pending, 1)
+	require.Len(t, groups, 2)
+	require.Equal(t, []byte("x"), groups[0].key)
+	require.Equal(t, []byte("y"), groups[1].key)
+}
@@ -64,6 +64,8 @@ var (
 	createResolution                 = voting.CreateResolution
 	approveResolution                = voting.ApproveResolution
 	resolutionExists                 = voting.ResolutionExists
+	isProcessed                      = voting.IsProcessed
+	getResolutionsByType             = voting.GetResolutionsByType
 	resolutionByID                   = voting.GetResolutionInfo
 	// deleteResolution                 = voting.DeleteResolution
 )
@@ -148,8 +148,34 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 	}
 
 	if tx.Body.PayloadType == types.PayloadTypeValidatorVoteBodies {
-		// not sure if this is the right error code
-		return errors.New("validator vote bodies can not enter the mempool, and can only be submitted during block proposal")
+		voteBodies := &types.ValidatorVoteBodies{}
+		if err := voteBodies.UnmarshalBinary(tx.Body.Payload); err != nil {
+			return err
+		}
+
+		// Vote bodies for aggregated resolutions are submitted by each
+		// validator, since each validator may vote for a different value.
+		if !allAggregated(voteBodies.Events) {
+			// not sure if this is the right error code
+			return errors.New("validator vote bodies can not enter the mempool, and can only be submitted during block proposal")
+		}
+
+		keyType, err := authExt.GetAuthenticatorKeyType(tx.Signature.Type)
+		if err != nil {
+			return fmt.Errorf("invalid key type: %w", err)
+		}
+
+		power, err := m.validatorMgr.GetValidatorPower(ctx.Ctx, tx.Sender, keyType)
+		if err != nil {
+			return err
+		}
+		if power == 0 {
+			return errors.New("only validators can submit validator vote transactions")
+		}
+
+		if maxVotes := ctx.BlockContext.ChainContext.NetworkParameters.MaxVotesPerTx; (int64)(len(voteBodies.Events)) > maxVotes {
+			return fmt.Errorf("number of vote bodies exceeds the limit of %d", maxVotes)
+		}
 	}
 
 	// get sender account identifier
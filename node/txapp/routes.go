@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"reflect"
 	"sync"
 	"time"
 
@@ -161,7 +162,11 @@ func (d *baseRoute) Execute(ctx *common.TxContext, router *TxApp, db sql.DB, tx
 
 	svc := router.service.NamedLogger("route_" + d.Name())
 
-	code, err = d.PreTx(ctx, svc, tx)
+	// Routes keep the decoded payload between PreTx and InTx, so each
+	// transaction uses its own copy of the route.
+	impl := routeForTx(d.Route)
+
+	code, err = impl.PreTx(ctx, svc, tx)
 	if err != nil {
 		return txRes(spend, code, "", err)
 	}
@@ -180,7 +185,7 @@ func (d *baseRoute) Execute(ctx *common.TxContext, router *TxApp, db sql.DB, tx
 		Validators: router.Validators,
 	}
 
-	code, log, err := d.InTx(ctx, app, tx)
+	code, log, err := impl.InTx(ctx, app, tx)
 	if err != nil {
 		return txRes(spend, code, log, err)
 	}
@@ -193,6 +198,20 @@ func (d *baseRoute) Execute(ctx *common.TxContext, router *TxApp, db sql.DB, tx
 	return txRes(spend, types.CodeOk, log, nil)
 }
 
+// routeForTx returns a shallow copy of a route that is a pointer to a struct,
+// so that the state it sets in PreTx is not shared with transactions executed
+// concurrently, such as a simulated transaction and one in a block. Other
+// routes are returned as is.
+func routeForTx(route consensus.Route) consensus.Route {
+	v := reflect.ValueOf(route)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return route
+	}
+	cp := reflect.New(v.Elem().Type())
+	cp.Elem().Set(v.Elem())
+	return cp.Interface().(consensus.Route)
+}
+
 // ========================== route implementations ==========================
 // Each of the following route implementation satisfy the consensus.Route
 // interface, which is embedded by the baseRoute for used by TxApp.
@@ -821,17 +840,18 @@ func (d *validatorVoteBodiesRoute) PreTx(ctx *common.TxContext, _ *common.Servic
 		return types.CodeNetworkInMigration, errors.New("cannot vote during migration")
 	}
 
-	// Only proposer can issue a VoteBody transaction.
-	if !bytes.Equal(tx.Sender, ctx.BlockContext.Proposer.Bytes()) {
-		return types.CodeInvalidSender, ErrCallerNotProposer
-	}
-
 	vote := &types.ValidatorVoteBodies{}
 	err := vote.UnmarshalBinary(tx.Body.Payload)
 	if err != nil {
 		return types.CodeEncodingError, err
 	}
 
+	// Only proposer can issue a VoteBody transaction, unless all of its events
+	// are for aggregated resolutions, where each validator submits its own body.
+	if !bytes.Equal(tx.Sender, ctx.BlockContext.Proposer.Bytes()) && !allAggregated(vote.Events) {
+		return types.CodeInvalidSender, ErrCallerNotProposer
+	}
+
 	d.events = vote.Events
 
 	return 0, nil
@@ -859,10 +879,32 @@ func (d *validatorVoteBodiesRoute) InTx(ctx *common.TxContext, app *common.App,
 			return types.CodeInvalidSender, "", fmt.Errorf("failed to parse key type: %w", err)
 		}
 
-		expiryHeight := ctx.BlockContext.Timestamp + int64(resCfg.ExpirationPeriod.Seconds())
-		err = createResolution(ctx.Ctx, app.DB, ev, expiryHeight, tx.Sender, keyType)
-		if err != nil {
-			return types.CodeUnknownError, "", err
+		// Validators with the same value for an aggregated resolution vote on
+		// the same body, so it may already have been created by another
+		// validator. Votes for keys that were already resolved are ignored.
+		create := true
+		if resCfg.Aggregate != nil {
+			skip, exists, err := checkAggregatedVote(ctx.Ctx, app.DB, ev)
+			if err != nil {
+				return types.CodeUnknownError, "", err
+			}
+			if skip {
+				if fromLocalValidator {
+					if err = deleteEvent(ctx.Ctx, app.DB, ev.ID()); err != nil {
+						return types.CodeUnknownError, "", err
+					}
+				}
+				continue
+			}
+			create = !exists
+		}
+
+		if create {
+			expiryHeight := ctx.BlockContext.Timestamp + int64(resCfg.ExpirationPeriod.Seconds())
+			err = createResolution(ctx.Ctx, app.DB, ev, expiryHeight, tx.Sender, keyType)
+			if err != nil {
+				return types.CodeUnknownError, "", err
+			}
 		}
 
 		// since the vote body proposer is implicitly voting for the event,
@@ -884,6 +926,45 @@ func (d *validatorVoteBodiesRoute) InTx(ctx *common.TxContext, app *common.App,
 	return 0, "", nil
 }
 
+// allAggregated reports whether all of the events are for aggregated
+// resolutions, which any validator may submit vote bodies for.
+func allAggregated(events []*types.VotableEvent) bool {
+	if len(events) == 0 {
+		return false
+	}
+	for _, event := range events {
+		cfg, err := resolutions.GetResolution(event.Type)
+		if err != nil || cfg.Aggregate == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// checkAggregatedVote checks the vote body for an aggregated resolution. It
+// returns skip if the vote must be ignored, either because its body is not an
+// Outcome or because its key was already resolved, and exists if a
+// resolution for the same body has already been created.
+func checkAggregatedVote(ctx context.Context, db sql.Executor, ev *types.VotableEvent) (skip, exists bool, err error) {
+	var outcome resolutions.Outcome
+	if err := outcome.UnmarshalBinary(ev.Body); err != nil {
+		return true, false, nil
+	}
+
+	processed, err := isProcessed(ctx, db, resolutions.OutcomeID(ev.Type, outcome.Key))
+	if err != nil || processed {
+		return processed, false, err
+	}
+
+	processed, err = isProcessed(ctx, db, ev.ID())
+	if err != nil || processed {
+		return processed, false, err
+	}
+
+	exists, err = resolutionExists(ctx, db, ev.ID())
+	return false, exists, err
+}
+
 type createResolutionRoute struct {
 	resolution *types.VotableEvent
 	expiry     int64
@@ -2,6 +2,8 @@ package txapp
 
 import (
 	"encoding/hex"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/kwilteam/kwil-db/common"
@@ -10,6 +12,7 @@ import (
 	"github.com/kwilteam/kwil-db/core/log"
 	"github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/extensions/resolutions"
+	"github.com/kwilteam/kwil-db/node/accounts"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 	"github.com/kwilteam/kwil-db/node/voting"
 
@@ -289,6 +292,92 @@ func Test_Routes(t *testing.T) {
 	}
 }
 
+// namespaceEngine is an engine whose calls succeed if the namespace is the ID
+// of the transaction being executed.
+type namespaceEngine struct {
+	common.Engine
+}
+
+func (e *namespaceEngine) Call(ctx *common.EngineContext, _ sql.DB, namespace, _ string, _ []any, _ func(*common.Row) error) (*common.CallResult, error) {
+	if namespace != ctx.TxContext.TxID {
+		return nil, fmt.Errorf("transaction %s called namespace %s", ctx.TxContext.TxID, namespace)
+	}
+	return &common.CallResult{}, nil
+}
+
+func (e *namespaceEngine) Scratch(common.Accounts, common.Validators) common.Engine {
+	return e
+}
+
+// scratchAccounts is an account store that can be used to simulate
+// transactions.
+type scratchAccounts struct {
+	*mockAccount
+}
+
+func (scratchAccounts) Scratch() *accounts.Accounts {
+	return (&accounts.Accounts{}).Scratch()
+}
+
+// Test_SimulateConcurrentExecute checks that a simulated transaction does not
+// change the payload of a transaction executed at the same time, since the
+// routes are shared. It should be run with -race.
+func Test_SimulateConcurrentExecute(t *testing.T) {
+	app := &TxApp{
+		Engine:     &namespaceEngine{},
+		Accounts:   scratchAccounts{&mockAccount{}},
+		Validators: &mockValidator{},
+		signer:     signer1,
+		service: &common.Service{
+			Logger:   log.DiscardLogger,
+			Identity: signer1.CompactID(),
+		},
+	}
+
+	newTx := func(namespace string) *types.Transaction {
+		tx, err := types.CreateTransaction(&types.ActionExecution{
+			Namespace: namespace,
+			Action:    "act",
+		}, "chainid", 1)
+		require.NoError(t, err)
+		tx.Body.Fee = big.NewInt(0)
+		require.NoError(t, tx.Sign(signer1))
+		return tx
+	}
+	newCtx := func(txID string) *common.TxContext {
+		return &common.TxContext{
+			Ctx:  context.Background(),
+			TxID: txID,
+			BlockContext: &common.BlockContext{
+				ChainContext: &common.ChainContext{
+					NetworkParameters: &types.NetworkParameters{DisabledGasCosts: true},
+				},
+			},
+		}
+	}
+
+	const n = 200
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		tx := newTx("block")
+		for range n {
+			res := app.Execute(newCtx("block"), &mockDb{}, tx)
+			assert.NoError(t, res.Error)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		tx := newTx("sim")
+		for range n {
+			res := app.Simulate(newCtx("sim"), &mockDb{}, tx)
+			assert.NoError(t, res.Error)
+		}
+	}()
+	wg.Wait()
+}
+
 type mockAccount struct {
 }
 
@@ -11,6 +11,9 @@ import (
 	"slices"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/config"
 	"github.com/kwilteam/kwil-db/core/crypto"
@@ -23,10 +26,13 @@ import (
 	"github.com/kwilteam/kwil-db/extensions/resolutions"
 	"github.com/kwilteam/kwil-db/node/accounts"
 	"github.com/kwilteam/kwil-db/node/meta"
+	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 	"github.com/kwilteam/kwil-db/node/voting"
 )
 
+var mets metrics.VotesMetrics = metrics.Votes
+
 // TxApp is the transaction processor for the Kwil node.
 // It is responsible for interpreting payload bodies and routing them properly,
 // maintaining a mempool for uncommitted accounts, pricing transactions,
@@ -151,11 +157,11 @@ func (r *TxApp) Begin(ctx context.Context, height int64) error {
 // Execute executes a transaction.  It will route the transaction to the
 // appropriate module(s) for execution and return the response.
 // This method must only be called from the consensus engine,
-// sequentially, when executing transactions in a block.
+// sequentially, when executing transactions in a block. Transactions that are
+// simulated with Simulate may be executed at the same time.
 func (r *TxApp) Execute(ctx *common.TxContext, db sql.DB, tx *types.Transaction) *TxResponse {
-	// RegisterRoute call is not concurrent
-	route, ok := routes[tx.Body.PayloadType.String()]
-	if !ok {
+	route := getRoute(tx.Body.PayloadType.String())
+	if route == nil {
 		return txRes(nil, types.CodeInvalidTxType, "", fmt.Errorf("%w: %s", types.ErrUnknownPayloadType, tx.Body.PayloadType.String()))
 	}
 
@@ -164,8 +170,63 @@ func (r *TxApp) Execute(ctx *common.TxContext, db sql.DB, tx *types.Transaction)
 	// no need to error out if we cannot track the validator join approval
 	r.trackValidatorJoinApprovals(tx)
 
+	// The route and anything it calls use the span's context. The caller's
+	// context is restored after since the TxContext may be reused.
+	parent := ctx.Ctx
+	var span trace.Span
+	ctx.Ctx, span = metrics.StartSpan(parent, metrics.TxAppTracer, "tx "+tx.Body.PayloadType.String(),
+		attribute.String("tx", ctx.TxID), attribute.String("caller", ctx.Caller))
+
 	// track event count
-	return route.Execute(ctx, r, db, tx)
+	res := route.Execute(ctx, r, db, tx)
+	ctx.Ctx = parent
+	span.SetAttributes(attribute.Int64("code", int64(res.ResponseCode)))
+	metrics.EndSpan(span, res.Error)
+	return res
+}
+
+// Simulate executes a transaction in the same way as Execute, but without
+// affecting the state used by consensus, to show what the transaction would do
+// if it were included in a block. The transaction is executed with scratch
+// copies of the account store and engine, and validators cannot be modified.
+// The db must be a transaction that the caller rolls back.
+func (r *TxApp) Simulate(ctx *common.TxContext, db sql.DB, tx *types.Transaction) *TxResponse {
+	accts, ok := r.Accounts.(interface{ Scratch() *accounts.Accounts })
+	if !ok {
+		return txRes(nil, types.CodeUnknownError, "", errors.New("account store does not support simulation"))
+	}
+	engine, ok := r.Engine.(interface {
+		Scratch(common.Accounts, common.Validators) common.Engine
+	})
+	if !ok {
+		return txRes(nil, types.CodeUnknownError, "", errors.New("engine does not support simulation"))
+	}
+
+	scratchAccts := accts.Scratch()
+	vals := readOnlyValidators{r.Validators}
+	sim := &TxApp{
+		Engine:     engine.Scratch(scratchAccts, vals),
+		Accounts:   scratchAccts,
+		Validators: vals,
+		service:    r.service,
+		signer:     r.signer,
+		resTypes:   r.resTypes,
+	}
+	return sim.Execute(ctx, db, tx)
+}
+
+// ErrSimulatedValidatorUpdate is returned when a simulated transaction attempts
+// to modify a validator's power.
+var ErrSimulatedValidatorUpdate = errors.New("cannot modify validators in a simulated transaction")
+
+// readOnlyValidators is used to simulate transactions, since changes to
+// validators are kept in memory until the block is committed.
+type readOnlyValidators struct {
+	Validators
+}
+
+func (readOnlyValidators) SetValidatorPower(context.Context, sql.Executor, []byte, crypto.KeyType, int64) error {
+	return ErrSimulatedValidatorUpdate
 }
 
 // trackValidatorJoinApprovals tracks validator join approvals from this node.
@@ -260,6 +321,47 @@ func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.Block
 			return nil, fmt.Errorf("error getting resolution config: %w", err)
 		}
 
+		// aggregated resolutions are confirmed by key, across all of the
+		// values voted for the key.
+		if cfg.Aggregate != nil {
+			pending, err := getResolutionsByType(ctx, db, resolutionType)
+			if err != nil {
+				return nil, fmt.Errorf("error getting resolutions: %w", err)
+			}
+
+			required := requiredPower(ctx, db, cfg.ConfirmationThreshold, totalPower)
+			for _, outcome := range groupOutcomes(pending, required) {
+				for _, resolution := range outcome.resolutions {
+					credits.applyResolution(resolution)
+					finalizedIDs = append(finalizedIDs, resolution.ID)
+					markProcessedIDs = append(markProcessedIDs, resolution.ID)
+				}
+				// marking the key as processed discards late votes for it
+				markProcessedIDs = append(markProcessedIDs, resolutions.OutcomeID(resolutionType, outcome.key))
+
+				value, err := cfg.Aggregate(outcome.votes)
+				if err != nil {
+					r.service.Logger.Warn("error aggregating resolution votes, discarding them", "type", resolutionType,
+						"key", hex.EncodeToString(outcome.key), "error", err)
+					continue
+				}
+
+				resolution, err := outcome.resolution(resolutionType, value)
+				if err != nil {
+					return nil, fmt.Errorf("error creating aggregated resolution: %w", err)
+				}
+
+				resolveFuncs = append(resolveFuncs, &struct {
+					Resolution  *resolutions.Resolution
+					ResolveFunc func(ctx context.Context, app *common.App, resolution *resolutions.Resolution, block *common.BlockContext) error
+				}{
+					Resolution:  resolution,
+					ResolveFunc: cfg.ResolveFunc,
+				})
+			}
+			continue
+		}
+
 		finalized, err := getResolutionsByThresholdAndType(ctx, db, cfg.ConfirmationThreshold, resolutionType, totalPower)
 		if err != nil {
 			return nil, fmt.Errorf("error getting resolutions: %w", err)
@@ -300,6 +402,7 @@ func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.Block
 			Accounts:   r.Accounts,
 			Validators: r.Validators,
 		}, resolveFunc.Resolution, block) // block context include chain context, and thus network params and param updates
+		mets.ResolutionResolved(ctx, resolveFunc.Resolution.Type, err != nil)
 		if err != nil {
 			r.service.Logger.Warn("error resolving resolution", "type", resolveFunc.Resolution.Type, "id", resolveFunc.Resolution.ID.String(), "error", err)
 
@@ -364,6 +467,7 @@ func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.Block
 		}
 
 		r.service.Logger.Info("expiring resolution", "type", resolution.Type, "id", resolution.ID.String(), "refunded", refunded)
+		mets.ResolutionExpired(ctx, resolution.Type, refunded)
 	}
 
 	allIDs := append(finalizedIDs, expiredIDs...)
@@ -377,6 +481,10 @@ func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.Block
 		return nil, fmt.Errorf("error marking resolutions as processed: %w", err)
 	}
 
+	if err = r.recordPendingResolutions(ctx, db); err != nil {
+		return nil, err
+	}
+
 	// This is to ensure that the nodes that never get to vote on this event due to limitation
 	// per block vote sizes, they never get to vote and essentially delete the event
 	// So this is handled instead when the nodes are approved.
@@ -419,6 +527,32 @@ func (r *TxApp) processVotes(ctx context.Context, db sql.DB, block *common.Block
 	return expiredJoins, nil
 }
 
+// recordPendingResolutions records metrics for the resolutions that are still
+// waiting for votes after the block's resolutions have been processed.
+func (r *TxApp) recordPendingResolutions(ctx context.Context, db sql.DB) error {
+	totalPower := r.validatorSetPower()
+	for _, resolutionType := range r.resTypes {
+		cfg, err := resolutions.GetResolution(resolutionType)
+		if err != nil {
+			return fmt.Errorf("error getting resolution config: %w", err)
+		}
+
+		pending, err := getResolutionsByType(ctx, db, resolutionType)
+		if err != nil {
+			return fmt.Errorf("error getting resolutions: %w", err)
+		}
+
+		var maxApproval float64
+		if required := requiredPower(ctx, db, cfg.ConfirmationThreshold, totalPower); required > 0 {
+			for _, resolution := range pending {
+				maxApproval = max(maxApproval, float64(resolution.ApprovedPower)/float64(required))
+			}
+		}
+		mets.ResolutionsPending(ctx, resolutionType, int64(len(pending)), maxApproval)
+	}
+	return nil
+}
+
 var (
 	ValidatorVoteBodyBytePrice int64 = 1000                  // Per byte cost
 	ValidatorVoteIDPrice             = big.NewInt(1000 * 16) // 16 bytes for the UUID
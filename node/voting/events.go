@@ -81,6 +81,13 @@ const (
 	INNER JOIN ` + schemaName + `.events AS e ON r.id = e.id
 	WHERE NOT e.broadcasted;`
 
+	// unbroadcastedNewEvents returns the list of events observed by the validator to which resolutions
+	// do not exist, and that have not been broadcasted by the validator.
+	unbroadcastedNewEvents = `SELECT e.data, e.event_type
+	FROM ` + schemaName + `.events AS e
+	LEFT JOIN ` + votingSchemaName + `.resolutions AS r ON e.id = r.id
+	WHERE r.id IS NULL AND NOT e.broadcasted;`
+
 	// mark list of events as broadcasted.
 	markBroadcasted = `UPDATE ` + schemaName + `.events SET broadcasted = TRUE WHERE id =ANY($1);`
 
@@ -276,6 +283,19 @@ func (e *EventStore) GetUnbroadcastedEvents(ctx context.Context) ([]*types.UUID,
 	return ids, nil
 }
 
+// GetUnbroadcastedNewEvents returns the events observed by the validator to
+// which resolutions have not yet been created, and that have not been
+// previously broadcasted.
+func (e *EventStore) GetUnbroadcastedNewEvents(ctx context.Context) ([]*types.VotableEvent, error) {
+	readTx, err := e.eventWriter.BeginReadTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer readTx.Rollback(ctx) // only reading, so we can always rollback
+
+	return readEvents(ctx, readTx, unbroadcastedNewEvents)
+}
+
 // MarkBroadcasted marks the event as broadcasted.
 func (e *EventStore) MarkBroadcasted(ctx context.Context, ids []*types.UUID) error {
 	if len(ids) == 0 {
@@ -305,7 +325,12 @@ func (e *EventStore) MarkRebroadcast(ctx context.Context, ids []*types.UUID) err
 
 // GetEvents gets all events in the event store to which resolutions have not yet been created.
 func GetEvents(ctx context.Context, db sql.Executor) ([]*types.VotableEvent, error) {
-	res, err := db.Execute(ctx, getNewEvents)
+	return readEvents(ctx, db, getNewEvents)
+}
+
+// readEvents runs a query that returns the data and type of events.
+func readEvents(ctx context.Context, db sql.Executor, query string) ([]*types.VotableEvent, error) {
+	res, err := db.Execute(ctx, query)
 	if err != nil {
 		return nil, err
 	}